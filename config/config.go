@@ -12,6 +12,7 @@ import (
 	"golang.org/x/exp/slices"
 	"os"
 	"strings"
+	"time"
 )
 
 type InitConfig struct {
@@ -29,15 +30,61 @@ type AddConfig struct {
 	Digest                checksum.DigestAlgorithm
 	Fixity                []string
 	Message               string
+	Workers               int
+	IngestMode            string
+	AllowHardlink         bool
 }
 
 type UpdateConfig struct {
-	Deduplicate bool
-	NoCompress  bool
-	User        *UserConfig
-	Echo        bool
-	Message     string
-	Digest      checksum.DigestAlgorithm
+	Deduplicate   bool
+	NoCompress    bool
+	User          *UserConfig
+	Echo          bool
+	Message       string
+	Digest        checksum.DigestAlgorithm
+	Workers       int
+	IngestMode    string
+	AllowHardlink bool
+}
+
+type IngestConfig struct {
+	Include       []string
+	Exclude       []string
+	IncludeRegexp []string
+	ExcludeRegexp []string
+	IgnoreFile    string
+	Report        string
+	PathPolicy    *PathPolicyConfig
+}
+
+type BatchConfig struct {
+	Parallel int
+	Report   string
+}
+
+type WatchConfig struct {
+	Inbox    string
+	Done     string
+	Failed   string
+	Sentinel string
+	Quiet    configutil.Duration
+	Poll     configutil.Duration
+	IDFile   string `toml:"idfile"`
+	IDPrefix string `toml:"idprefix"`
+}
+
+type LockConfig struct {
+	Enabled bool
+	Wait    bool
+	Timeout configutil.Duration
+	Retry   configutil.Duration
+	S3      bool `toml:"s3"`
+}
+
+type PathPolicyConfig struct {
+	Normalization string
+	CaseCollision string
+	Windows       string
 }
 
 type AESConfig struct {
@@ -49,13 +96,23 @@ type AESConfig struct {
 	Key          configutil.EnvString
 }
 
+type DisplayRootConfig struct {
+	Path        string
+	S3          *S3Config
+	SearchIndex string
+}
+
 type DisplayConfig struct {
-	Addr      string
-	AddrExt   string
-	CertFile  string
-	KeyFile   string
-	Templates string
-	Obfuscate bool
+	Addr        string
+	AddrExt     string
+	CertFile    string
+	KeyFile     string
+	Templates   string
+	Obfuscate   bool
+	Search      bool
+	SearchIndex string
+	WebDAV      bool
+	Roots       map[string]*DisplayRootConfig
 }
 
 type ExtractConfig struct {
@@ -72,12 +129,13 @@ type ValidateConfig struct {
 }
 
 type ExtractMetaConfig struct {
-	Version    string
-	Format     string
-	Output     string
-	ObjectPath string
-	ObjectID   string
-	Obfuscate  bool
+	Version     string
+	Format      string
+	Output      string
+	ObjectPath  string
+	ObjectID    string
+	Obfuscate   bool
+	IIIFBaseURL string
 }
 
 type StatConfig struct {
@@ -86,6 +144,37 @@ type StatConfig struct {
 	ObjectID   string
 }
 
+type RecoverConfig struct {
+	ObjectPath string
+	ObjectID   string
+	DryRun     bool
+}
+
+type UnlockConfig struct {
+	ObjectPath string
+	ObjectID   string
+	Force      bool
+}
+
+type UpdateMetaConfig struct {
+	Metafile string
+	Patch    string
+	Area     string
+}
+
+type TagConfig struct {
+	ObjectPath string
+	ObjectID   string
+	Version    string
+}
+
+type DedupReportConfig struct {
+	Format string
+	Output string
+	Top    int
+	Digest checksum.DigestAlgorithm
+}
+
 type UserConfig struct {
 	Name    string
 	Address string
@@ -142,15 +231,25 @@ type GOCFLConfig struct {
 	Init          *InitConfig
 	Add           *AddConfig
 	Update        *UpdateConfig
+	Ingest        *IngestConfig
+	Batch         *BatchConfig
+	Watch         *WatchConfig
+	Lock          *LockConfig
 	Display       *DisplayConfig
 	Extract       *ExtractConfig
 	ExtractMeta   *ExtractMetaConfig
 	Stat          *StatConfig
 	Validate      *ValidateConfig
+	Recover       *RecoverConfig
+	Unlock        *UnlockConfig
+	DedupReport   *DedupReportConfig
+	UpdateMeta    *UpdateMetaConfig
+	Tag           *TagConfig
 	S3            *S3Config
 	DefaultArea   string
 	Log           stashconfig.Config `toml:"log"`
 	TempDir       string
+	Progress      string
 }
 
 func LoadGOCFLConfig(data string) (*GOCFLConfig, error) {
@@ -179,24 +278,57 @@ func LoadGOCFLConfig(data string) (*GOCFLConfig, error) {
 			Fixity:                []string{},
 			Message:               "initial add",
 			Digest:                "sha512",
+			Workers:               1,
+			IngestMode:            "copy",
 		},
 		Update: &UpdateConfig{
 			Deduplicate: true,
 			NoCompress:  true,
 			User:        &UserConfig{},
 			Echo:        false,
+			Workers:     1,
+			IngestMode:  "copy",
+		},
+		Ingest: &IngestConfig{
+			Include:       []string{},
+			Exclude:       []string{},
+			IncludeRegexp: []string{},
+			ExcludeRegexp: []string{},
+			IgnoreFile:    ".gocflignore",
+			PathPolicy: &PathPolicyConfig{
+				Normalization: "warn",
+				CaseCollision: "warn",
+				Windows:       "warn",
+			},
+		},
+		Batch: &BatchConfig{
+			Parallel: 1,
+		},
+		Watch: &WatchConfig{
+			Quiet: configutil.Duration(time.Minute),
+			Poll:  configutil.Duration(10 * time.Second),
+		},
+		Lock: &LockConfig{
+			Enabled: true,
+			Wait:    true,
+			Timeout: configutil.Duration(5 * time.Minute),
+			Retry:   configutil.Duration(time.Second),
+			S3:      true,
 		},
 		Display: &DisplayConfig{
 			Addr:    "localhost:80",
 			AddrExt: "http://localhost:80/",
+			Search:  true,
+			WebDAV:  true,
 		},
 		Extract: &ExtractConfig{
 			Manifest: false,
 			Version:  "latest",
 		},
 		ExtractMeta: &ExtractMetaConfig{
-			Version: "latest",
-			Format:  "json",
+			Version:     "latest",
+			Format:      "json",
+			IIIFBaseURL: "http://localhost:8080",
 		},
 		Stat: &StatConfig{
 			Info: []string{
@@ -212,13 +344,26 @@ func LoadGOCFLConfig(data string) (*GOCFLConfig, error) {
 			},
 		},
 		Validate: &ValidateConfig{},
+		Recover:  &RecoverConfig{},
+		Unlock:   &UnlockConfig{},
+		DedupReport: &DedupReportConfig{
+			Format: "text",
+			Top:    20,
+		},
+		UpdateMeta: &UpdateMetaConfig{
+			Area: "metadata",
+		},
+		Tag: &TagConfig{
+			Version: "latest",
+		},
 		Init: &InitConfig{
 			OCFLVersion:                "1.1",
 			StorageRootExtensionFolder: "",
 			Documentation:              "ocfl",
 		},
-		S3:      &S3Config{},
-		TempDir: os.TempDir(),
+		S3:       &S3Config{},
+		TempDir:  os.TempDir(),
+		Progress: "auto",
 	}
 
 	if _, err := toml.Decode(data, conf); err != nil {
@@ -45,4 +45,7 @@ var WebRoot embed.FS
 //go:embed templates/version.gohtml
 //go:embed templates/detail.gohtml
 //go:embed templates/report.gohtml
+//go:embed templates/search.gohtml
+//go:embed templates/diff.gohtml
+//go:embed templates/roots.gohtml
 var TemplateRoot embed.FS
@@ -31,7 +31,7 @@ var addCmd = &cobra.Command{
 	Use:     "add [path to ocfl structure]",
 	Aliases: []string{},
 	Short:   "adds new object to existing ocfl structure",
-	Long:    "opens an existing ocfl structure and adds a new object. if an object with the given id already exists, an error is produced",
+	Long:    "opens an existing ocfl structure and adds a new object. if an object with the given id already exists, an error is produced. the source is a folder, a zip or tar archive, an s3 prefix or a http(s) url manifest",
 	Example: "gocfl add ./archive.zip /tmp/testdata -u 'Jane Doe' -a 'mailto:user@domain' -m 'initial add' -object-id 'id:abc123'",
 	Args:    cobra.MinimumNArgs(2),
 	Run:     doAdd,
@@ -49,6 +49,13 @@ func initAdd() {
 	addCmd.Flags().StringP("digest", "d", "", "digest to use for ocfl checksum")
 	addCmd.Flags().Bool("deduplicate", false, "force deduplication (slower)")
 	addCmd.Flags().Bool("no-compress", false, "do not compress data in zip file")
+	addCmd.Flags().Bool("dry-run", false, "show the planned changes without writing content or inventories")
+	addCmd.Flags().String("plan-format", "text", "format of the dry run plan (text|json)")
+	addCmd.Flags().Int("workers", 0, "number of files read and hashed in parallel (default 1, not for zip files)")
+	addCmd.Flags().String("ingest-mode", "", "place content into a local storage root by copy, reflink or hardlink after hashing, falls back to copy on different filesystems (copy|reflink|hardlink), hardlink needs --allow-hardlink")
+	addCmd.Flags().Bool("allow-hardlink", false, "allow ingest mode hardlink. WARNING: object and source share the files, a later change of the source corrupts the object")
+	initDigestManifestFlag(addCmd)
+	initIngestFlags(addCmd)
 }
 
 func doAddConf(cmd *cobra.Command) {
@@ -87,6 +94,25 @@ func doAddConf(cmd *cobra.Command) {
 	if b, ok := getFlagBool(cmd, "no-compress"); ok {
 		conf.Add.NoCompress = b
 	}
+	if i, ok := getFlagInt(cmd, "workers"); ok {
+		conf.Add.Workers = i
+	}
+	if str := getFlagString(cmd, "ingest-mode"); str != "" {
+		conf.Add.IngestMode = str
+	}
+	if mode, err := ocfl.ParseIngestMode(conf.Add.IngestMode); err != nil {
+		_ = cmd.Help()
+		cobra.CheckErr(errors.Errorf("invalid ingest mode '%s' for flag 'ingest-mode' or 'Add.IngestMode' config file entry", conf.Add.IngestMode))
+	} else {
+		conf.Add.IngestMode = string(mode)
+	}
+	if b, ok := getFlagBool(cmd, "allow-hardlink"); ok {
+		conf.Add.AllowHardlink = b
+	}
+	if conf.Add.IngestMode == string(ocfl.IngestModeHardlink) && !conf.Add.AllowHardlink {
+		_ = cmd.Help()
+		cobra.CheckErr(errors.Errorf("ingest mode hardlink shares the files of the source with the object, use flag 'allow-hardlink' or 'Add.AllowHardlink' config file entry to allow it"))
+	}
 
 	if str := getFlagString(cmd, "digest"); str != "" {
 		conf.Add.Digest = checksum.DigestAlgorithm(str)
@@ -117,7 +143,7 @@ func doAdd(cmd *cobra.Command, args []string) {
 		cobra.CheckErr(err)
 		return
 	}
-	srcPath, err := ocfl.Fullpath(args[1])
+	srcPath, err := sourcePath(args[1])
 	if err != nil {
 		cobra.CheckErr(err)
 		return
@@ -166,6 +192,8 @@ func doAdd(cmd *cobra.Command, args []string) {
 	var logger zLogger.ZLogger = &l2
 
 	doAddConf(cmd)
+	doIngestConf(cmd)
+	dryRun, planFormat := getDryRun(cmd)
 
 	var addr string
 	var localCache bool
@@ -198,11 +226,7 @@ func doAdd(cmd *cobra.Command, args []string) {
 		fixityAlgs = append(fixityAlgs, checksum.DigestAlgorithm(alg))
 	}
 
-	if _, err := os.Stat(srcPath); err != nil {
-		logger.Panic().Stack().Err(err).Msgf("cannot stat '%s'", srcPath)
-	}
-
-	fsFactory, err := initializeFSFactory([]checksum.DigestAlgorithm{conf.Add.Digest}, nil, nil, conf.Add.NoCompress, false, logger)
+	fsFactory, err := initializeFSFactory([]checksum.DigestAlgorithm{conf.Add.Digest}, nil, conf.S3, conf.Add.NoCompress, false, logger)
 	if err != nil {
 		logger.Debug().Stack().Any(
 			errorTopic,
@@ -214,11 +238,12 @@ func doAdd(cmd *cobra.Command, args []string) {
 		logger.Panic().Err(err).Msg("cannot create filesystem factory")
 	}
 
-	sourceFS, err := fsFactory.Get(srcPath, true)
+	sourceFS, err := openSource(context.Background(), fsFactory, srcPath)
 	if err != nil {
-		logger.Panic().Stack().Err(err).Msgf("cannot get filesystem for '%s'", srcPath)
+		logger.Panic().Stack().Err(err).Msgf("cannot open source '%s'", srcPath)
 	}
-	destFS, err := fsFactory.Get(ocflPath, false)
+	defer writefs.Close(sourceFS)
+	destFS, err := fsFactory.Get(ocflPath, dryRun)
 	if err != nil {
 		logger.Panic().Stack().Err(err).Msgf("cannot get filesystem for '%s'", ocflPath)
 	}
@@ -237,6 +262,22 @@ func doAdd(cmd *cobra.Command, args []string) {
 	if area == "" {
 		area = "content"
 	}
+	filter, err := newSourceFilter(logger)
+	if err != nil {
+		doNotClose = true
+		logger.Panic().Stack().Err(err).Msg("cannot create ingest filter")
+	}
+	defer filter.report(logger)
+	trusted, err := getTrustedDigests(cmd, sourceFS, area, conf.Add.Digest)
+	if err != nil {
+		doNotClose = true
+		logger.Panic().Stack().Err(err).Msg("cannot load digest manifest")
+	}
+	sourceFS, err = filter.wrap(sourceFS, srcPath, area)
+	if err != nil {
+		doNotClose = true
+		logger.Panic().Stack().Err(err).Msgf("cannot filter '%s'", srcPath)
+	}
 	var areaPaths = map[string]fs.FS{}
 	for i := 2; i < len(args); i++ {
 		matches := areaPathRegexp.FindStringSubmatch(args[i])
@@ -249,10 +290,16 @@ func doAdd(cmd *cobra.Command, args []string) {
 				)).Msg("")
 			continue
 		}
-		areaPaths[matches[1]], err = fsFactory.Get(matches[2], true)
+		areaPaths[matches[1]], err = openSource(context.Background(), fsFactory, matches[2])
+		if err != nil {
+			doNotClose = true
+			logger.Panic().Stack().Err(err).Msgf("cannot open source '%s'", args[i])
+		}
+		defer writefs.Close(areaPaths[matches[1]])
+		areaPaths[matches[1]], err = filter.wrap(areaPaths[matches[1]], matches[2], matches[1])
 		if err != nil {
 			doNotClose = true
-			logger.Panic().Stack().Err(err).Msgf("cannot get filesystem for '%s'", args[i])
+			logger.Panic().Stack().Err(err).Msgf("cannot filter '%s'", args[i])
 		}
 	}
 
@@ -282,12 +329,21 @@ func doAdd(cmd *cobra.Command, args []string) {
 		logger.Panic().Stack().Err(err).Msg("cannot initialize default extensions")
 	}
 
-	ctx := ocfl.NewContextValidation(context.TODO())
+	cmdCtx, stop := cancelContext()
+	defer stop()
+	cmdCtx, endProgress, err := progressContext(cmdCtx)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msg("cannot initialize progress output")
+		return
+	}
+	defer endProgress()
+	ctx := ocfl.NewContextValidation(cmdCtx)
 	storageRoot, err := ocfl.LoadStorageRoot(ctx, destFS, extensionFactory, logger, ErrorFactory, conf.Init.Documentation)
 	if err != nil {
 		doNotClose = true
 		logger.Panic().Stack().Err(err).Msg("cannot open storage root")
 	}
+	storageRoot.SetLockOptions(objectLocks(ocflPath))
 	if storageRoot.GetDigest() == "" {
 		storageRoot.SetDigest(checksum.DigestAlgorithm(conf.Add.Digest))
 	} else {
@@ -312,11 +368,38 @@ func doAdd(cmd *cobra.Command, args []string) {
 		return
 	}
 
+	if dryRun {
+		if err := dryRunObjectByPath(
+			ctx,
+			storageRoot,
+			"",
+			"",
+			fixityAlgs,
+			objectExtensionManager,
+			conf.Add.Deduplicate,
+			flagObjectID,
+			sourceFS,
+			area,
+			areaPaths,
+			false,
+			trusted,
+			planFormat,
+			os.Stdout,
+			logger,
+		); err != nil {
+			logger.Error().Stack().Err(err).Msgf("cannot plan object '%s'", flagObjectID)
+		}
+		return
+	}
+
 	_, err = addObjectByPath(
+		ctx,
 		storageRoot,
 		fixityAlgs,
 		objectExtensionManager,
 		conf.Add.Deduplicate,
+		ingestWorkers(ocflPath, conf.Add.Workers, logger),
+		ocfl.IngestMode(conf.Add.IngestMode),
 		flagObjectID,
 		conf.Add.User.Name,
 		conf.Add.User.Address,
@@ -325,6 +408,7 @@ func doAdd(cmd *cobra.Command, args []string) {
 		area,
 		areaPaths,
 		false,
+		trusted,
 		logger,
 	)
 	if err != nil {
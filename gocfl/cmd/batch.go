@@ -0,0 +1,446 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"emperror.dev/errors"
+	"github.com/je4/filesystem/v3/pkg/writefs"
+	"github.com/je4/utils/v2/pkg/checksum"
+	"github.com/je4/utils/v2/pkg/zLogger"
+	"github.com/ocfl-archive/gocfl/v2/internal"
+	"github.com/ocfl-archive/gocfl/v2/pkg/ocfl"
+	"github.com/ocfl-archive/gocfl/v2/pkg/subsystem/batch"
+	"github.com/ocfl-archive/gocfl/v2/pkg/subsystem/migration"
+	"github.com/ocfl-archive/gocfl/v2/pkg/subsystem/thumbnail"
+	ironmaiden "github.com/ocfl-archive/indexer/v3/pkg/indexer"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/pkgerrors"
+	"github.com/spf13/cobra"
+	ublogger "gitlab.switch.ch/ub-unibas/go-ublogger/v2"
+	"go.ub.unibas.ch/cloud/certloader/v2/pkg/loader"
+)
+
+var batchCmd = &cobra.Command{
+	Use:     "batch [path to ocfl structure]",
+	Aliases: []string{},
+	Short:   "adds or updates the objects of a manifest file",
+	Long: "opens an existing ocfl structure and ingests every row of a csv or jsonl manifest. new objects are created, existing objects are updated with update strategy 'contribute'. " +
+		"the result of every row is appended to the report. on restart, rows which were successful are skipped",
+	Example: "gocfl batch ./archive --manifest ./batch.csv --parallel 4 -u 'Jane Doe' -a 'mailto:user@domain' -m 'digitisation'",
+	Args:    cobra.ExactArgs(1),
+	Run:     doBatch,
+}
+
+// initBatch initializes the gocfl batch command
+func initBatch() {
+	batchCmd.Flags().String("manifest", "", "csv or jsonl file with one object per row (required)")
+	batchCmd.MarkFlagRequired("manifest")
+	batchCmd.Flags().String("report", "", "jsonl file with the result of every row (default is the manifest name with extension .report.jsonl)")
+	batchCmd.Flags().Int("parallel", 0, "number of objects ingested in parallel (default 1, not for zip files)")
+	batchCmd.Flags().String("default-object-extensions", "", "folder with initial extension configurations for new OCFL objects")
+	batchCmd.Flags().StringP("message", "m", "", "message for new object versions, if not set in the manifest")
+	batchCmd.Flags().StringP("user-name", "u", "", "user name for new object versions, if not set in the manifest")
+	batchCmd.Flags().StringP("user-address", "a", "", "user address for new object versions, if not set in the manifest")
+	batchCmd.Flags().StringP("fixity", "f", "", "comma separated list of digest algorithms for fixity, if not set in the manifest")
+	batchCmd.Flags().StringP("digest", "d", "", "digest to use for ocfl checksum")
+	batchCmd.Flags().Bool("deduplicate", false, "force deduplication (slower)")
+	batchCmd.Flags().Bool("no-compress", false, "do not compress data in zip file")
+	batchCmd.Flags().Int("workers", 0, "number of files read and hashed in parallel per object (default 1, not for zip files)")
+	batchCmd.Flags().String("ingest-mode", "", "place content into a local storage root by copy, reflink or hardlink after hashing, falls back to copy on different filesystems (copy|reflink|hardlink), hardlink needs --allow-hardlink")
+	batchCmd.Flags().Bool("allow-hardlink", false, "allow ingest mode hardlink. WARNING: object and source share the files, a later change of the source corrupts the object")
+	initIngestFlags(batchCmd)
+}
+
+func doBatchConf(cmd *cobra.Command) {
+	doAddConf(cmd)
+	if str := getFlagString(cmd, "report"); str != "" {
+		conf.Batch.Report = str
+	}
+	if i, ok := getFlagInt(cmd, "parallel"); ok {
+		conf.Batch.Parallel = i
+	}
+}
+
+// batchIngest contains everything, which is shared by the rows of a batch
+type batchIngest struct {
+	cmd            *cobra.Command
+	destFS         fs.FS
+	fsFactory      *writefs.Factory
+	indexerActions *ironmaiden.ActionDispatcher
+	workers        int
+	locks          *ocfl.LockOptions
+	logger         zLogger.ZLogger
+}
+
+// doBatch executes the gocfl batch command
+func doBatch(cmd *cobra.Command, args []string) {
+	var err error
+
+	if err := cmd.ValidateRequiredFlags(); err != nil {
+		cobra.CheckErr(err)
+		return
+	}
+
+	ocflPath, err := ocfl.Fullpath(args[0])
+	if err != nil {
+		cobra.CheckErr(err)
+		return
+	}
+	manifestPath, err := ocfl.Fullpath(getFlagString(cmd, "manifest"))
+	if err != nil {
+		cobra.CheckErr(err)
+		return
+	}
+
+	if !slices.Contains([]string{"DEBUG", "ERROR", "WARNING", "INFO", "CRITICAL"}, conf.Log.Level) {
+		_ = cmd.Help()
+		cobra.CheckErr(errors.Errorf("invalid log level '%s' for flag 'log-level' or 'LogLevel' config file entry", persistentFlagLoglevel))
+	}
+
+	// create logger instance
+	hostname, err := os.Hostname()
+	if err != nil {
+		log.Fatalf("cannot get hostname: %v", err)
+	}
+
+	var loggerTLSConfig *tls.Config
+	var loggerLoader io.Closer
+	if conf.Log.Stash.TLS != nil {
+		loggerTLSConfig, loggerLoader, err = loader.CreateClientLoader(conf.Log.Stash.TLS, nil)
+		if err != nil {
+			log.Fatalf("cannot create client loader: %v", err)
+		}
+		defer loggerLoader.Close()
+	}
+
+	zerolog.ErrorStackMarshaler = pkgerrors.MarshalStack
+	_logger, _logstash, _logfile, err := ublogger.CreateUbMultiLoggerTLS(conf.Log.Level, conf.Log.File,
+		ublogger.SetDataset(conf.Log.Stash.Dataset),
+		ublogger.SetLogStash(conf.Log.Stash.LogstashHost, conf.Log.Stash.LogstashPort, conf.Log.Stash.Namespace, conf.Log.Stash.LogstashTraceLevel),
+		ublogger.SetTLS(conf.Log.Stash.TLS != nil),
+		ublogger.SetTLSConfig(loggerTLSConfig),
+	)
+	if err != nil {
+		log.Fatalf("cannot create logger: %v", err)
+	}
+	if _logstash != nil {
+		defer _logstash.Close()
+	}
+
+	if _logfile != nil {
+		defer _logfile.Close()
+	}
+
+	l2 := _logger.With().Timestamp().Str("host", hostname).Logger() //.Output(output)
+	var logger zLogger.ZLogger = &l2
+
+	doBatchConf(cmd)
+	doIngestConf(cmd)
+
+	rows, err := batch.LoadManifest(manifestPath)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msgf("cannot load manifest '%s'", manifestPath)
+		return
+	}
+	reportPath := conf.Batch.Report
+	if reportPath == "" {
+		reportPath = strings.TrimSuffix(manifestPath, filepath.Ext(manifestPath)) + ".report.jsonl"
+	}
+	report, err := batch.OpenReport(reportPath)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msgf("cannot open report '%s'", reportPath)
+		return
+	}
+	defer report.Close()
+
+	t := startTimer()
+	defer func() {
+		logger.Info().Any(
+			errorTopic,
+			ErrorFactory.NewError(
+				ErrorGOCFL,
+				fmt.Sprintf("duration: %s", t.String()),
+				err,
+			),
+		).Msg("")
+	}()
+	logger.Info().Msgf("opening '%s'", ocflPath)
+
+	cmdCtx, stop := cancelContext()
+	defer stop()
+	cmdCtx, endProgress, err := progressContext(cmdCtx)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msg("cannot initialize progress output")
+		return
+	}
+	defer endProgress()
+
+	b, err := newBatchIngest(cmdCtx, cmd, ocflPath, logger)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msgf("cannot open '%s'", ocflPath)
+		return
+	}
+	defer b.Close()
+
+	parallel := ingestWorkers(ocflPath, conf.Batch.Parallel, logger)
+
+	var counter = map[string]int{}
+	var counterLock sync.Mutex
+	count := func(status string) {
+		counterLock.Lock()
+		counter[status]++
+		counterLock.Unlock()
+	}
+
+	// the rows of an object are processed sequentially
+	groups := make(chan []*batch.Row)
+	var wg sync.WaitGroup
+	for i := 0; i < parallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for group := range groups {
+				var failed *batch.Row
+				for _, row := range group {
+					if report.Completed(row) {
+						logger.Info().Msgf("line %d: object '%s' from '%s' already done", row.Line, row.ID, row.Source)
+						count("done")
+						continue
+					}
+					if cmdCtx.Err() != nil {
+						break
+					}
+					var result *batch.Result
+					if failed != nil {
+						result = batch.NewResult(row).Done(errors.Errorf("line %d of object '%s' failed", failed.Line, failed.ID))
+					} else {
+						result = b.ingest(cmdCtx, row)
+					}
+					if result.Status != batch.StatusOK && failed == nil {
+						failed = row
+					}
+					count(result.Status)
+					if err := report.Write(result); err != nil {
+						logger.Error().Stack().Err(err).Msgf("cannot write report '%s'", reportPath)
+					}
+				}
+			}
+		}()
+	}
+	for _, group := range batch.GroupByID(rows) {
+		if cmdCtx.Err() != nil {
+			break
+		}
+		groups <- group
+	}
+	close(groups)
+	wg.Wait()
+
+	msg := fmt.Sprintf("batch '%s': %d rows, %d ok, %d failed, %d already done", manifestPath, len(rows), counter[batch.StatusOK], counter[batch.StatusFailed], counter["done"])
+	if counter[batch.StatusFailed] > 0 || cmdCtx.Err() != nil {
+		logger.Error().Any(ErrorFactory.LogError(ErrorGOCFL, msg, cmdCtx.Err())).Msg("")
+		return
+	}
+	logger.Info().Any(ErrorFactory.LogError(ErrorGOCFL, msg, nil)).Msg("")
+}
+
+// newBatchIngest initializes the indexer and opens the storage root at ocflPath
+func newBatchIngest(ctx context.Context, cmd *cobra.Command, ocflPath string, logger zLogger.ZLogger) (*batchIngest, error) {
+	var fss = map[string]fs.FS{"internal": internal.InternalFS}
+
+	indexerActions, err := ironmaiden.InitActionDispatcher(fss, *conf.Indexer, logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot init indexer")
+	}
+	fsFactory, err := initializeFSFactory([]checksum.DigestAlgorithm{conf.Add.Digest}, nil, conf.S3, conf.Add.NoCompress, false, logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create filesystem factory")
+	}
+	destFS, err := fsFactory.Get(ocflPath, false)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot get filesystem for '%s'", ocflPath)
+	}
+	b := &batchIngest{
+		cmd:            cmd,
+		destFS:         destFS,
+		fsFactory:      fsFactory,
+		indexerActions: indexerActions,
+		workers:        ingestWorkers(ocflPath, conf.Add.Workers, logger),
+		locks:          objectLocks(ocflPath),
+		logger:         logger,
+	}
+
+	// check the digest of the storage root once
+	extensionFactory, err := InitExtensionFactory(GetExtensionParamValues(cmd, conf), "", false, nil, nil, nil, nil, logger, conf.TempDir)
+	if err != nil {
+		b.Close()
+		return nil, errors.Wrap(err, "cannot initialize extension factory")
+	}
+	storageRoot, err := ocfl.LoadStorageRoot(ocfl.NewContextValidation(ctx), destFS, extensionFactory, logger, ErrorFactory, conf.Init.Documentation)
+	if err != nil {
+		b.Close()
+		return nil, errors.Wrap(err, "cannot open storage root")
+	}
+	if digest := storageRoot.GetDigest(); digest != "" && digest != conf.Add.Digest {
+		b.Close()
+		return nil, errors.Errorf("storageroot already uses digest '%s' not '%s'", digest, conf.Add.Digest)
+	}
+	return b, nil
+}
+
+func (b *batchIngest) Close() {
+	if err := writefs.Close(b.destFS); err != nil {
+		b.logger.Error().Stack().Err(err).Msgf("error closing filesystem '%s'", b.destFS)
+	}
+}
+
+// ingest adds the content of one row to its object
+func (b *batchIngest) ingest(ctx context.Context, row *batch.Row) *batch.Result {
+	result := batch.NewResult(row)
+	b.logger.Info().Msgf("line %d: ingesting '%s' into object '%s'", row.Line, row.Source, row.ID)
+	err := b.ingestRow(ctx, row, result)
+	if err != nil {
+		b.logger.Error().Stack().Err(err).Msgf("line %d: cannot ingest '%s' into object '%s'", row.Line, row.Source, row.ID)
+	}
+	return result.Done(err)
+}
+
+func (b *batchIngest) ingestRow(ctx context.Context, row *batch.Row, result *batch.Result) error {
+	message := row.Message
+	if message == "" {
+		message = conf.Add.Message
+	}
+	userName := row.UserName
+	if userName == "" {
+		userName = conf.Add.User.Name
+	}
+	userAddress := row.UserAddress
+	if userAddress == "" {
+		userAddress = conf.Add.User.Address
+	}
+	if message == "" || userName == "" || userAddress == "" {
+		return errors.New("message, user name and user address required")
+	}
+	fixity := row.Fixity
+	if len(fixity) == 0 {
+		fixity = conf.Add.Fixity
+	}
+	var fixityAlgs = []checksum.DigestAlgorithm{}
+	for _, alg := range fixity {
+		alg = strings.TrimSpace(strings.ToLower(alg))
+		if alg == "" {
+			continue
+		}
+		if _, err := checksum.GetHash(checksum.DigestAlgorithm(alg)); err != nil {
+			return errors.Errorf("invalid fixity '%s'", alg)
+		}
+		fixityAlgs = append(fixityAlgs, checksum.DigestAlgorithm(alg))
+	}
+
+	srcPath, err := sourcePath(row.Source)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	sourceFS, err := openSource(ctx, b.fsFactory, srcPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer writefs.Close(sourceFS)
+	area := conf.DefaultArea
+	if area == "" {
+		area = "content"
+	}
+	filter, err := newSourceFilter(b.logger)
+	if err != nil {
+		return errors.Wrap(err, "cannot create ingest filter")
+	}
+	defer func() {
+		result.Skipped = len(filter.logSkipped(b.logger))
+	}()
+	if sourceFS, err = filter.wrap(sourceFS, srcPath, area); err != nil {
+		return errors.Wrapf(err, "cannot filter '%s'", srcPath)
+	}
+	var areaPaths = map[string]fs.FS{}
+	for a, aPath := range row.Areas {
+		fsys, err := openSource(ctx, b.fsFactory, aPath)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		defer writefs.Close(fsys)
+		if areaPaths[a], err = filter.wrap(fsys, aPath, a); err != nil {
+			return errors.Wrapf(err, "cannot filter '%s'", aPath)
+		}
+	}
+
+	// extensions keep state per object, so every row gets its own instances
+	mig, err := migration.GetMigrations(conf)
+	if err != nil {
+		return errors.Wrap(err, "cannot get migrations")
+	}
+	mig.SetSourceFS(sourceFS)
+	thumb, err := thumbnail.GetThumbnails(conf)
+	if err != nil {
+		return errors.Wrap(err, "cannot get thumbnails")
+	}
+	thumb.SetSourceFS(sourceFS)
+	extensionFactory, err := InitExtensionFactory(GetExtensionParamValues(b.cmd, conf), "", false, b.indexerActions, mig, thumb, sourceFS, b.logger, conf.TempDir)
+	if err != nil {
+		return errors.Wrap(err, "cannot initialize extension factory")
+	}
+	_, objectExtensionManager, err := initDefaultExtensions(extensionFactory, "", conf.Add.ObjectExtensionFolder, b.logger)
+	if err != nil {
+		return errors.Wrap(err, "cannot initialize default extensions")
+	}
+
+	ctx = ocfl.NewContextValidation(ctx)
+	storageRoot, err := ocfl.LoadStorageRoot(ctx, b.destFS, extensionFactory, b.logger, ErrorFactory, conf.Init.Documentation)
+	if err != nil {
+		return errors.Wrap(err, "cannot open storage root")
+	}
+	storageRoot.SetLockOptions(b.locks)
+	if storageRoot.GetDigest() == "" {
+		storageRoot.SetDigest(conf.Add.Digest)
+	}
+	result.Modified, err = addObjectByPath(
+		ctx,
+		storageRoot,
+		fixityAlgs,
+		objectExtensionManager,
+		conf.Add.Deduplicate,
+		b.workers,
+		ocfl.IngestMode(conf.Add.IngestMode),
+		row.ID,
+		userName,
+		userAddress,
+		message,
+		sourceFS,
+		area,
+		areaPaths,
+		false,
+		nil,
+		b.logger,
+	)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	status, err := ocfl.GetValidationStatus(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot get status of validation")
+	}
+	for _, verr := range status.Errors {
+		if verr.Code[0] == 'E' {
+			result.Errors++
+		}
+	}
+	return nil
+}
@@ -30,7 +30,8 @@ var createCmd = &cobra.Command{
 	Aliases: []string{},
 	Short:   "creates a new ocfl structure with initial content of one object",
 	Long: "initializes an empty ocfl structure and adds contents of a directory subtree to it\n" +
-		"This command is a combination of init and add",
+		"This command is a combination of init and add\n" +
+		"The source is a folder, a zip or tar archive, an s3 prefix or a http(s) url manifest",
 	Example: "gocfl create ./archive.zip /tmp/testdata --digest sha512 -u 'Jane Doe' -a 'mailto:user@domain' -m 'initial add' -object-id 'id:abc123'",
 	Args:    cobra.MinimumNArgs(2),
 	Run:     doCreate,
@@ -51,6 +52,12 @@ func initCreate() {
 	createCmd.Flags().String("default-area", "", "default area for update or ingest (default: content)")
 	createCmd.Flags().Bool("deduplicate", false, "force deduplication (slower)")
 	createCmd.Flags().Bool("no-compress", false, "do not compress data in zip file")
+	createCmd.Flags().Bool("dry-run", false, "show the planned changes without writing content or inventories")
+	createCmd.Flags().String("plan-format", "text", "format of the dry run plan (text|json)")
+	createCmd.Flags().Int("workers", 0, "number of files read and hashed in parallel (default 1, not for zip files)")
+	createCmd.Flags().String("ingest-mode", "", "place content into a local storage root by copy, reflink or hardlink after hashing, falls back to copy on different filesystems (copy|reflink|hardlink), hardlink needs --allow-hardlink")
+	createCmd.Flags().Bool("allow-hardlink", false, "allow ingest mode hardlink. WARNING: object and source share the files, a later change of the source corrupts the object")
+	initIngestFlags(createCmd)
 	createCmd.Flags().Bool("encrypt-aes", false, "create encrypted container (only for container target)")
 	createCmd.Flags().String("aes-key", "", "key to use for encrypted container in hex format (64 chars, empty: generate random key)")
 	createCmd.Flags().String("aes-iv", "", "initialisation vector to use for encrypted container in hex format (32 char, sempty: generate random vector)")
@@ -108,7 +115,7 @@ func doCreate(cmd *cobra.Command, args []string) {
 		cobra.CheckErr(err)
 		return
 	}
-	srcPath, err := ocfl.Fullpath(args[1])
+	srcPath, err := sourcePath(args[1])
 	if err != nil {
 		cobra.CheckErr(err)
 		return
@@ -153,7 +160,9 @@ func doCreate(cmd *cobra.Command, args []string) {
 
 	doInitConf(cmd)
 	doAddConf(cmd)
+	doIngestConf(cmd)
 	doCreateConf(cmd)
+	dryRun, planFormat := getDryRun(cmd)
 
 	var addr string
 	var localCache bool
@@ -226,29 +235,46 @@ func doCreate(cmd *cobra.Command, args []string) {
 		}
 	}
 
-	sourceFS, err := fsFactory.Get(srcPath, true)
+	sourceFS, err := openSource(context.Background(), fsFactory, srcPath)
 	if err != nil {
-		logger.Panic().Stack().Err(err).Msgf("cannot get filesystem for '%s'", srcPath)
+		logger.Panic().Stack().Err(err).Msgf("cannot open source '%s'", srcPath)
 	}
-	destFS, err := fsFactory.Get(ocflPath, false)
-	if err != nil {
-		logger.Panic().Stack().Msgf("cannot get filesystem for '%s'", ocflPath)
-	}
-	defer func() {
-		if err := writefs.Close(destFS); err != nil {
-			ErrorFactory.LogSetError(logger.Error().Stack(), ErrorFactory.NewError(
-				ErrorFS,
-				fmt.Sprintf("error closing filesystem '%s'", destFS),
-				err,
-			),
-			).Msg("")
+	defer writefs.Close(sourceFS)
+	// a dry run does not create the storage root
+	var destFS fs.FS
+	if !dryRun {
+		destFS, err = fsFactory.Get(ocflPath, false)
+		if err != nil {
+			logger.Panic().Stack().Msgf("cannot get filesystem for '%s'", ocflPath)
 		}
-	}()
+		defer func() {
+			if err := writefs.Close(destFS); err != nil {
+				ErrorFactory.LogSetError(logger.Error().Stack(), ErrorFactory.NewError(
+					ErrorFS,
+					fmt.Sprintf("error closing filesystem '%s'", destFS),
+					err,
+				),
+				).Msg("")
+			}
+		}()
+	}
 
 	area := conf.DefaultArea
 	if area == "" {
 		area = "content"
 	}
+	filter, err := newSourceFilter(logger)
+	if err != nil {
+		ErrorFactory.LogSetError(logger.Error().Stack(), ErrorFactory.NewError(
+			ErrorGOCFL, "cannot create ingest filter", err,
+		)).Msg("")
+		return
+	}
+	defer filter.report(logger)
+	sourceFS, err = filter.wrap(sourceFS, srcPath, area)
+	if err != nil {
+		logger.Panic().Stack().Err(err).Msgf("cannot filter '%s'", srcPath)
+	}
 	var areaPaths = map[string]fs.FS{}
 	for i := 2; i < len(args); i++ {
 		matches := areaPathRegexp.FindStringSubmatch(args[i])
@@ -261,13 +287,18 @@ func doCreate(cmd *cobra.Command, args []string) {
 			).Msg("")
 			continue
 		}
-		path, err := ocfl.Fullpath(matches[2])
+		path, err := sourcePath(matches[2])
 		if err != nil {
 			logger.Panic().Stack().Err(err).Msgf("cannot get fullpath for '%s'", matches[2])
 		}
-		areaPaths[matches[1]], err = fsFactory.Get(path, true)
+		areaPaths[matches[1]], err = openSource(context.Background(), fsFactory, path)
+		if err != nil {
+			logger.Panic().Stack().Err(err).Msgf("cannot open source '%s'", args[i])
+		}
+		defer writefs.Close(areaPaths[matches[1]])
+		areaPaths[matches[1]], err = filter.wrap(areaPaths[matches[1]], path, matches[1])
 		if err != nil {
-			logger.Panic().Stack().Err(err).Msgf("cannot get filesystem for '%s'", args[i])
+			logger.Panic().Stack().Err(err).Msgf("cannot filter '%s'", args[i])
 		}
 	}
 
@@ -330,7 +361,38 @@ func doCreate(cmd *cobra.Command, args []string) {
 		}
 	}()
 
-	ctx := ocfl.NewContextValidation(context.TODO())
+	cmdCtx, stop := cancelContext()
+	defer stop()
+	cmdCtx, endProgress, err := progressContext(cmdCtx)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msg("cannot initialize progress output")
+		return
+	}
+	defer endProgress()
+	ctx := ocfl.NewContextValidation(cmdCtx)
+	if dryRun {
+		if err := dryRunObjectByPath(
+			ctx,
+			nil,
+			ocfl.OCFLVersion(conf.Init.OCFLVersion),
+			conf.Init.Digest,
+			fixityAlgs,
+			objectExtensionManager,
+			conf.Add.Deduplicate,
+			flagObjectID,
+			sourceFS,
+			area,
+			areaPaths,
+			false,
+			nil,
+			planFormat,
+			os.Stdout,
+			logger,
+		); err != nil {
+			ErrorFactory.LogSetError(logger.Error(), err).Msg("cannot plan new object")
+		}
+		return
+	}
 	storageRoot, err := ocfl.CreateStorageRoot(
 		ctx,
 		destFS,
@@ -355,12 +417,16 @@ func doCreate(cmd *cobra.Command, args []string) {
 		}
 		ErrorFactory.LogSetError(logger.Error(), err).Msg("cannot create new storage root")
 	}
+	storageRoot.SetLockOptions(objectLocks(ocflPath))
 
 	_, err = addObjectByPath(
+		ctx,
 		storageRoot,
 		fixityAlgs,
 		objectExtensionManager,
 		conf.Add.Deduplicate,
+		ingestWorkers(ocflPath, conf.Add.Workers, logger),
+		ocfl.IngestMode(conf.Add.IngestMode),
 		flagObjectID,
 		conf.Add.User.Name,
 		conf.Add.User.Address,
@@ -369,6 +435,7 @@ func doCreate(cmd *cobra.Command, args []string) {
 		area,
 		areaPaths,
 		false,
+		nil,
 		logger,
 	)
 	if err != nil {
@@ -0,0 +1,272 @@
+package cmd
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"slices"
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/je4/filesystem/v3/pkg/writefs"
+	"github.com/je4/utils/v2/pkg/checksum"
+	"github.com/je4/utils/v2/pkg/zLogger"
+	"github.com/ocfl-archive/gocfl/v2/pkg/ocfl"
+	"github.com/ocfl-archive/gocfl/v2/pkg/subsystem/dedup"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/pkgerrors"
+	"github.com/spf13/cobra"
+	ublogger "gitlab.switch.ch/ub-unibas/go-ublogger/v2"
+	"go.ub.unibas.ch/cloud/certloader/v2/pkg/loader"
+)
+
+var dedupReportCmd = &cobra.Command{
+	Use:   "dedup-report [path to ocfl structure]",
+	Short: "reports content, which is stored in more than one object",
+	Long: "builds an index of the digests of all objects of the storage root from their inventory manifests. " +
+		"content with more than one stored copy is reported as cluster with its objects, versions and logical paths, ordered by wasted bytes",
+	Example: "gocfl dedup-report ./archive --format csv --output ./duplicates.csv --top 100",
+	Args:    cobra.ExactArgs(1),
+	Run:     doDedupReport,
+}
+
+func initDedupReport() {
+	dedupReportCmd.Flags().String("format", "", fmt.Sprintf("output format (%s)", strings.Join(dedup.Formats, "|")))
+	dedupReportCmd.Flags().String("output", "", "output file (default stdout)")
+	dedupReportCmd.Flags().Int("top", 0, "number of clusters with the most wasted bytes to list, 0 lists all (default 20)")
+	dedupReportCmd.Flags().StringP("digest", "d", "", "digest algorithm of the index (default digest of the storage root)")
+}
+
+func doDedupReportConf(cmd *cobra.Command) {
+	if str := getFlagString(cmd, "format"); str != "" {
+		conf.DedupReport.Format = str
+	}
+	conf.DedupReport.Format = strings.ToLower(conf.DedupReport.Format)
+	if conf.DedupReport.Format == "" {
+		conf.DedupReport.Format = dedup.FormatText
+	}
+	if !slices.Contains(dedup.Formats, conf.DedupReport.Format) {
+		_ = cmd.Help()
+		cobra.CheckErr(errors.Errorf("invalid format '%s' for flag 'format' or 'DedupReport.Format' config file entry", conf.DedupReport.Format))
+	}
+	if str := getFlagString(cmd, "output"); str != "" {
+		conf.DedupReport.Output = str
+	}
+	if i, ok := getFlagInt(cmd, "top"); ok {
+		conf.DedupReport.Top = i
+	}
+	if str := getFlagString(cmd, "digest"); str != "" {
+		conf.DedupReport.Digest = checksum.DigestAlgorithm(strings.ToLower(str))
+	}
+	if conf.DedupReport.Digest != "" && !checksum.HashExists(conf.DedupReport.Digest) {
+		_ = cmd.Help()
+		cobra.CheckErr(errors.Errorf("invalid digest '%s' for flag 'digest' or 'DedupReport.Digest' config file entry", conf.DedupReport.Digest))
+	}
+}
+
+func doDedupReport(cmd *cobra.Command, args []string) {
+	ocflPath, err := ocfl.Fullpath(args[0])
+	if err != nil {
+		cobra.CheckErr(err)
+		return
+	}
+
+	// create logger instance
+	hostname, err := os.Hostname()
+	if err != nil {
+		log.Fatalf("cannot get hostname: %v", err)
+	}
+
+	var loggerTLSConfig *tls.Config
+	var loggerLoader io.Closer
+	if conf.Log.Stash.TLS != nil {
+		loggerTLSConfig, loggerLoader, err = loader.CreateClientLoader(conf.Log.Stash.TLS, nil)
+		if err != nil {
+			log.Fatalf("cannot create client loader: %v", err)
+		}
+		defer loggerLoader.Close()
+	}
+
+	zerolog.ErrorStackMarshaler = pkgerrors.MarshalStack
+	_logger, _logstash, _logfile, err := ublogger.CreateUbMultiLoggerTLS(conf.Log.Level, conf.Log.File,
+		ublogger.SetDataset(conf.Log.Stash.Dataset),
+		ublogger.SetLogStash(conf.Log.Stash.LogstashHost, conf.Log.Stash.LogstashPort, conf.Log.Stash.Namespace, conf.Log.Stash.LogstashTraceLevel),
+		ublogger.SetTLS(conf.Log.Stash.TLS != nil),
+		ublogger.SetTLSConfig(loggerTLSConfig),
+	)
+	if err != nil {
+		log.Fatalf("cannot create logger: %v", err)
+	}
+	if _logstash != nil {
+		defer _logstash.Close()
+	}
+
+	if _logfile != nil {
+		defer _logfile.Close()
+	}
+
+	l2 := _logger.With().Timestamp().Str("host", hostname).Logger() //.Output(output)
+	var logger zLogger.ZLogger = &l2
+
+	doDedupReportConf(cmd)
+
+	t := startTimer()
+	defer func() { logger.Info().Msgf("Duration: %s", t.String()) }()
+
+	logger.Info().Msgf("indexing '%s'", ocflPath)
+
+	fsFactory, err := initializeFSFactory(nil, nil, nil, true, false, logger)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msg("cannot create filesystem factory")
+		return
+	}
+
+	destFS, err := fsFactory.Get(ocflPath, true)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msgf("cannot get filesystem for '%s'", ocflPath)
+		return
+	}
+	defer func() {
+		if err := writefs.Close(destFS); err != nil {
+			logger.Error().Stack().Err(err).Msgf("cannot close filesystem '%s'", destFS)
+		}
+	}()
+
+	extensionParams := GetExtensionParamValues(cmd, conf)
+	extensionFactory, err := InitExtensionFactory(extensionParams, "", false, nil, nil, nil, nil, logger, conf.TempDir)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msgf("cannot initialize extension factory")
+		return
+	}
+
+	cmdCtx, stop := cancelContext()
+	defer stop()
+	ctx := ocfl.NewContextValidation(cmdCtx)
+	storageRoot, err := ocfl.LoadStorageRoot(ctx, destFS, extensionFactory, logger, ErrorFactory, conf.Init.Documentation)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msg("cannot load storage root")
+		return
+	}
+
+	algorithm := conf.DedupReport.Digest
+	if algorithm == "" {
+		if algorithm = storageRoot.GetDigest(); algorithm == "" {
+			algorithm = checksum.DigestSHA512
+		}
+	}
+	folders, err := storageRoot.GetObjectFolders()
+	if err != nil {
+		logger.Error().Stack().Err(err).Msg("cannot get object folders")
+		return
+	}
+	index := dedup.NewIndex(algorithm)
+	objectFS := map[string]fs.FS{}
+	for _, folder := range folders {
+		if cmdCtx.Err() != nil {
+			break
+		}
+		o, err := storageRoot.LoadObjectByFolder(folder)
+		if err != nil {
+			logger.Error().Stack().Err(err).Msgf("cannot load object in folder '%s'", folder)
+			return
+		}
+		if !indexObject(index, o.GetID(), o.GetInventory()) {
+			logger.Warn().Msgf("object '%s' has no %s digests, skipping", o.GetID(), algorithm)
+			continue
+		}
+		objectFS[o.GetID()] = o.GetFS()
+	}
+
+	report, err := index.Report(func(object, contentPath string) (int64, error) {
+		fi, err := fs.Stat(objectFS[object], contentPath)
+		if err != nil {
+			return 0, errors.WithStack(err)
+		}
+		return fi.Size(), nil
+	}, conf.DedupReport.Top)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msg("cannot build duplicate report")
+		return
+	}
+	logger.Info().Msgf("%d objects, %d digests, %d duplicate clusters, %d bytes wasted", report.Objects, report.Digests, report.Clusters, report.WastedBytes)
+
+	var w io.Writer = os.Stdout
+	if output := conf.DedupReport.Output; output != "" {
+		fp, err := os.Create(output)
+		if err != nil {
+			logger.Error().Stack().Err(err).Msgf("cannot create '%s'", output)
+			return
+		}
+		defer fp.Close()
+		w = fp
+	}
+	if err := report.Write(w, conf.DedupReport.Format); err != nil {
+		logger.Error().Stack().Err(err).Msg("cannot write duplicate report")
+		return
+	}
+	_ = showStatus(ctx, logger)
+}
+
+// indexObject adds the manifest and the version states of inventory to index.
+// If the inventory uses another digest algorithm, the fixity of the index algorithm is used.
+// false is returned, if the object has no digests of the index algorithm
+func indexObject(index *dedup.Index, id string, inventory ocfl.Inventory) bool {
+	manifest := inventory.GetManifest()
+	// digest of the inventory -> digest of the index
+	var translate = map[string]string{}
+	if inventory.GetDigestAlgorithm() == index.Algorithm {
+		for digest := range manifest {
+			translate[digest] = digest
+		}
+	} else {
+		fixity, ok := inventory.GetFixity()[index.Algorithm]
+		if !ok {
+			index.SkipObject(id)
+			return false
+		}
+		contentDigest := map[string]string{}
+		for digest, contentPaths := range fixity {
+			for _, contentPath := range contentPaths {
+				contentDigest[contentPath] = digest
+			}
+		}
+		for digest, contentPaths := range manifest {
+			if len(contentPaths) == 0 {
+				continue
+			}
+			fixityDigest, ok := contentDigest[contentPaths[0]]
+			if !ok {
+				index.SkipObject(id)
+				return false
+			}
+			translate[digest] = fixityDigest
+		}
+	}
+
+	index.AddObject(id)
+	for digest, contentPaths := range manifest {
+		index.AddContent(id, translate[digest], contentPaths)
+	}
+	var seen = map[string]bool{}
+	versions := inventory.GetVersions()
+	for _, versionName := range inventory.GetVersionStrings() {
+		version, ok := versions[versionName]
+		if !ok || version == nil || version.State == nil {
+			continue
+		}
+		for digest, paths := range version.State.State {
+			for _, path := range paths {
+				key := digest + "/" + path
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				index.AddLocation(translate[digest], &dedup.Location{Object: id, Version: versionName, Path: path})
+			}
+		}
+	}
+	return true
+}
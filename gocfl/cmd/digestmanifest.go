@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"io/fs"
+
+	"emperror.dev/errors"
+	"github.com/je4/utils/v2/pkg/checksum"
+	"github.com/ocfl-archive/gocfl/v2/pkg/ocfl"
+	"github.com/ocfl-archive/gocfl/v2/pkg/subsystem/digestmanifest"
+	"github.com/ocfl-archive/gocfl/v2/pkg/subsystem/sourcefs"
+	"github.com/spf13/cobra"
+)
+
+// initDigestManifestFlag adds the flag for trusted digests of the source to cmd
+func initDigestManifestFlag(cmd *cobra.Command) {
+	cmd.Flags().String("digest-manifest", "", "file with trusted digests of the source (sha512sum or BagIt manifest), replaces hashing before deduplication")
+}
+
+// getTrustedDigests loads the digest manifest of the flag digest-manifest for the files of sourceFS in area.
+// Paths of a BagIt manifest are relative to the bag. If sourceFS is the payload folder, the prefix 'data/' is removed.
+// Without digest manifest, the digests of a url manifest are trusted, so the files are downloaded only once.
+// digest is the preferred algorithm of the url manifest digests
+func getTrustedDigests(cmd *cobra.Command, sourceFS fs.FS, area string, digest checksum.DigestAlgorithm) (*ocfl.TrustedDigests, error) {
+	filename := getFlagString(cmd, "digest-manifest")
+	if filename == "" {
+		if httpFS, ok := sourceFS.(*sourcefs.HTTPFS); ok {
+			algorithm, digests := httpFS.Digests(digest)
+			return &ocfl.TrustedDigests{
+				Algorithm: algorithm,
+				Area:      area,
+				Digests:   digests,
+			}, nil
+		}
+		return nil, nil
+	}
+	manifest, err := digestmanifest.Load(filename)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if manifest.Format == digestmanifest.FormatBagIt {
+		if fi, err := fs.Stat(sourceFS, digestmanifest.BagItPayload); err != nil || !fi.IsDir() {
+			manifest.StripPrefix(digestmanifest.BagItPayload)
+		}
+	}
+	return &ocfl.TrustedDigests{
+		Algorithm: manifest.Algorithm,
+		Area:      area,
+		Digests:   manifest.Digests,
+	}, nil
+}
@@ -3,11 +3,14 @@ package cmd
 import (
 	"context"
 	"crypto/tls"
+	"emperror.dev/errors"
 	"github.com/je4/filesystem/v3/pkg/writefs"
 	"github.com/je4/utils/v2/pkg/zLogger"
+	"github.com/ocfl-archive/gocfl/v2/config"
 	"github.com/ocfl-archive/gocfl/v2/data/displaydata"
 	"github.com/ocfl-archive/gocfl/v2/gocfl/cmd/display"
 	"github.com/ocfl-archive/gocfl/v2/pkg/ocfl"
+	"github.com/ocfl-archive/gocfl/v2/pkg/subsystem/search"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/pkgerrors"
 	"github.com/spf13/cobra"
@@ -28,9 +31,10 @@ var displayCmd = &cobra.Command{
 	Aliases: []string{"viewer"},
 	Short:   "show content of ocfl object in webbrowser",
 	//Long:    "an utterly useless command for testing",
-	Example: "gocfl display ./archive.zip",
-	Args:    cobra.MinimumNArgs(1),
-	Run:     doDisplay,
+	Example: "gocfl display ./archive.zip\n" +
+		"gocfl display --config ./display.toml",
+	Args: cobra.MaximumNArgs(1),
+	Run:  doDisplay,
 }
 
 /*
@@ -50,6 +54,9 @@ func initDisplay() {
 	displayCmd.Flags().StringP("display-tls-cert", "c", "", "path to tls certificate")
 	displayCmd.Flags().StringP("display-tls-key", "k", "", "path to tls certificate key")
 	displayCmd.Flags().Bool("obfuscate", false, "obfuscate metadata")
+	displayCmd.Flags().Bool("display-search", true, "enable search (not available with obfuscate)")
+	displayCmd.Flags().String("display-search-index", "", "file to persist the search index between runs")
+	displayCmd.Flags().Bool("display-webdav", true, "enable read-only webdav access at /dav (not available with obfuscate)")
 }
 
 func doDisplayConf(cmd *cobra.Command) {
@@ -71,13 +78,26 @@ func doDisplayConf(cmd *cobra.Command) {
 	if b, ok := getFlagBool(cmd, "obfuscate"); ok {
 		conf.Display.Obfuscate = b
 	}
+	if b, ok := getFlagBool(cmd, "display-search"); ok {
+		conf.Display.Search = b
+	}
+	if b, ok := getFlagBool(cmd, "display-webdav"); ok {
+		conf.Display.WebDAV = b
+	}
+	if str := getFlagString(cmd, "display-search-index"); str != "" {
+		conf.Display.SearchIndex = str
+	}
 }
 
 func doDisplay(cmd *cobra.Command, args []string) {
-	ocflPath, err := ocfl.Fullpath(args[0])
-	if err != nil {
-		cobra.CheckErr(err)
-		return
+	var ocflPath string
+	var err error
+	if len(args) > 0 {
+		ocflPath, err = ocfl.Fullpath(args[0])
+		if err != nil {
+			cobra.CheckErr(err)
+			return
+		}
 	}
 
 	// create logger instance
@@ -122,25 +142,12 @@ func doDisplay(cmd *cobra.Command, args []string) {
 
 	doDisplayConf(cmd)
 
-	logger.Info().Msgf("opening '%s'", ocflPath)
-
-	fsFactory, err := initializeFSFactory(nil, nil, nil, true, true, logger)
-	if err != nil {
-		logger.Error().Stack().Err(err).Msg("cannot create filesystem factory")
+	if ocflPath == "" && len(conf.Display.Roots) == 0 {
+		cmd.Help()
+		cobra.CheckErr(errors.New("no path to ocfl structure and no storage roots in section [Display.Roots] of config file"))
 		return
 	}
 
-	destFS, err := fsFactory.Get(ocflPath, true)
-	if err != nil {
-		logger.Error().Stack().Err(err).Msgf("cannot get filesystem for '%s'", ocflPath)
-		return
-	}
-	defer func() {
-		if err := writefs.Close(destFS); err != nil {
-			logger.Error().Stack().Err(err).Msgf("cannot close filesystem for '%s'", destFS)
-		}
-	}()
-
 	extensionParams := GetExtensionParamValues(cmd, conf)
 	extensionFactory, err := InitExtensionFactory(extensionParams, "", false, nil, nil, nil, nil, logger, conf.TempDir)
 	if err != nil {
@@ -148,16 +155,6 @@ func doDisplay(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	ctx := ocfl.NewContextValidation(context.TODO())
-	if !writefs.HasContent(destFS) {
-
-	}
-	storageRoot, err := ocfl.LoadStorageRoot(ctx, destFS, extensionFactory, logger, ErrorFactory, conf.Init.Documentation)
-	if err != nil {
-		logger.Error().Stack().Err(err).Msg("cannot load storage root")
-		return
-	}
-
 	urlC, _ := url.Parse(conf.Display.AddrExt)
 	var templateFS fs.FS
 	if conf.Display.Templates == "" {
@@ -169,12 +166,63 @@ func doDisplay(cmd *cobra.Command, args []string) {
 	} else {
 		templateFS = os.DirFS(conf.Display.Templates)
 	}
-	srv, err := display.NewServer(storageRoot, "gocfl", conf.Display.Addr, urlC, displaydata.WebRoot, templateFS, conf.Display.Obfuscate, logger, io.Discard)
+
+	var storageRoot ocfl.StorageRoot
+	var searchIndex *search.Index
+	if ocflPath != "" {
+		var rootFS fs.FS
+		storageRoot, rootFS, err = loadDisplayRoot(ocflPath, conf.S3, extensionFactory, logger)
+		if err != nil {
+			logger.Error().Stack().Err(err).Msgf("cannot load storage root '%s'", ocflPath)
+			return
+		}
+		defer func() {
+			if err := writefs.Close(rootFS); err != nil {
+				logger.Error().Stack().Err(err).Msgf("cannot close filesystem for '%s'", rootFS)
+			}
+		}()
+		searchIndex = startSearchIndex(storageRoot, conf.Display.SearchIndex, logger)
+		if searchIndex != nil {
+			defer saveSearchIndex(searchIndex, conf.Display.SearchIndex, logger)
+		}
+	}
+
+	srv, err := display.NewServer(storageRoot, "gocfl", conf.Display.Addr, urlC, displaydata.WebRoot, templateFS, conf.Display.Obfuscate, conf.Display.WebDAV, searchIndex, logger, io.Discard)
 	if err != nil {
 		logger.Error().Stack().Err(err).Msg("cannot create server")
 		return
 	}
 
+	for name, rootConf := range conf.Display.Roots {
+		rootPath, err := ocfl.Fullpath(rootConf.Path)
+		if err != nil {
+			logger.Error().Stack().Err(err).Msgf("invalid path '%s' for storage root '%s'", rootConf.Path, name)
+			return
+		}
+		s3Config := rootConf.S3
+		if s3Config == nil {
+			s3Config = conf.S3
+		}
+		root, rootFS, err := loadDisplayRoot(rootPath, s3Config, extensionFactory, logger)
+		if err != nil {
+			logger.Error().Stack().Err(err).Msgf("cannot load storage root '%s' from '%s'", name, rootPath)
+			return
+		}
+		defer func() {
+			if err := writefs.Close(rootFS); err != nil {
+				logger.Error().Stack().Err(err).Msgf("cannot close filesystem for '%s'", rootFS)
+			}
+		}()
+		rootIndex := startSearchIndex(root, rootConf.SearchIndex, logger)
+		if rootIndex != nil {
+			defer saveSearchIndex(rootIndex, rootConf.SearchIndex, logger)
+		}
+		if err := srv.AddRoot(name, root, rootIndex); err != nil {
+			logger.Error().Stack().Err(err).Msgf("cannot add storage root '%s'", name)
+			return
+		}
+	}
+
 	go func() {
 		if err := srv.ListenAndServe("", ""); err != nil {
 			logger.Error().Stack().Err(err).Msgf("cannot start server")
@@ -210,3 +258,65 @@ func doDisplay(cmd *cobra.Command, args []string) {
 	logger.Info().Msg("server stopped")
 
 }
+
+// loadDisplayRoot opens a storage root with its own filesystem factory
+func loadDisplayRoot(ocflPath string, s3Config *config.S3Config, extensionFactory *ocfl.ExtensionFactory, logger zLogger.ZLogger) (ocfl.StorageRoot, fs.FS, error) {
+	logger.Info().Msgf("opening '%s'", ocflPath)
+
+	fsFactory, err := initializeFSFactory(nil, nil, s3Config, true, true, logger)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "cannot create filesystem factory")
+	}
+
+	destFS, err := fsFactory.Get(ocflPath, true)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "cannot get filesystem for '%s'", ocflPath)
+	}
+
+	ctx := ocfl.NewContextValidation(context.TODO())
+	storageRoot, err := ocfl.LoadStorageRoot(ctx, destFS, extensionFactory, logger, ErrorFactory, conf.Init.Documentation)
+	if err != nil {
+		writefs.Close(destFS)
+		return nil, nil, errors.Wrap(err, "cannot load storage root")
+	}
+	return storageRoot, destFS, nil
+}
+
+// startSearchIndex creates the search index of a storage root and refreshes it in the background
+func startSearchIndex(storageRoot ocfl.StorageRoot, filename string, logger zLogger.ZLogger) *search.Index {
+	if !conf.Display.Search || conf.Display.Obfuscate {
+		return nil
+	}
+	searchIndex := search.NewIndex()
+	if filename != "" {
+		if fp, err := os.Open(filename); err == nil {
+			if err := searchIndex.Load(fp); err != nil {
+				logger.Warn().Err(err).Msgf("cannot load search index from '%s'", filename)
+			}
+			fp.Close()
+		}
+	}
+	go func() {
+		if err := searchIndex.Refresh(storageRoot, logger); err != nil {
+			logger.Error().Stack().Err(err).Msgf("cannot build search index for '%s'", storageRoot.String())
+		}
+		logger.Info().Msgf("search index of '%s' contains %d objects", storageRoot.String(), len(searchIndex.ObjectIDs()))
+		saveSearchIndex(searchIndex, filename, logger)
+	}()
+	return searchIndex
+}
+
+func saveSearchIndex(searchIndex *search.Index, filename string, logger zLogger.ZLogger) {
+	if filename == "" {
+		return
+	}
+	fp, err := os.Create(filename)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msgf("cannot create search index file '%s'", filename)
+		return
+	}
+	defer fp.Close()
+	if err := searchIndex.Save(fp); err != nil {
+		logger.Error().Stack().Err(err).Msgf("cannot write search index file '%s'", filename)
+	}
+}
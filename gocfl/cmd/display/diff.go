@@ -0,0 +1,116 @@
+package display
+
+import (
+	"emperror.dev/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/ocfl-archive/gocfl/v2/pkg/ocfl"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// maximum file size for inline text diff
+const textDiffMaxSize = 512 * 1024
+
+func isTextMimetype(mimetype string) bool {
+	mimetype, _, _ = strings.Cut(mimetype, ";")
+	return strings.HasPrefix(mimetype, "text/") ||
+		mimetype == "application/json" ||
+		mimetype == "application/xml" ||
+		strings.HasSuffix(mimetype, "+xml") ||
+		strings.HasSuffix(mimetype, "+json")
+}
+
+func (s *Server) readText(digest string) (string, error) {
+	file, ok := s.metadata.Files[digest]
+	if !ok || len(file.InternalName) == 0 {
+		return "", errors.Errorf("no file with checksum %s found", digest)
+	}
+	fp, err := s.object.GetFS().Open(file.InternalName[0])
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot open '%s'", file.InternalName[0])
+	}
+	defer fp.Close()
+	data, err := io.ReadAll(io.LimitReader(fp, textDiffMaxSize+1))
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot read '%s'", file.InternalName[0])
+	}
+	if len(data) > textDiffMaxSize {
+		return "", errors.Errorf("'%s' too large for text diff", file.InternalName[0])
+	}
+	if !utf8.Valid(data) {
+		return "", errors.Errorf("'%s' is not valid utf-8", file.InternalName[0])
+	}
+	return string(data), nil
+}
+
+func (s *Server) diff(c *gin.Context) {
+	var err error
+	type idParam struct {
+		ID   string `uri:"id" binding:"required"`
+		From string `uri:"from" binding:"required"`
+		To   string `uri:"to" binding:"required"`
+	}
+	var iop idParam
+	if err = c.ShouldBindUri(&iop); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	iop.ID, err = url.PathUnescape(iop.ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot unescape '%s'", iop.ID).Error()})
+		return
+	}
+	if err := s.loadObject(iop.ID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	versionDiff, err := s.metadata.Diff(iop.From, iop.To)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if c.Query("format") == "json" {
+		c.JSON(http.StatusOK, versionDiff)
+		return
+	}
+
+	var textDiffs = map[string][]DiffLine{}
+	if !s.obfuscate {
+		for _, fd := range versionDiff.Files {
+			if fd.Type != ocfl.DiffChanged || !isTextMimetype(fd.Mimetype) || !isTextMimetype(fd.OldMimeType) {
+				continue
+			}
+			oldText, err := s.readText(fd.OldDigest)
+			if err != nil {
+				s.log.Debug().Err(err).Msgf("no text diff for '%s'", fd.Path)
+				continue
+			}
+			newText, err := s.readText(fd.Digest)
+			if err != nil {
+				s.log.Debug().Err(err).Msgf("no text diff for '%s'", fd.Path)
+				continue
+			}
+			if lines, ok := textDiff(oldText, newText); ok {
+				textDiffs[fd.Path] = lines
+			}
+		}
+	}
+
+	var counts = map[string]int{}
+	for _, fd := range versionDiff.Files {
+		counts[string(fd.Type)]++
+	}
+
+	var params = map[string]any{
+		"title":     "Diff",
+		"id":        s.object.GetID(),
+		"versions":  s.metadata.Versions,
+		"diff":      versionDiff,
+		"counts":    counts,
+		"textDiffs": textDiffs,
+	}
+	c.HTML(http.StatusOK, "diff.gohtml", gin.H(params))
+}
@@ -0,0 +1,328 @@
+package display
+
+import (
+	"emperror.dev/errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// default histogram buckets in seconds
+var defaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+type histogram struct {
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+func newHistogram(buckets []float64) *histogram {
+	return &histogram{
+		buckets: buckets,
+		counts:  make([]uint64, len(buckets)),
+	}
+}
+
+func (h *histogram) observe(value float64) {
+	for i, bound := range h.buckets {
+		if value <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += value
+	h.count++
+}
+
+func (h *histogram) write(w io.Writer, name, labels string) {
+	sep := ""
+	if labels != "" {
+		sep = ","
+	}
+	for i, bound := range h.buckets {
+		fmt.Fprintf(w, "%s_bucket{%s%sle=\"%s\"} %d\n", name, labels, sep, strconv.FormatFloat(bound, 'g', -1, 64), h.counts[i])
+	}
+	fmt.Fprintf(w, "%s_bucket{%s%sle=\"+Inf\"} %d\n", name, labels, sep, h.count)
+	if labels != "" {
+		labels = "{" + labels + "}"
+	}
+	fmt.Fprintf(w, "%s_sum%s %s\n", name, labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+	fmt.Fprintf(w, "%s_count%s %d\n", name, labels, h.count)
+}
+
+type requestKey struct {
+	route, method string
+	status        int
+}
+
+// Metrics collects runtime metrics of the display server and writes them in prometheus text format
+type Metrics struct {
+	sync.Mutex
+	started         time.Time
+	requests        map[requestKey]uint64
+	requestDuration map[string]*histogram // route
+	bytesServed     map[string]uint64     // route
+	objectLoad      map[string]*histogram // root
+	cacheHits       map[string]uint64     // root
+	cacheMisses     map[string]uint64     // root
+	validation      map[string]*histogram // root
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{
+		started:         time.Now(),
+		requests:        map[requestKey]uint64{},
+		requestDuration: map[string]*histogram{},
+		bytesServed:     map[string]uint64{},
+		objectLoad:      map[string]*histogram{},
+		cacheHits:       map[string]uint64{},
+		cacheMisses:     map[string]uint64{},
+		validation:      map[string]*histogram{},
+	}
+}
+
+// Middleware counts requests and measures their latency per route
+func (m *Metrics) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		m.ObserveRequest(route, c.Request.Method, c.Writer.Status(), c.Writer.Size(), time.Since(start))
+	}
+}
+
+func (m *Metrics) ObserveRequest(route, method string, status, size int, duration time.Duration) {
+	m.Lock()
+	defer m.Unlock()
+	m.requests[requestKey{route: route, method: method, status: status}]++
+	h, ok := m.requestDuration[route]
+	if !ok {
+		h = newHistogram(defaultBuckets)
+		m.requestDuration[route] = h
+	}
+	h.observe(duration.Seconds())
+	if size > 0 {
+		m.bytesServed[route] += uint64(size)
+	}
+}
+
+func (m *Metrics) ObserveObjectLoad(root string, duration time.Duration) {
+	m.Lock()
+	defer m.Unlock()
+	observe(m.objectLoad, root, duration)
+}
+
+func (m *Metrics) ObserveValidation(root string, duration time.Duration) {
+	m.Lock()
+	defer m.Unlock()
+	observe(m.validation, root, duration)
+}
+
+// ObserveCache counts object cache hits and misses
+func (m *Metrics) ObserveCache(root string, hit bool) {
+	m.Lock()
+	defer m.Unlock()
+	if hit {
+		m.cacheHits[root]++
+	} else {
+		m.cacheMisses[root]++
+	}
+}
+
+func observe(hists map[string]*histogram, root string, duration time.Duration) {
+	h, ok := hists[root]
+	if !ok {
+		h = newHistogram(defaultBuckets)
+		hists[root] = h
+	}
+	h.observe(duration.Seconds())
+}
+
+func label(name, value string) string {
+	return fmt.Sprintf("%s=%q", name, value)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	var keys = make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// Write writes all metrics in prometheus text exposition format
+func (m *Metrics) Write(w io.Writer) {
+	m.Lock()
+	defer m.Unlock()
+
+	fmt.Fprintln(w, "# HELP gocfl_display_uptime_seconds Time since start of the display server.")
+	fmt.Fprintln(w, "# TYPE gocfl_display_uptime_seconds gauge")
+	fmt.Fprintf(w, "gocfl_display_uptime_seconds %s\n", strconv.FormatFloat(time.Since(m.started).Seconds(), 'f', 3, 64))
+
+	var keys = make([]requestKey, 0, len(m.requests))
+	for key := range m.requests {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b requestKey) int {
+		if c := strings.Compare(a.route, b.route); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.method, b.method); c != 0 {
+			return c
+		}
+		return a.status - b.status
+	})
+	fmt.Fprintln(w, "# HELP gocfl_display_requests_total Number of http requests.")
+	fmt.Fprintln(w, "# TYPE gocfl_display_requests_total counter")
+	for _, key := range keys {
+		fmt.Fprintf(w, "gocfl_display_requests_total{%s,%s,%s} %d\n",
+			label("route", key.route), label("method", key.method), label("status", strconv.Itoa(key.status)),
+			m.requests[key])
+	}
+
+	fmt.Fprintln(w, "# HELP gocfl_display_request_duration_seconds Latency of http requests.")
+	fmt.Fprintln(w, "# TYPE gocfl_display_request_duration_seconds histogram")
+	for _, route := range sortedKeys(m.requestDuration) {
+		m.requestDuration[route].write(w, "gocfl_display_request_duration_seconds", label("route", route))
+	}
+
+	fmt.Fprintln(w, "# HELP gocfl_display_bytes_served_total Number of bytes in http responses.")
+	fmt.Fprintln(w, "# TYPE gocfl_display_bytes_served_total counter")
+	for _, route := range sortedKeys(m.bytesServed) {
+		fmt.Fprintf(w, "gocfl_display_bytes_served_total{%s} %d\n", label("route", route), m.bytesServed[route])
+	}
+
+	fmt.Fprintln(w, "# HELP gocfl_display_object_load_duration_seconds Time to load an object and its metadata.")
+	fmt.Fprintln(w, "# TYPE gocfl_display_object_load_duration_seconds histogram")
+	for _, root := range sortedKeys(m.objectLoad) {
+		m.objectLoad[root].write(w, "gocfl_display_object_load_duration_seconds", label("root", root))
+	}
+
+	fmt.Fprintln(w, "# HELP gocfl_display_object_cache_hits_total Number of requests served from the loaded object.")
+	fmt.Fprintln(w, "# TYPE gocfl_display_object_cache_hits_total counter")
+	for _, root := range sortedKeys(m.cacheHits) {
+		fmt.Fprintf(w, "gocfl_display_object_cache_hits_total{%s} %d\n", label("root", root), m.cacheHits[root])
+	}
+	fmt.Fprintln(w, "# HELP gocfl_display_object_cache_misses_total Number of requests, which needed to load an object.")
+	fmt.Fprintln(w, "# TYPE gocfl_display_object_cache_misses_total counter")
+	for _, root := range sortedKeys(m.cacheMisses) {
+		fmt.Fprintf(w, "gocfl_display_object_cache_misses_total{%s} %d\n", label("root", root), m.cacheMisses[root])
+	}
+	fmt.Fprintln(w, "# HELP gocfl_display_object_cache_hit_ratio Ratio of object cache hits to all object requests.")
+	fmt.Fprintln(w, "# TYPE gocfl_display_object_cache_hit_ratio gauge")
+	var roots = sortedKeys(m.cacheHits)
+	for _, root := range sortedKeys(m.cacheMisses) {
+		roots = InsertSortedUnique(roots, root)
+	}
+	for _, root := range roots {
+		hits, misses := m.cacheHits[root], m.cacheMisses[root]
+		fmt.Fprintf(w, "gocfl_display_object_cache_hit_ratio{%s} %s\n", label("root", root),
+			strconv.FormatFloat(float64(hits)/float64(hits+misses), 'f', 4, 64))
+	}
+
+	fmt.Fprintln(w, "# HELP gocfl_display_validation_duration_seconds Time to validate an object.")
+	fmt.Fprintln(w, "# TYPE gocfl_display_validation_duration_seconds histogram")
+	for _, root := range sortedKeys(m.validation) {
+		m.validation[root].write(w, "gocfl_display_validation_duration_seconds", label("root", root))
+	}
+}
+
+func (s *Server) metricsHandler(c *gin.Context) {
+	c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.Status(http.StatusOK)
+	s.metrics.Write(c.Writer)
+}
+
+func (s *Server) healthz(c *gin.Context) {
+	c.String(http.StatusOK, "ok")
+}
+
+// readyz reports ready, if all storage roots are loaded and all search indexes are built
+func (s *Server) readyz(c *gin.Context) {
+	var servers = []*Server{}
+	if s.storageRoot != nil {
+		servers = append(servers, s)
+	}
+	for _, name := range s.rootNames {
+		servers = append(servers, s.roots[name])
+	}
+	var notReady = []string{}
+	for _, srv := range servers {
+		name := srv.rootName
+		if name == "" {
+			name = "default"
+		}
+		if srv.storageRoot == nil {
+			notReady = append(notReady, fmt.Sprintf("storage root '%s' not loaded", name))
+			continue
+		}
+		if srv.searchIndex != nil && srv.searchIndex.LastRefresh().IsZero() {
+			notReady = append(notReady, fmt.Sprintf("search index of '%s' not built", name))
+		}
+	}
+	if len(servers) == 0 {
+		notReady = append(notReady, "no storage root")
+	}
+	if len(notReady) > 0 {
+		c.String(http.StatusServiceUnavailable, strings.Join(notReady, "\n"))
+		return
+	}
+	c.String(http.StatusOK, "ok")
+}
+
+// metricsRoot returns the name of the storage root used as metrics label
+func (s *Server) metricsRoot() string {
+	if s.rootName == "" {
+		return "default"
+	}
+	return s.rootName
+}
+
+// validate checks the object and records the duration of the validation
+func (s *Server) validate(c *gin.Context) {
+	var err error
+	type idParam struct {
+		ID string `uri:"id" binding:"required"`
+	}
+	var iop idParam
+	if err = c.ShouldBindUri(&iop); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	iop.ID, err = url.PathUnescape(iop.ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot unescape '%s'", iop.ID).Error()})
+		return
+	}
+	object, err := s.storageRoot.LoadObjectByID(iop.ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot load object %s", iop.ID).Error()})
+		return
+	}
+	start := time.Now()
+	err = object.Check(c.Request.Context())
+	duration := time.Since(start)
+	s.metrics.ObserveValidation(s.metricsRoot(), duration)
+	var result = gin.H{
+		"id":       iop.ID,
+		"duration": duration.Seconds(),
+	}
+	if err != nil {
+		s.log.Error().Err(err).Msgf("cannot validate object %s", iop.ID)
+		result["error"] = err.Error()
+		c.JSON(http.StatusInternalServerError, result)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
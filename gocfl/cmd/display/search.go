@@ -0,0 +1,59 @@
+package display
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/ocfl-archive/gocfl/v2/pkg/subsystem/search"
+	"net/http"
+	"strconv"
+)
+
+const searchPageSize = 100
+
+func (s *Server) searchQuery(c *gin.Context) *search.Query {
+	q := &search.Query{
+		Text:   c.Query("q"),
+		Filter: map[string][]string{},
+		Limit:  searchPageSize,
+	}
+	for _, facet := range search.Facets {
+		if values := c.QueryArray(facet); len(values) > 0 {
+			q.Filter[facet] = values
+		}
+	}
+	if offset, err := strconv.Atoi(c.Query("offset")); err == nil && offset > 0 {
+		q.Offset = offset
+	}
+	if limit, err := strconv.Atoi(c.Query("limit")); err == nil && limit > 0 {
+		q.Limit = limit
+	}
+	return q
+}
+
+func (s *Server) search(c *gin.Context) {
+	q := s.searchQuery(c)
+	result := s.searchIndex.Search(q)
+	var next string
+	if q.Offset+len(result.Hits) < result.Total {
+		values := c.Request.URL.Query()
+		values.Set("offset", strconv.Itoa(q.Offset+len(result.Hits)))
+		next = "search?" + values.Encode()
+	}
+	c.HTML(http.StatusOK, "search.gohtml", gin.H{
+		"title":  "gocfl search",
+		"query":  q,
+		"result": result,
+		"next":   next,
+	})
+}
+
+func (s *Server) apiSearch(c *gin.Context) {
+	c.JSON(http.StatusOK, s.searchIndex.Search(s.searchQuery(c)))
+}
+
+func (s *Server) apiSearchRefresh(c *gin.Context) {
+	if err := s.searchIndex.Refresh(s.storageRoot, s.log); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"objects": s.searchIndex.ObjectIDs()})
+}
@@ -16,7 +16,9 @@ import (
 	iou "github.com/je4/utils/v2/pkg/io"
 	"github.com/je4/utils/v2/pkg/zLogger"
 	"github.com/ocfl-archive/gocfl/v2/pkg/extension"
+	"github.com/ocfl-archive/gocfl/v2/pkg/iiif"
 	"github.com/ocfl-archive/gocfl/v2/pkg/ocfl"
+	"github.com/ocfl-archive/gocfl/v2/pkg/subsystem/search"
 	"github.com/ocfl-archive/indexer/v3/pkg/indexer"
 	"golang.org/x/exp/maps"
 	"html/template"
@@ -26,6 +28,7 @@ import (
 	"net/http"
 	"net/url"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -49,9 +52,16 @@ type Server struct {
 	templateFS     fs.FS
 	obfuscate      bool
 	objectFS       http.FileSystem
+	searchIndex    *search.Index
+	rootName       string
+	prefix         string
+	roots          map[string]*Server
+	rootNames      []string
+	metrics        *Metrics
+	webDAV         bool
 }
 
-func NewServer(storageRoot ocfl.StorageRoot, service, addr string, urlExt *url.URL, dataFS, templateFS fs.FS, obfuscate bool, log zLogger.ZLogger, accessLog io.Writer) (*Server, error) {
+func NewServer(storageRoot ocfl.StorageRoot, service, addr string, urlExt *url.URL, dataFS, templateFS fs.FS, obfuscate, webDAV bool, searchIndex *search.Index, log zLogger.ZLogger, accessLog io.Writer) (*Server, error) {
 	host, port, err := net.SplitHostPort(addr)
 	if err != nil {
 		return nil, emperror.Wrapf(err, "cannot split address %s", addr)
@@ -68,20 +78,105 @@ func NewServer(storageRoot ocfl.StorageRoot, service, addr string, urlExt *url.U
 		accessLog:   accessLog,
 		storageRoot: storageRoot,
 		obfuscate:   obfuscate,
+		searchIndex: searchIndex,
+		roots:       map[string]*Server{},
+		rootNames:   []string{},
+		metrics:     NewMetrics(),
+		webDAV:      webDAV,
 	}
 
 	return srv, nil
 }
 
+// AddRoot adds a named storage root, which is served at /root/{name}
+func (s *Server) AddRoot(name string, storageRoot ocfl.StorageRoot, searchIndex *search.Index) error {
+	if name == "" || strings.ContainsAny(name, "/?#") {
+		return errors.Errorf("invalid storage root name '%s'", name)
+	}
+	if _, ok := s.roots[name]; ok {
+		return errors.Errorf("storage root '%s' already exists", name)
+	}
+	s.roots[name] = &Server{
+		service:     s.service,
+		log:         s.log,
+		urlExt:      s.urlExt,
+		dataFS:      s.dataFS,
+		templateFS:  s.templateFS,
+		obfuscate:   s.obfuscate,
+		storageRoot: storageRoot,
+		searchIndex: searchIndex,
+		rootName:    name,
+		prefix:      "/root/" + url.PathEscape(name),
+		metrics:     s.metrics,
+		webDAV:      s.webDAV,
+	}
+	s.rootNames = InsertSortedUnique(s.rootNames, name)
+	return nil
+}
+
+// registerRoutes registers all routes of a storage root
+func (s *Server) registerRoutes(route gin.IRouter) {
+	route.GET("/", s.storageroot)
+
+	route.GET("/object/id/:id", s.loadObjectID)
+	route.GET("/object/id/:id/manifest", s.manifest)
+	route.GET("/object/id/:id/version/:version", s.version)
+	route.GET("/object/id/:id/version/:version/iiif/manifest.json", s.iiifManifest)
+	route.GET("/object/id/:id/diff/:from/:to", s.diff)
+	route.GET("/object/id/:id/detail/:checksum", s.detail)
+	route.GET("/object/id/:id/report", s.report)
+	route.GET("/object/id/:id/validate", s.validate)
+	route.GET("/object/id/:id/download/:checksum/:filename", s.download)
+	route.GET("/object/id/:id/extension/:extension/download/*path", s.downloadExtFile)
+	route.GET("/object/folder/*path", s.loadObjectPath)
+	route.GET("/object/id/:id/browse/*path", s.loadObjectBrowser)
+	if s.searchIndex != nil {
+		route.GET("/search", s.search)
+		route.GET("/api/search", s.apiSearch)
+		route.POST("/api/search/refresh", s.apiSearchRefresh)
+	}
+	if s.webDAV && !s.obfuscate {
+		s.registerWebDAV(route)
+	}
+
+	route.StaticFS("/static", http.FS(s.dataFS))
+}
+
+// landing page with all named storage roots
+func (s *Server) rootList(c *gin.Context) {
+	type rootEntry struct {
+		Name        string
+		Path        string
+		StorageRoot string
+	}
+	var roots = []*rootEntry{}
+	for _, name := range s.rootNames {
+		roots = append(roots, &rootEntry{
+			Name:        name,
+			Path:        s.roots[name].prefix + "/",
+			StorageRoot: s.roots[name].storageRoot.String(),
+		})
+	}
+	c.HTML(http.StatusOK, "roots.gohtml", gin.H{
+		"title": "gocfl",
+		"roots": roots,
+	})
+}
+
 func (s *Server) ListenAndServe(cert, key string) (err error) {
 	gin.SetMode(gin.ReleaseMode)
 	route := gin.Default()
 	route.UseRawPath = true
 	route.UnescapePathValues = false
 
+	route.Use(s.metrics.Middleware())
+
 	route.GET("/ping", func(c *gin.Context) {
 		c.String(http.StatusOK, "pong")
 	})
+	route.GET("/metrics", s.metricsHandler)
+	route.GET("/healthz", s.healthz)
+	route.GET("/readyz", s.readyz)
 
 	mt := multitemplate.New()
 
@@ -92,6 +187,9 @@ func (s *Server) ListenAndServe(cert, key string) (err error) {
 		"version.gohtml",
 		"detail.gohtml",
 		"report.gohtml",
+		"search.gohtml",
+		"diff.gohtml",
+		"roots.gohtml",
 	}
 
 	for _, tplfile := range tplfiles {
@@ -108,6 +206,12 @@ func (s *Server) ListenAndServe(cert, key string) (err error) {
 		funcMap["humanizeTime"] = func(t time.Time) string {
 			return t.Format("2006-01-02 15:04:05")
 		}
+		funcMap["humanizeDelta"] = func(delta int64) string {
+			if delta < 0 {
+				return "-" + humanize.Bytes(uint64(-delta))
+			}
+			return "+" + humanize.Bytes(uint64(delta))
+		}
 
 		tpl, err := template.New(tplfile).Funcs(funcMap).ParseFS(s.templateFS, tplfile)
 		if err != nil {
@@ -117,20 +221,17 @@ func (s *Server) ListenAndServe(cert, key string) (err error) {
 	}
 
 	route.HTMLRender = mt
-	route.GET("/", s.storageroot)
-	//	route.GET("/:id", s.dashboard)
-
-	route.GET("/object/id/:id", s.loadObjectID)
-	route.GET("/object/id/:id/manifest", s.manifest)
-	route.GET("/object/id/:id/version/:version", s.version)
-	route.GET("/object/id/:id/detail/:checksum", s.detail)
-	route.GET("/object/id/:id/report", s.report)
-	route.GET("/object/id/:id/download/:checksum/:filename", s.download)
-	route.GET("/object/id/:id/extension/:extension/download/*path", s.downloadExtFile)
-	route.GET("/object/folder/*path", s.loadObjectPath)
-	route.GET("/object/id/:id/browse/*path", s.loadObjectBrowser)
-
-	route.StaticFS("/static", http.FS(s.dataFS))
+	if s.storageRoot != nil {
+		s.registerRoutes(route)
+	} else {
+		route.GET("/", s.rootList)
+		route.StaticFS("/static", http.FS(s.dataFS))
+	}
+	route.GET("/roots", s.rootList)
+	for _, name := range s.rootNames {
+		root := s.roots[name]
+		root.registerRoutes(route.Group(root.prefix))
+	}
 
 	s.srv = &http.Server{
 		Addr:    net.JoinHostPort(s.host, s.port),
@@ -183,37 +284,9 @@ func (s *Server) downloadExtFile(c *gin.Context) {
 		return
 	}
 
-	if s.object != nil && s.object.GetID() == iop.ID {
-		if s.metadata == nil {
-			s.metadata, err = s.object.GetMetadata()
-			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot get metadata for object %s", s.object.GetID()).Error()})
-				return
-			}
-			if s.obfuscate {
-				if err := s.metadata.Obfuscate(); err != nil {
-					c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot obfuscate metadata").Error()})
-					return
-				}
-			}
-		}
-	} else {
-		s.object, err = s.storageRoot.LoadObjectByID(iop.ID)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
-		s.metadata, err = s.object.GetMetadata()
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot get metadata for object %s", s.object.GetID()).Error()})
-			return
-		}
-		if s.obfuscate {
-			if err := s.metadata.Obfuscate(); err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot obfuscate metadata").Error()})
-				return
-			}
-		}
+	if err := s.loadObject(iop.ID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 	pathStr := filepath.ToSlash(filepath.Join("extensions", iop.Extension, iop.Path))
 	fp, err := s.object.GetFS().Open(pathStr)
@@ -258,37 +331,9 @@ func (s *Server) download(c *gin.Context) {
 		return
 	}
 
-	if s.object != nil && s.object.GetID() == iop.ID {
-		if s.metadata == nil {
-			s.metadata, err = s.object.GetMetadata()
-			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot get metadata for object %s", s.object.GetID()).Error()})
-				return
-			}
-			if s.obfuscate {
-				if err := s.metadata.Obfuscate(); err != nil {
-					c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot obfuscate metadata").Error()})
-					return
-				}
-			}
-		}
-	} else {
-		s.object, err = s.storageRoot.LoadObjectByID(iop.ID)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
-		s.metadata, err = s.object.GetMetadata()
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot get metadata for object %s", s.object.GetID()).Error()})
-			return
-		}
-		if s.obfuscate {
-			if err := s.metadata.Obfuscate(); err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot obfuscate metadata").Error()})
-				return
-			}
-		}
+	if err := s.loadObject(iop.ID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
 	file, ok := s.metadata.Files[iop.Checksum]
@@ -329,37 +374,9 @@ func (s *Server) detail(c *gin.Context) {
 		return
 	}
 
-	if s.object != nil && s.object.GetID() == iop.ID {
-		if s.metadata == nil {
-			s.metadata, err = s.object.GetMetadata()
-			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot get metadata for object %s", s.object.GetID()).Error()})
-				return
-			}
-			if s.obfuscate {
-				if err := s.metadata.Obfuscate(); err != nil {
-					c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot obfuscate metadata").Error()})
-					return
-				}
-			}
-		}
-	} else {
-		s.object, err = s.storageRoot.LoadObjectByID(iop.ID)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
-		s.metadata, err = s.object.GetMetadata()
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot get metadata for object %s", s.object.GetID()).Error()})
-			return
-		}
-		if s.obfuscate {
-			if err := s.metadata.Obfuscate(); err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot obfuscate metadata").Error()})
-				return
-			}
-		}
+	if err := s.loadObject(iop.ID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
 	file, ok := s.metadata.Files[iop.Checksum]
@@ -515,6 +532,10 @@ func (s *Server) storageroot(c *gin.Context) {
 		"id":          id,
 		"folders":     folders,
 		"storageroot": s.storageRoot.String(),
+		"search":      s.searchIndex != nil,
+		"prefix":      s.prefix,
+		"name":        s.rootName,
+		"roots":       s.rootNames,
 	})
 }
 
@@ -534,39 +555,9 @@ func (s *Server) manifest(c *gin.Context) {
 		return
 	}
 
-	if s.object != nil && s.object.GetID() == iop.ID {
-		if s.metadata == nil {
-			s.metadata, err = s.object.GetMetadata()
-			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot get metadata for object %s", s.object.GetID()).Error()})
-				return
-			}
-			if s.obfuscate {
-				if err := s.metadata.Obfuscate(); err != nil {
-					c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot obfuscate metadata").Error()})
-					return
-				}
-			}
-
-		}
-	} else {
-		s.object, err = s.storageRoot.LoadObjectByID(iop.ID)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
-		s.metadata, err = s.object.GetMetadata()
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot get metadata for object %s", s.object.GetID()).Error()})
-			return
-		}
-		if s.obfuscate {
-			if err := s.metadata.Obfuscate(); err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot obfuscate metadata").Error()})
-				return
-			}
-		}
-
+	if err := s.loadObject(iop.ID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
 	type fEntry struct {
@@ -638,40 +629,16 @@ func (s *Server) version(c *gin.Context) {
 		return
 	}
 
-	if s.object != nil && s.object.GetID() == iop.ID {
-		if s.metadata == nil {
-			s.metadata, err = s.object.GetMetadata()
-			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot get metadata for object %s", s.object.GetID()).Error()})
-				return
-			}
-			if s.obfuscate {
-				if err := s.metadata.Obfuscate(); err != nil {
-					c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot obfuscate metadata").Error()})
-					return
-				}
-			}
-
-		}
-	} else {
-		s.object, err = s.storageRoot.LoadObjectByID(iop.ID)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
-		s.metadata, err = s.object.GetMetadata()
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot get metadata for object %s", s.object.GetID()).Error()})
-			return
-		}
-		if s.obfuscate {
-			if err := s.metadata.Obfuscate(); err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot obfuscate metadata").Error()})
-				return
-			}
-		}
-
+	if err := s.loadObject(iop.ID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	version, ok := s.metadata.ResolveVersion(iop.Version)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("version or tag '%s' not found in object '%s'", iop.Version, iop.ID)})
+		return
 	}
+	iop.Version = version
 
 	type fEntry struct {
 		CTime     string
@@ -735,6 +702,12 @@ func (s *Server) version(c *gin.Context) {
 		}
 	}
 
+	var previous string
+	versionStrings := s.object.GetInventory().GetVersionStrings()
+	if i := slices.Index(versionStrings, iop.Version); i > 0 {
+		previous = versionStrings[i-1]
+	}
+
 	var params = map[string]any{
 		"title":     "Version",
 		"id":        s.object.GetID(),
@@ -742,12 +715,101 @@ func (s *Server) version(c *gin.Context) {
 		"files":     files,
 		"filenames": filenames,
 		"version":   iop.Version,
+		"previous":  previous,
 	}
 
 	c.HTML(http.StatusOK, "version.gohtml", gin.H(params))
 
 }
 
+// loadObject makes sure, that the object with the given id and its metadata are loaded
+func (s *Server) loadObject(id string) error {
+	var err error
+	if s.object != nil && s.object.GetID() == id && s.metadata != nil {
+		s.metrics.ObserveCache(s.metricsRoot(), true)
+		return nil
+	}
+	s.metrics.ObserveCache(s.metricsRoot(), false)
+	start := time.Now()
+	defer func() {
+		s.metrics.ObserveObjectLoad(s.metricsRoot(), time.Since(start))
+	}()
+	if s.object == nil || s.object.GetID() != id {
+		s.metadata = nil
+		s.objectFS = nil
+		s.object, err = s.storageRoot.LoadObjectByID(id)
+		if err != nil {
+			s.object = nil
+			return errors.Wrapf(err, "cannot load object %s", id)
+		}
+	}
+	if s.metadata == nil {
+		s.metadata, err = s.object.GetMetadata()
+		if err != nil {
+			return errors.Wrapf(err, "cannot get metadata for object %s", s.object.GetID())
+		}
+		if s.obfuscate {
+			if err := s.metadata.Obfuscate(); err != nil {
+				return errors.Wrapf(err, "cannot obfuscate metadata")
+			}
+		}
+		s.updateSearchIndex()
+	}
+	return nil
+}
+
+// updateSearchIndex reindexes the current object, if it has changed since the last indexing
+func (s *Server) updateSearchIndex() {
+	if s.searchIndex == nil || s.obfuscate || s.metadata == nil {
+		return
+	}
+	if s.searchIndex.NeedsUpdate(s.metadata.ID, s.metadata.Head) {
+		s.searchIndex.UpdateObject(s.metadata)
+	}
+}
+
+func (s *Server) iiifManifest(c *gin.Context) {
+	var err error
+	type idParam struct {
+		ID      string `uri:"id" binding:"required"`
+		Version string `uri:"version" binding:"required"`
+	}
+	var iop idParam
+	if err = c.ShouldBindUri(&iop); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	iop.ID, err = url.PathUnescape(iop.ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot unescape '%s'", iop.ID).Error()})
+		return
+	}
+	if err := s.loadObject(iop.ID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	version, ok := s.metadata.ResolveVersion(iop.Version)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("version or tag '%s' not found in object '%s'", iop.Version, iop.ID)})
+		return
+	}
+	objectURL := strings.TrimRight(s.urlExt.String(), "/") + s.prefix + "/object/id/" + url.PathEscape(iop.ID)
+	manifest, err := iiif.NewManifest(
+		s.metadata,
+		version,
+		fmt.Sprintf("%s/version/%s/iiif", objectURL, url.PathEscape(iop.Version)),
+		func(digest, logicalPath string) string {
+			return fmt.Sprintf("%s/download/%s/%s", objectURL, digest, url.PathEscape(filepath.Base(logicalPath)))
+		},
+	)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot create iiif manifest for object %s", iop.ID).Error()})
+		return
+	}
+	c.Header("Access-Control-Allow-Origin", "*")
+	c.JSON(http.StatusOK, manifest)
+}
+
 func (s *Server) loadObjectID(c *gin.Context) {
 	var err error
 	type idParam struct {
@@ -763,38 +825,9 @@ func (s *Server) loadObjectID(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot unescape '%s'", iop.ID).Error()})
 		return
 	}
-	if s.object != nil && s.object.GetID() == iop.ID {
-		// already loaded
-		if s.metadata == nil {
-			s.metadata, err = s.object.GetMetadata()
-			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot get metadata for object %s", s.object.GetID()).Error()})
-				return
-			}
-			if s.obfuscate {
-				if err := s.metadata.Obfuscate(); err != nil {
-					c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot obfuscate metadata").Error()})
-					return
-				}
-			}
-		}
-	} else {
-		s.object, err = s.storageRoot.LoadObjectByID(iop.ID)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
-		s.metadata, err = s.object.GetMetadata()
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot get metadata for object %s", s.object.GetID()).Error()})
-			return
-		}
-		if s.obfuscate {
-			if err := s.metadata.Obfuscate(); err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot obfuscate metadata").Error()})
-				return
-			}
-		}
+	if err := s.loadObject(iop.ID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 	s.displayObject(c)
 }
@@ -826,7 +859,7 @@ func (s *Server) loadObjectPath(c *gin.Context) {
 		}
 	}
 
-	c.Redirect(http.StatusPermanentRedirect, s.urlExt.String()+fmt.Sprintf("/object/id/%s", url.PathEscape(s.object.GetID())))
+	c.Redirect(http.StatusPermanentRedirect, strings.TrimRight(s.urlExt.String(), "/")+s.prefix+fmt.Sprintf("/object/id/%s", url.PathEscape(s.object.GetID())))
 	//	s.displayObject(c)
 }
 
@@ -911,38 +944,9 @@ func (s *Server) loadObjectBrowser(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot unescape '%s'", iop.ID).Error()})
 		return
 	}
-	if s.object != nil && s.object.GetID() == iop.ID {
-		// already loaded
-		if s.metadata == nil {
-			s.metadata, err = s.object.GetMetadata()
-			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot get metadata for object %s", s.object.GetID()).Error()})
-				return
-			}
-			if s.obfuscate {
-				if err := s.metadata.Obfuscate(); err != nil {
-					c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot obfuscate metadata").Error()})
-					return
-				}
-			}
-		}
-	} else {
-		s.object, err = s.storageRoot.LoadObjectByID(iop.ID)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
-		s.metadata, err = s.object.GetMetadata()
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot get metadata for object %s", s.object.GetID()).Error()})
-			return
-		}
-		if s.obfuscate {
-			if err := s.metadata.Obfuscate(); err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot obfuscate metadata").Error()})
-				return
-			}
-		}
+	if err := s.loadObject(iop.ID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 	if s.objectFS == nil {
 		objectFS, err := NewObjectFS(s.object)
@@ -975,38 +979,9 @@ func (s *Server) loadObjectContentRoot(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot unescape '%s'", iop.ID).Error()})
 		return
 	}
-	if s.object != nil && s.object.GetID() == iop.ID {
-		// already loaded
-		if s.metadata == nil {
-			s.metadata, err = s.object.GetMetadata()
-			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot get metadata for object %s", s.object.GetID()).Error()})
-				return
-			}
-			if s.obfuscate {
-				if err := s.metadata.Obfuscate(); err != nil {
-					c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot obfuscate metadata").Error()})
-					return
-				}
-			}
-		}
-	} else {
-		s.object, err = s.storageRoot.LoadObjectByID(iop.ID)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
-		s.metadata, err = s.object.GetMetadata()
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot get metadata for object %s", s.object.GetID()).Error()})
-			return
-		}
-		if s.obfuscate {
-			if err := s.metadata.Obfuscate(); err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot obfuscate metadata").Error()})
-				return
-			}
-		}
+	if err := s.loadObject(iop.ID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 	s.displayObjectContentRoot(c)
 }
@@ -1064,39 +1039,9 @@ func (s *Server) report(c *gin.Context) {
 	}
 	full := c.DefaultQuery("full", "none") != "none"
 
-	if s.object != nil && s.object.GetID() == iop.ID {
-		if s.metadata == nil {
-			s.metadata, err = s.object.GetMetadata()
-			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot get metadata for object %s", s.object.GetID()).Error()})
-				return
-			}
-			if s.obfuscate {
-				if err := s.metadata.Obfuscate(); err != nil {
-					c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot obfuscate metadata").Error()})
-					return
-				}
-			}
-
-		}
-	} else {
-		s.object, err = s.storageRoot.LoadObjectByID(iop.ID)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
-		s.metadata, err = s.object.GetMetadata()
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot get metadata for object %s", s.object.GetID()).Error()})
-			return
-		}
-		if s.obfuscate {
-			if err := s.metadata.Obfuscate(); err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": errors.Wrapf(err, "cannot obfuscate metadata").Error()})
-				return
-			}
-		}
-
+	if err := s.loadObject(iop.ID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
 	if s.metadata == nil {
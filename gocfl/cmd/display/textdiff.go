@@ -0,0 +1,83 @@
+package display
+
+import (
+	"strings"
+)
+
+// maximum number of lines per file and size of lcs table for inline text diff
+const (
+	textDiffMaxLines = 20000
+	textDiffMaxTable = 4000000
+)
+
+type DiffLine struct {
+	Op   string
+	Text string
+}
+
+// textDiff creates a line based diff of two texts using longest common subsequence.
+// returns false, if the texts are too large
+func textDiff(oldText, newText string) ([]DiffLine, bool) {
+	a := strings.Split(strings.ReplaceAll(oldText, "\r\n", "\n"), "\n")
+	b := strings.Split(strings.ReplaceAll(newText, "\r\n", "\n"), "\n")
+	if len(a) > textDiffMaxLines || len(b) > textDiffMaxLines {
+		return nil, false
+	}
+	// strip common prefix and suffix to keep the lcs table small
+	var prefix, suffix int
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	ma := a[prefix : len(a)-suffix]
+	mb := b[prefix : len(b)-suffix]
+	if len(ma)*len(mb) > textDiffMaxTable {
+		return nil, false
+	}
+
+	lcs := make([][]int, len(ma)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(mb)+1)
+	}
+	for i := len(ma) - 1; i >= 0; i-- {
+		for j := len(mb) - 1; j >= 0; j-- {
+			if ma[i] == mb[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var result = []DiffLine{}
+	for _, line := range a[:prefix] {
+		result = append(result, DiffLine{Op: " ", Text: line})
+	}
+	i, j := 0, 0
+	for i < len(ma) && j < len(mb) {
+		switch {
+		case ma[i] == mb[j]:
+			result = append(result, DiffLine{Op: " ", Text: ma[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			result = append(result, DiffLine{Op: "-", Text: ma[i]})
+			i++
+		default:
+			result = append(result, DiffLine{Op: "+", Text: mb[j]})
+			j++
+		}
+	}
+	for ; i < len(ma); i++ {
+		result = append(result, DiffLine{Op: "-", Text: ma[i]})
+	}
+	for ; j < len(mb); j++ {
+		result = append(result, DiffLine{Op: "+", Text: mb[j]})
+	}
+	for _, line := range a[len(a)-suffix:] {
+		result = append(result, DiffLine{Op: " ", Text: line})
+	}
+	return result, true
+}
@@ -0,0 +1,470 @@
+package display
+
+import (
+	"context"
+	"emperror.dev/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/ocfl-archive/gocfl/v2/pkg/ocfl"
+	"golang.org/x/net/webdav"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maximum number of objects kept in memory and lifetime of the object list
+const (
+	davMaxObjects  = 256
+	davIDCacheTime = time.Minute
+)
+
+// davEscapeID converts an object id to a single path segment
+func davEscapeID(id string) string {
+	return strings.NewReplacer("%", "%25", "/", "%2F").Replace(id)
+}
+
+func davUnescapeID(name string) (string, error) {
+	return url.PathUnescape(name)
+}
+
+type davNode struct {
+	name     string
+	modTime  time.Time
+	children map[string]*davNode // nil for files
+	digest   string
+	internal string
+	size     int64 // -1 if not yet known
+}
+
+func newDavDir(name string, modTime time.Time) *davNode {
+	return &davNode{
+		name:     name,
+		modTime:  modTime,
+		children: map[string]*davNode{},
+		size:     -1,
+	}
+}
+
+func (n *davNode) isDir() bool {
+	return n.children != nil
+}
+
+func (n *davNode) sortedChildren() []*davNode {
+	var result = make([]*davNode, 0, len(n.children))
+	for _, child := range n.children {
+		result = append(result, child)
+	}
+	slices.SortFunc(result, func(a, b *davNode) int {
+		return strings.Compare(a.name, b.name)
+	})
+	return result
+}
+
+type davObject struct {
+	object ocfl.Object
+	root   *davNode
+}
+
+// newDavObject builds the virtual tree of all versions of an object from the version states
+func newDavObject(object ocfl.Object) (*davObject, error) {
+	inventory := object.GetInventory()
+	versions := inventory.GetVersions()
+	var headTime time.Time
+	if head, ok := versions[inventory.GetHead()]; ok {
+		headTime = head.Created.Time
+	}
+	root := newDavDir(davEscapeID(object.GetID()), headTime)
+	for _, versionStr := range inventory.GetVersionStrings() {
+		var created time.Time
+		if version, ok := versions[versionStr]; ok {
+			created = version.Created.Time
+		}
+		versionNode := newDavDir(versionStr, created)
+		root.children[versionStr] = versionNode
+		if err := inventory.IterateStateFiles(versionStr, func(internals, externals []string, digest string) error {
+			for _, external := range externals {
+				parts := strings.Split(external, "/")
+				dir := versionNode
+				for _, part := range parts[:len(parts)-1] {
+					child, ok := dir.children[part]
+					if !ok {
+						child = newDavDir(part, created)
+						dir.children[part] = child
+					}
+					if !child.isDir() {
+						return errors.Errorf("'%s' is a file and a folder in version '%s'", part, versionStr)
+					}
+					dir = child
+				}
+				name := parts[len(parts)-1]
+				dir.children[name] = &davNode{
+					name:     name,
+					modTime:  created,
+					digest:   digest,
+					internal: internals[0],
+					size:     -1,
+				}
+			}
+			return nil
+		}); err != nil {
+			return nil, errors.Wrapf(err, "cannot iterate state files of version '%s'", versionStr)
+		}
+	}
+	return &davObject{object: object, root: root}, nil
+}
+
+// davFS is a read-only webdav.FileSystem with the virtual tree /{objectID}/{version}/{logical path}.
+// File content is streamed from the internal manifest path of the object
+type davFS struct {
+	sync.Mutex
+	storageRoot ocfl.StorageRoot
+	objects     map[string]*davObject
+	ids         []string
+	idsLoaded   time.Time
+}
+
+func newDavFS(storageRoot ocfl.StorageRoot) *davFS {
+	return &davFS{
+		storageRoot: storageRoot,
+		objects:     map[string]*davObject{},
+	}
+}
+
+func (d *davFS) objectIDs() ([]string, error) {
+	d.Lock()
+	defer d.Unlock()
+	if d.ids != nil && time.Since(d.idsLoaded) < davIDCacheTime {
+		return d.ids, nil
+	}
+	folders, err := d.storageRoot.GetObjectFolders()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get object folders")
+	}
+	var ids = []string{}
+	for _, folder := range folders {
+		object, err := d.storageRoot.LoadObjectByFolder(folder)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot load object from folder '%s'", folder)
+		}
+		ids = append(ids, object.GetID())
+	}
+	slices.Sort(ids)
+	d.ids = ids
+	d.idsLoaded = time.Now()
+	return ids, nil
+}
+
+func (d *davFS) loadObject(id string) (*davObject, error) {
+	d.Lock()
+	defer d.Unlock()
+	if obj, ok := d.objects[id]; ok {
+		return obj, nil
+	}
+	object, err := d.storageRoot.LoadObjectByID(id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot load object '%s'", id)
+	}
+	obj, err := newDavObject(object)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot build tree of object '%s'", id)
+	}
+	if len(d.objects) >= davMaxObjects {
+		d.objects = map[string]*davObject{}
+	}
+	d.objects[id] = obj
+	return obj, nil
+}
+
+// resolve returns the node for name and the object it belongs to. The root node has no object
+func (d *davFS) resolve(op, name string) (*davNode, *davObject, error) {
+	name = path.Clean("/" + name)
+	if name == "/" {
+		ids, err := d.objectIDs()
+		if err != nil {
+			return nil, nil, err
+		}
+		root := newDavDir("/", time.Time{})
+		for _, id := range ids {
+			escaped := davEscapeID(id)
+			root.children[escaped] = newDavDir(escaped, time.Time{})
+		}
+		return root, nil, nil
+	}
+	parts := strings.Split(strings.TrimPrefix(name, "/"), "/")
+	id, err := davUnescapeID(parts[0])
+	if err != nil {
+		return nil, nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	obj, err := d.loadObject(id)
+	if err != nil {
+		return nil, nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	node := obj.root
+	for _, part := range parts[1:] {
+		child, ok := node.children[part]
+		if !ok {
+			return nil, nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+		}
+		node = child
+	}
+	return node, obj, nil
+}
+
+// stat returns the file info of a node. The size of files is read from the object filesystem once
+func (d *davFS) stat(node *davNode, obj *davObject) (*davFileInfo, error) {
+	if !node.isDir() && node.size < 0 {
+		fi, err := fs.Stat(obj.object.GetFS(), node.internal)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot stat '%s'", node.internal)
+		}
+		d.Lock()
+		node.size = fi.Size()
+		d.Unlock()
+	}
+	return &davFileInfo{node: node}, nil
+}
+
+func (d *davFS) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	return &fs.PathError{Op: "mkdir", Path: name, Err: fs.ErrPermission}
+}
+
+func (d *davFS) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND) != 0 {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	node, obj, err := d.resolve("open", name)
+	if err != nil {
+		return nil, err
+	}
+	return &davFile{fsys: d, node: node, obj: obj}, nil
+}
+
+func (d *davFS) RemoveAll(ctx context.Context, name string) error {
+	return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrPermission}
+}
+
+func (d *davFS) Rename(ctx context.Context, oldName, newName string) error {
+	return &fs.PathError{Op: "rename", Path: oldName, Err: fs.ErrPermission}
+}
+
+func (d *davFS) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	node, obj, err := d.resolve("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return d.stat(node, obj)
+}
+
+type davFileInfo struct {
+	node *davNode
+}
+
+func (fi *davFileInfo) Name() string { return fi.node.name }
+
+func (fi *davFileInfo) Size() int64 {
+	if fi.node.size < 0 {
+		return 0
+	}
+	return fi.node.size
+}
+
+func (fi *davFileInfo) Mode() fs.FileMode {
+	if fi.node.isDir() {
+		return fs.ModeDir | 0555
+	}
+	return 0444
+}
+
+func (fi *davFileInfo) ModTime() time.Time { return fi.node.modTime }
+
+func (fi *davFileInfo) IsDir() bool { return fi.node.isDir() }
+
+func (fi *davFileInfo) Sys() any { return nil }
+
+// ContentType avoids reading the content of every file while listing a folder
+func (fi *davFileInfo) ContentType(ctx context.Context) (string, error) {
+	if ctype := mime.TypeByExtension(path.Ext(fi.node.name)); ctype != "" {
+		return ctype, nil
+	}
+	return "application/octet-stream", nil
+}
+
+// ETag uses the digest of the file content
+func (fi *davFileInfo) ETag(ctx context.Context) (string, error) {
+	if fi.node.digest == "" {
+		return "", webdav.ErrNotImplemented
+	}
+	return `"` + fi.node.digest + `"`, nil
+}
+
+// davFile is a read-only webdav.File. The content is opened on first read.
+// Seeking backwards reopens the content, if the underlying file is not seekable
+type davFile struct {
+	fsys   *davFS
+	node   *davNode
+	obj    *davObject
+	dirPos int
+	fp     fs.File
+	fpPos  int64
+	pos    int64
+}
+
+func (f *davFile) Close() error {
+	if f.fp == nil {
+		return nil
+	}
+	err := f.fp.Close()
+	f.fp = nil
+	return errors.WithStack(err)
+}
+
+func (f *davFile) Read(p []byte) (int, error) {
+	if f.node.isDir() {
+		return 0, &fs.PathError{Op: "read", Path: f.node.name, Err: fs.ErrInvalid}
+	}
+	if f.fp == nil || f.fpPos != f.pos {
+		if err := f.position(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.fp.Read(p)
+	f.fpPos += int64(n)
+	f.pos = f.fpPos
+	return n, err
+}
+
+// position moves the underlying file to the current read position
+func (f *davFile) position() error {
+	if f.fp != nil {
+		if seeker, ok := f.fp.(io.Seeker); ok {
+			pos, err := seeker.Seek(f.pos, io.SeekStart)
+			if err != nil {
+				return errors.Wrapf(err, "cannot seek '%s'", f.node.internal)
+			}
+			f.fpPos = pos
+			return nil
+		}
+		if f.fpPos > f.pos {
+			if err := f.Close(); err != nil {
+				return errors.Wrapf(err, "cannot close '%s'", f.node.internal)
+			}
+		}
+	}
+	if f.fp == nil {
+		fp, err := f.obj.object.GetFS().Open(f.node.internal)
+		if err != nil {
+			return errors.Wrapf(err, "cannot open '%s'", f.node.internal)
+		}
+		f.fp = fp
+		f.fpPos = 0
+	}
+	if f.fpPos < f.pos {
+		n, err := io.CopyN(io.Discard, f.fp, f.pos-f.fpPos)
+		f.fpPos += n
+		if err != nil {
+			return errors.Wrapf(err, "cannot skip to position %d in '%s'", f.pos, f.node.internal)
+		}
+	}
+	return nil
+}
+
+func (f *davFile) Seek(offset int64, whence int) (int64, error) {
+	if f.node.isDir() {
+		return 0, &fs.PathError{Op: "seek", Path: f.node.name, Err: fs.ErrInvalid}
+	}
+	var pos int64
+	switch whence {
+	case io.SeekStart:
+		pos = offset
+	case io.SeekCurrent:
+		pos = f.pos + offset
+	case io.SeekEnd:
+		fi, err := f.fsys.stat(f.node, f.obj)
+		if err != nil {
+			return 0, err
+		}
+		pos = fi.Size() + offset
+	default:
+		return 0, errors.Errorf("invalid whence %d", whence)
+	}
+	if pos < 0 {
+		return 0, errors.Errorf("negative position %d", pos)
+	}
+	f.pos = pos
+	return pos, nil
+}
+
+func (f *davFile) Readdir(count int) ([]fs.FileInfo, error) {
+	if !f.node.isDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: f.node.name, Err: fs.ErrInvalid}
+	}
+	children := f.node.sortedChildren()[f.dirPos:]
+	if count > 0 {
+		if len(children) == 0 {
+			return nil, io.EOF
+		}
+		if len(children) > count {
+			children = children[:count]
+		}
+	}
+	var result = make([]fs.FileInfo, 0, len(children))
+	for _, child := range children {
+		result = append(result, &davFileInfo{node: child})
+	}
+	f.dirPos += len(children)
+	return result, nil
+}
+
+func (f *davFile) Stat() (fs.FileInfo, error) {
+	if f.obj == nil {
+		return &davFileInfo{node: f.node}, nil
+	}
+	return f.fsys.stat(f.node, f.obj)
+}
+
+func (f *davFile) Write(p []byte) (int, error) {
+	return 0, &fs.PathError{Op: "write", Path: f.node.name, Err: fs.ErrPermission}
+}
+
+var (
+	_ webdav.FileSystem = &davFS{}
+	_ webdav.File       = &davFile{}
+)
+
+// registerWebDAV exposes the object versions of the storage root as read-only WebDAV share at /dav
+func (s *Server) registerWebDAV(route gin.IRouter) {
+	prefix := "/dav"
+	if s.rootName != "" {
+		prefix = "/root/" + s.rootName + prefix
+	}
+	handler := &webdav.Handler{
+		Prefix:     prefix,
+		FileSystem: newDavFS(s.storageRoot),
+		LockSystem: webdav.NewMemLS(),
+		Logger: func(r *http.Request, err error) {
+			if err != nil {
+				s.log.Debug().Err(err).Msgf("webdav %s %s", r.Method, r.URL.Path)
+			}
+		},
+	}
+	serve := func(c *gin.Context) {
+		handler.ServeHTTP(c.Writer, c.Request)
+	}
+	readOnly := func(c *gin.Context) {
+		c.Header("Allow", "OPTIONS, GET, HEAD, PROPFIND")
+		c.String(http.StatusMethodNotAllowed, "read-only")
+	}
+	for _, method := range []string{"OPTIONS", "GET", "HEAD", "PROPFIND"} {
+		route.Handle(method, "/dav/*path", serve)
+	}
+	for _, method := range []string{"PUT", "DELETE", "MKCOL", "COPY", "MOVE", "PROPPATCH", "LOCK", "UNLOCK"} {
+		route.Handle(method, "/dav/*path", readOnly)
+	}
+}
@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"context"
 	"crypto/tls"
 	"emperror.dev/errors"
 	"fmt"
@@ -33,7 +32,7 @@ func initExtract() {
 	extractCmd.Flags().StringP("object-path", "p", "", "object path to extract")
 	extractCmd.Flags().StringP("object-id", "i", "", "object id to extract")
 	extractCmd.Flags().Bool("with-manifest", false, "generate manifest file in object extraction folder")
-	extractCmd.Flags().String("version", "", "version to extract")
+	extractCmd.Flags().String("version", "", "version or tag to extract")
 	extractCmd.Flags().String("area", "content", "data area to extract")
 }
 func doExtractConf(cmd *cobra.Command) {
@@ -151,7 +150,15 @@ func doExtract(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	ctx := ocfl.NewContextValidation(context.TODO())
+	cmdCtx, stop := cancelContext()
+	defer stop()
+	cmdCtx, endProgress, err := progressContext(cmdCtx)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msg("cannot initialize progress output")
+		return
+	}
+	defer endProgress()
+	ctx := ocfl.NewContextValidation(cmdCtx)
 	storageRoot, err := ocfl.LoadStorageRoot(ctx, ocflFS, extensionFactory, logger, ErrorFactory, conf.Init.Documentation)
 	if err != nil {
 		logger.Error().Stack().Err(err).Msg("cannot load storage root")
@@ -169,7 +176,7 @@ func doExtract(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	if err := storageRoot.Extract(destFS, oPath, oID, conf.Extract.Version, conf.Extract.Manifest, conf.Extract.Area); err != nil {
+	if err := storageRoot.Extract(ctx, destFS, oPath, oID, conf.Extract.Version, conf.Extract.Manifest, conf.Extract.Area); err != nil {
 		fmt.Printf("cannot extract storage root: %v\n", err)
 		logger.Error().Stack().Err(err).Msg("cannot extract storage root")
 		return
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"github.com/je4/filesystem/v3/pkg/writefs"
 	"github.com/je4/utils/v2/pkg/zLogger"
+	"github.com/ocfl-archive/gocfl/v2/pkg/iiif"
 	"github.com/ocfl-archive/gocfl/v2/pkg/ocfl"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/pkgerrors"
@@ -16,7 +17,9 @@ import (
 	"go.ub.unibas.ch/cloud/certloader/v2/pkg/loader"
 	"io"
 	"log"
+	"net/url"
 	"os"
+	"path"
 	"strings"
 )
 
@@ -25,16 +28,18 @@ var extractMetaCmd = &cobra.Command{
 	Aliases: []string{},
 	Short:   "extract metadata from ocfl structure",
 	//Long:    "an utterly useless command for testing",
-	Example: "gocfl extractmeta ./archive.zip --output-json ./archive_meta.json",
-	Args:    cobra.ExactArgs(1),
-	Run:     doExtractMeta,
+	Example: "gocfl extractmeta ./archive.zip --output-json ./archive_meta.json\n" +
+		"gocfl extractmeta ./archive.zip --object-id id:blah-blubb --version v2 --format iiif --iiif-base-url https://ocfl.example.org",
+	Args: cobra.ExactArgs(1),
+	Run:  doExtractMeta,
 }
 
 func initExtractMeta() {
 	extractMetaCmd.Flags().StringP("object-path", "p", "", "object path to extract")
 	extractMetaCmd.Flags().StringP("object-id", "i", "", "object id to extract")
-	extractMetaCmd.Flags().String("version", "latest", "version to extract")
-	extractMetaCmd.Flags().String("format", "json", "output format (json)")
+	extractMetaCmd.Flags().String("version", "latest", "version or tag to extract")
+	extractMetaCmd.Flags().String("format", "json", "output format (json|iiif)")
+	extractMetaCmd.Flags().String("iiif-base-url", "", "base url of gocfl display for iiif resource id's (default http://localhost:8080)")
 	extractMetaCmd.Flags().String("output", "", "output file (default stdout)")
 	extractMetaCmd.Flags().Bool("obfuscate", false, "obfuscate metadata")
 }
@@ -61,6 +66,9 @@ func doExtractMetaConf(cmd *cobra.Command) {
 	if b, ok := getFlagBool(cmd, "obfuscate"); ok {
 		conf.ExtractMeta.Obfuscate = b
 	}
+	if str := getFlagString(cmd, "iiif-base-url"); str != "" {
+		conf.ExtractMeta.IIIFBaseURL = str
+	}
 }
 
 func doExtractMeta(cmd *cobra.Command, args []string) {
@@ -120,11 +128,16 @@ func doExtractMeta(cmd *cobra.Command, args []string) {
 		return
 	}
 	format := strings.ToLower(conf.ExtractMeta.Format)
-	if format != "json" {
+	if format != "json" && format != "iiif" {
 		cmd.Help()
 		cobra.CheckErr(errors.Errorf("invalid format '%s' for flag 'format' or 'Format' config file entry", format))
 		return
 	}
+	if format == "iiif" && oPath == "" && oID == "" {
+		cmd.Help()
+		cobra.CheckErr(errors.New("format 'iiif' needs object-path or object-id"))
+		return
+	}
 	output := conf.ExtractMeta.Output
 
 	logger.Info().Msgf("extracting metadata from '%s'", ocflPath)
@@ -174,7 +187,40 @@ func doExtractMeta(cmd *cobra.Command, args []string) {
 		}
 	}
 
-	jsonBytes, err := json.MarshalIndent(metadata, "", "  ")
+	var result any = metadata
+	if format == "iiif" {
+		var objectMeta *ocfl.ObjectMetadata
+		for _, om := range metadata.Objects {
+			objectMeta = om
+		}
+		if objectMeta == nil {
+			fmt.Printf("no object found\n")
+			logger.Error().Msgf("no object found for '%s%s'", oPath, oID)
+			return
+		}
+		objectURL := strings.TrimRight(conf.ExtractMeta.IIIFBaseURL, "/") + "/object/id/" + url.PathEscape(objectMeta.ID)
+		version, ok := objectMeta.ResolveVersion(conf.ExtractMeta.Version)
+		if !ok {
+			fmt.Printf("version or tag '%s' not found\n", conf.ExtractMeta.Version)
+			logger.Error().Msgf("version or tag '%s' not found in object '%s'", conf.ExtractMeta.Version, objectMeta.ID)
+			return
+		}
+		result, err = iiif.NewManifest(
+			objectMeta,
+			version,
+			fmt.Sprintf("%s/version/%s/iiif", objectURL, url.PathEscape(version)),
+			func(digest, logicalPath string) string {
+				return fmt.Sprintf("%s/download/%s/%s", objectURL, digest, url.PathEscape(path.Base(logicalPath)))
+			},
+		)
+		if err != nil {
+			fmt.Printf("cannot create iiif manifest: %v\n", err)
+			logger.Error().Stack().Err(err).Msg("cannot create iiif manifest")
+			return
+		}
+	}
+
+	jsonBytes, err := json.MarshalIndent(result, "", "  ")
 	if err != nil {
 		fmt.Printf("cannot marshal metadata")
 		logger.Error().Stack().Err(err).Msg("cannot marshal metadata")
@@ -4,9 +4,15 @@ import (
 	"context"
 	"fmt"
 	"io/fs"
+	"os"
+	"os/signal"
 	"path/filepath"
+	"regexp"
+	"strings"
+	"syscall"
 	"time"
 
+	"emperror.dev/errors"
 	"github.com/google/tink/go/core/registry"
 	"github.com/je4/filesystem/v3/pkg/osfsrw"
 	"github.com/je4/filesystem/v3/pkg/s3fsrw"
@@ -23,6 +29,7 @@ import (
 	"github.com/ocfl-archive/gocfl/v2/pkg/extension"
 	"github.com/ocfl-archive/gocfl/v2/pkg/ocfl"
 	"github.com/ocfl-archive/gocfl/v2/pkg/subsystem/migration"
+	"github.com/ocfl-archive/gocfl/v2/pkg/subsystem/sourcefs"
 	"github.com/ocfl-archive/gocfl/v2/pkg/subsystem/thumbnail"
 	ironmaiden "github.com/ocfl-archive/indexer/v3/pkg/indexer"
 	"github.com/spf13/cobra"
@@ -149,6 +156,11 @@ func InitExtensionFactory(
 		return extension.NewMetaFileFS(fsys)
 	})
 
+	logExtInit(logger, extension.VersionTagsName)
+	extensionFactory.AddCreator(extension.VersionTagsName, func(fsys fs.FS) (ocfl.Extension, error) {
+		return extension.NewVersionTagsFS(fsys)
+	})
+
 	logExtInit(logger, extension.IndexerName)
 	extensionFactory.AddCreator(extension.IndexerName, func(fsys fs.FS) (ocfl.Extension, error) {
 		ext, err := extension.NewIndexerFS(
@@ -434,21 +446,101 @@ func showStatus(ctx context.Context, logger zLogger.ZLogger) error {
 	return nil
 }
 
+// cancelContext returns a context, which is cancelled on SIGINT or SIGTERM.
+// an open object version is rolled back on cancellation
+func cancelContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+// ingestWorkers returns the number of parallel ingest workers for the storage root at ocflPath.
+// zip files are written sequentially, so they always get a single worker
+func ingestWorkers(ocflPath string, workers int, logger zLogger.ZLogger) int {
+	if workers < 1 {
+		return 1
+	}
+	if workers > 1 && strings.ToLower(filepath.Ext(ocflPath)) == ".zip" {
+		logger.Info().Msgf("parallel ingest not supported for zip file '%s', using a single worker", ocflPath)
+		return 1
+	}
+	return workers
+}
+
+// objectLocks returns the lock options for the objects of the storage root at ocflPath.
+// zip files are written as a whole and not locked
+func objectLocks(ocflPath string) *ocfl.LockOptions {
+	if !conf.Lock.Enabled || strings.ToLower(filepath.Ext(ocflPath)) == ".zip" {
+		return nil
+	}
+	return &ocfl.LockOptions{
+		Wait:    conf.Lock.Wait,
+		Timeout: time.Duration(conf.Lock.Timeout),
+		Retry:   time.Duration(conf.Lock.Retry),
+		Remote:  conf.Lock.S3,
+	}
+}
+
+var s3ARNRegexp = regexp.MustCompile(s3fsrw.ARNRegexStr)
+
+// sourcePath returns the full path of an ingest source. urls and s3 arns are returned unchanged
+func sourcePath(source string) (string, error) {
+	if sourcefs.IsURL(source) || s3ARNRegexp.MatchString(source) {
+		return source, nil
+	}
+	return ocfl.Fullpath(source)
+}
+
+// openSource opens an ingest source. A source is a local folder, a zip or tar archive, an s3 prefix
+// or a http(s) url manifest. Files are read directly from the source without a local copy
+func openSource(ctx context.Context, fsFactory *writefs.Factory, source string) (fs.FS, error) {
+	switch {
+	case sourcefs.IsURL(source):
+		fsys, err := sourcefs.OpenHTTPManifest(ctx, nil, source)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot open url manifest '%s'", source)
+		}
+		return fsys, nil
+	case s3ARNRegexp.MatchString(source):
+		if conf.S3 == nil || conf.S3.Endpoint == "" {
+			return nil, errors.Errorf("cannot open '%s': no s3 endpoint configured", source)
+		}
+	default:
+		if _, err := os.Stat(filepath.FromSlash(source)); err != nil {
+			return nil, errors.Wrapf(err, "cannot stat '%s'", source)
+		}
+		if sourcefs.IsTar(source) {
+			fsys, err := sourcefs.NewTarFS(filepath.FromSlash(source))
+			if err != nil {
+				return nil, errors.Wrapf(err, "cannot open tar archive '%s'", source)
+			}
+			return fsys, nil
+		}
+	}
+	fsys, err := fsFactory.Get(source, true)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot get filesystem for '%s'", source)
+	}
+	return fsys, nil
+}
+
 func addObjectByPath(
+	ctx context.Context,
 	storageRoot ocfl.StorageRoot,
 	fixity []checksum.DigestAlgorithm,
 	extensionManager ocfl.ExtensionManager,
 	checkDuplicates bool,
+	workers int,
+	ingestMode ocfl.IngestMode,
 	id, userName, userAddress, message string,
 	sourceFS fs.FS, area string,
 	areaPaths map[string]fs.FS,
 	echo bool,
+	trusted *ocfl.TrustedDigests,
 	logger zLogger.ZLogger) (bool, error) {
 	if fixity == nil {
 		fixity = []checksum.DigestAlgorithm{}
 	}
 	var o ocfl.Object
-	exists, err := storageRoot.ObjectExists(flagObjectID)
+	exists, err := storageRoot.ObjectExists(id)
 	if err != nil {
 		logger.Error().Any(
 			errorTopic,
@@ -504,7 +596,10 @@ func addObjectByPath(
 			return false, err
 		}
 	}
-	versionFS, err := o.StartUpdate(sourceFS, message, userName, userAddress, echo)
+	o.SetWorkers(workers)
+	o.SetIngestMode(ingestMode)
+	o.SetTrustedDigests(trusted)
+	versionFS, err := o.StartUpdate(ctx, sourceFS, message, userName, userAddress, echo)
 	if err != nil {
 		logger.Error().Any(
 			errorTopic,
@@ -520,7 +615,19 @@ func addObjectByPath(
 		)
 		return false, err
 	}
-	if err := o.AddFolder(sourceFS, versionFS, checkDuplicates, area); err != nil {
+	// discard the staged version, if the update fails before it is complete
+	rollback := func() {
+		if err := o.Rollback(); err != nil {
+			logger.Error().Any(
+				errorTopic,
+				ErrorFactory.NewError(
+					ErrorOCFLCreation,
+					fmt.Sprintf("cannot roll back update of object %s", id),
+					err,
+				)).Msg("")
+		}
+	}
+	if err := o.AddFolder(ctx, sourceFS, versionFS, checkDuplicates, area); err != nil {
 		logger.Error().Any(
 			errorTopic,
 			ErrorFactory.NewError(
@@ -533,11 +640,12 @@ func addObjectByPath(
 			fmt.Sprintf("cannot add folder '%s' to '%s'", sourceFS, id),
 			err,
 		)
+		rollback()
 		return false, err
 	}
 	if areaPaths != nil {
 		for a, aPath := range areaPaths {
-			if err := o.AddFolder(aPath, versionFS, checkDuplicates, a); err != nil {
+			if err := o.AddFolder(ctx, aPath, versionFS, checkDuplicates, a); err != nil {
 				logger.Error().Any(
 					errorTopic,
 					ErrorFactory.NewError(
@@ -550,6 +658,7 @@ func addObjectByPath(
 					fmt.Sprintf("cannot add area '%s' folder '%s' to '%s'", a, aPath, id),
 					err,
 				)
+				rollback()
 				return false, err
 			}
 		}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"emperror.dev/errors"
+	"github.com/je4/utils/v2/pkg/zLogger"
+	"github.com/ocfl-archive/gocfl/v2/pkg/ocfl"
+	"github.com/ocfl-archive/gocfl/v2/pkg/subsystem/ingestfilter"
+	"github.com/spf13/cobra"
+)
+
+// initIngestFlags adds the flags for include and exclude rules to cmd
+func initIngestFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArray("include", []string{}, "glob of files to ingest. if set, only matching files are ingested (repeatable)")
+	cmd.Flags().StringArray("exclude", []string{}, "glob of files or folders (trailing slash) not to ingest (repeatable)")
+	cmd.Flags().StringArray("include-regexp", []string{}, "regular expression for paths of files to ingest (repeatable)")
+	cmd.Flags().StringArray("exclude-regexp", []string{}, "regular expression for paths of files or folders not to ingest (repeatable)")
+	cmd.Flags().String("ignore-file", "", fmt.Sprintf("name of the file with exclude rules in the source folder (default %s)", ingestfilter.DefaultIgnoreFile))
+	cmd.Flags().String("skip-report", "", "json file with the list of skipped files")
+	cmd.Flags().String("path-normalization", "", "action on paths, which are not unicode NFC normalized (ignore|warn|reject|fix)")
+	cmd.Flags().String("path-case-collision", "", "action on paths, which differ only in case (ignore|warn|reject|fix)")
+	cmd.Flags().String("path-windows", "", "action on paths with reserved names or characters on windows (ignore|warn|reject|fix)")
+}
+
+// doIngestConf adds the rules of the flags to the rules of the config file
+func doIngestConf(cmd *cobra.Command) {
+	for flag, rules := range map[string]*[]string{
+		"include":        &conf.Ingest.Include,
+		"exclude":        &conf.Ingest.Exclude,
+		"include-regexp": &conf.Ingest.IncludeRegexp,
+		"exclude-regexp": &conf.Ingest.ExcludeRegexp,
+	} {
+		values, err := cmd.Flags().GetStringArray(flag)
+		if err != nil {
+			_ = cmd.Help()
+			cobra.CheckErr(errors.Errorf("canot get flag %s: %v", flag, err))
+		}
+		*rules = append(*rules, values...)
+	}
+	if str := getFlagString(cmd, "ignore-file"); str != "" {
+		conf.Ingest.IgnoreFile = str
+	}
+	if str := getFlagString(cmd, "skip-report"); str != "" {
+		conf.Ingest.Report = str
+	}
+	if str := getFlagString(cmd, "path-normalization"); str != "" {
+		conf.Ingest.PathPolicy.Normalization = str
+	}
+	if str := getFlagString(cmd, "path-case-collision"); str != "" {
+		conf.Ingest.PathPolicy.CaseCollision = str
+	}
+	if str := getFlagString(cmd, "path-windows"); str != "" {
+		conf.Ingest.PathPolicy.Windows = str
+	}
+}
+
+type filteredSource struct {
+	source string
+	area   string
+	fsys   *ingestfilter.FS
+}
+
+// sourceFilter hides the files of the ingest sources, which are skipped by the rules in conf.Ingest,
+// and applies the path policy to the remaining files
+type sourceFilter struct {
+	filter  *ingestfilter.Filter
+	policy  *ocfl.PathPolicy
+	logger  zLogger.ZLogger
+	sources []*filteredSource
+}
+
+func newSourceFilter(logger zLogger.ZLogger) (*sourceFilter, error) {
+	filter, err := ingestfilter.NewFilter(conf.Ingest.Include, conf.Ingest.Exclude, conf.Ingest.IncludeRegexp, conf.Ingest.ExcludeRegexp)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid ingest rules")
+	}
+	policy, err := ocfl.NewPathPolicy(conf.Ingest.PathPolicy.Normalization, conf.Ingest.PathPolicy.CaseCollision, conf.Ingest.PathPolicy.Windows)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid path policy")
+	}
+	return &sourceFilter{filter: filter, policy: policy, logger: logger, sources: []*filteredSource{}}, nil
+}
+
+func (sf *sourceFilter) wrap(fsys fs.FS, source, area string) (fs.FS, error) {
+	ffs, err := ingestfilter.NewFS(fsys, sf.filter, conf.Ingest.IgnoreFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot filter '%s'", source)
+	}
+	sf.sources = append(sf.sources, &filteredSource{source: source, area: area, fsys: ffs})
+	if sf.policy.IsEmpty() {
+		return ffs, nil
+	}
+	pfs, err := ocfl.NewPathPolicyFS(ffs, sf.policy, sf.logger, ErrorFactory)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot apply path policy to '%s'", source)
+	}
+	return pfs, nil
+}
+
+type skippedReportEntry struct {
+	Source string `json:"source"`
+	Area   string `json:"area"`
+	*ingestfilter.Skipped
+}
+
+// report logs the skipped files and writes them to conf.Ingest.Report
+func (sf *sourceFilter) report(logger zLogger.ZLogger) {
+	entries := sf.logSkipped(logger)
+	if conf.Ingest.Report == "" {
+		return
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		logger.Error().Stack().Err(err).Msg("cannot marshal skip report")
+		return
+	}
+	if err := os.WriteFile(conf.Ingest.Report, data, 0644); err != nil {
+		logger.Error().Stack().Err(err).Msgf("cannot write skip report '%s'", conf.Ingest.Report)
+	}
+}
+
+// logSkipped logs the skipped files of all sources
+func (sf *sourceFilter) logSkipped(logger zLogger.ZLogger) []*skippedReportEntry {
+	var entries = []*skippedReportEntry{}
+	for _, src := range sf.sources {
+		for _, skipped := range src.fsys.Skipped() {
+			logger.Info().Any(
+				ErrorFactory.LogError(
+					ErrorGOCFL,
+					fmt.Sprintf("skipped %s:%s (%s)", src.area, skipped.Path, skipped.Reason),
+					nil,
+				)).Msg("")
+			entries = append(entries, &skippedReportEntry{Source: src.source, Area: src.area, Skipped: skipped})
+		}
+	}
+	return entries
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/je4/utils/v2/pkg/checksum"
+	"github.com/je4/utils/v2/pkg/zLogger"
+	"github.com/ocfl-archive/gocfl/v2/pkg/ocfl"
+	"github.com/spf13/cobra"
+)
+
+// getDryRun returns whether a plan should be shown instead of updating the object and the format of the plan
+func getDryRun(cmd *cobra.Command) (dryRun bool, format string) {
+	dryRun, _ = getFlagBool(cmd, "dry-run")
+	format = strings.ToLower(getFlagString(cmd, "plan-format"))
+	if format == "" {
+		format = "text"
+	}
+	if format != "text" && format != "json" {
+		_ = cmd.Help()
+		cobra.CheckErr(errors.Errorf("invalid plan format '%s' (text|json)", format))
+	}
+	return
+}
+
+// dryRunObjectByPath writes the plan for adding sourceFS and areaPaths to the object id to w.
+// if the object does not exist in storageRoot or storageRoot is nil, a new object is planned
+func dryRunObjectByPath(
+	ctx context.Context,
+	storageRoot ocfl.StorageRoot,
+	version ocfl.OCFLVersion,
+	digest checksum.DigestAlgorithm,
+	fixity []checksum.DigestAlgorithm,
+	extensionManager ocfl.ExtensionManager,
+	checkDuplicates bool,
+	id string,
+	sourceFS fs.FS, area string,
+	areaPaths map[string]fs.FS,
+	echo bool,
+	trusted *ocfl.TrustedDigests,
+	format string,
+	w io.Writer,
+	logger zLogger.ZLogger,
+) error {
+	var o ocfl.Object
+	var exists bool
+	if storageRoot != nil {
+		var err error
+		if exists, err = storageRoot.ObjectExists(id); err != nil {
+			return errors.Wrapf(err, "cannot check for existence of %s", id)
+		}
+		version = storageRoot.GetVersion()
+		digest = storageRoot.GetDigest()
+	}
+	if exists {
+		var err error
+		if o, err = storageRoot.LoadObjectByID(id); err != nil {
+			return errors.Wrapf(err, "cannot load object %s", id)
+		}
+	} else {
+		var err error
+		if o, err = ocfl.NewPlanObject(ctx, id, version, digest, fixity, extensionManager, logger, ErrorFactory); err != nil {
+			return errors.Wrapf(err, "cannot create plan object %s", id)
+		}
+	}
+	o.SetTrustedDigests(trusted)
+	plan, err := o.Plan(ctx, sourceFS, area, areaPaths, checkDuplicates, echo)
+	if err != nil {
+		return errors.Wrapf(err, "cannot plan update of object %s", id)
+	}
+	if err := writePlan(w, plan, format); err != nil {
+		return errors.Wrap(err, "cannot write plan")
+	}
+	return nil
+}
+
+// writePlan writes the plan as json or as text. unchanged files are not listed in the text format
+func writePlan(w io.Writer, plan *ocfl.UpdatePlan, format string) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return errors.WithStack(enc.Encode(plan))
+	}
+	head := plan.Head
+	if head == "" {
+		head = "new object"
+	}
+	strategy := "contribute"
+	if plan.Echo {
+		strategy = "echo"
+	}
+	fmt.Fprintf(w, "dry run for object '%s' (%s, update strategy '%s', deduplication %v)\n", plan.ObjectID, head, strategy, plan.Deduplicate)
+	for _, entry := range plan.Entries {
+		if entry.Action == ocfl.PlanActionUnchanged {
+			continue
+		}
+		if entry.Action == ocfl.PlanActionDeleted {
+			fmt.Fprintf(w, "  %-12s %s\n", entry.Action, entry.Path)
+			continue
+		}
+		if entry.Action == ocfl.PlanActionRenamed {
+			fmt.Fprintf(w, "  %-12s %s -> %s\n", entry.Action, entry.PreviousPath, entry.Path)
+			continue
+		}
+		fmt.Fprintf(w, "  %-12s %s (%s)\n", entry.Action, entry.Path, formatBytes(entry.Size))
+	}
+	fmt.Fprintf(w, "added: %d, replaced: %d, deleted: %d, renamed: %d, deduplicated: %d, unchanged: %d\n", plan.Added, plan.Replaced, plan.Deleted, plan.Renamed, plan.Deduplicated, plan.Unchanged)
+	fmt.Fprintf(w, "bytes to write: %d (%s)\n", plan.BytesWritten, formatBytes(plan.BytesWritten))
+	return nil
+}
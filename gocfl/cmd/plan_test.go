@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/fs"
+	"maps"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/je4/filesystem/v3/pkg/osfsrw"
+	"github.com/je4/utils/v2/pkg/checksum"
+	archiveerror "github.com/ocfl-archive/error/pkg/error"
+	"github.com/ocfl-archive/gocfl/v2/internal"
+	"github.com/ocfl-archive/gocfl/v2/pkg/extension"
+	"github.com/ocfl-archive/gocfl/v2/pkg/ocfl"
+	"github.com/rs/zerolog"
+)
+
+func planFiles(files map[string]string) fstest.MapFS {
+	var result = fstest.MapFS{}
+	for name, content := range files {
+		result[name] = &fstest.MapFile{Data: []byte(content), Mode: 0644}
+	}
+	return result
+}
+
+// TestDryRunMatchesUpdate writes the plan of the dry run, runs the same update and compares the plan with the new version
+func TestDryRunMatchesUpdate(t *testing.T) {
+	l := zerolog.Nop()
+	archiveErrs, err := archiveerror.LoadTOMLFileFS(internal.InternalFS, "errors.toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ErrorFactory.RegisterErrors(archiveErrs); err != nil {
+		t.Fatal(err)
+	}
+	extensionFactory, err := ocfl.NewExtensionFactory(map[string]string{}, &l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	extensionFactory.AddCreator(extension.InitialName, func(fsys fs.FS) (ocfl.Extension, error) {
+		return extension.NewInitialFS(fsys)
+	})
+	extensionFactory.AddCreator(extension.GOCFLExtensionManagerName, func(fsys fs.FS) (ocfl.Extension, error) {
+		return extension.NewGOCFLExtensionManagerFS(fsys)
+	})
+	extensionFactory.AddCreator(extension.StorageLayoutFlatDirectName, func(fsys fs.FS) (ocfl.Extension, error) {
+		return extension.NewStorageLayoutFlatDirectFS(fsys)
+	})
+	rootExtensions, err := extensionFactory.LoadExtensions(fstest.MapFS{
+		extension.StorageLayoutFlatDirectName + "/config.json": &fstest.MapFile{
+			Data: []byte(`{"extensionName": "` + extension.StorageLayoutFlatDirectName + `"}`),
+		},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys, err := osfsrw.NewFS(t.TempDir(), false, &l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := ocfl.NewContextValidation(context.Background())
+	storageRoot, err := ocfl.CreateStorageRoot(ctx, fsys, ocfl.Version1_1, extensionFactory, rootExtensions, checksum.DigestSHA512, &l, ErrorFactory, "ocfl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	storageRoot.SetLockOptions(&ocfl.LockOptions{})
+
+	for _, test := range []struct {
+		name        string
+		source      map[string]string
+		echo        bool
+		deduplicate bool
+		summary     string
+	}{
+		{
+			name:        "new object",
+			source:      map[string]string{"a.txt": "a", "b.txt": "b", "copy.txt": "a", "dir/c.txt": "c"},
+			deduplicate: true,
+			summary:     "added: 3, replaced: 0, deleted: 0, renamed: 0, deduplicated: 1, unchanged: 0",
+		},
+		{
+			name:        "contribute",
+			source:      map[string]string{"a.txt": "changed", "d.txt": "new"},
+			deduplicate: true,
+			summary:     "added: 1, replaced: 1, deleted: 0, renamed: 0, deduplicated: 0, unchanged: 0",
+		},
+		{
+			name:    "echo",
+			source:  map[string]string{"a.txt": "changed", "moved.txt": "b", "d.txt": "new"},
+			echo:    true,
+			summary: "added: 0, replaced: 0, deleted: 2, renamed: 1, deduplicated: 0, unchanged: 2",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			manager, err := extensionFactory.LoadExtensions(fstest.MapFS{}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			source := planFiles(test.source)
+			before := objectState(t, storageRoot, "a")
+
+			var text bytes.Buffer
+			if err := dryRunObjectByPath(ctx, storageRoot, "", "", nil, manager, test.deduplicate, "a", source, "content", nil, test.echo, nil, "text", &text, &l); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(text.String(), test.summary+"\n") {
+				t.Fatalf("expected '%s' in plan:\n%s", test.summary, text.String())
+			}
+			var data bytes.Buffer
+			if err := dryRunObjectByPath(ctx, storageRoot, "", "", nil, manager, test.deduplicate, "a", source, "content", nil, test.echo, nil, "json", &data, &l); err != nil {
+				t.Fatal(err)
+			}
+			plan := &ocfl.UpdatePlan{}
+			if err := json.Unmarshal(data.Bytes(), plan); err != nil {
+				t.Fatal(err)
+			}
+			if state := objectState(t, storageRoot, "a"); !maps.Equal(state, before) {
+				t.Fatalf("dry run changed the object: %v", state)
+			}
+
+			if _, err := addObjectByPath(ctx, storageRoot, nil, manager, test.deduplicate, 1, ocfl.IngestModeCopy, "a", "tester", "mailto:tester@example.org", "test", source, "content", nil, test.echo, nil, &l); err != nil {
+				t.Fatal(err)
+			}
+			expected := maps.Clone(before)
+			for _, entry := range plan.Entries {
+				switch entry.Action {
+				case ocfl.PlanActionDeleted:
+					delete(expected, entry.Path)
+				case ocfl.PlanActionRenamed:
+					delete(expected, entry.PreviousPath)
+					expected[entry.Path] = entry.Digest
+				default:
+					expected[entry.Path] = entry.Digest
+				}
+			}
+			if after := objectState(t, storageRoot, "a"); !maps.Equal(after, expected) {
+				t.Fatalf("planned state %v, got %v", expected, after)
+			}
+		})
+	}
+}
+
+// objectState returns the logical paths of the head version of object id with their digests
+func objectState(t *testing.T, storageRoot ocfl.StorageRoot, id string) map[string]string {
+	t.Helper()
+	var state = map[string]string{}
+	exists, err := storageRoot.ObjectExists(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exists {
+		return state
+	}
+	o, err := storageRoot.LoadObjectByID(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := o.GetInventory()
+	if err := i.IterateStateFiles(i.GetHead(), func(_ []string, external []string, digest string) error {
+		for _, name := range external {
+			state[name] = digest
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return state
+}
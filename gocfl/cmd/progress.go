@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/ocfl-archive/gocfl/v2/pkg/ocfl"
+)
+
+const progressBarWidth = 30
+
+// progressRenderer shows progress events as a progress bar or as json lines
+type progressRenderer struct {
+	sync.Mutex
+	w         io.Writer
+	json      bool
+	interval  time.Duration
+	last      time.Time
+	lastPhase map[ocfl.ProgressPhase]time.Time
+	lastLen   int
+	started   time.Time
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// newProgressRenderer returns nil for mode "none"
+func newProgressRenderer(mode string, f *os.File) (*progressRenderer, error) {
+	mode = strings.ToLower(mode)
+	if mode == "" || mode == "auto" {
+		if isTerminal(f) {
+			mode = "bar"
+		} else {
+			mode = "json"
+		}
+	}
+	switch mode {
+	case "none":
+		return nil, nil
+	case "bar":
+		return &progressRenderer{w: f, interval: 200 * time.Millisecond, lastPhase: map[ocfl.ProgressPhase]time.Time{}, started: time.Now()}, nil
+	case "json":
+		return &progressRenderer{w: f, json: true, interval: 5 * time.Second, lastPhase: map[ocfl.ProgressPhase]time.Time{}, started: time.Now()}, nil
+	default:
+		return nil, errors.Errorf("invalid progress mode '%s' (auto|bar|json|none)", mode)
+	}
+}
+
+func (pr *progressRenderer) Progress(event ocfl.ProgressEvent) {
+	pr.Lock()
+	defer pr.Unlock()
+	now := time.Now()
+	if pr.json {
+		// json lines are written for every phase separately
+		last, ok := pr.lastPhase[event.Phase]
+		if !event.Done && ok && now.Sub(last) < pr.interval {
+			return
+		}
+		pr.writeJSON(event, now)
+		pr.lastPhase[event.Phase] = now
+		return
+	}
+	// the progress bar shows the most recent phase, phases interleave while adding files
+	if !event.Done && now.Sub(pr.last) < pr.interval {
+		return
+	}
+	pr.writeBar(event)
+	pr.last = now
+}
+
+func (pr *progressRenderer) writeJSON(event ocfl.ProgressEvent, now time.Time) {
+	data, err := json.Marshal(struct {
+		Time    time.Time `json:"time"`
+		Elapsed float64   `json:"elapsed"`
+		ocfl.ProgressEvent
+	}{
+		Time:          now,
+		Elapsed:       now.Sub(pr.started).Seconds(),
+		ProgressEvent: event,
+	})
+	if err != nil {
+		return
+	}
+	fmt.Fprintln(pr.w, string(data))
+}
+
+func (pr *progressRenderer) writeBar(event ocfl.ProgressEvent) {
+	var line strings.Builder
+	fmt.Fprintf(&line, "%-9s ", event.Phase)
+	switch {
+	case event.TotalBytes > 0:
+		writeBar(&line, float64(event.Bytes)/float64(event.TotalBytes))
+		fmt.Fprintf(&line, " %s/%s", formatBytes(event.Bytes), formatBytes(event.TotalBytes))
+	case event.TotalFiles > 0:
+		writeBar(&line, float64(event.Files)/float64(event.TotalFiles))
+		fmt.Fprintf(&line, " %s", formatBytes(event.Bytes))
+	default:
+		fmt.Fprintf(&line, "%s", formatBytes(event.Bytes))
+	}
+	if event.TotalFiles > 0 {
+		fmt.Fprintf(&line, " %d/%d files", event.Files, event.TotalFiles)
+	} else {
+		fmt.Fprintf(&line, " %d files", event.Files)
+	}
+	if event.File != "" {
+		file := event.File
+		if len(file) > 40 {
+			file = "..." + file[len(file)-37:]
+		}
+		fmt.Fprintf(&line, " %s", file)
+	}
+	str := line.String()
+	// overwrite remains of the last line
+	padding := pr.lastLen - len(str)
+	if padding < 0 {
+		padding = 0
+	}
+	fmt.Fprintf(pr.w, "\r%s%s", str, strings.Repeat(" ", padding))
+	pr.lastLen = len(str)
+	if event.Done {
+		// keep the final state of the phase
+		fmt.Fprintln(pr.w)
+		pr.lastLen = 0
+	}
+}
+
+func writeBar(w io.Writer, ratio float64) {
+	ratio = min(max(ratio, 0), 1)
+	filled := int(ratio * progressBarWidth)
+	fmt.Fprintf(w, "[%s%s] %5.1f%%", strings.Repeat("=", filled), strings.Repeat(" ", progressBarWidth-filled), ratio*100)
+}
+
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// Close terminates an open progress bar line
+func (pr *progressRenderer) Close() {
+	pr.Lock()
+	defer pr.Unlock()
+	if pr.lastLen > 0 {
+		fmt.Fprintln(pr.w)
+		pr.lastLen = 0
+	}
+}
+
+// progressContext adds the progress renderer configured in conf.Progress to ctx.
+// The returned function has to be called at the end of the operation
+func progressContext(ctx context.Context) (context.Context, func(), error) {
+	renderer, err := newProgressRenderer(conf.Progress, os.Stderr)
+	if err != nil {
+		return ctx, func() {}, errors.WithStack(err)
+	}
+	if renderer == nil {
+		return ctx, func() {}, nil
+	}
+	return ocfl.NewContextProgress(ctx, renderer), renderer.Close, nil
+}
@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"crypto/tls"
+	"emperror.dev/emperror"
+	"emperror.dev/errors"
+	"github.com/je4/filesystem/v3/pkg/writefs"
+	"github.com/je4/utils/v2/pkg/zLogger"
+	"github.com/ocfl-archive/gocfl/v2/pkg/ocfl"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/pkgerrors"
+	"github.com/spf13/cobra"
+	ublogger "gitlab.switch.ch/ub-unibas/go-ublogger/v2"
+	"go.ub.unibas.ch/cloud/certloader/v2/pkg/loader"
+	"io"
+	"log"
+	"os"
+)
+
+var recoverCmd = &cobra.Command{
+	Use:     "recover [path to ocfl structure]",
+	Short:   "cleans up objects after interrupted updates",
+	Long:    "removes abandoned staging areas and incomplete versions and completes versions, which were promoted but not committed to the root inventory",
+	Example: "gocfl recover ./archive --dry-run",
+	Args:    cobra.ExactArgs(1),
+	Run:     doRecover,
+}
+
+func initRecover() {
+	recoverCmd.Flags().StringP("object-path", "p", "", "object path to recover")
+	recoverCmd.Flags().StringP("object-id", "i", "", "object id to recover")
+	recoverCmd.Flags().Bool("dry-run", false, "only show what would be done")
+}
+
+func doRecoverConf(cmd *cobra.Command) {
+	if str := getFlagString(cmd, "object-path"); str != "" {
+		conf.Recover.ObjectPath = str
+	}
+	if str := getFlagString(cmd, "object-id"); str != "" {
+		conf.Recover.ObjectID = str
+	}
+	if b, ok := getFlagBool(cmd, "dry-run"); ok {
+		conf.Recover.DryRun = b
+	}
+}
+
+func doRecover(cmd *cobra.Command, args []string) {
+	ocflPath, err := ocfl.Fullpath(args[0])
+	if err != nil {
+		cobra.CheckErr(err)
+		return
+	}
+
+	// create logger instance
+	hostname, err := os.Hostname()
+	if err != nil {
+		log.Fatalf("cannot get hostname: %v", err)
+	}
+
+	var loggerTLSConfig *tls.Config
+	var loggerLoader io.Closer
+	if conf.Log.Stash.TLS != nil {
+		loggerTLSConfig, loggerLoader, err = loader.CreateClientLoader(conf.Log.Stash.TLS, nil)
+		if err != nil {
+			log.Fatalf("cannot create client loader: %v", err)
+		}
+		defer loggerLoader.Close()
+	}
+
+	zerolog.ErrorStackMarshaler = pkgerrors.MarshalStack
+	_logger, _logstash, _logfile, err := ublogger.CreateUbMultiLoggerTLS(conf.Log.Level, conf.Log.File,
+		ublogger.SetDataset(conf.Log.Stash.Dataset),
+		ublogger.SetLogStash(conf.Log.Stash.LogstashHost, conf.Log.Stash.LogstashPort, conf.Log.Stash.Namespace, conf.Log.Stash.LogstashTraceLevel),
+		ublogger.SetTLS(conf.Log.Stash.TLS != nil),
+		ublogger.SetTLSConfig(loggerTLSConfig),
+	)
+	if err != nil {
+		log.Fatalf("cannot create logger: %v", err)
+	}
+	if _logstash != nil {
+		defer _logstash.Close()
+	}
+
+	if _logfile != nil {
+		defer _logfile.Close()
+	}
+
+	l2 := _logger.With().Timestamp().Str("host", hostname).Logger() //.Output(output)
+	var logger zLogger.ZLogger = &l2
+
+	doRecoverConf(cmd)
+
+	oPath := conf.Recover.ObjectPath
+	oID := conf.Recover.ObjectID
+	if oPath != "" && oID != "" {
+		emperror.Panic(cmd.Help())
+		cobra.CheckErr(errors.New("do not use object-path AND object-id at the same time"))
+		return
+	}
+	dryRun := conf.Recover.DryRun
+
+	t := startTimer()
+	defer func() { logger.Info().Msgf("Duration: %s", t.String()) }()
+
+	logger.Info().Msgf("recovering '%s'", ocflPath)
+
+	fsFactory, err := initializeFSFactory(nil, nil, nil, true, false, logger)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msg("cannot create filesystem factory")
+		return
+	}
+
+	destFS, err := fsFactory.Get(ocflPath, dryRun)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msgf("cannot get filesystem for '%s'", ocflPath)
+		return
+	}
+	defer func() {
+		if err := writefs.Close(destFS); err != nil {
+			logger.Error().Stack().Err(err).Msgf("cannot close filesystem '%s'", destFS)
+		}
+	}()
+
+	extensionParams := GetExtensionParamValues(cmd, conf)
+	extensionFactory, err := InitExtensionFactory(extensionParams, "", false, nil, nil, nil, nil, logger, conf.TempDir)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msgf("cannot initialize extension factory")
+		return
+	}
+
+	cmdCtx, stop := cancelContext()
+	defer stop()
+	ctx := ocfl.NewContextValidation(cmdCtx)
+	storageRoot, err := ocfl.LoadStorageRoot(ctx, destFS, extensionFactory, logger, ErrorFactory, conf.Init.Documentation)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msg("cannot load storage root")
+		return
+	}
+
+	var folders []string
+	switch {
+	case oPath != "":
+		folders = []string{oPath}
+	case oID != "":
+		folder, err := storageRoot.GetObjectFolder(oID)
+		if err != nil {
+			logger.Error().Stack().Err(err).Msgf("cannot get folder of object '%s'", oID)
+			return
+		}
+		folders = []string{folder}
+	default:
+		folders, err = storageRoot.GetObjectFolders()
+		if err != nil {
+			logger.Error().Stack().Err(err).Msg("cannot get object folders")
+			return
+		}
+	}
+
+	var recovered, failed int
+	for _, folder := range folders {
+		if cmdCtx.Err() != nil {
+			break
+		}
+		objectFS, err := writefs.Sub(destFS, folder)
+		if err != nil {
+			logger.Error().Stack().Err(err).Msgf("cannot create subfs of '%v' for '%s'", destFS, folder)
+			failed++
+			continue
+		}
+		workspace, err := ocfl.ObjectWorkspace(destFS, folder)
+		if err != nil {
+			logger.Error().Stack().Err(err).Msgf("cannot get workspace of '%s'", folder)
+			failed++
+			continue
+		}
+		actions, err := ocfl.RecoverObject(ctx, objectFS, workspace, objectLocks(ocflPath), dryRun)
+		for _, action := range actions {
+			if dryRun {
+				logger.Warn().Msgf("[dry-run] %s/%s", folder, action)
+			} else {
+				logger.Warn().Msgf("%s/%s", folder, action)
+			}
+		}
+		if err != nil {
+			logger.Error().Stack().Err(err).Msgf("cannot recover object in '%s'", folder)
+			failed++
+			continue
+		}
+		if len(actions) > 0 {
+			recovered++
+		}
+	}
+	logger.Info().Msgf("%d objects checked, %d recovered, %d failed", len(folders), recovered, failed)
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 
 	"emperror.dev/errors"
 	configutil "github.com/je4/utils/v2/pkg/config"
@@ -87,6 +88,9 @@ var persistentFlagErrorConfig string
 
 var persistentFlagLogfile string
 var persistentFlagLoglevel string
+var persistentFlagProgress string
+var persistentFlagLockWait string
+var persistentFlagLockTimeout string
 
 var persistenFlagS3Endpoint string
 var persistenFlagS3AccessKeyID string
@@ -141,6 +145,19 @@ func getFlagBool(cmd *cobra.Command, flag string) (value bool, ok bool) {
 	return b, true
 }
 
+func getFlagInt(cmd *cobra.Command, flag string) (value int, ok bool) {
+	f := cmd.Flags().Lookup(flag)
+	if f == nil || !f.Changed {
+		return 0, false
+	}
+	i, err := cmd.Flags().GetInt(flag)
+	if err != nil {
+		_ = cmd.Help()
+		cobra.CheckErr(errors.Errorf("canot get flag %s: %v", flag, err))
+	}
+	return i, true
+}
+
 func configErrorFactory() {
 	var archiveErrs []*archiveerror.Error
 	if conf.ErrorConfig != "" {
@@ -213,6 +230,27 @@ func initConfig() {
 	if persistentFlagLoglevel != "" {
 		conf.Log.Level = persistentFlagLoglevel
 	}
+	if persistentFlagProgress != "" {
+		conf.Progress = persistentFlagProgress
+	}
+	if persistentFlagLockWait != "" {
+		switch strings.ToLower(persistentFlagLockWait) {
+		case "true":
+			conf.Lock.Wait = true
+		case "false":
+			conf.Lock.Wait = false
+		default:
+			cobra.CheckErr(errors.Errorf("invalid value '%s' for flag 'lock-wait'", persistentFlagLockWait))
+		}
+	}
+	if persistentFlagLockTimeout != "" {
+		if persistentFlagLockTimeout == "0" {
+			persistentFlagLockTimeout = "0s"
+		}
+		if err := conf.Lock.Timeout.UnmarshalText([]byte(persistentFlagLockTimeout)); err != nil {
+			cobra.CheckErr(errors.Errorf("invalid duration '%s' for flag 'lock-timeout'", persistentFlagLockTimeout))
+		}
+	}
 	if persistenFlagS3Endpoint != "" {
 		conf.S3.Endpoint = configutil.EnvString(persistenFlagS3Endpoint)
 	}
@@ -247,6 +285,9 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&persistentFlagErrorConfig, "error-config", "", "error config file (default is embedded)")
 	rootCmd.PersistentFlags().StringVar(&persistentFlagLogfile, "log-file", "", "log output file (default is console)")
 	rootCmd.PersistentFlags().StringVar(&persistentFlagLoglevel, "log-level", "", "log level (CRITICAL|ERROR|WARNING|NOTICE|INFO|DEBUG)")
+	rootCmd.PersistentFlags().StringVar(&persistentFlagProgress, "progress", "", "progress output (auto|bar|json|none). auto shows a progress bar on a terminal and json lines otherwise")
+	rootCmd.PersistentFlags().StringVar(&persistentFlagLockWait, "lock-wait", "", "wait for objects, which are locked by another writer (true|false)")
+	rootCmd.PersistentFlags().StringVar(&persistentFlagLockTimeout, "lock-timeout", "", "maximum time to wait for a locked object (0 waits forever)")
 	rootCmd.PersistentFlags().StringVar(&persistenFlagS3Endpoint, "s3-endpoint", "", "Endpoint for S3 Buckets")
 	rootCmd.PersistentFlags().StringVar(&persistenFlagS3AccessKeyID, "s3-access-key-id", "", "Access Key ID for S3 Buckets")
 	rootCmd.PersistentFlags().StringVar(&persistenFlagS3SecretAccessKey, "s3-secret-access-key", "", "Secret Access Key for S3 Buckets")
@@ -262,9 +303,16 @@ func init() {
 	initExtractMeta()
 	initDisplay()
 	initDecrypt()
-
-	setExtensionFlags(validateCmd, initCmd, createCmd, addCmd, updateCmd, statCmd, extractCmd, extractMetaCmd, displayCmd, decryptCmd)
-	rootCmd.AddCommand(validateCmd, initCmd, createCmd, addCmd, updateCmd, statCmd, extractCmd, extractMetaCmd, displayCmd, decryptCmd)
+	initBatch()
+	initWatch()
+	initRecover()
+	initUnlock()
+	initDedupReport()
+	initUpdateMeta()
+	initTag()
+
+	setExtensionFlags(validateCmd, initCmd, createCmd, addCmd, updateCmd, statCmd, extractCmd, extractMetaCmd, displayCmd, decryptCmd, batchCmd, watchCmd, recoverCmd, unlockCmd, dedupReportCmd, updateMetaCmd, tagCmd, moveTagCmd, untagCmd, listTagsCmd)
+	rootCmd.AddCommand(validateCmd, initCmd, createCmd, addCmd, updateCmd, statCmd, extractCmd, extractMetaCmd, displayCmd, decryptCmd, batchCmd, watchCmd, recoverCmd, unlockCmd, dedupReportCmd, updateMetaCmd, tagCmd, moveTagCmd, untagCmd, listTagsCmd)
 }
 
 func Execute() {
@@ -0,0 +1,327 @@
+package cmd
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+
+	"emperror.dev/emperror"
+	"emperror.dev/errors"
+	"github.com/je4/filesystem/v3/pkg/writefs"
+	"github.com/je4/utils/v2/pkg/zLogger"
+	"github.com/ocfl-archive/gocfl/v2/pkg/extension"
+	"github.com/ocfl-archive/gocfl/v2/pkg/ocfl"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/pkgerrors"
+	"github.com/spf13/cobra"
+	ublogger "gitlab.switch.ch/ub-unibas/go-ublogger/v2"
+	"go.ub.unibas.ch/cloud/certloader/v2/pkg/loader"
+)
+
+var tagCmd = &cobra.Command{
+	Use:     "tag [path to ocfl structure] [tag]",
+	Short:   "labels an object version with a tag",
+	Long:    "adds a tag like 'received' or 'published' to a version of an object. the tags are stored with the NNNN-version-tags extension, which is added to the object if necessary. no new version is created",
+	Example: "gocfl tag ./archive -i 'id:abc123' --version v2 published",
+	Args:    cobra.ExactArgs(2),
+	Run:     doTag,
+}
+
+var moveTagCmd = &cobra.Command{
+	Use:     "move-tag [path to ocfl structure] [tag]",
+	Short:   "moves an existing tag to another object version",
+	Example: "gocfl move-tag ./archive -i 'id:abc123' --version v3 published",
+	Args:    cobra.ExactArgs(2),
+	Run:     doMoveTag,
+}
+
+var untagCmd = &cobra.Command{
+	Use:     "untag [path to ocfl structure] [tag]",
+	Short:   "removes a tag from an object",
+	Example: "gocfl untag ./archive -i 'id:abc123' published",
+	Args:    cobra.ExactArgs(2),
+	Run:     doUntag,
+}
+
+var listTagsCmd = &cobra.Command{
+	Use:     "list-tags [path to ocfl structure]",
+	Aliases: []string{"tags"},
+	Short:   "lists the version tags of objects",
+	Long:    "lists the version tags of one object or of all objects of the storage root",
+	Example: "gocfl list-tags ./archive -i 'id:abc123'",
+	Args:    cobra.ExactArgs(1),
+	Run:     doListTags,
+}
+
+func initTag() {
+	for _, cmd := range []*cobra.Command{tagCmd, moveTagCmd, untagCmd, listTagsCmd} {
+		cmd.Flags().StringP("object-path", "p", "", "object path")
+		cmd.Flags().StringP("object-id", "i", "", "object id")
+	}
+	tagCmd.Flags().String("version", "", "version to tag (default latest)")
+	moveTagCmd.Flags().String("version", "", "new version of the tag (default latest)")
+}
+
+func doTagConf(cmd *cobra.Command) {
+	if str := getFlagString(cmd, "object-path"); str != "" {
+		conf.Tag.ObjectPath = str
+	}
+	if str := getFlagString(cmd, "object-id"); str != "" {
+		conf.Tag.ObjectID = str
+	}
+	if cmd.Flags().Lookup("version") != nil {
+		if str := getFlagString(cmd, "version"); str != "" {
+			conf.Tag.Version = str
+		}
+	}
+}
+
+func doTag(cmd *cobra.Command, args []string) {
+	tag := args[1]
+	runTagCommand(cmd, args[0], false, func(o ocfl.Object, logger zLogger.ZLogger) error {
+		version, err := o.ResolveVersion(conf.Tag.Version)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if err := o.UpdateVersionTags(func(tags map[string]string) error {
+			if v, ok := tags[tag]; ok {
+				return errors.Errorf("tag '%s' already exists on version '%s', use move-tag", tag, v)
+			}
+			tags[tag] = version
+			return nil
+		}); err != nil {
+			return errors.WithStack(err)
+		}
+		logger.Info().Msgf("object '%s': tag '%s' added to version '%s'", o.GetID(), tag, version)
+		return nil
+	})
+}
+
+func doMoveTag(cmd *cobra.Command, args []string) {
+	tag := args[1]
+	runTagCommand(cmd, args[0], false, func(o ocfl.Object, logger zLogger.ZLogger) error {
+		version, err := o.ResolveVersion(conf.Tag.Version)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		var old string
+		if err := o.UpdateVersionTags(func(tags map[string]string) error {
+			var ok bool
+			if old, ok = tags[tag]; !ok {
+				return errors.Errorf("tag '%s' not found", tag)
+			}
+			tags[tag] = version
+			return nil
+		}); err != nil {
+			return errors.WithStack(err)
+		}
+		logger.Info().Msgf("object '%s': tag '%s' moved from version '%s' to '%s'", o.GetID(), tag, old, version)
+		return nil
+	})
+}
+
+func doUntag(cmd *cobra.Command, args []string) {
+	tag := args[1]
+	runTagCommand(cmd, args[0], false, func(o ocfl.Object, logger zLogger.ZLogger) error {
+		var old string
+		if err := o.UpdateVersionTags(func(tags map[string]string) error {
+			var ok bool
+			if old, ok = tags[tag]; !ok {
+				return errors.Errorf("tag '%s' not found", tag)
+			}
+			delete(tags, tag)
+			return nil
+		}); err != nil {
+			return errors.WithStack(err)
+		}
+		logger.Info().Msgf("object '%s': tag '%s' removed from version '%s'", o.GetID(), tag, old)
+		return nil
+	})
+}
+
+func doListTags(cmd *cobra.Command, args []string) {
+	runTagCommand(cmd, args[0], true, func(o ocfl.Object, logger zLogger.ZLogger) error {
+		tags, err := o.GetVersionTags()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		var names = make([]string, 0, len(tags))
+		for tag := range tags {
+			names = append(names, tag)
+		}
+		slices.Sort(names)
+		for _, tag := range names {
+			fmt.Printf("%s\t%s\t%s\n", o.GetID(), tag, tags[tag])
+		}
+		return nil
+	})
+}
+
+// addVersionTagsExtension adds a new NNNN-version-tags extension to an object without tags.
+// the extension folder is written with the first tag
+func addVersionTagsExtension(o ocfl.Object) error {
+	ext, err := extension.NewVersionTags(&extension.VersionTagsConfig{
+		ExtensionConfig: &ocfl.ExtensionConfig{ExtensionName: extension.VersionTagsName},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "cannot create extension %s", extension.VersionTagsName)
+	}
+	extFS, err := writefs.SubFSCreate(o.GetFS(), "extensions/"+extension.VersionTagsName)
+	if err != nil {
+		return errors.Wrapf(err, "cannot create folder of extension %s", extension.VersionTagsName)
+	}
+	ext.SetFS(extFS, true)
+	if err := o.GetExtensionManager().Add(ext); err != nil {
+		return errors.Wrapf(err, "cannot add extension %s", extension.VersionTagsName)
+	}
+	return nil
+}
+
+func hasVersionTagsExtension(o ocfl.Object) bool {
+	for _, ext := range o.GetExtensionManager().GetExtensions() {
+		if _, ok := ext.(ocfl.ExtensionVersionTags); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// runTagCommand calls fn for the selected object or for all objects, if readOnly is set and no object is selected
+func runTagCommand(cmd *cobra.Command, path string, readOnly bool, fn func(o ocfl.Object, logger zLogger.ZLogger) error) {
+	ocflPath, err := ocfl.Fullpath(path)
+	if err != nil {
+		cobra.CheckErr(err)
+		return
+	}
+
+	// create logger instance
+	hostname, err := os.Hostname()
+	if err != nil {
+		log.Fatalf("cannot get hostname: %v", err)
+	}
+
+	var loggerTLSConfig *tls.Config
+	var loggerLoader io.Closer
+	if conf.Log.Stash.TLS != nil {
+		loggerTLSConfig, loggerLoader, err = loader.CreateClientLoader(conf.Log.Stash.TLS, nil)
+		if err != nil {
+			log.Fatalf("cannot create client loader: %v", err)
+		}
+		defer loggerLoader.Close()
+	}
+
+	zerolog.ErrorStackMarshaler = pkgerrors.MarshalStack
+	_logger, _logstash, _logfile, err := ublogger.CreateUbMultiLoggerTLS(conf.Log.Level, conf.Log.File,
+		ublogger.SetDataset(conf.Log.Stash.Dataset),
+		ublogger.SetLogStash(conf.Log.Stash.LogstashHost, conf.Log.Stash.LogstashPort, conf.Log.Stash.Namespace, conf.Log.Stash.LogstashTraceLevel),
+		ublogger.SetTLS(conf.Log.Stash.TLS != nil),
+		ublogger.SetTLSConfig(loggerTLSConfig),
+	)
+	if err != nil {
+		log.Fatalf("cannot create logger: %v", err)
+	}
+	if _logstash != nil {
+		defer _logstash.Close()
+	}
+
+	if _logfile != nil {
+		defer _logfile.Close()
+	}
+
+	l2 := _logger.With().Timestamp().Str("host", hostname).Logger() //.Output(output)
+	var logger zLogger.ZLogger = &l2
+
+	doTagConf(cmd)
+
+	oPath := conf.Tag.ObjectPath
+	oID := conf.Tag.ObjectID
+	if oPath != "" && oID != "" {
+		emperror.Panic(cmd.Help())
+		cobra.CheckErr(errors.New("do not use object-path AND object-id at the same time"))
+		return
+	}
+	if !readOnly && oPath == "" && oID == "" {
+		emperror.Panic(cmd.Help())
+		cobra.CheckErr(errors.New("object-path or object-id required"))
+		return
+	}
+
+	fsFactory, err := initializeFSFactory(nil, nil, conf.S3, true, readOnly, logger)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msg("cannot create filesystem factory")
+		return
+	}
+
+	destFS, err := fsFactory.Get(ocflPath, readOnly)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msgf("cannot get filesystem for '%s'", ocflPath)
+		return
+	}
+	defer func() {
+		if err := writefs.Close(destFS); err != nil {
+			logger.Error().Stack().Err(err).Msgf("cannot close filesystem '%s'", destFS)
+		}
+	}()
+
+	extensionParams := GetExtensionParamValues(cmd, conf)
+	extensionFactory, err := InitExtensionFactory(extensionParams, "", false, nil, nil, nil, nil, logger, conf.TempDir)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msgf("cannot initialize extension factory")
+		return
+	}
+
+	cmdCtx, stop := cancelContext()
+	defer stop()
+	ctx := ocfl.NewContextValidation(cmdCtx)
+	storageRoot, err := ocfl.LoadStorageRoot(ctx, destFS, extensionFactory, logger, ErrorFactory, conf.Init.Documentation)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msg("cannot load storage root")
+		return
+	}
+	storageRoot.SetLockOptions(objectLocks(ocflPath))
+
+	var folders []string
+	switch {
+	case oPath != "":
+		folders = []string{oPath}
+	case oID != "":
+		folder, err := storageRoot.GetObjectFolder(oID)
+		if err != nil {
+			logger.Error().Stack().Err(err).Msgf("cannot get folder of object '%s'", oID)
+			return
+		}
+		folders = []string{folder}
+	default:
+		folders, err = storageRoot.GetObjectFolders()
+		if err != nil {
+			logger.Error().Stack().Err(err).Msg("cannot get object folders")
+			return
+		}
+	}
+
+	for _, folder := range folders {
+		if cmdCtx.Err() != nil {
+			break
+		}
+		o, err := storageRoot.LoadObjectByFolder(folder)
+		if err != nil {
+			logger.Error().Stack().Err(err).Msgf("cannot load object '%s'", folder)
+			continue
+		}
+		if !readOnly && !hasVersionTagsExtension(o) {
+			if err := addVersionTagsExtension(o); err != nil {
+				logger.Error().Stack().Err(err).Msgf("cannot add version tags to object '%s'", o.GetID())
+				continue
+			}
+		}
+		if err := fn(o, logger); err != nil {
+			logger.Error().Stack().Err(err).Msgf("cannot process tags of object '%s'", o.GetID())
+		}
+		if err := o.Close(); err != nil {
+			logger.Error().Stack().Err(err).Msgf("cannot close object '%s'", o.GetID())
+		}
+	}
+}
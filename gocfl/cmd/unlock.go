@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"crypto/tls"
+	"emperror.dev/emperror"
+	"emperror.dev/errors"
+	"github.com/je4/filesystem/v3/pkg/writefs"
+	"github.com/je4/utils/v2/pkg/zLogger"
+	"github.com/ocfl-archive/gocfl/v2/pkg/ocfl"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/pkgerrors"
+	"github.com/spf13/cobra"
+	ublogger "gitlab.switch.ch/ub-unibas/go-ublogger/v2"
+	"go.ub.unibas.ch/cloud/certloader/v2/pkg/loader"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+)
+
+var unlockCmd = &cobra.Command{
+	Use:     "unlock [path to ocfl structure]",
+	Short:   "removes stale object locks",
+	Long:    "removes the locks of objects, which were left behind by processes, which are not running anymore. locks of other hosts or running processes are only removed with --force",
+	Example: "gocfl unlock ./archive --object-id 'id:abc123' --force",
+	Args:    cobra.ExactArgs(1),
+	Run:     doUnlock,
+}
+
+func initUnlock() {
+	unlockCmd.Flags().StringP("object-path", "p", "", "object path to unlock")
+	unlockCmd.Flags().StringP("object-id", "i", "", "object id to unlock")
+	unlockCmd.Flags().Bool("force", false, "remove locks, even if the locking process may still be running")
+}
+
+func doUnlockConf(cmd *cobra.Command) {
+	if str := getFlagString(cmd, "object-path"); str != "" {
+		conf.Unlock.ObjectPath = str
+	}
+	if str := getFlagString(cmd, "object-id"); str != "" {
+		conf.Unlock.ObjectID = str
+	}
+	if b, ok := getFlagBool(cmd, "force"); ok {
+		conf.Unlock.Force = b
+	}
+}
+
+func doUnlock(cmd *cobra.Command, args []string) {
+	ocflPath, err := ocfl.Fullpath(args[0])
+	if err != nil {
+		cobra.CheckErr(err)
+		return
+	}
+
+	// create logger instance
+	hostname, err := os.Hostname()
+	if err != nil {
+		log.Fatalf("cannot get hostname: %v", err)
+	}
+
+	var loggerTLSConfig *tls.Config
+	var loggerLoader io.Closer
+	if conf.Log.Stash.TLS != nil {
+		loggerTLSConfig, loggerLoader, err = loader.CreateClientLoader(conf.Log.Stash.TLS, nil)
+		if err != nil {
+			log.Fatalf("cannot create client loader: %v", err)
+		}
+		defer loggerLoader.Close()
+	}
+
+	zerolog.ErrorStackMarshaler = pkgerrors.MarshalStack
+	_logger, _logstash, _logfile, err := ublogger.CreateUbMultiLoggerTLS(conf.Log.Level, conf.Log.File,
+		ublogger.SetDataset(conf.Log.Stash.Dataset),
+		ublogger.SetLogStash(conf.Log.Stash.LogstashHost, conf.Log.Stash.LogstashPort, conf.Log.Stash.Namespace, conf.Log.Stash.LogstashTraceLevel),
+		ublogger.SetTLS(conf.Log.Stash.TLS != nil),
+		ublogger.SetTLSConfig(loggerTLSConfig),
+	)
+	if err != nil {
+		log.Fatalf("cannot create logger: %v", err)
+	}
+	if _logstash != nil {
+		defer _logstash.Close()
+	}
+
+	if _logfile != nil {
+		defer _logfile.Close()
+	}
+
+	l2 := _logger.With().Timestamp().Str("host", hostname).Logger() //.Output(output)
+	var logger zLogger.ZLogger = &l2
+
+	doUnlockConf(cmd)
+
+	oPath := conf.Unlock.ObjectPath
+	oID := conf.Unlock.ObjectID
+	if oPath != "" && oID != "" {
+		emperror.Panic(cmd.Help())
+		cobra.CheckErr(errors.New("do not use object-path AND object-id at the same time"))
+		return
+	}
+	force := conf.Unlock.Force
+
+	t := startTimer()
+	defer func() { logger.Info().Msgf("Duration: %s", t.String()) }()
+
+	logger.Info().Msgf("unlocking '%s'", ocflPath)
+
+	fsFactory, err := initializeFSFactory(nil, nil, nil, true, false, logger)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msg("cannot create filesystem factory")
+		return
+	}
+
+	destFS, err := fsFactory.Get(ocflPath, false)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msgf("cannot get filesystem for '%s'", ocflPath)
+		return
+	}
+	defer func() {
+		if err := writefs.Close(destFS); err != nil {
+			logger.Error().Stack().Err(err).Msgf("cannot close filesystem '%s'", destFS)
+		}
+	}()
+
+	extensionParams := GetExtensionParamValues(cmd, conf)
+	extensionFactory, err := InitExtensionFactory(extensionParams, "", false, nil, nil, nil, nil, logger, conf.TempDir)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msgf("cannot initialize extension factory")
+		return
+	}
+
+	cmdCtx, stop := cancelContext()
+	defer stop()
+	ctx := ocfl.NewContextValidation(cmdCtx)
+	storageRoot, err := ocfl.LoadStorageRoot(ctx, destFS, extensionFactory, logger, ErrorFactory, conf.Init.Documentation)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msg("cannot load storage root")
+		return
+	}
+
+	var folders []string
+	switch {
+	case oPath != "":
+		folders = []string{oPath}
+	case oID != "":
+		folder, err := storageRoot.GetObjectFolder(oID)
+		if err != nil {
+			logger.Error().Stack().Err(err).Msgf("cannot get folder of object '%s'", oID)
+			return
+		}
+		folders = []string{folder}
+	default:
+		folders, err = storageRoot.GetObjectFolders()
+		if err != nil {
+			logger.Error().Stack().Err(err).Msg("cannot get object folders")
+			return
+		}
+	}
+
+	var locked, removed int
+	for _, folder := range folders {
+		if cmdCtx.Err() != nil {
+			break
+		}
+		workspace, err := ocfl.ObjectWorkspace(destFS, folder)
+		if err != nil {
+			logger.Error().Stack().Err(err).Msgf("cannot get workspace of '%s'", folder)
+			continue
+		}
+		info, err := ocfl.ReadLock(workspace)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		locked++
+		switch {
+		case err != nil && !force:
+			logger.Error().Stack().Err(err).Msgf("cannot read lock of '%s'", folder)
+			continue
+		case err == nil && !force && !info.Stale():
+			logger.Warn().Msgf("%s: locked (%s), use --force to remove the lock", folder, info)
+			continue
+		}
+		if err := ocfl.RemoveLock(workspace); err != nil {
+			logger.Error().Stack().Err(err).Msgf("cannot remove lock of '%s'", folder)
+			continue
+		}
+		removed++
+		if info != nil {
+			logger.Warn().Msgf("%s: lock removed (%s)", folder, info)
+		} else {
+			logger.Warn().Msgf("%s: lock removed", folder)
+		}
+	}
+	logger.Info().Msgf("%d objects checked, %d locked, %d locks removed", len(folders), locked, removed)
+}
@@ -28,7 +28,7 @@ var updateCmd = &cobra.Command{
 	Use:     "update [path to ocfl structure]",
 	Aliases: []string{},
 	Short:   "update object in existing ocfl structure",
-	Long:    "opens an existing ocfl structure and updates an object. if an object with the given id does not exist, an error is produced",
+	Long:    "opens an existing ocfl structure and updates an object. if an object with the given id does not exist, an error is produced. the source is a folder, a zip or tar archive, an s3 prefix or a http(s) url manifest",
 	Example: "gocfl update ./archive.zip /tmp/testdata -u 'Jane Doe' -a 'mailto:user@domain' -m 'initial add' -object-id 'id:abc123'",
 	Args:    cobra.MinimumNArgs(2),
 	Run:     doUpdate,
@@ -41,8 +41,15 @@ func initUpdate() {
 	updateCmd.Flags().StringP("user-address", "a", "", "user address for new object version (required)")
 	updateCmd.Flags().StringP("digest", "d", "", "digest to use for zip file checksum")
 	updateCmd.Flags().Bool("no-deduplicate", false, "disable deduplication (faster)")
-	updateCmd.Flags().Bool("echo", false, "update strategy 'echo' (reflects deletions). if not set, update strategy is 'contribute'")
+	updateCmd.Flags().Bool("echo", false, "update strategy 'echo' (reflects deletions, moved files are recorded as renames). if not set, update strategy is 'contribute'")
 	updateCmd.Flags().Bool("no-compress", false, "do not compress data in zip file")
+	updateCmd.Flags().Bool("dry-run", false, "show the planned changes without writing content or inventories")
+	updateCmd.Flags().String("plan-format", "text", "format of the dry run plan (text|json)")
+	updateCmd.Flags().Int("workers", 0, "number of files read and hashed in parallel (default 1, not for zip files)")
+	updateCmd.Flags().String("ingest-mode", "", "place content into a local storage root by copy, reflink or hardlink after hashing, falls back to copy on different filesystems (copy|reflink|hardlink), hardlink needs --allow-hardlink")
+	updateCmd.Flags().Bool("allow-hardlink", false, "allow ingest mode hardlink. WARNING: object and source share the files, a later change of the source corrupts the object")
+	initDigestManifestFlag(updateCmd)
+	initIngestFlags(updateCmd)
 	updateCmd.Flags().Bool("encrypt-aes", false, "set flag to create encrypted container (only for container target)")
 	updateCmd.Flags().String("aes-key", "", "key to use for encrypted container in hex format (64 chars, empty: generate random key")
 	updateCmd.Flags().String("aes-iv", "", "initialisation vector to use for encrypted container in hex format (32 charsempty: generate random vector")
@@ -77,6 +84,25 @@ func doUpdateConf(cmd *cobra.Command) {
 	if b, ok := getFlagBool(cmd, "echo"); ok {
 		conf.Update.Echo = b
 	}
+	if i, ok := getFlagInt(cmd, "workers"); ok {
+		conf.Update.Workers = i
+	}
+	if str := getFlagString(cmd, "ingest-mode"); str != "" {
+		conf.Update.IngestMode = str
+	}
+	if mode, err := ocfl.ParseIngestMode(conf.Update.IngestMode); err != nil {
+		_ = cmd.Help()
+		cobra.CheckErr(errors.Errorf("invalid ingest mode '%s' for flag 'ingest-mode' or 'Update.IngestMode' config file entry", conf.Update.IngestMode))
+	} else {
+		conf.Update.IngestMode = string(mode)
+	}
+	if b, ok := getFlagBool(cmd, "allow-hardlink"); ok {
+		conf.Update.AllowHardlink = b
+	}
+	if conf.Update.IngestMode == string(ocfl.IngestModeHardlink) && !conf.Update.AllowHardlink {
+		_ = cmd.Help()
+		cobra.CheckErr(errors.Errorf("ingest mode hardlink shares the files of the source with the object, use flag 'allow-hardlink' or 'Update.AllowHardlink' config file entry to allow it"))
+	}
 
 }
 
@@ -88,7 +114,7 @@ func doUpdate(cmd *cobra.Command, args []string) {
 		cobra.CheckErr(err)
 		return
 	}
-	srcPath, err := ocfl.Fullpath(args[1])
+	srcPath, err := sourcePath(args[1])
 	if err != nil {
 		cobra.CheckErr(err)
 		return
@@ -132,6 +158,8 @@ func doUpdate(cmd *cobra.Command, args []string) {
 	var logger zLogger.ZLogger = &l2
 
 	doUpdateConf(cmd)
+	doIngestConf(cmd)
+	dryRun, planFormat := getDryRun(cmd)
 
 	var addr string
 	var localCache bool
@@ -145,23 +173,22 @@ func doUpdate(cmd *cobra.Command, args []string) {
 	t := startTimer()
 	defer func() { logger.Info().Msgf("Duration: %s", t.String()) }()
 
-	fmt.Printf("opening '%s'\n", ocflPath)
-	logger.Info().Msgf("opening '%s'", ocflPath)
-
-	if _, err := os.Stat(srcPath); err != nil {
-		logger.Panic().Stack().Err(err).Msgf("cannot stat '%s'", srcPath)
+	if !dryRun {
+		fmt.Printf("opening '%s'\n", ocflPath)
 	}
+	logger.Info().Msgf("opening '%s'", ocflPath)
 
-	fsFactory, err := initializeFSFactory([]checksum.DigestAlgorithm{conf.Update.Digest}, nil, nil, conf.Update.NoCompress, false, logger)
+	fsFactory, err := initializeFSFactory([]checksum.DigestAlgorithm{conf.Update.Digest}, nil, conf.S3, conf.Update.NoCompress, false, logger)
 	if err != nil {
 		logger.Panic().Stack().Err(err).Msg("cannot create filesystem factory")
 	}
 
-	sourceFS, err := fsFactory.Get(srcPath, true)
+	sourceFS, err := openSource(context.Background(), fsFactory, srcPath)
 	if err != nil {
-		logger.Panic().Stack().Err(err).Msgf("cannot get filesystem for '%s'", srcPath)
+		logger.Panic().Stack().Err(err).Msgf("cannot open source '%s'", srcPath)
 	}
-	destFS, err := fsFactory.Get(ocflPath, false)
+	defer writefs.Close(sourceFS)
+	destFS, err := fsFactory.Get(ocflPath, dryRun)
 	if err != nil {
 		logger.Panic().Stack().Err(err).Msgf("cannot get filesystem for '%s'", ocflPath)
 	}
@@ -180,6 +207,22 @@ func doUpdate(cmd *cobra.Command, args []string) {
 	if area == "" {
 		area = "content"
 	}
+	filter, err := newSourceFilter(logger)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msg("cannot create ingest filter")
+		return
+	}
+	defer filter.report(logger)
+	trusted, err := getTrustedDigests(cmd, sourceFS, area, conf.Update.Digest)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msg("cannot load digest manifest")
+		return
+	}
+	sourceFS, err = filter.wrap(sourceFS, srcPath, area)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msgf("cannot filter '%s'", srcPath)
+		return
+	}
 	var areaPaths = map[string]fs.FS{}
 	for i := 2; i < len(args); i++ {
 		matches := areaPathRegexp.FindStringSubmatch(args[i])
@@ -187,10 +230,16 @@ func doUpdate(cmd *cobra.Command, args []string) {
 			logger.Error().Msgf("invalid areapath '%s'", args[i])
 			continue
 		}
-		areaPaths[matches[1]], err = fsFactory.Get(matches[2], true)
+		areaPaths[matches[1]], err = openSource(context.Background(), fsFactory, matches[2])
+		if err != nil {
+			doNotClose = true
+			logger.Panic().Stack().Err(err).Msgf("cannot open source '%s'", args[i])
+		}
+		defer writefs.Close(areaPaths[matches[1]])
+		areaPaths[matches[1]], err = filter.wrap(areaPaths[matches[1]], matches[2], matches[1])
 		if err != nil {
 			doNotClose = true
-			logger.Panic().Stack().Err(err).Msgf("cannot get filesystem for '%s'", args[i])
+			logger.Panic().Stack().Err(err).Msgf("cannot filter '%s'", args[i])
 		}
 	}
 
@@ -239,7 +288,15 @@ func doUpdate(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	ctx := ocfl.NewContextValidation(context.TODO())
+	cmdCtx, stop := cancelContext()
+	defer stop()
+	cmdCtx, endProgress, err := progressContext(cmdCtx)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msg("cannot initialize progress output")
+		return
+	}
+	defer endProgress()
+	ctx := ocfl.NewContextValidation(cmdCtx)
 	if !writefs.HasContent(destFS) {
 
 	}
@@ -249,6 +306,7 @@ func doUpdate(cmd *cobra.Command, args []string) {
 		doNotClose = true
 		return
 	}
+	storageRoot.SetLockOptions(objectLocks(ocflPath))
 
 	exists, err := storageRoot.ObjectExists(flagObjectID)
 	if err != nil {
@@ -262,11 +320,38 @@ func doUpdate(cmd *cobra.Command, args []string) {
 		return
 	}
 
+	if dryRun {
+		if err := dryRunObjectByPath(
+			ctx,
+			storageRoot,
+			"",
+			"",
+			nil,
+			objectExtensions,
+			conf.Update.Deduplicate,
+			flagObjectID,
+			sourceFS,
+			area,
+			areaPaths,
+			conf.Update.Echo,
+			trusted,
+			planFormat,
+			os.Stdout,
+			logger,
+		); err != nil {
+			logger.Error().Stack().Err(err).Msgf("cannot plan update of object '%s'", flagObjectID)
+		}
+		return
+	}
+
 	_, err = addObjectByPath(
+		ctx,
 		storageRoot,
 		nil,
 		objectExtensions,
 		conf.Update.Deduplicate,
+		ingestWorkers(ocflPath, conf.Update.Workers, logger),
+		ocfl.IngestMode(conf.Update.IngestMode),
 		flagObjectID,
 		conf.Update.User.Name,
 		conf.Update.User.Address,
@@ -275,6 +360,7 @@ func doUpdate(cmd *cobra.Command, args []string) {
 		area,
 		areaPaths,
 		conf.Update.Echo,
+		trusted,
 		logger,
 	)
 	if err != nil {
@@ -0,0 +1,376 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/je4/filesystem/v3/pkg/writefs"
+	"github.com/je4/utils/v2/pkg/checksum"
+	"github.com/je4/utils/v2/pkg/zLogger"
+	"github.com/ocfl-archive/gocfl/v2/internal"
+	"github.com/ocfl-archive/gocfl/v2/pkg/extension"
+	"github.com/ocfl-archive/gocfl/v2/pkg/ocfl"
+	"github.com/ocfl-archive/gocfl/v2/pkg/subsystem/jsonpatch"
+	"github.com/ocfl-archive/gocfl/v2/pkg/subsystem/migration"
+	"github.com/ocfl-archive/gocfl/v2/pkg/subsystem/thumbnail"
+	ironmaiden "github.com/ocfl-archive/indexer/v3/pkg/indexer"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/pkgerrors"
+	"github.com/spf13/cobra"
+	ublogger "gitlab.switch.ch/ub-unibas/go-ublogger/v2"
+	"go.ub.unibas.ch/cloud/certloader/v2/pkg/loader"
+)
+
+var updateMetaCmd = &cobra.Command{
+	Use:     "updatemeta [path to ocfl structure]",
+	Aliases: []string{"update-meta"},
+	Short:   "creates a new object version, which only changes metadata",
+	Long: "creates a new version of an existing object with a replacement or a json patch of the metadata file of the NNNN-metafile extension and/or replacement files of the metadata area. " +
+		"the content of the previous version is carried over unchanged. the new metadata file is validated against the schema of the extension",
+	Example: "gocfl updatemeta ./archive.zip -i 'id:abc123' --patch ./fix-title.json -u 'Jane Doe' -a 'mailto:user@domain' -m 'fix typo in title'",
+	Args:    cobra.ExactArgs(1),
+	Run:     doUpdateMeta,
+}
+
+func initUpdateMeta() {
+	updateMetaCmd.Flags().StringVarP(&flagObjectID, "object-id", "i", "", "object id to update (required)")
+	updateMetaCmd.Flags().StringP("message", "m", "", "message for new object version (required)")
+	updateMetaCmd.Flags().StringP("user-name", "u", "", "user name for new object version (required)")
+	updateMetaCmd.Flags().StringP("user-address", "a", "", "user address for new object version (required)")
+	updateMetaCmd.Flags().String("metafile", "", "replacement metadata file of the NNNN-metafile extension (.json, .toml or .yaml)")
+	updateMetaCmd.Flags().String("patch", "", "json patch (RFC 6902) or json merge patch (RFC 7386) of the metadata file of the NNNN-metafile extension")
+	updateMetaCmd.Flags().StringArray("file", nil, "replacement file of the metadata area as [name]=[path] (multiple)")
+	updateMetaCmd.Flags().String("area", "", "area of the replacement files (default metadata)")
+	updateMetaCmd.Flags().Bool("dry-run", false, "show the new metadata file without writing a new version")
+}
+
+func doUpdateMetaConf(cmd *cobra.Command) {
+	if str := getFlagString(cmd, "user-name"); str != "" {
+		conf.Update.User.Name = str
+	}
+	if str := getFlagString(cmd, "user-address"); str != "" {
+		conf.Update.User.Address = str
+	}
+	if str := getFlagString(cmd, "message"); str != "" {
+		conf.Update.Message = str
+	}
+	if str := getFlagString(cmd, "metafile"); str != "" {
+		conf.UpdateMeta.Metafile = str
+	}
+	if str := getFlagString(cmd, "patch"); str != "" {
+		conf.UpdateMeta.Patch = str
+	}
+	if conf.UpdateMeta.Metafile != "" && conf.UpdateMeta.Patch != "" {
+		_ = cmd.Help()
+		cobra.CheckErr(errors.New("do not use metafile AND patch at the same time"))
+	}
+	if str := getFlagString(cmd, "area"); str != "" {
+		conf.UpdateMeta.Area = str
+	}
+	if conf.UpdateMeta.Area == "" {
+		conf.UpdateMeta.Area = "metadata"
+	}
+}
+
+// metaFileExtension returns the NNNN-metafile extension of object
+func metaFileExtension(object ocfl.Object) (*extension.MetaFile, bool) {
+	for _, ext := range object.GetExtensionManager().GetExtensions() {
+		if metaFile, ok := ext.(*extension.MetaFile); ok {
+			return metaFile, true
+		}
+	}
+	return nil, false
+}
+
+// newMetaInfo returns the replacement metadata or the patched metadata of the head version of object.
+// false is returned, if the metadata does not change
+func newMetaInfo(object ocfl.Object, metaFile *extension.MetaFile, metafile, patch string) (any, bool, error) {
+	metadata, err := metaFile.GetMetadata(object)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "cannot read metadata of object '%s'", object.GetID())
+	}
+	current := metadata[""]
+	var info any
+	if metafile != "" {
+		if info, err = metaFile.ReadInfo(metafile); err != nil {
+			return nil, false, errors.WithStack(err)
+		}
+	} else {
+		data, err := os.ReadFile(patch)
+		if err != nil {
+			return nil, false, errors.Wrapf(err, "cannot read patch '%s'", patch)
+		}
+		if info, err = jsonpatch.Apply(current, data); err != nil {
+			return nil, false, errors.Wrapf(err, "cannot apply patch '%s'", patch)
+		}
+	}
+	currentData, err := json.Marshal(current)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "cannot marshal current metadata")
+	}
+	infoData, err := json.Marshal(info)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "cannot marshal new metadata")
+	}
+	return info, !bytes.Equal(currentData, infoData), nil
+}
+
+func doUpdateMeta(cmd *cobra.Command, args []string) {
+	ocflPath, err := ocfl.Fullpath(args[0])
+	if err != nil {
+		cobra.CheckErr(err)
+		return
+	}
+
+	// create logger instance
+	hostname, err := os.Hostname()
+	if err != nil {
+		log.Fatalf("cannot get hostname: %v", err)
+	}
+
+	var loggerTLSConfig *tls.Config
+	var loggerLoader io.Closer
+	if conf.Log.Stash.TLS != nil {
+		loggerTLSConfig, loggerLoader, err = loader.CreateClientLoader(conf.Log.Stash.TLS, nil)
+		if err != nil {
+			log.Fatalf("cannot create client loader: %v", err)
+		}
+		defer loggerLoader.Close()
+	}
+
+	zerolog.ErrorStackMarshaler = pkgerrors.MarshalStack
+	_logger, _logstash, _logfile, err := ublogger.CreateUbMultiLoggerTLS(conf.Log.Level, conf.Log.File,
+		ublogger.SetDataset(conf.Log.Stash.Dataset),
+		ublogger.SetLogStash(conf.Log.Stash.LogstashHost, conf.Log.Stash.LogstashPort, conf.Log.Stash.Namespace, conf.Log.Stash.LogstashTraceLevel),
+		ublogger.SetTLS(conf.Log.Stash.TLS != nil),
+		ublogger.SetTLSConfig(loggerTLSConfig),
+	)
+	if err != nil {
+		log.Fatalf("cannot create logger: %v", err)
+	}
+	if _logstash != nil {
+		defer _logstash.Close()
+	}
+
+	if _logfile != nil {
+		defer _logfile.Close()
+	}
+
+	l2 := _logger.With().Timestamp().Str("host", hostname).Logger() //.Output(output)
+	var logger zLogger.ZLogger = &l2
+
+	doUpdateMetaConf(cmd)
+	dryRun, _ := getFlagBool(cmd, "dry-run")
+
+	if flagObjectID == "" {
+		_ = cmd.Help()
+		cobra.CheckErr(errors.New("no object id given"))
+		return
+	}
+	// logical name in area -> local file
+	var areaFiles = map[string]string{}
+	areaFlags, err := cmd.Flags().GetStringArray("file")
+	if err != nil {
+		cobra.CheckErr(err)
+		return
+	}
+	for _, areaFlag := range areaFlags {
+		name, path, ok := strings.Cut(areaFlag, "=")
+		name = strings.Trim(name, "/")
+		if !ok || name == "" || path == "" {
+			_ = cmd.Help()
+			cobra.CheckErr(errors.Errorf("invalid file '%s' for flag 'file', use [name]=[path]", areaFlag))
+			return
+		}
+		areaFiles[name] = path
+	}
+	if conf.UpdateMeta.Metafile == "" && conf.UpdateMeta.Patch == "" && len(areaFiles) == 0 {
+		_ = cmd.Help()
+		cobra.CheckErr(errors.New("no metafile, patch or file given"))
+		return
+	}
+
+	var fss = map[string]fs.FS{"internal": internal.InternalFS}
+	indexerActions, err := ironmaiden.InitActionDispatcher(fss, *conf.Indexer, logger)
+	if err != nil {
+		logger.Panic().Stack().Err(err).Msg("cannot init indexer")
+	}
+
+	t := startTimer()
+	defer func() { logger.Info().Msgf("Duration: %s", t.String()) }()
+
+	logger.Info().Msgf("opening '%s'", ocflPath)
+
+	fsFactory, err := initializeFSFactory([]checksum.DigestAlgorithm{conf.Update.Digest}, nil, conf.S3, conf.Update.NoCompress, false, logger)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msg("cannot create filesystem factory")
+		return
+	}
+	destFS, err := fsFactory.Get(ocflPath, dryRun)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msgf("cannot get filesystem for '%s'", ocflPath)
+		return
+	}
+	var doNotClose = false
+	defer func() {
+		if doNotClose {
+			logger.Panic().Msgf("filesystem '%s' not closed", destFS)
+		} else {
+			if err := writefs.Close(destFS); err != nil {
+				logger.Panic().Stack().Err(err).Msgf("error closing filesystem '%s'", destFS)
+			}
+		}
+	}()
+
+	mig, err := migration.GetMigrations(conf)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msg("cannot get migrations")
+		return
+	}
+	thumb, err := thumbnail.GetThumbnails(conf)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msg("cannot get thumbnails")
+		return
+	}
+
+	extensionParams := GetExtensionParamValues(cmd, conf)
+	extensionFactory, err := InitExtensionFactory(extensionParams, "", false, indexerActions, mig, thumb, nil, logger, conf.TempDir)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msg("cannot initialize extension factory")
+		return
+	}
+
+	cmdCtx, stop := cancelContext()
+	defer stop()
+	cmdCtx, endProgress, err := progressContext(cmdCtx)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msg("cannot initialize progress output")
+		return
+	}
+	defer endProgress()
+	ctx := ocfl.NewContextValidation(cmdCtx)
+	storageRoot, err := ocfl.LoadStorageRoot(ctx, destFS, extensionFactory, logger, ErrorFactory, conf.Init.Documentation)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msg("cannot load storage root")
+		return
+	}
+	storageRoot.SetLockOptions(objectLocks(ocflPath))
+
+	exists, err := storageRoot.ObjectExists(flagObjectID)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msgf("cannot check for object '%s'", flagObjectID)
+		return
+	}
+	if !exists {
+		fmt.Printf("Object '%s' does not exists, exiting", flagObjectID)
+		return
+	}
+	o, err := storageRoot.LoadObjectByID(flagObjectID)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msgf("cannot load object '%s'", flagObjectID)
+		return
+	}
+
+	var metaFile *extension.MetaFile
+	var info any
+	if conf.UpdateMeta.Metafile != "" || conf.UpdateMeta.Patch != "" {
+		var ok bool
+		if metaFile, ok = metaFileExtension(o); !ok {
+			logger.Error().Msgf("object '%s' has no %s extension", flagObjectID, extension.MetaFileName)
+			return
+		}
+		var changed bool
+		info, changed, err = newMetaInfo(o, metaFile, conf.UpdateMeta.Metafile, conf.UpdateMeta.Patch)
+		if err != nil {
+			logger.Error().Stack().Err(err).Msgf("cannot create new metadata of object '%s'", flagObjectID)
+			return
+		}
+		if err := metaFile.Validate(info); err != nil {
+			logger.Error().Stack().Err(err).Msgf("new metadata of object '%s' does not match the schema of %s", flagObjectID, extension.MetaFileName)
+			return
+		}
+		if !changed {
+			logger.Warn().Msgf("metadata of object '%s' not changed", flagObjectID)
+			if len(areaFiles) == 0 {
+				return
+			}
+			metaFile = nil
+		}
+	}
+
+	if dryRun {
+		if metaFile != nil {
+			data, err := json.MarshalIndent(info, "", "  ")
+			if err != nil {
+				logger.Error().Stack().Err(err).Msg("cannot marshal new metadata")
+				return
+			}
+			fmt.Printf("%s\n", data)
+		}
+		for name, path := range areaFiles {
+			fmt.Printf("%s:%s <- %s\n", conf.UpdateMeta.Area, name, path)
+		}
+		return
+	}
+
+	if metaFile != nil {
+		if err := metaFile.SetInfo(info); err != nil {
+			logger.Error().Stack().Err(err).Msgf("cannot set new metadata of object '%s'", flagObjectID)
+			return
+		}
+	}
+	previousHead := o.GetInventory().GetHead()
+	if _, err := o.StartUpdate(ctx, nil, conf.Update.Message, conf.Update.User.Name, conf.Update.User.Address, false); err != nil {
+		logger.Error().Stack().Err(err).Msgf("cannot start update for object '%s'", flagObjectID)
+		doNotClose = true
+		return
+	}
+	for name, path := range areaFiles {
+		if err := addAreaFile(o, conf.UpdateMeta.Area, name, path); err != nil {
+			logger.Error().Stack().Err(err).Msgf("cannot add '%s' to object '%s'", path, flagObjectID)
+			if err := o.Rollback(); err != nil {
+				logger.Error().Stack().Err(err).Msgf("cannot roll back update of object '%s'", flagObjectID)
+			}
+			doNotClose = true
+			return
+		}
+	}
+	if err := o.EndUpdate(); err != nil {
+		logger.Error().Stack().Err(err).Msgf("cannot end update for object '%s'", flagObjectID)
+		doNotClose = true
+		return
+	}
+	if err := o.Close(); err != nil {
+		logger.Error().Stack().Err(err).Msgf("cannot close object '%s'", flagObjectID)
+		doNotClose = true
+		return
+	}
+	// an update without changes is dropped by EndUpdate
+	if head := o.GetInventory().GetHead(); head != previousHead {
+		fmt.Printf("object '%s': new version '%s'\n", flagObjectID, head)
+	} else {
+		fmt.Printf("object '%s': no new version, content not changed\n", flagObjectID)
+	}
+	_ = showStatus(ctx, logger)
+}
+
+// addAreaFile replaces the file name of area with the local file path
+func addAreaFile(object ocfl.Object, area, name, path string) error {
+	fp, err := os.Open(path)
+	if err != nil {
+		return errors.Wrapf(err, "cannot open '%s'", path)
+	}
+	defer fp.Close()
+	if _, err := object.AddReader(fp, []string{name}, area, true, false); err != nil {
+		return errors.Wrapf(err, "cannot add '%s' as '%s:%s'", path, area, name)
+	}
+	return nil
+}
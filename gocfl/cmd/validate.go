@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"context"
 	"crypto/tls"
 	"github.com/je4/filesystem/v3/pkg/writefs"
 	"github.com/je4/utils/v2/pkg/zLogger"
@@ -115,7 +114,15 @@ func validate(cmd *cobra.Command, args []string) {
 		}
 	}()
 
-	ctx := ocfl.NewContextValidation(context.TODO())
+	cmdCtx, stop := cancelContext()
+	defer stop()
+	cmdCtx, endProgress, err := progressContext(cmdCtx)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msg("cannot initialize progress output")
+		return
+	}
+	defer endProgress()
+	ctx := ocfl.NewContextValidation(cmdCtx)
 	storageRoot, err := ocfl.LoadStorageRoot(ctx, destFS, extensionFactory, logger, ErrorFactory, conf.Init.Documentation)
 	if err != nil {
 		logger.Error().Stack().Err(err).Msg("cannot load storageroot")
@@ -128,18 +135,18 @@ func validate(cmd *cobra.Command, args []string) {
 		return
 	}
 	if objectID == "" && objectPath == "" {
-		if err := storageRoot.Check(); err != nil {
+		if err := storageRoot.Check(ctx); err != nil {
 			logger.Error().Stack().Err(err).Msg("ocfl not valid")
 			return
 		}
 	} else {
 		if objectID != "" {
-			if err := storageRoot.CheckObjectByID(objectID); err != nil {
+			if err := storageRoot.CheckObjectByID(ctx, objectID); err != nil {
 				logger.Error().Stack().Err(err).Msgf("ocfl object '%s' not valid", objectID)
 				return
 			}
 		} else {
-			if err := storageRoot.CheckObjectByFolder(objectPath); err != nil {
+			if err := storageRoot.CheckObjectByFolder(ctx, objectPath); err != nil {
 				logger.Error().Stack().Err(err).Msgf("ocfl object '%s' not valid", objectPath)
 				return
 			}
@@ -0,0 +1,236 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/je4/utils/v2/pkg/config"
+	"github.com/je4/utils/v2/pkg/zLogger"
+	"github.com/ocfl-archive/gocfl/v2/pkg/ocfl"
+	"github.com/ocfl-archive/gocfl/v2/pkg/subsystem/batch"
+	"github.com/ocfl-archive/gocfl/v2/pkg/subsystem/watch"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/pkgerrors"
+	"github.com/spf13/cobra"
+	ublogger "gitlab.switch.ch/ub-unibas/go-ublogger/v2"
+	"go.ub.unibas.ch/cloud/certloader/v2/pkg/loader"
+)
+
+var watchCmd = &cobra.Command{
+	Use:     "watch [path to ocfl structure]",
+	Aliases: []string{},
+	Short:   "ingests the deliveries of a hot folder",
+	Long: "watches an inbox folder and ingests every complete delivery subfolder into an existing ocfl structure. " +
+		"a delivery is complete, if it contains the sentinel file and/or nothing changed for the quiet period. " +
+		"new objects are created, existing objects are updated with update strategy 'contribute'. " +
+		"afterwards the delivery is moved to the done or failed folder together with a result file",
+	Example: "gocfl watch ./archive --inbox ./inbox --sentinel READY --quiet 0 --id-prefix 'info:' -u 'Jane Doe' -a 'mailto:user@domain' -m 'delivery'",
+	Args:    cobra.ExactArgs(1),
+	Run:     doWatch,
+}
+
+// initWatch initializes the gocfl watch command
+func initWatch() {
+	watchCmd.Flags().String("inbox", "", "folder with one subfolder per delivery")
+	watchCmd.Flags().String("done", "", "folder for successful deliveries (default is done in the inbox)")
+	watchCmd.Flags().String("failed", "", "folder for failed deliveries (default is failed in the inbox)")
+	watchCmd.Flags().String("sentinel", "", "name of the file, which marks a delivery as complete")
+	watchCmd.Flags().String("quiet", "", "time without changes, after which a delivery is complete (default 1m, 0 for sentinel only)")
+	watchCmd.Flags().String("poll", "", "interval for scanning the inbox (default 10s)")
+	watchCmd.Flags().String("id-file", "", "file in the delivery with the object id or a json row of a batch manifest (default is the folder name)")
+	watchCmd.Flags().String("id-prefix", "", "prefix of the object ids")
+	watchCmd.Flags().Bool("once", false, "ingest the deliveries in the inbox and exit")
+	watchCmd.Flags().String("default-object-extensions", "", "folder with initial extension configurations for new OCFL objects")
+	watchCmd.Flags().StringP("message", "m", "", "message for new object versions, if not set in the id file")
+	watchCmd.Flags().StringP("user-name", "u", "", "user name for new object versions, if not set in the id file")
+	watchCmd.Flags().StringP("user-address", "a", "", "user address for new object versions, if not set in the id file")
+	watchCmd.Flags().StringP("fixity", "f", "", "comma separated list of digest algorithms for fixity, if not set in the id file")
+	watchCmd.Flags().StringP("digest", "d", "", "digest to use for ocfl checksum")
+	watchCmd.Flags().Bool("deduplicate", false, "force deduplication (slower)")
+	watchCmd.Flags().Bool("no-compress", false, "do not compress data in zip file")
+	watchCmd.Flags().Int("workers", 0, "number of files read and hashed in parallel (default 1, not for zip files)")
+	watchCmd.Flags().String("ingest-mode", "", "place content into a local storage root by copy, reflink or hardlink after hashing, falls back to copy on different filesystems (copy|reflink|hardlink), hardlink needs --allow-hardlink")
+	watchCmd.Flags().Bool("allow-hardlink", false, "allow ingest mode hardlink. WARNING: object and source share the files, a later change of the source corrupts the object")
+	initIngestFlags(watchCmd)
+}
+
+func doWatchConf(cmd *cobra.Command) {
+	doAddConf(cmd)
+	for flag, value := range map[string]*string{
+		"inbox":     &conf.Watch.Inbox,
+		"done":      &conf.Watch.Done,
+		"failed":    &conf.Watch.Failed,
+		"sentinel":  &conf.Watch.Sentinel,
+		"id-file":   &conf.Watch.IDFile,
+		"id-prefix": &conf.Watch.IDPrefix,
+	} {
+		if str := getFlagString(cmd, flag); str != "" {
+			*value = str
+		}
+	}
+	for flag, value := range map[string]*config.Duration{
+		"quiet": &conf.Watch.Quiet,
+		"poll":  &conf.Watch.Poll,
+	} {
+		if str := getFlagString(cmd, flag); str != "" {
+			if str == "0" {
+				str = "0s"
+			}
+			if err := value.UnmarshalText([]byte(str)); err != nil {
+				_ = cmd.Help()
+				cobra.CheckErr(errors.Errorf("invalid duration '%s' for flag '%s'", str, flag))
+			}
+		}
+	}
+}
+
+// doWatch executes the gocfl watch command
+func doWatch(cmd *cobra.Command, args []string) {
+	var err error
+
+	ocflPath, err := ocfl.Fullpath(args[0])
+	if err != nil {
+		cobra.CheckErr(err)
+		return
+	}
+
+	if !slices.Contains([]string{"DEBUG", "ERROR", "WARNING", "INFO", "CRITICAL"}, conf.Log.Level) {
+		_ = cmd.Help()
+		cobra.CheckErr(errors.Errorf("invalid log level '%s' for flag 'log-level' or 'LogLevel' config file entry", persistentFlagLoglevel))
+	}
+
+	// create logger instance
+	hostname, err := os.Hostname()
+	if err != nil {
+		log.Fatalf("cannot get hostname: %v", err)
+	}
+
+	var loggerTLSConfig *tls.Config
+	var loggerLoader io.Closer
+	if conf.Log.Stash.TLS != nil {
+		loggerTLSConfig, loggerLoader, err = loader.CreateClientLoader(conf.Log.Stash.TLS, nil)
+		if err != nil {
+			log.Fatalf("cannot create client loader: %v", err)
+		}
+		defer loggerLoader.Close()
+	}
+
+	zerolog.ErrorStackMarshaler = pkgerrors.MarshalStack
+	_logger, _logstash, _logfile, err := ublogger.CreateUbMultiLoggerTLS(conf.Log.Level, conf.Log.File,
+		ublogger.SetDataset(conf.Log.Stash.Dataset),
+		ublogger.SetLogStash(conf.Log.Stash.LogstashHost, conf.Log.Stash.LogstashPort, conf.Log.Stash.Namespace, conf.Log.Stash.LogstashTraceLevel),
+		ublogger.SetTLS(conf.Log.Stash.TLS != nil),
+		ublogger.SetTLSConfig(loggerTLSConfig),
+	)
+	if err != nil {
+		log.Fatalf("cannot create logger: %v", err)
+	}
+	if _logstash != nil {
+		defer _logstash.Close()
+	}
+
+	if _logfile != nil {
+		defer _logfile.Close()
+	}
+
+	l2 := _logger.With().Timestamp().Str("host", hostname).Logger() //.Output(output)
+	var logger zLogger.ZLogger = &l2
+
+	doWatchConf(cmd)
+	doIngestConf(cmd)
+
+	if conf.Watch.Inbox == "" {
+		_ = cmd.Help()
+		cobra.CheckErr(errors.New("no inbox defined"))
+	}
+	inboxPath, err := ocfl.Fullpath(conf.Watch.Inbox)
+	if err != nil {
+		cobra.CheckErr(err)
+		return
+	}
+	donePath := filepath.Join(inboxPath, "done")
+	if conf.Watch.Done != "" {
+		if donePath, err = ocfl.Fullpath(conf.Watch.Done); err != nil {
+			cobra.CheckErr(err)
+			return
+		}
+	}
+	failedPath := filepath.Join(inboxPath, "failed")
+	if conf.Watch.Failed != "" {
+		if failedPath, err = ocfl.Fullpath(conf.Watch.Failed); err != nil {
+			cobra.CheckErr(err)
+			return
+		}
+	}
+	// the control files of a delivery are not part of the object
+	for _, name := range []string{conf.Watch.Sentinel, conf.Watch.IDFile} {
+		if name != "" {
+			conf.Ingest.ExcludeRegexp = append(conf.Ingest.ExcludeRegexp, "^"+regexp.QuoteMeta(name)+"$")
+		}
+	}
+
+	inbox, err := watch.NewInbox(inboxPath, donePath, failedPath, conf.Watch.Sentinel, time.Duration(conf.Watch.Quiet), conf.Watch.IDFile, conf.Watch.IDPrefix)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msgf("cannot open inbox '%s'", inboxPath)
+		return
+	}
+
+	t := startTimer()
+	defer func() {
+		logger.Info().Any(
+			errorTopic,
+			ErrorFactory.NewError(
+				ErrorGOCFL,
+				fmt.Sprintf("duration: %s", t.String()),
+				err,
+			),
+		).Msg("")
+	}()
+	logger.Info().Msgf("opening '%s'", ocflPath)
+
+	cmdCtx, stop := cancelContext()
+	defer stop()
+	cmdCtx, endProgress, err := progressContext(cmdCtx)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msg("cannot initialize progress output")
+		return
+	}
+	defer endProgress()
+
+	b, err := newBatchIngest(cmdCtx, cmd, ocflPath, logger)
+	if err != nil {
+		logger.Error().Stack().Err(err).Msgf("cannot open '%s'", ocflPath)
+		return
+	}
+	defer b.Close()
+
+	once, _ := getFlagBool(cmd, "once")
+	logger.Info().Msgf("watching inbox '%s'", inboxPath)
+	handler := func(ctx context.Context, delivery *watch.Delivery) *batch.Result {
+		row, err := inbox.Row(delivery)
+		result := batch.NewResult(row)
+		if err == nil {
+			logger.Info().Msgf("ingesting delivery '%s' into object '%s'", delivery.Name, row.ID)
+			err = b.ingestRow(ctx, row, result)
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			logger.Error().Any(ErrorFactory.LogError(ErrorGOCFL, fmt.Sprintf("cannot ingest delivery '%s'", delivery.Name), err)).Msg("")
+		}
+		return result.Done(err)
+	}
+	if err = watch.Watch(cmdCtx, inbox, time.Duration(conf.Watch.Poll), once, handler, logger); err != nil {
+		logger.Error().Stack().Err(err).Msgf("cannot watch inbox '%s'", inboxPath)
+	}
+}
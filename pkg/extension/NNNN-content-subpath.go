@@ -1,6 +1,7 @@
 package extension
 
 import (
+	"context"
 	"emperror.dev/errors"
 	"encoding/json"
 	"fmt"
@@ -138,11 +139,11 @@ func (sl *ContentSubPath) BuildObjectManifestPath(object ocfl.Object, originalPa
 	return path, nil
 }
 
-func (sl *ContentSubPath) UpdateObjectBefore(object ocfl.Object) error {
+func (sl *ContentSubPath) UpdateObjectBefore(ctx context.Context, object ocfl.Object) error {
 
 	return nil
 }
-func (sl *ContentSubPath) UpdateObjectAfter(object ocfl.Object) error {
+func (sl *ContentSubPath) UpdateObjectAfter(ctx context.Context, object ocfl.Object) error {
 	readme := doc.NewMarkDown()
 	readme.WriteTitle("Description of folders", doc.LevelTitle).
 		WriteLines(2)
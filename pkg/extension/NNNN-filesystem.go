@@ -3,6 +3,7 @@ package extension
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"emperror.dev/errors"
 	"encoding/json"
 	"fmt"
@@ -96,19 +97,19 @@ func (extFS *Filesystem) GetConfig() any {
 	return extFS.FilesystemConfig
 }
 
-func (extFS *Filesystem) AddFileBefore(object ocfl.Object, sourceFS fs.FS, source string, dest string, area string, isDir bool) error {
+func (extFS *Filesystem) AddFileBefore(ctx context.Context, object ocfl.Object, sourceFS fs.FS, source string, dest string, area string, isDir bool) error {
 	return nil
 }
 
-func (extFS *Filesystem) UpdateFileBefore(object ocfl.Object, sourceFS fs.FS, source, dest, area string, isDir bool) error {
+func (extFS *Filesystem) UpdateFileBefore(ctx context.Context, object ocfl.Object, sourceFS fs.FS, source, dest, area string, isDir bool) error {
 	return nil
 }
 
-func (extFS *Filesystem) DeleteFileBefore(object ocfl.Object, dest string, area string) error {
+func (extFS *Filesystem) DeleteFileBefore(ctx context.Context, object ocfl.Object, dest string, area string) error {
 	return nil
 }
 
-func (extFS *Filesystem) AddFileAfter(object ocfl.Object, sourceFS fs.FS, source []string, internalPath, digest, area string, isDir bool) error {
+func (extFS *Filesystem) AddFileAfter(ctx context.Context, object ocfl.Object, sourceFS fs.FS, source []string, internalPath, digest, area string, isDir bool) error {
 	if isDir && extFS.Folders == "" {
 		return nil
 	}
@@ -144,8 +145,12 @@ func (extFS *Filesystem) AddFileAfter(object ocfl.Object, sourceFS fs.FS, source
 			}
 			return errors.Wrapf(err, "cannot get fullpath for '%v/%s'", sourceFS, src)
 		}
-		fsMeta := &FilesystemMeta{StateVersion: inventory.GetHead()}
 		// we work only on local filesystems with this extension
+		// archives and object stores return paths relative to their root
+		if !filepath.IsAbs(filepath.FromSlash(fullpath)) {
+			continue
+		}
+		fsMeta := &FilesystemMeta{StateVersion: inventory.GetHead()}
 		stat, err := os.Stat(fullpath)
 		if err != nil {
 			return errors.Wrapf(err, "cannot stat file '%s'", src)
@@ -197,14 +202,14 @@ func (extFS *Filesystem) AddFileAfter(object ocfl.Object, sourceFS fs.FS, source
 	return nil
 }
 
-func (extFS *Filesystem) UpdateFileAfter(object ocfl.Object, sourceFS fs.FS, source, dest, area string, isDir bool) error {
+func (extFS *Filesystem) UpdateFileAfter(ctx context.Context, object ocfl.Object, sourceFS fs.FS, source, dest, area string, isDir bool) error {
 	return errors.WithStack(
-		extFS.AddFileAfter(object, sourceFS, []string{source}, "", "", area, isDir),
+		extFS.AddFileAfter(ctx, object, sourceFS, []string{source}, "", "", area, isDir),
 	)
 
 }
 
-func (extFS *Filesystem) DeleteFileAfter(object ocfl.Object, dest string, area string) error {
+func (extFS *Filesystem) DeleteFileAfter(ctx context.Context, object ocfl.Object, dest string, area string) error {
 	return nil
 }
 
@@ -283,11 +288,11 @@ func (extFS *Filesystem) GetMetadata(object ocfl.Object) (map[string]any, error)
 	return retResult, nil
 }
 
-func (extFS *Filesystem) UpdateObjectBefore(object ocfl.Object) error {
+func (extFS *Filesystem) UpdateObjectBefore(ctx context.Context, object ocfl.Object) error {
 	return nil
 }
 
-func (extFS *Filesystem) UpdateObjectAfter(object ocfl.Object) error {
+func (extFS *Filesystem) UpdateObjectAfter(ctx context.Context, object ocfl.Object) error {
 	if extFS.writer == nil {
 		return nil
 	}
@@ -3,6 +3,7 @@ package extension
 import (
 	"bufio"
 	"cmp"
+	"context"
 	"emperror.dev/errors"
 	"encoding/json"
 	"github.com/je4/filesystem/v3/pkg/writefs"
@@ -402,60 +403,78 @@ func (manager *GOCFLExtensionManager) BuildObjectStatePath(object ocfl.Object, o
 }
 
 // ContentChange
-func (manager *GOCFLExtensionManager) AddFileBefore(object ocfl.Object, sourceFS fs.FS, source string, dest string, area string, isDir bool) error {
+func (manager *GOCFLExtensionManager) AddFileBefore(ctx context.Context, object ocfl.Object, sourceFS fs.FS, source string, dest string, area string, isDir bool) error {
 	var errs = []error{}
 	for _, ocp := range manager.contentChange {
-		if err := ocp.AddFileBefore(object, sourceFS, source, dest, area, isDir); err != nil {
+		if err := ctx.Err(); err != nil {
+			return errors.Wrap(err, "extension hooks cancelled")
+		}
+		if err := ocp.AddFileBefore(ctx, object, sourceFS, source, dest, area, isDir); err != nil {
 			errs = append(errs, err)
 			continue
 		}
 	}
 	return errors.Combine(errs...)
 }
-func (manager *GOCFLExtensionManager) UpdateFileBefore(object ocfl.Object, sourceFS fs.FS, source, dest, area string, isDir bool) error {
+func (manager *GOCFLExtensionManager) UpdateFileBefore(ctx context.Context, object ocfl.Object, sourceFS fs.FS, source, dest, area string, isDir bool) error {
 	var errs = []error{}
 	for _, ocp := range manager.contentChange {
-		if err := ocp.UpdateFileBefore(object, sourceFS, source, dest, area, isDir); err != nil {
+		if err := ctx.Err(); err != nil {
+			return errors.Wrap(err, "extension hooks cancelled")
+		}
+		if err := ocp.UpdateFileBefore(ctx, object, sourceFS, source, dest, area, isDir); err != nil {
 			errs = append(errs, err)
 			continue
 		}
 	}
 	return errors.Combine(errs...)
 }
-func (manager *GOCFLExtensionManager) DeleteFileBefore(object ocfl.Object, dest string, area string) error {
+func (manager *GOCFLExtensionManager) DeleteFileBefore(ctx context.Context, object ocfl.Object, dest string, area string) error {
 	var errs = []error{}
 	for _, ocp := range manager.contentChange {
-		if err := ocp.DeleteFileBefore(object, dest, area); err != nil {
+		if err := ctx.Err(); err != nil {
+			return errors.Wrap(err, "extension hooks cancelled")
+		}
+		if err := ocp.DeleteFileBefore(ctx, object, dest, area); err != nil {
 			errs = append(errs, err)
 			continue
 		}
 	}
 	return errors.Combine(errs...)
 }
-func (manager *GOCFLExtensionManager) AddFileAfter(object ocfl.Object, sourceFS fs.FS, source []string, internalPath, digest, area string, isDir bool) error {
+func (manager *GOCFLExtensionManager) AddFileAfter(ctx context.Context, object ocfl.Object, sourceFS fs.FS, source []string, internalPath, digest, area string, isDir bool) error {
 	var errs = []error{}
 	for _, ocp := range manager.contentChange {
-		if err := ocp.AddFileAfter(object, sourceFS, source, internalPath, digest, area, isDir); err != nil {
+		if err := ctx.Err(); err != nil {
+			return errors.Wrap(err, "extension hooks cancelled")
+		}
+		if err := ocp.AddFileAfter(ctx, object, sourceFS, source, internalPath, digest, area, isDir); err != nil {
 			errs = append(errs, err)
 			continue
 		}
 	}
 	return errors.Combine(errs...)
 }
-func (manager *GOCFLExtensionManager) UpdateFileAfter(object ocfl.Object, sourceFS fs.FS, source, dest, area string, isDir bool) error {
+func (manager *GOCFLExtensionManager) UpdateFileAfter(ctx context.Context, object ocfl.Object, sourceFS fs.FS, source, dest, area string, isDir bool) error {
 	var errs = []error{}
 	for _, ocp := range manager.contentChange {
-		if err := ocp.UpdateFileAfter(object, sourceFS, source, dest, area, isDir); err != nil {
+		if err := ctx.Err(); err != nil {
+			return errors.Wrap(err, "extension hooks cancelled")
+		}
+		if err := ocp.UpdateFileAfter(ctx, object, sourceFS, source, dest, area, isDir); err != nil {
 			errs = append(errs, err)
 			continue
 		}
 	}
 	return errors.Combine(errs...)
 }
-func (manager *GOCFLExtensionManager) DeleteFileAfter(object ocfl.Object, dest string, area string) error {
+func (manager *GOCFLExtensionManager) DeleteFileAfter(ctx context.Context, object ocfl.Object, dest string, area string) error {
 	var errs = []error{}
 	for _, ocp := range manager.contentChange {
-		if err := ocp.DeleteFileAfter(object, dest, area); err != nil {
+		if err := ctx.Err(); err != nil {
+			return errors.Wrap(err, "extension hooks cancelled")
+		}
+		if err := ocp.DeleteFileAfter(ctx, object, dest, area); err != nil {
 			errs = append(errs, err)
 			continue
 		}
@@ -464,20 +483,26 @@ func (manager *GOCFLExtensionManager) DeleteFileAfter(object ocfl.Object, dest s
 }
 
 // ObjectChange
-func (manager *GOCFLExtensionManager) UpdateObjectBefore(object ocfl.Object) error {
+func (manager *GOCFLExtensionManager) UpdateObjectBefore(ctx context.Context, object ocfl.Object) error {
 	var errs = []error{}
 	for _, ocp := range manager.objectChange {
-		if err := ocp.UpdateObjectBefore(object); err != nil {
+		if err := ctx.Err(); err != nil {
+			return errors.Wrap(err, "extension hooks cancelled")
+		}
+		if err := ocp.UpdateObjectBefore(ctx, object); err != nil {
 			errs = append(errs, err)
 			continue
 		}
 	}
 	return errors.Combine(errs...)
 }
-func (manager *GOCFLExtensionManager) UpdateObjectAfter(object ocfl.Object) error {
+func (manager *GOCFLExtensionManager) UpdateObjectAfter(ctx context.Context, object ocfl.Object) error {
 	var errs = []error{}
 	for _, ocp := range manager.objectChange {
-		if err := ocp.UpdateObjectAfter(object); err != nil {
+		if err := ctx.Err(); err != nil {
+			return errors.Wrap(err, "extension hooks cancelled")
+		}
+		if err := ocp.UpdateObjectAfter(ctx, object); err != nil {
 			errs = append(errs, err)
 			continue
 		}
@@ -567,8 +592,13 @@ func (manager *GOCFLExtensionManager) DoNewVersion(object ocfl.Object) error {
 	return nil
 }
 
+// HasStreamExtensions returns true, if an extension needs the content of added files
+func (manager *GOCFLExtensionManager) HasStreamExtensions() bool {
+	return len(manager.stream) > 0
+}
+
 // Stream
-func (manager *GOCFLExtensionManager) StreamObject(object ocfl.Object, reader io.Reader, stateFiles []string, dest string) error {
+func (manager *GOCFLExtensionManager) StreamObject(ctx context.Context, object ocfl.Object, reader io.Reader, stateFiles []string, dest string) error {
 	if len(manager.stream) == 0 {
 		_, _ = io.Copy(io.Discard, reader)
 		return nil
@@ -582,7 +612,7 @@ func (manager *GOCFLExtensionManager) StreamObject(object ocfl.Object, reader io
 		writer = append(writer, iou.NewWriteIgnoreCloser(pw))
 		go func(r io.Reader, extension ocfl.ExtensionStream) {
 			defer wg.Done()
-			if err := extension.StreamObject(object, r, stateFiles, dest); err != nil {
+			if err := extension.StreamObject(ctx, object, r, stateFiles, dest); err != nil {
 				extErrors <- errors.Wrapf(err, "cannot call StreamObject() from extension '%s' for object '%s'", extension.GetName(), object.GetID())
 			}
 			// discard remaining data
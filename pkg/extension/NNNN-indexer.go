@@ -3,6 +3,7 @@ package extension
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
@@ -14,6 +15,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"emperror.dev/errors"
 	"github.com/andybalholm/brotli"
 	"github.com/je4/filesystem/v3/pkg/writefs"
 	"github.com/je4/utils/v2/pkg/zLogger"
@@ -322,11 +324,11 @@ func (sl *Indexer) WriteConfig() error {
 	return nil
 }
 
-func (sl *Indexer) UpdateObjectBefore(object ocfl.Object) error {
+func (sl *Indexer) UpdateObjectBefore(ctx context.Context, object ocfl.Object) error {
 	return nil
 }
 
-func (sl *Indexer) UpdateObjectAfter(object ocfl.Object) error {
+func (sl *Indexer) UpdateObjectAfter(ctx context.Context, object ocfl.Object) error {
 	if sl.indexerActions == nil {
 		factoryErr := sl.errorFactory.NewError(
 			errorExtensionConfig, "please enable indexer in config file", nil,
@@ -409,6 +411,10 @@ func (sl *Indexer) GetMetadata(object ocfl.Object) (map[string]any, error) {
 			}
 		} else {
 			data, err = ocfl.ReadJsonL(object, "indexer", v, sl.IndexerConfig.Compress, sl.StorageType, sl.StorageName, sl.fsys)
+			if errors.Is(err, fs.ErrNotExist) {
+				// version without indexed files, i.e. metadata only
+				continue
+			}
 			if err != nil {
 				factoryErr := sl.errorFactory.NewError(
 					errorExtensionRunner,
@@ -499,7 +505,7 @@ func (sl *Indexer) extractIndexErrors(indexErrors map[string]string) {
 
 // StreamObject streams the data to the Indexer module to be analysed
 // by the different Indexer components.
-func (sl *Indexer) StreamObject(object ocfl.Object, reader io.Reader, stateFiles []string, dest string) error {
+func (sl *Indexer) StreamObject(ctx context.Context, object ocfl.Object, reader io.Reader, stateFiles []string, dest string) error {
 	if !sl.active {
 		return nil
 	}
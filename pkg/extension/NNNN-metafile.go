@@ -2,6 +2,7 @@ package extension
 
 import (
 	"bytes"
+	"context"
 	"emperror.dev/errors"
 	"encoding/json"
 	"fmt"
@@ -113,6 +114,8 @@ type MetaFile struct {
 	compiledSchema *jsonschema.Schema
 	stored         bool
 	info           map[string][]byte
+	// pending is the metadata of SetInfo
+	pending []byte
 }
 
 func (sl *MetaFile) Terminate() error {
@@ -202,87 +205,110 @@ func toStringKeys(val interface{}) (interface{}, error) {
 	}
 }
 
-func (sl *MetaFile) UpdateObjectBefore(object ocfl.Object) error {
-	if sl.stored {
-		return nil
-	}
-	sl.stored = true
-	var err error
-	inventory := object.GetInventory()
-	if inventory == nil {
-		return errors.New("no inventory available")
-	}
-	if sl.fsys == nil {
-		return errors.New("no filesystem set")
-	}
-	if sl.metadataSource == "" {
-		// only a problem, if first version
-		if len(inventory.GetVersionStrings()) < 2 {
-			return errors.New("no metadata source configured")
-		}
-		return nil
-	}
+// ReadInfo reads and decodes the metadata file or url fname. Only .json, .toml and .yaml are supported
+func (sl *MetaFile) ReadInfo(fname string) (any, error) {
 	var rc io.ReadCloser
-	var fname = strings.Replace(sl.metadataSource, "$ID", object.GetID(), -1)
 	u, err := url.Parse(fname)
 	if err == nil && u.Scheme != "" && u.Host != "" {
 		resp, err := http.Get(fname)
 		if err != nil {
-			return errors.Wrapf(err, "cannot get '%s'", fname)
+			return nil, errors.Wrapf(err, "cannot get '%s'", fname)
 		}
 		rc = resp.Body
 	} else {
 		rc, err = os.Open(fname)
 		if err != nil {
-			return errors.Wrapf(err, "cannot open '%s'", fname)
+			return nil, errors.Wrapf(err, "cannot open '%s'", fname)
 		}
 	}
 	defer rc.Close()
 
-	var infoData []byte
 	var info any
-
 	switch strings.ToLower(filepath.Ext(fname)) {
 	case ".json":
 		jr := json.NewDecoder(rc)
 		if err := jr.Decode(&info); err != nil {
-			return errors.Wrap(err, "cannot decode info file")
-		}
-		if err := sl.compiledSchema.Validate(info); err != nil {
-			return errors.Wrap(err, "cannot validate info file")
+			return nil, errors.Wrap(err, "cannot decode info file")
 		}
 	case ".yaml":
 		jr := yaml.NewDecoder(rc)
 		if err := jr.Decode(&info); err != nil {
-			return errors.Wrap(err, "cannot decode info file")
+			return nil, errors.Wrap(err, "cannot decode info file")
 		}
 		info, err = toStringKeys(info)
 		if err != nil {
-			return errors.Wrap(err, "cannot convert map[any]any to map[string]any")
-		}
-		if err := sl.compiledSchema.Validate(info); err != nil {
-			return errors.Wrap(err, "cannot validate info file")
+			return nil, errors.Wrap(err, "cannot convert map[any]any to map[string]any")
 		}
 	case ".toml":
 		jr := toml.NewDecoder(rc)
 		if _, err := jr.Decode(&info); err != nil {
-			return errors.Wrap(err, "cannot decode info file")
+			return nil, errors.Wrap(err, "cannot decode info file")
 		}
 		info, err = toStringKeys(info)
 		if err != nil {
-			return errors.Wrap(err, "cannot convert map[any]any to map[string]any")
-		}
-		if err := sl.compiledSchema.Validate(info); err != nil {
-			return errors.Wrap(err, "cannot validate info file")
+			return nil, errors.Wrap(err, "cannot convert map[any]any to map[string]any")
 		}
 	default:
-		return errors.Errorf("unknown file extension in '%s' only .json, .toml and .yaml supported", fname)
+		return nil, errors.Errorf("unknown file extension in '%s' only .json, .toml and .yaml supported", fname)
+	}
+	return info, nil
+}
+
+// Validate validates info against the schema of the extension
+func (sl *MetaFile) Validate(info any) error {
+	if err := sl.compiledSchema.Validate(info); err != nil {
+		return errors.Wrap(err, "cannot validate info file")
 	}
+	return nil
+}
 
-	infoData, err = json.MarshalIndent(info, "", "  ")
+// SetInfo validates info and stores it with the next update instead of the metadata source
+func (sl *MetaFile) SetInfo(info any) error {
+	if err := sl.Validate(info); err != nil {
+		return errors.WithStack(err)
+	}
+	infoData, err := json.MarshalIndent(info, "", "  ")
 	if err != nil {
 		return errors.Wrap(err, "cannot marshal info json")
 	}
+	sl.pending = infoData
+	return nil
+}
+
+func (sl *MetaFile) UpdateObjectBefore(ctx context.Context, object ocfl.Object) error {
+	if sl.stored {
+		return nil
+	}
+	sl.stored = true
+	inventory := object.GetInventory()
+	if inventory == nil {
+		return errors.New("no inventory available")
+	}
+	if sl.fsys == nil {
+		return errors.New("no filesystem set")
+	}
+	infoData := sl.pending
+	if infoData == nil {
+		if sl.metadataSource == "" {
+			// only a problem, if first version
+			if len(inventory.GetVersionStrings()) < 2 {
+				return errors.New("no metadata source configured")
+			}
+			return nil
+		}
+		var fname = strings.Replace(sl.metadataSource, "$ID", object.GetID(), -1)
+		info, err := sl.ReadInfo(fname)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if err := sl.Validate(info); err != nil {
+			return errors.WithStack(err)
+		}
+		infoData, err = json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "cannot marshal info json")
+		}
+	}
 
 	switch strings.ToLower(sl.StorageType) {
 	case "area":
@@ -330,7 +356,7 @@ func downloadFile(u string) ([]byte, error) {
 
 var windowsPathWithDrive = regexp.MustCompile("^/[a-zA-Z]:")
 
-func (sl *MetaFile) UpdateObjectAfter(object ocfl.Object) error {
+func (sl *MetaFile) UpdateObjectAfter(ctx context.Context, object ocfl.Object) error {
 	return nil
 }
 
@@ -2,6 +2,7 @@ package extension
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha512"
 	"emperror.dev/errors"
 	"encoding/json"
@@ -169,7 +170,7 @@ func (me *Mets) WriteConfig() error {
 	return nil
 }
 
-func (me *Mets) UpdateObjectBefore(object ocfl.Object) error {
+func (me *Mets) UpdateObjectBefore(ctx context.Context, object ocfl.Object) error {
 	return nil
 }
 
@@ -221,7 +222,7 @@ type metaFileBase struct {
 
 */
 
-func (me *Mets) UpdateObjectAfter(object ocfl.Object) error {
+func (me *Mets) UpdateObjectAfter(ctx context.Context, object ocfl.Object) error {
 	inventory := object.GetInventory()
 	metadata, err := object.GetMetadata()
 	if err != nil {
@@ -3,6 +3,7 @@ package extension
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"emperror.dev/errors"
 	"encoding/json"
 	"fmt"
@@ -139,7 +140,7 @@ func (mi *Migration) WriteConfig() error {
 	return nil
 }
 
-func (mi *Migration) UpdateObjectBefore(ocfl.Object) error {
+func (mi *Migration) UpdateObjectBefore(context.Context, ocfl.Object) error {
 	return nil
 }
 
@@ -152,7 +153,7 @@ func (mi *Migration) alreadyMigrated(cs string) bool {
 	return false
 }
 
-func (mi *Migration) UpdateObjectAfter(object ocfl.Object) error {
+func (mi *Migration) UpdateObjectAfter(ctx context.Context, object ocfl.Object) error {
 	inventory := object.GetInventory()
 	if inventory == nil {
 		return errors.Errorf("inventory is nil")
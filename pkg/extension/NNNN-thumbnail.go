@@ -3,6 +3,7 @@ package extension
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"image"
@@ -328,11 +329,11 @@ func (thumb *Thumbnail) DoThumbnail(object ocfl.Object, head string, thumbFunc *
 	return targetFile, digest, errors.Wrap(err, "cannot store thumbnail")
 }
 
-func (thumb *Thumbnail) UpdateObjectBefore(ocfl.Object) error {
+func (thumb *Thumbnail) UpdateObjectBefore(context.Context, ocfl.Object) error {
 	return nil
 }
 
-func (thumb *Thumbnail) UpdateObjectAfter(object ocfl.Object) error {
+func (thumb *Thumbnail) UpdateObjectAfter(ctx context.Context, object ocfl.Object) error {
 	inventory := object.GetInventory()
 	head := inventory.GetHead()
 	thumb.buffer[head] = &bytes.Buffer{}
@@ -3,6 +3,7 @@
 package extension
 
 import (
+	"context"
 	"fmt"
 	"image"
 	"image/jpeg"
@@ -22,7 +23,7 @@ import (
 	_ "golang.org/x/image/webp"
 )
 
-func (thumb *Thumbnail) StreamObject(object ocfl.Object, reader io.Reader, stateFiles []string, dest string) error {
+func (thumb *Thumbnail) StreamObject(ctx context.Context, object ocfl.Object, reader io.Reader, stateFiles []string, dest string) error {
 	if len(stateFiles) == 0 {
 		return errors.Errorf("no state files for object '%s'", object.GetID())
 	}
@@ -163,7 +164,7 @@ func (thumb *Thumbnail) StreamObject(object ocfl.Object, reader io.Reader, state
 	return nil
 }
 
-func (thumb *Thumbnail) AddFileAfter(object ocfl.Object, sourceFS fs.FS, source []string, internalPath string, digest string, area string, isDir bool) error {
+func (thumb *Thumbnail) AddFileAfter(ctx context.Context, object ocfl.Object, sourceFS fs.FS, source []string, internalPath string, digest string, area string, isDir bool) error {
 	inventory := object.GetInventory()
 	head := inventory.GetHead()
 	if _, ok := thumb.counter[head]; !ok {
@@ -249,23 +250,23 @@ func (thumb *Thumbnail) AddFileAfter(object ocfl.Object, sourceFS fs.FS, source
 	return nil
 }
 
-func (thumb *Thumbnail) AddFileBefore(object ocfl.Object, sourceFS fs.FS, source string, dest string, area string, isDir bool) error {
+func (thumb *Thumbnail) AddFileBefore(ctx context.Context, object ocfl.Object, sourceFS fs.FS, source string, dest string, area string, isDir bool) error {
 	return nil
 }
 
-func (thumb *Thumbnail) UpdateFileBefore(object ocfl.Object, sourceFS fs.FS, source, dest, area string, isDir bool) error {
+func (thumb *Thumbnail) UpdateFileBefore(ctx context.Context, object ocfl.Object, sourceFS fs.FS, source, dest, area string, isDir bool) error {
 	return nil
 }
 
-func (thumb *Thumbnail) DeleteFileBefore(object ocfl.Object, dest string, area string) error {
+func (thumb *Thumbnail) DeleteFileBefore(ctx context.Context, object ocfl.Object, dest string, area string) error {
 	return nil
 }
 
-func (thumb *Thumbnail) UpdateFileAfter(object ocfl.Object, sourceFS fs.FS, source string, dest string, area string, isDir bool) error {
+func (thumb *Thumbnail) UpdateFileAfter(ctx context.Context, object ocfl.Object, sourceFS fs.FS, source string, dest string, area string, isDir bool) error {
 	return nil
 }
 
-func (thumb *Thumbnail) DeleteFileAfter(object ocfl.Object, dest string, area string) error {
+func (thumb *Thumbnail) DeleteFileAfter(ctx context.Context, object ocfl.Object, dest string, area string) error {
 	return nil
 }
 
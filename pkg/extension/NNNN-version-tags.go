@@ -0,0 +1,138 @@
+package extension
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+
+	"emperror.dev/errors"
+	"github.com/je4/filesystem/v3/pkg/writefs"
+	"github.com/ocfl-archive/gocfl/v2/pkg/ocfl"
+)
+
+const VersionTagsName = "NNNN-version-tags"
+const VersionTagsDescription = "labels for object versions"
+
+// VersionTagsFile is the file in the extension folder with the mapping of tags to versions
+const VersionTagsFile = "tags.json"
+
+func NewVersionTagsFS(fsys fs.FS) (*VersionTags, error) {
+	data, err := fs.ReadFile(fsys, "config.json")
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read config.json")
+	}
+
+	var config = &VersionTagsConfig{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, errors.Wrapf(err, "cannot unmarshal VersionTagsConfig '%s'", string(data))
+	}
+	return NewVersionTags(config)
+}
+
+func NewVersionTags(config *VersionTagsConfig) (*VersionTags, error) {
+	sl := &VersionTags{
+		VersionTagsConfig: config,
+	}
+	if config.ExtensionName != sl.GetName() {
+		return nil, errors.New(fmt.Sprintf("invalid extension name'%s'for extension %s", config.ExtensionName, sl.GetName()))
+	}
+	return sl, nil
+}
+
+type VersionTagsConfig struct {
+	*ocfl.ExtensionConfig
+}
+
+type VersionTags struct {
+	*VersionTagsConfig
+	fsys fs.FS
+}
+
+func (sl *VersionTags) Terminate() error {
+	return nil
+}
+
+func (sl *VersionTags) GetFS() fs.FS {
+	return sl.fsys
+}
+
+func (sl *VersionTags) GetConfig() any {
+	return sl.VersionTagsConfig
+}
+
+func (sl *VersionTags) IsRegistered() bool {
+	return false
+}
+
+func (sl *VersionTags) SetParams(params map[string]string) error {
+	return nil
+}
+
+func (sl *VersionTags) SetFS(fsys fs.FS, create bool) {
+	sl.fsys = fsys
+}
+
+func (sl *VersionTags) GetName() string { return VersionTagsName }
+
+func (sl *VersionTags) WriteConfig() error {
+	if sl.fsys == nil {
+		return errors.New("no filesystem set")
+	}
+	configWriter, err := writefs.Create(sl.fsys, "config.json")
+	if err != nil {
+		return errors.Wrap(err, "cannot open config.json")
+	}
+	defer configWriter.Close()
+	jenc := json.NewEncoder(configWriter)
+	jenc.SetIndent("", "   ")
+	if err := jenc.Encode(sl.VersionTagsConfig); err != nil {
+		return errors.Wrapf(err, "cannot encode config to file")
+	}
+
+	return nil
+}
+
+// GetVersionTags reads the tags of the object. An object without tags file has no tags
+func (sl *VersionTags) GetVersionTags(object ocfl.Object) (map[string]string, error) {
+	var tags = map[string]string{}
+	if sl.fsys == nil {
+		return tags, nil
+	}
+	data, err := fs.ReadFile(sl.fsys, VersionTagsFile)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return tags, nil
+		}
+		return nil, errors.Wrapf(err, "cannot read '%v/%s'", sl.fsys, VersionTagsFile)
+	}
+	if err := json.Unmarshal(data, &tags); err != nil {
+		return nil, errors.Wrapf(err, "cannot unmarshal '%v/%s'", sl.fsys, VersionTagsFile)
+	}
+	return tags, nil
+}
+
+// SetVersionTags replaces the tags file of the object. The config is written, if the extension is new in the object
+func (sl *VersionTags) SetVersionTags(object ocfl.Object, tags map[string]string) error {
+	if sl.fsys == nil {
+		return errors.New("no filesystem set")
+	}
+	if _, err := fs.Stat(sl.fsys, "config.json"); errors.Is(err, fs.ErrNotExist) {
+		if err := sl.WriteConfig(); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	data, err := json.MarshalIndent(tags, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "cannot marshal version tags")
+	}
+	if _, err := writefs.WriteFile(sl.fsys, VersionTagsFile, data); err != nil {
+		return errors.Wrapf(err, "cannot write '%v/%s'", sl.fsys, VersionTagsFile)
+	}
+	return nil
+}
+
+// check interface satisfaction
+var (
+	_ ocfl.Extension            = &VersionTags{}
+	_ ocfl.ExtensionVersionTags = &VersionTags{}
+)
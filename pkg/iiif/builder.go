@@ -0,0 +1,209 @@
+package iiif
+
+import (
+	"emperror.dev/errors"
+	"fmt"
+	"github.com/ocfl-archive/gocfl/v2/pkg/extension"
+	"github.com/ocfl-archive/gocfl/v2/pkg/ocfl"
+	"github.com/ocfl-archive/indexer/v3/pkg/indexer"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ContentURLFunc returns the url of the content file with the given digest and logical path
+type ContentURLFunc func(digest, logicalPath string) string
+
+// metafile entries which are not mapped to the generic metadata list
+var metafileSpecialFields = []string{"title", "description", "licenses"}
+
+// NewManifest builds a IIIF Presentation 3.0 manifest for a version of an object.
+// baseID is the prefix for all generated resource id's, the manifest itself gets the id "{baseID}/manifest.json"
+func NewManifest(meta *ocfl.ObjectMetadata, version, baseID string, contentURL ContentURLFunc) (*Manifest, error) {
+	if meta == nil {
+		return nil, errors.New("no object metadata")
+	}
+	if version == "" || version == "latest" {
+		version = meta.Head
+	}
+	versionMeta, ok := meta.Versions[version]
+	if !ok {
+		return nil, errors.Errorf("version '%s' not found in object '%s'", version, meta.ID)
+	}
+	baseID = strings.TrimRight(baseID, "/")
+
+	manifest := &Manifest{
+		Context: PresentationContext,
+		ID:      baseID + "/manifest.json",
+		Type:    "Manifest",
+		Label:   NewLanguageMap(meta.ID),
+		Items:   []*Canvas{},
+	}
+	if !versionMeta.Created.IsZero() {
+		manifest.NavDate = versionMeta.Created.UTC().Format(time.RFC3339)
+	}
+
+	manifest.Metadata = append(manifest.Metadata,
+		&MetadataEntry{Label: NewLanguageMap("id"), Value: NewLanguageMap(meta.ID)},
+		&MetadataEntry{Label: NewLanguageMap("version"), Value: NewLanguageMap(version)},
+	)
+	if versionMeta.Message != "" {
+		manifest.Metadata = append(manifest.Metadata, &MetadataEntry{Label: NewLanguageMap("message"), Value: NewLanguageMap(versionMeta.Message)})
+	}
+	if versionMeta.Name != "" {
+		manifest.RequiredStatement = &MetadataEntry{Label: NewLanguageMap("user"), Value: NewLanguageMap(versionMeta.Name)}
+	}
+
+	if info := metafileInfo(meta); info != nil {
+		if title := toStrings(info["title"]); len(title) > 0 {
+			manifest.Label = NewLanguageMap(title...)
+		}
+		if description := toStrings(info["description"]); len(description) > 0 {
+			manifest.Summary = NewLanguageMap(description...)
+		}
+		for _, license := range toStrings(info["licenses"]) {
+			if manifest.Rights == "" && (strings.HasPrefix(license, "http://") || strings.HasPrefix(license, "https://")) {
+				manifest.Rights = license
+				continue
+			}
+			manifest.Metadata = append(manifest.Metadata, &MetadataEntry{Label: NewLanguageMap("license"), Value: NewLanguageMap(license)})
+		}
+		keys := make([]string, 0, len(info))
+		for key := range info {
+			if slices.Contains(metafileSpecialFields, key) {
+				continue
+			}
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			values := toStrings(info[key])
+			if len(values) == 0 {
+				continue
+			}
+			manifest.Metadata = append(manifest.Metadata, &MetadataEntry{Label: NewLanguageMap(key), Value: NewLanguageMap(values...)})
+		}
+	}
+
+	type canvasFile struct {
+		digest      string
+		logicalPath string
+		idx         *indexer.ResultV2
+	}
+	var files = []*canvasFile{}
+	for digest, file := range meta.Files {
+		idxAny, _ := file.Extension[extension.IndexerName]
+		idx, _ := idxAny.(*indexer.ResultV2)
+		if idx == nil || mediaType(idx) == "" {
+			continue
+		}
+		for _, name := range file.VersionName[version] {
+			files = append(files, &canvasFile{digest: digest, logicalPath: name, idx: idx})
+		}
+	}
+	// canvases are ordered by logical path
+	slices.SortFunc(files, func(a, b *canvasFile) int {
+		return strings.Compare(a.logicalPath, b.logicalPath)
+	})
+
+	for num, f := range files {
+		canvasID := fmt.Sprintf("%s/canvas/%d", baseID, num+1)
+		body := &Body{
+			ID:     contentURL(f.digest, f.logicalPath),
+			Type:   mediaType(f.idx),
+			Format: f.idx.Mimetype,
+		}
+		canvas := &Canvas{
+			ID:    canvasID,
+			Type:  "Canvas",
+			Label: NewLanguageMap(path.Base(f.logicalPath)),
+			Metadata: []*MetadataEntry{
+				{Label: NewLanguageMap("path"), Value: NewLanguageMap(f.logicalPath)},
+			},
+		}
+		if f.idx.Mimetype != "" {
+			canvas.Metadata = append(canvas.Metadata, &MetadataEntry{Label: NewLanguageMap("mimetype"), Value: NewLanguageMap(f.idx.Mimetype)})
+		}
+		if f.idx.Pronom != "" {
+			canvas.Metadata = append(canvas.Metadata, &MetadataEntry{Label: NewLanguageMap("pronom"), Value: NewLanguageMap(f.idx.Pronom)})
+		}
+		if f.idx.Size > 0 {
+			canvas.Metadata = append(canvas.Metadata, &MetadataEntry{Label: NewLanguageMap("size"), Value: NewLanguageMap(fmt.Sprintf("%d", f.idx.Size))})
+		}
+		if body.Type != "Sound" {
+			body.Width, body.Height = f.idx.Width, f.idx.Height
+			canvas.Width, canvas.Height = f.idx.Width, f.idx.Height
+		}
+		if body.Type != "Image" && f.idx.Duration > 0 {
+			body.Duration = float64(f.idx.Duration)
+			canvas.Duration = float64(f.idx.Duration)
+		}
+		canvas.Items = []*AnnotationPage{
+			{
+				ID:   canvasID + "/page",
+				Type: "AnnotationPage",
+				Items: []*Annotation{
+					{
+						ID:         canvasID + "/page/annotation",
+						Type:       "Annotation",
+						Motivation: "painting",
+						Body:       body,
+						Target:     canvasID,
+					},
+				},
+			},
+		}
+		manifest.Items = append(manifest.Items, canvas)
+	}
+	return manifest, nil
+}
+
+// mediaType returns the IIIF content resource type or an empty string for unsupported content
+func mediaType(idx *indexer.ResultV2) string {
+	mimeType, _, _ := strings.Cut(strings.ToLower(idx.Mimetype), "/")
+	for _, t := range []string{strings.ToLower(idx.Type), mimeType} {
+		switch t {
+		case "image":
+			return "Image"
+		case "audio":
+			return "Sound"
+		case "video":
+			return "Video"
+		}
+	}
+	return ""
+}
+
+func metafileInfo(meta *ocfl.ObjectMetadata) map[string]any {
+	extMap, ok := meta.Extension.(map[string]any)
+	if !ok {
+		return nil
+	}
+	info, _ := extMap[extension.MetaFileName].(map[string]any)
+	return info
+}
+
+func toStrings(val any) []string {
+	switch v := val.(type) {
+	case nil:
+		return nil
+	case string:
+		if v == "" {
+			return nil
+		}
+		return []string{v}
+	case []string:
+		return v
+	case []any:
+		var result = []string{}
+		for _, e := range v {
+			result = append(result, toStrings(e)...)
+		}
+		return result
+	case map[string]any:
+		return nil
+	default:
+		return []string{fmt.Sprintf("%v", v)}
+	}
+}
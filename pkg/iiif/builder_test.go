@@ -0,0 +1,70 @@
+package iiif
+
+import (
+	"github.com/ocfl-archive/gocfl/v2/pkg/extension"
+	"github.com/ocfl-archive/gocfl/v2/pkg/ocfl"
+	"github.com/ocfl-archive/indexer/v3/pkg/indexer"
+	"testing"
+	"time"
+)
+
+func TestNewManifest(t *testing.T) {
+	meta := &ocfl.ObjectMetadata{
+		ID:   "id:test",
+		Head: "v2",
+		Versions: map[string]*ocfl.VersionMetadata{
+			"v1": {Created: time.Now(), Message: "initial commit"},
+			"v2": {Created: time.Now(), Message: "update"},
+		},
+		Files: ocfl.FilesMetadata{
+			"aaa": {
+				VersionName: map[string][]string{"v1": {"data/b.png"}, "v2": {"data/b.png"}},
+				Extension:   map[string]any{extension.IndexerName: &indexer.ResultV2{Mimetype: "image/png", Width: 100, Height: 50}},
+			},
+			"bbb": {
+				VersionName: map[string][]string{"v2": {"data/a.mp3"}},
+				Extension:   map[string]any{extension.IndexerName: &indexer.ResultV2{Mimetype: "audio/mpeg", Type: "audio", Duration: 12}},
+			},
+			"ccc": {
+				VersionName: map[string][]string{"v2": {"data/c.txt"}},
+				Extension:   map[string]any{extension.IndexerName: &indexer.ResultV2{Mimetype: "text/plain"}},
+			},
+		},
+		Extension: map[string]any{extension.MetaFileName: map[string]any{"title": "Test Object", "keywords": []any{"a", "b"}}},
+	}
+	manifest, err := NewManifest(meta, "latest", "http://localhost/iiif", func(digest, logicalPath string) string {
+		return "http://localhost/download/" + digest
+	})
+	if err != nil {
+		t.Fatalf("cannot create manifest: %v", err)
+	}
+	if manifest.ID != "http://localhost/iiif/manifest.json" {
+		t.Errorf("wrong manifest id %s", manifest.ID)
+	}
+	if manifest.Label["none"][0] != "Test Object" {
+		t.Errorf("wrong label %v", manifest.Label)
+	}
+	if len(manifest.Items) != 2 {
+		t.Fatalf("expected 2 canvases, got %d", len(manifest.Items))
+	}
+	sound := manifest.Items[0].Items[0].Items[0].Body
+	if sound.Type != "Sound" || sound.Duration != 12 || sound.ID != "http://localhost/download/bbb" {
+		t.Errorf("wrong first canvas body %v", sound)
+	}
+	image := manifest.Items[1].Items[0].Items[0].Body
+	if image.Type != "Image" || image.Width != 100 || image.Height != 50 {
+		t.Errorf("wrong second canvas body %v", image)
+	}
+
+	manifest, err = NewManifest(meta, "v1", "http://localhost/iiif", func(digest, logicalPath string) string { return digest })
+	if err != nil {
+		t.Fatalf("cannot create manifest: %v", err)
+	}
+	if len(manifest.Items) != 1 {
+		t.Errorf("expected 1 canvas in v1, got %d", len(manifest.Items))
+	}
+
+	if _, err := NewManifest(meta, "v3", "", nil); err == nil {
+		t.Errorf("missing version not detected")
+	}
+}
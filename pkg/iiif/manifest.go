@@ -0,0 +1,65 @@
+package iiif
+
+// Presentation 3.0 types
+// see https://iiif.io/api/presentation/3.0/
+
+const PresentationContext = "http://iiif.io/api/presentation/3/context.json"
+
+// LanguageMap maps a language code ("none" if unknown) to a list of values
+type LanguageMap map[string][]string
+
+func NewLanguageMap(values ...string) LanguageMap {
+	return LanguageMap{"none": values}
+}
+
+type MetadataEntry struct {
+	Label LanguageMap `json:"label"`
+	Value LanguageMap `json:"value"`
+}
+
+type Body struct {
+	ID       string  `json:"id"`
+	Type     string  `json:"type"`
+	Format   string  `json:"format,omitempty"`
+	Width    uint    `json:"width,omitempty"`
+	Height   uint    `json:"height,omitempty"`
+	Duration float64 `json:"duration,omitempty"`
+}
+
+type Annotation struct {
+	ID         string `json:"id"`
+	Type       string `json:"type"`
+	Motivation string `json:"motivation"`
+	Body       *Body  `json:"body"`
+	Target     string `json:"target"`
+}
+
+type AnnotationPage struct {
+	ID    string        `json:"id"`
+	Type  string        `json:"type"`
+	Items []*Annotation `json:"items"`
+}
+
+type Canvas struct {
+	ID       string            `json:"id"`
+	Type     string            `json:"type"`
+	Label    LanguageMap       `json:"label,omitempty"`
+	Width    uint              `json:"width,omitempty"`
+	Height   uint              `json:"height,omitempty"`
+	Duration float64           `json:"duration,omitempty"`
+	Metadata []*MetadataEntry  `json:"metadata,omitempty"`
+	Items    []*AnnotationPage `json:"items"`
+}
+
+type Manifest struct {
+	Context           string           `json:"@context"`
+	ID                string           `json:"id"`
+	Type              string           `json:"type"`
+	Label             LanguageMap      `json:"label"`
+	Summary           LanguageMap      `json:"summary,omitempty"`
+	Metadata          []*MetadataEntry `json:"metadata,omitempty"`
+	Rights            string           `json:"rights,omitempty"`
+	RequiredStatement *MetadataEntry   `json:"requiredStatement,omitempty"`
+	NavDate           string           `json:"navDate,omitempty"`
+	Items             []*Canvas        `json:"items"`
+}
@@ -1,6 +1,7 @@
 package ocfl
 
 import (
+	"context"
 	"fmt"
 	"github.com/je4/utils/v2/pkg/checksum"
 	"io"
@@ -75,23 +76,23 @@ type ExtensionArea interface {
 
 type ExtensionStream interface {
 	Extension
-	StreamObject(object Object, reader io.Reader, stateFiles []string, dest string) error
+	StreamObject(ctx context.Context, object Object, reader io.Reader, stateFiles []string, dest string) error
 }
 
 type ExtensionContentChange interface {
 	Extension
-	AddFileBefore(object Object, sourceFS fs.FS, source string, dest string, area string, isDir bool) error
-	UpdateFileBefore(object Object, sourceFS fs.FS, source, dest, area string, isDir bool) error
-	DeleteFileBefore(object Object, dest string, area string) error
-	AddFileAfter(object Object, sourceFS fs.FS, source []string, internalPath, digest, area string, isDir bool) error
-	UpdateFileAfter(object Object, sourceFS fs.FS, source, dest, area string, isDir bool) error
-	DeleteFileAfter(object Object, dest string, area string) error
+	AddFileBefore(ctx context.Context, object Object, sourceFS fs.FS, source string, dest string, area string, isDir bool) error
+	UpdateFileBefore(ctx context.Context, object Object, sourceFS fs.FS, source, dest, area string, isDir bool) error
+	DeleteFileBefore(ctx context.Context, object Object, dest string, area string) error
+	AddFileAfter(ctx context.Context, object Object, sourceFS fs.FS, source []string, internalPath, digest, area string, isDir bool) error
+	UpdateFileAfter(ctx context.Context, object Object, sourceFS fs.FS, source, dest, area string, isDir bool) error
+	DeleteFileAfter(ctx context.Context, object Object, dest string, area string) error
 }
 
 type ExtensionObjectChange interface {
 	Extension
-	UpdateObjectBefore(object Object) error
-	UpdateObjectAfter(object Object) error
+	UpdateObjectBefore(ctx context.Context, object Object) error
+	UpdateObjectAfter(ctx context.Context, object Object) error
 }
 
 type ExtensionFixityDigest interface {
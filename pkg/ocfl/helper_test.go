@@ -0,0 +1,234 @@
+package ocfl_test
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/je4/filesystem/v3/pkg/osfsrw"
+	"github.com/je4/utils/v2/pkg/checksum"
+	"github.com/je4/utils/v2/pkg/zLogger"
+	archiveerror "github.com/ocfl-archive/error/pkg/error"
+	"github.com/ocfl-archive/gocfl/v2/internal"
+	"github.com/ocfl-archive/gocfl/v2/pkg/extension"
+	"github.com/ocfl-archive/gocfl/v2/pkg/ocfl"
+	"github.com/rs/zerolog"
+)
+
+type testRoot struct {
+	t                *testing.T
+	folder           string
+	logger           zLogger.ZLogger
+	errorFactory     *archiveerror.Factory
+	extensionFactory *ocfl.ExtensionFactory
+	storageRoot      ocfl.StorageRoot
+}
+
+// newTestRoot creates a storage root with flat object folders in a temporary folder
+func newTestRoot(t *testing.T) *testRoot {
+	t.Helper()
+	l := zerolog.Nop()
+	tr := &testRoot{
+		t:            t,
+		folder:       t.TempDir(),
+		logger:       &l,
+		errorFactory: archiveerror.NewFactory("gocfl"),
+	}
+	archiveErrs, err := archiveerror.LoadTOMLFileFS(internal.InternalFS, "errors.toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.errorFactory.RegisterErrors(archiveErrs); err != nil {
+		t.Fatal(err)
+	}
+	tr.extensionFactory, err = ocfl.NewExtensionFactory(map[string]string{}, tr.logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr.extensionFactory.AddCreator(extension.InitialName, func(fsys fs.FS) (ocfl.Extension, error) {
+		return extension.NewInitialFS(fsys)
+	})
+	tr.extensionFactory.AddCreator(extension.GOCFLExtensionManagerName, func(fsys fs.FS) (ocfl.Extension, error) {
+		return extension.NewGOCFLExtensionManagerFS(fsys)
+	})
+	tr.extensionFactory.AddCreator(extension.StorageLayoutFlatDirectName, func(fsys fs.FS) (ocfl.Extension, error) {
+		return extension.NewStorageLayoutFlatDirectFS(fsys)
+	})
+	rootExtensions, err := tr.extensionFactory.LoadExtensions(fstest.MapFS{
+		extension.StorageLayoutFlatDirectName + "/config.json": &fstest.MapFile{
+			Data: []byte(`{"extensionName": "` + extension.StorageLayoutFlatDirectName + `"}`),
+		},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys, err := osfsrw.NewFS(tr.folder, false, tr.logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ocfl.CreateStorageRoot(context.Background(), fsys, ocfl.Version1_1, tr.extensionFactory, rootExtensions, checksum.DigestSHA512, tr.logger, tr.errorFactory, "ocfl"); err != nil {
+		t.Fatal(err)
+	}
+	tr.storageRoot = tr.load()
+	return tr
+}
+
+// load opens the storage root again, like a second process would do
+func (tr *testRoot) load() ocfl.StorageRoot {
+	tr.t.Helper()
+	return tr.loadContext(ocfl.NewContextValidation(context.Background()))
+}
+
+// loadContext opens the storage root with ctx, which collects the validation errors
+func (tr *testRoot) loadContext(ctx context.Context) ocfl.StorageRoot {
+	tr.t.Helper()
+	fsys, err := osfsrw.NewFS(tr.folder, false, tr.logger)
+	if err != nil {
+		tr.t.Fatal(err)
+	}
+	storageRoot, err := ocfl.LoadStorageRoot(ctx, fsys, tr.extensionFactory, tr.logger, tr.errorFactory, "ocfl")
+	if err != nil {
+		tr.t.Fatal(err)
+	}
+	storageRoot.SetLockOptions(&ocfl.LockOptions{})
+	if storageRoot.GetDigest() == "" {
+		storageRoot.SetDigest(checksum.DigestSHA512)
+	}
+	return storageRoot
+}
+
+// objectManager returns a new object extension manager without extensions
+func (tr *testRoot) objectManager() ocfl.ExtensionManager {
+	tr.t.Helper()
+	manager, err := tr.extensionFactory.LoadExtensions(fstest.MapFS{}, nil)
+	if err != nil {
+		tr.t.Fatal(err)
+	}
+	return manager
+}
+
+// update writes a new version of object id with the files of source
+func (tr *testRoot) update(id string, source fstest.MapFS) error {
+	tr.t.Helper()
+	o, err := tr.open(id)
+	if err != nil {
+		return err
+	}
+	return updateObject(o, source)
+}
+
+// updateObject writes a new version of o with the files of source
+func updateObject(o ocfl.Object, source fs.FS) error {
+	if _, err := o.StartUpdate(context.Background(), source, "test", "tester", "mailto:tester@example.org", false); err != nil {
+		return err
+	}
+	if err := o.AddFolder(context.Background(), source, nil, true, "content"); err != nil {
+		return err
+	}
+	if err := o.EndUpdate(); err != nil {
+		return err
+	}
+	return o.Close()
+}
+
+// open loads object id or creates it, if it does not exist
+func (tr *testRoot) open(id string) (ocfl.Object, error) {
+	tr.t.Helper()
+	exists, err := tr.storageRoot.ObjectExists(id)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return tr.storageRoot.LoadObjectByID(id)
+	}
+	return tr.storageRoot.CreateObject(id, tr.storageRoot.GetVersion(), tr.storageRoot.GetDigest(), nil, tr.objectManager())
+}
+
+// objectFS returns the folder of object id
+func (tr *testRoot) objectFS(id string) fs.FS {
+	tr.t.Helper()
+	fsys, err := osfsrw.NewFS(tr.objectFolder(id), false, tr.logger)
+	if err != nil {
+		tr.t.Fatal(err)
+	}
+	return fsys
+}
+
+// objectFolder returns the local folder of object id
+func (tr *testRoot) objectFolder(id string) string {
+	return filepath.Join(tr.folder, id)
+}
+
+// workspaceFS returns the workspace of object id in the storage root
+func (tr *testRoot) workspaceFS(id string) fs.FS {
+	tr.t.Helper()
+	fsys, err := osfsrw.NewFS(tr.folder, false, tr.logger)
+	if err != nil {
+		tr.t.Fatal(err)
+	}
+	workspace, err := ocfl.ObjectWorkspace(fsys, id)
+	if err != nil {
+		tr.t.Fatal(err)
+	}
+	return workspace
+}
+
+// workspaceFolder returns the local folder of the workspace of object id
+func (tr *testRoot) workspaceFolder(id string) string {
+	return filepath.Join(tr.folder, ocfl.WorkspaceFolder, id)
+}
+
+// head returns the head of object id, after the storage root was loaded again
+func (tr *testRoot) head(id string) string {
+	tr.t.Helper()
+	o, err := tr.load().LoadObjectByID(id)
+	if err != nil {
+		tr.t.Fatal(err)
+	}
+	return o.GetInventory().GetHead()
+}
+
+func testFiles(files map[string]string) fstest.MapFS {
+	var result = fstest.MapFS{}
+	for name, content := range files {
+		result[name] = &fstest.MapFile{Data: []byte(content), Mode: 0644}
+	}
+	return result
+}
+
+const hookExtensionName = "test-hook"
+
+// hookExtension fails in the object hooks, if requested
+type hookExtension struct {
+	failBefore bool
+	failAfter  bool
+	fsys       fs.FS
+}
+
+func (he *hookExtension) GetName() string                          { return hookExtensionName }
+func (he *hookExtension) SetFS(fsys fs.FS, create bool)            { he.fsys = fsys }
+func (he *hookExtension) GetFS() fs.FS                             { return he.fsys }
+func (he *hookExtension) SetParams(params map[string]string) error { return nil }
+func (he *hookExtension) WriteConfig() error                       { return nil }
+func (he *hookExtension) GetConfig() any                           { return nil }
+func (he *hookExtension) IsRegistered() bool                       { return false }
+func (he *hookExtension) Terminate() error                         { return nil }
+
+func (he *hookExtension) UpdateObjectBefore(ctx context.Context, object ocfl.Object) error {
+	if he.failBefore {
+		return errors.New("UpdateObjectBefore failed")
+	}
+	return nil
+}
+
+func (he *hookExtension) UpdateObjectAfter(ctx context.Context, object ocfl.Object) error {
+	if he.failAfter {
+		return errors.New("UpdateObjectAfter failed")
+	}
+	return nil
+}
+
+var _ ocfl.ExtensionObjectChange = &hookExtension{}
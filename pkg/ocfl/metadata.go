@@ -85,6 +85,8 @@ type VersionMetadata struct {
 	Message string
 	Name    string
 	Address string
+	// Tags are the labels of the version
+	Tags []string `json:"Tags,omitempty"`
 }
 
 type ObjectMetadata struct {
@@ -0,0 +1,184 @@
+package ocfl
+
+import (
+	"bytes"
+	"emperror.dev/errors"
+	"encoding/json"
+	"github.com/ocfl-archive/indexer/v3/pkg/indexer"
+	"slices"
+	"strings"
+)
+
+type DiffType string
+
+const (
+	DiffAdded   DiffType = "added"
+	DiffRemoved DiffType = "removed"
+	DiffChanged DiffType = "changed"
+	DiffRenamed DiffType = "renamed"
+)
+
+type FileDiff struct {
+	Type        DiffType
+	Path        string
+	OldPath     string
+	Digest      string
+	OldDigest   string
+	Size        uint64
+	OldSize     uint64
+	SizeDelta   int64
+	Mimetype    string
+	OldMimeType string
+	// Extension contains the names of the extensions with different metadata for old and new content
+	Extension []string
+}
+
+type VersionDiff struct {
+	ID        string
+	From      string
+	To        string
+	Files     []*FileDiff
+	SizeDelta int64
+}
+
+func (om *ObjectMetadata) versionState(version string) map[string]string {
+	var state = map[string]string{}
+	for digest, fm := range om.Files {
+		for _, name := range fm.VersionName[version] {
+			state[name] = digest
+		}
+	}
+	return state
+}
+
+func (om *ObjectMetadata) fileIndexer(digest string) *indexer.ResultV2 {
+	fm, ok := om.Files[digest]
+	if !ok {
+		return nil
+	}
+	idx, _ := fm.Extension["NNNN-indexer"].(*indexer.ResultV2)
+	return idx
+}
+
+// extensionDiff returns the names of extensions, which have different metadata for the two digests
+func (om *ObjectMetadata) extensionDiff(oldDigest, newDigest string) []string {
+	var result = []string{}
+	oldFM, ok1 := om.Files[oldDigest]
+	newFM, ok2 := om.Files[newDigest]
+	if !ok1 || !ok2 {
+		return result
+	}
+	var names = []string{}
+	for name := range oldFM.Extension {
+		names = append(names, name)
+	}
+	for name := range newFM.Extension {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		oldData, _ := json.Marshal(oldFM.Extension[name])
+		newData, _ := json.Marshal(newFM.Extension[name])
+		if !bytes.Equal(oldData, newData) {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+func (om *ObjectMetadata) newFileDiff(t DiffType, oldPath, oldDigest, path, digest string) *FileDiff {
+	fd := &FileDiff{
+		Type:      t,
+		Path:      path,
+		OldPath:   oldPath,
+		Digest:    digest,
+		OldDigest: oldDigest,
+		Extension: []string{},
+	}
+	if idx := om.fileIndexer(digest); idx != nil {
+		fd.Size = idx.Size
+		fd.Mimetype = idx.Mimetype
+	}
+	if idx := om.fileIndexer(oldDigest); idx != nil {
+		fd.OldSize = idx.Size
+		fd.OldMimeType = idx.Mimetype
+	}
+	fd.SizeDelta = int64(fd.Size) - int64(fd.OldSize)
+	if t == DiffChanged {
+		fd.Extension = om.extensionDiff(oldDigest, digest)
+	}
+	return fd
+}
+
+// Diff compares the state of two versions or version tags.
+// Files with the same digest, which are removed from one path and added at another path, are reported as renamed
+func (om *ObjectMetadata) Diff(from, to string) (*VersionDiff, error) {
+	fromVersion, ok := om.ResolveVersion(from)
+	if !ok {
+		return nil, errors.Errorf("version '%s' not found in object '%s'", from, om.ID)
+	}
+	toVersion, ok := om.ResolveVersion(to)
+	if !ok {
+		return nil, errors.Errorf("version '%s' not found in object '%s'", to, om.ID)
+	}
+	from, to = fromVersion, toVersion
+	result := &VersionDiff{
+		ID:    om.ID,
+		From:  from,
+		To:    to,
+		Files: []*FileDiff{},
+	}
+	oldState := om.versionState(from)
+	newState := om.versionState(to)
+
+	var removed = map[string][]string{} // digest -> paths
+	for path, oldDigest := range oldState {
+		newDigest, ok := newState[path]
+		switch {
+		case !ok:
+			removed[oldDigest] = append(removed[oldDigest], path)
+		case newDigest != oldDigest:
+			result.Files = append(result.Files, om.newFileDiff(DiffChanged, path, oldDigest, path, newDigest))
+		}
+	}
+	var added = []string{}
+	for path := range newState {
+		if _, ok := oldState[path]; !ok {
+			added = append(added, path)
+		}
+	}
+	slices.Sort(added)
+	for _, paths := range removed {
+		slices.Sort(paths)
+	}
+	for _, path := range added {
+		digest := newState[path]
+		if oldPaths := removed[digest]; len(oldPaths) > 0 {
+			result.Files = append(result.Files, om.newFileDiff(DiffRenamed, oldPaths[0], digest, path, digest))
+			removed[digest] = oldPaths[1:]
+			continue
+		}
+		result.Files = append(result.Files, om.newFileDiff(DiffAdded, "", "", path, digest))
+	}
+	for digest, paths := range removed {
+		for _, path := range paths {
+			result.Files = append(result.Files, om.newFileDiff(DiffRemoved, path, digest, "", ""))
+		}
+	}
+	slices.SortFunc(result.Files, func(a, b *FileDiff) int {
+		pa, pb := a.Path, b.Path
+		if pa == "" {
+			pa = a.OldPath
+		}
+		if pb == "" {
+			pb = b.OldPath
+		}
+		return strings.Compare(pa, pb)
+	})
+	for _, fd := range result.Files {
+		result.SizeDelta += fd.SizeDelta
+	}
+	return result, nil
+}
@@ -26,24 +26,34 @@ type Object interface {
 	StoreExtensions() error
 	Init(id string, digest checksum.DigestAlgorithm, fixity []checksum.DigestAlgorithm, manager ExtensionManager) error
 	Load() error
-	StartUpdate(sourceFS fs.FS, msg string, UserName string, UserAddress string, echo bool) (fs.FS, error)
+	StartUpdate(ctx context.Context, sourceFS fs.FS, msg string, UserName string, UserAddress string, echo bool) (fs.FS, error)
 	EndUpdate() error
+	Rollback() error
+	SetWorkers(workers int)
+	SetTrustedDigests(trusted *TrustedDigests)
+	SetIngestMode(mode IngestMode)
+	Plan(ctx context.Context, fsys fs.FS, area string, areaPaths map[string]fs.FS, checkDuplicate bool, echo bool) (*UpdatePlan, error)
 	BeginArea(area string)
 	EndArea() error
-	AddFolder(fsys fs.FS, versionFS fs.FS, checkDuplicate bool, area string) error
-	AddFile(fsys fs.FS, versionFS fs.FS, path string, checkDuplicate bool, area string, noExtensionHook bool, isDir bool) error
+	AddFolder(ctx context.Context, fsys fs.FS, versionFS fs.FS, checkDuplicate bool, area string) error
+	AddFile(ctx context.Context, fsys fs.FS, versionFS fs.FS, path string, checkDuplicate bool, area string, noExtensionHook bool, isDir bool) error
 	AddData(data []byte, path string, checkDuplicate bool, area string, noExtensionHook bool, isDir bool) error
 	AddReader(r io.ReadCloser, files []string, area string, noExtensionHook bool, isDir bool) (string, error)
+	Create(path string, area string) (io.WriteCloser, error)
 	DeleteFile(virtualFilename string, digest string) error
 	RenameFile(virtualFilenameSource, virtualFilenameDest string, digest string) error
 	GetID() string
 	GetVersion() OCFLVersion
-	Check() error
+	Check(ctx context.Context) error
 	Close() error
 	GetFS() fs.FS
 	IsModified() bool
 	Stat(w io.Writer, statInfo []StatInfo) error
-	Extract(fsys fs.FS, version string, withManifest bool, area string) error
+	Extract(ctx context.Context, fsys fs.FS, version string, withManifest bool, area string) error
+	VersionFS(version, area string) (fs.FS, error)
+	ResolveVersion(version string) (string, error)
+	GetVersionTags() (map[string]string, error)
+	UpdateVersionTags(fn func(tags map[string]string) error) error
 	GetMetadata() (*ObjectMetadata, error)
 	GetAreaPath(area string) (string, error)
 	GetExtensionManager() ExtensionManager
@@ -86,6 +96,7 @@ func GetObjectVersion(ctx context.Context, ofs fs.FS) (version OCFLVersion, err
 func newObject(
 	ctx context.Context,
 	fsys fs.FS,
+	workspace fs.FS,
 	version OCFLVersion,
 	storageRoot StorageRoot,
 	extensionManager ExtensionManager,
@@ -101,19 +112,19 @@ func newObject(
 	}
 	switch version {
 	case Version1_1:
-		o, err := newObjectV1_1(ctx, fsys, storageRoot, extensionManager, logger, errorFactory)
+		o, err := newObjectV1_1(ctx, fsys, workspace, storageRoot, extensionManager, logger, errorFactory)
 		if err != nil {
 			return nil, errors.WithStack(err)
 		}
 		return o, nil
 	case Version2_0:
-		o, err := newObjectV2_0(ctx, fsys, storageRoot, extensionManager, logger, errorFactory)
+		o, err := newObjectV2_0(ctx, fsys, workspace, storageRoot, extensionManager, logger, errorFactory)
 		if err != nil {
 			return nil, errors.WithStack(err)
 		}
 		return o, nil
 	default:
-		o, err := newObjectV1_0(ctx, fsys, storageRoot, extensionManager, logger, errorFactory)
+		o, err := newObjectV1_0(ctx, fsys, workspace, storageRoot, extensionManager, logger, errorFactory)
 		if err != nil {
 			return nil, errors.WithStack(err)
 		}
@@ -16,12 +16,13 @@ type ObjectV1_0 struct {
 func newObjectV1_0(
 	ctx context.Context,
 	fsys fs.FS,
+	workspace fs.FS,
 	storageRoot StorageRoot,
 	extensionManager ExtensionManager,
 	logger zLogger.ZLogger,
 	errorFactory *archiveerror.Factory,
 ) (*ObjectV1_0, error) {
-	ob, err := newObjectBase(ctx, fsys, Version1_0, storageRoot, extensionManager, logger, errorFactory)
+	ob, err := newObjectBase(ctx, fsys, workspace, Version1_0, storageRoot, extensionManager, logger, errorFactory)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
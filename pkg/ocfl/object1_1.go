@@ -18,12 +18,13 @@ type ObjectV1_1 struct {
 func newObjectV1_1(
 	ctx context.Context,
 	fsys fs.FS,
+	workspace fs.FS,
 	storageRoot StorageRoot,
 	extensionManager ExtensionManager,
 	logger zLogger.ZLogger,
 	errorFactory *archiveerror.Factory,
 ) (*ObjectV1_1, error) {
-	ob, err := newObjectBase(ctx, fsys, Version1_1, storageRoot, extensionManager, logger, errorFactory)
+	ob, err := newObjectBase(ctx, fsys, workspace, Version1_1, storageRoot, extensionManager, logger, errorFactory)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
@@ -18,12 +18,13 @@ type ObjectV2_0 struct {
 func newObjectV2_0(
 	ctx context.Context,
 	fsys fs.FS,
+	workspace fs.FS,
 	storageRoot StorageRoot,
 	extensionManager ExtensionManager,
 	logger zLogger.ZLogger,
 	errorFactory *archiveerror.Factory,
 ) (*ObjectV2_0, error) {
-	ob, err := newObjectBase(ctx, fsys, Version2_0, storageRoot, extensionManager, logger, errorFactory)
+	ob, err := newObjectBase(ctx, fsys, workspace, Version2_0, storageRoot, extensionManager, logger, errorFactory)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
@@ -27,7 +27,10 @@ type ObjectBase struct {
 	storageRoot        StorageRoot
 	extensionManager   ExtensionManager
 	ctx                context.Context
+	updateCtx          context.Context
+	progress           objectProgress
 	fsys               fs.FS
+	workspace          fs.FS
 	i                  Inventory
 	versionFolders     []string
 	versionInventories map[string]Inventory
@@ -39,12 +42,20 @@ type ObjectBase struct {
 	echo               bool
 	updateFiles        []string
 	area               string
+	workers            int
+	committed          bool
+	lockInfo           *LockInfo
+	trusted            *TrustedDigests
+	renames            *renameSources
+	ingestMode         IngestMode
+	links              ingestLinks
 }
 
 // newObjectBase creates an empty ObjectBase structure
 func newObjectBase(
 	ctx context.Context,
 	fsys fs.FS,
+	workspace fs.FS,
 	defaultVersion OCFLVersion,
 	storageRoot StorageRoot,
 	extensionManager ExtensionManager,
@@ -54,6 +65,7 @@ func newObjectBase(
 	ocfl := &ObjectBase{
 		ctx:              ctx,
 		fsys:             fsys,
+		workspace:        workspace,
 		version:          defaultVersion,
 		storageRoot:      storageRoot,
 		extensionManager: extensionManager,
@@ -133,6 +145,16 @@ func (object *ObjectBase) GetMetadata() (*ObjectMetadata, error) {
 		ib, _ := strconv.Atoi(b)
 		return cmp.Compare(ia, ib)
 	})
+	tags, err := object.GetVersionTags()
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot get version tags of object '%s'", object.GetID())
+	}
+	for tag, v := range tags {
+		if vm, ok := result.Versions[v]; ok {
+			vm.Tags = append(vm.Tags, tag)
+			slices.Sort(vm.Tags)
+		}
+	}
 	extensionMetadata, err := object.extensionManager.GetMetadata(object)
 	if err != nil {
 		return nil, errors.Wrapf(err, "cannot get extension metadata for object '%s'", object.GetID())
@@ -447,7 +469,7 @@ func (object *ObjectBase) StoreExtensions() error {
 	return nil
 }
 
-func (object *ObjectBase) Init(id string, digest checksum.DigestAlgorithm, fixity []checksum.DigestAlgorithm, extensionManager ExtensionManager) error {
+func (object *ObjectBase) Init(id string, digest checksum.DigestAlgorithm, fixity []checksum.DigestAlgorithm, extensionManager ExtensionManager) (err error) {
 	object.logger.Debug().Any(
 		object.errorFactory.LogError(
 			ErrorOCFL,
@@ -456,6 +478,18 @@ func (object *ObjectBase) Init(id string, digest checksum.DigestAlgorithm, fixit
 		),
 	).Msg("")
 
+	// the object stays locked until it is closed
+	if err := object.lock(); err != nil {
+		return errors.Wrapf(err, "cannot lock object '%s'", id)
+	}
+	defer func() {
+		if err != nil {
+			if err2 := object.unlock(); err2 != nil {
+				err = errors.Combine(err, err2)
+			}
+		}
+	}()
+
 	objectConformanceDeclaration := "ocfl_object_" + string(object.version)
 	objectConformanceDeclarationFile := "0=" + objectConformanceDeclaration
 
@@ -546,13 +580,21 @@ func (object *ObjectBase) echoDelete() error {
 	if basePath == "." {
 		basePath = ""
 	}
+	if err := object.echoRename(basePath); err != nil {
+		return errors.Wrap(err, "cannot detect renamed files")
+	}
 	if err := object.i.echoDelete(object.updateFiles, basePath); err != nil {
 		return errors.Wrap(err, "cannot remove deleted files from inventory")
 	}
 	return nil
 }
 
-func (object *ObjectBase) Close() error {
+func (object *ObjectBase) Close() (err error) {
+	defer func() {
+		if err2 := object.unlock(); err2 != nil {
+			err = errors.Combine(err, errors.Wrapf(err2, "cannot unlock object '%s'", object.GetID()))
+		}
+	}()
 	if !(object.i.IsWriteable()) {
 		object.logger.Info().Any(
 			object.errorFactory.LogError(
@@ -568,19 +610,29 @@ func (object *ObjectBase) Close() error {
 		return nil
 	}
 	object.storageRoot.setModified()
+	if err := object.lock(); err != nil {
+		return errors.Wrapf(err, "cannot lock object '%s'", object.GetID())
+	}
 	if err := object.i.Clean(); err != nil {
 		return errors.Wrap(err, "cannot clean inventory")
 	}
-	if err := object.StoreInventory(false, true); err != nil {
-		return errors.Wrap(err, "cannot store inventory")
+	invProgress := object.progress.get(ProgressPhaseInventory)
+	// a promoted version already replaced the root inventory
+	if !object.committed {
+		invProgress.startFile("inventory.json")
+		if err := object.StoreInventory(false, true); err != nil {
+			return errors.Wrap(err, "cannot store inventory")
+		}
+		invProgress.endFile()
 	}
 	if err := object.StoreExtensions(); err != nil {
 		return errors.Wrap(err, "cannot store extensions")
 	}
+	invProgress.done()
 	return nil
 }
 
-func (object *ObjectBase) StartUpdate(sourceFS fs.FS, msg string, UserName string, UserAddress string, echo bool) (fs.FS, error) {
+func (object *ObjectBase) StartUpdate(ctx context.Context, sourceFS fs.FS, msg string, UserName string, UserAddress string, echo bool) (fs.FS, error) {
 	object.logger.Debug().Any(
 		object.errorFactory.LogError(
 			ErrorOCFL,
@@ -588,6 +640,9 @@ func (object *ObjectBase) StartUpdate(sourceFS fs.FS, msg string, UserName strin
 			nil,
 		),
 	).Msg("")
+	if err := ctx.Err(); err != nil {
+		return nil, errors.Wrapf(err, "cannot start update of object '%s'", object.GetID())
+	}
 	object.echo = echo
 
 	subfs, err := writefs.SubFSCreate(object.fsys, "extensions")
@@ -596,11 +651,32 @@ func (object *ObjectBase) StartUpdate(sourceFS fs.FS, msg string, UserName strin
 	}
 	object.extensionManager.SetFS(subfs, true)
 
+	if err := object.lock(); err != nil {
+		return nil, errors.Wrapf(err, "cannot lock object '%s'", object.GetID())
+	}
+	if err := object.reloadAfterLock(); err != nil {
+		return nil, object.unlockOnError(errors.WithStack(err))
+	}
+	if err := object.checkStaging(); err != nil {
+		return nil, object.unlockOnError(errors.WithStack(err))
+	}
+	if err := object.saveExtensions(); err != nil {
+		return nil, object.unlockOnError(errors.WithStack(err))
+	}
 	if err := object.i.NewVersion(msg, UserName, UserAddress); err != nil {
-		return nil, errors.Wrap(err, "cannot create new object version")
+		if object.workspace != nil {
+			_ = removeAll(object.workspace, ExtensionsBackupFolder)
+		}
+		return nil, object.unlockOnError(errors.Wrap(err, "cannot create new object version"))
 	}
-	if err := object.extensionManager.UpdateObjectBefore(object); err != nil {
-		return nil, errors.Wrapf(err, "cannot execute ext.UpdateObjectBefore()")
+	object.resetIngestLinks()
+	object.updateCtx = ctx
+	object.progress = newObjectProgress(ctx, object.GetID(), ProgressPhaseHash, ProgressPhaseCopy, ProgressPhaseExtension, ProgressPhaseInventory)
+	if err := object.startStaging(); err != nil {
+		return nil, object.rollbackOnError(errors.WithStack(err))
+	}
+	if err := object.extensionManager.UpdateObjectBefore(ctx, object); err != nil {
+		return nil, object.rollbackOnError(object.rollbackOnCancel(ctx, errors.Wrapf(err, "cannot execute ext.UpdateObjectBefore()")))
 	}
 	var versionFS fs.FS
 	return versionFS, nil
@@ -634,25 +710,52 @@ func (object *ObjectBase) EndUpdate() error {
 		).Msg("")
 	}
 
+	ctx := object.updateContext()
+	object.progress.get(ProgressPhaseHash).done()
+	object.progress.get(ProgressPhaseCopy).done()
 	if object.echo {
 		if err := object.echoDelete(); err != nil {
-			return errors.Wrap(err, "cannot delete files")
+			return object.rollbackOnError(errors.Wrap(err, "cannot delete files"))
 		}
 	}
-	if err := object.extensionManager.UpdateObjectAfter(object); err != nil {
-		return errors.Wrapf(err, "cannot execute ext.UpdateObjectAfter()")
+	extProgress := object.progress.get(ProgressPhaseExtension)
+	extProgress.startFile("")
+	if err := object.extensionManager.UpdateObjectAfter(ctx, object); err != nil {
+		return object.rollbackOnError(errors.Wrapf(err, "cannot execute ext.UpdateObjectAfter()"))
+	}
+	extProgress.done()
+	// last chance to cancel the update. after the version is promoted, it is complete
+	if err := object.rollbackOnCancel(ctx, nil); err != nil {
+		return err
 	}
 
+	object.recordIngestMode()
+	head := object.i.GetHead()
 	if err := object.i.Clean(); err != nil {
-		return errors.Wrap(err, "cannot clean inventory")
+		return object.rollbackOnError(errors.Wrap(err, "cannot clean inventory"))
+	}
+	// the unchanged version was dropped, nothing to store
+	if object.i.GetHead() != head {
+		object.updateCtx = nil
+		object.discardStaging()
+		return nil
 	}
+	invProgress := object.progress.get(ProgressPhaseInventory)
+	invProgress.startFile(object.i.GetHead() + "/inventory.json")
 	if err := object.StoreInventory(true, false); err != nil {
-		return errors.Wrap(err, "cannot store inventory")
+		return object.rollbackOnError(errors.Wrap(err, "cannot store inventory"))
+	}
+	invProgress.endFile()
+	object.updateCtx = nil
+	// a partly promoted version is left for 'gocfl recover'
+	if err := object.promote(); err != nil {
+		return object.unlockOnError(errors.Wrapf(err, "cannot promote version '%s'", object.i.GetHead()))
 	}
 	if needVersion, err := object.extensionManager.NeedNewVersion(object); err != nil {
-		return errors.Wrapf(err, "cannot execute ext.NeedNewVersion()")
+		return object.unlockOnError(errors.Wrapf(err, "cannot execute ext.NeedNewVersion()"))
 	} else if needVersion {
 		if _, err := object.StartUpdate(
+			ctx,
 			nil,
 			"automated version",
 			"gocfl",
@@ -662,7 +765,7 @@ func (object *ObjectBase) EndUpdate() error {
 			return errors.Wrap(err, "cannot create new version")
 		}
 		if err := object.extensionManager.DoNewVersion(object); err != nil {
-			return errors.Wrapf(err, "cannot execute ext.DoNewVersion()")
+			return object.rollbackOnError(errors.Wrapf(err, "cannot execute ext.DoNewVersion()"))
 		}
 		if err := object.EndUpdate(); err != nil {
 			return errors.Wrap(err, "cannot end update")
@@ -687,7 +790,7 @@ func (object *ObjectBase) EndArea() error {
 	return nil
 }
 
-func (object *ObjectBase) AddFolder(fsys fs.FS, versionFS fs.FS, checkDuplicate bool, area string) error {
+func (object *ObjectBase) AddFolder(ctx context.Context, fsys fs.FS, versionFS fs.FS, checkDuplicate bool, area string) error {
 	object.logger.Debug().Any(
 		object.errorFactory.LogError(
 			ErrorOCFL,
@@ -695,36 +798,102 @@ func (object *ObjectBase) AddFolder(fsys fs.FS, versionFS fs.FS, checkDuplicate
 			nil,
 		),
 	).Msg("")
+	if err := object.checkStatePaths(fsys, area, object.echo); err != nil {
+		return errors.WithStack(err)
+	}
+	// the path policy may have renamed files while checking the state
+	defer object.policyTrustedDigests(fsys)()
+	object.renames = nil
+	if object.echo && !checkDuplicate {
+		var err error
+		if object.renames, err = object.findRenameSources(fsys, area); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if copyProgress := object.progress.get(ProgressPhaseCopy); copyProgress.enabled() {
+		var files, size, hashFiles, hashSize int64
+		if err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			var fileSize int64
+			if info, err := d.Info(); err == nil {
+				fileSize = info.Size()
+			}
+			files++
+			size += fileSize
+			// files with a trusted digest are not hashed before copying
+			if _, ok := object.knownDigest(area, filepath.ToSlash(path)); !ok {
+				hashFiles++
+				hashSize += fileSize
+			}
+			return nil
+		}); err != nil {
+			return errors.Wrap(err, "cannot count files")
+		}
+		copyProgress.addTotal(files, size)
+		// parallel workers hash while copying
+		if checkDuplicate && object.workers <= 1 {
+			object.progress.get(ProgressPhaseHash).addTotal(hashFiles, hashSize)
+		}
+	}
+	if object.workers > 1 {
+		if !object.i.IsWriteable() {
+			return errors.New("object not writeable")
+		}
+		if err := object.addFolderParallel(ctx, fsys, checkDuplicate, area); err != nil {
+			return object.rollbackOnCancel(ctx, err)
+		}
+		return object.keepSkipped(fsys, area)
+	}
 	if err := fs.WalkDir(fsys, ".", func(path string, info fs.DirEntry, err error) error {
+		if err := ctx.Err(); err != nil {
+			return errors.WithStack(err)
+		}
 		path = filepath.ToSlash(path)
-		if err := object.AddFile(fsys, versionFS, path, checkDuplicate, area, false, info.IsDir()); err != nil {
+		if err := object.addFile(ctx, fsys, versionFS, path, checkDuplicate, area, false, info.IsDir()); err != nil {
 			return errors.Wrapf(err, "cannot add file '%s'", path)
 		}
 		return nil
 	}); err != nil {
-		return errors.Wrap(err, "cannot walk filesystem")
+		return object.rollbackOnCancel(ctx, errors.Wrap(err, "cannot walk filesystem"))
 	}
 
+	return object.keepSkipped(fsys, area)
+}
+
+// keepSkipped protects the files hidden by fsys from echo deletes
+func (object *ObjectBase) keepSkipped(fsys fs.FS, area string) error {
+	if !object.echo {
+		return nil
+	}
+	paths, err := object.skippedStatePaths(fsys, area)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	object.updateFiles = append(object.updateFiles, paths...)
 	return nil
 }
 
-func (object *ObjectBase) addReader(r io.ReadCloser, versionFS fs.FS, names *NamesStruct, noExtensionHook bool) (string, error) {
+// addReader copies r to the manifest path of names. If linkSrc is not empty, r is only hashed and linkSrc is linked afterwards
+func (object *ObjectBase) addReader(ctx context.Context, r io.ReadCloser, versionFS fs.FS, names *NamesStruct, noExtensionHook bool, trusted *trustedDigest, linkSrc string) (string, error) {
 
-	digestAlgorithms := object.i.GetFixityDigestAlgorithm()
+	digestAlgorithms, extra := object.copyDigestAlgorithms(trusted)
 
 	var digest string
+	var err error
 
 	object.updateFiles = append(object.updateFiles, names.ExternalPaths...)
 
-	if !slices.Contains(digestAlgorithms, object.i.GetDigestAlgorithm()) {
-		digestAlgorithms = append(digestAlgorithms, object.i.GetDigestAlgorithm())
-	}
-
-	writer, err := writefs.Create(object.fsys, names.ManifestPath)
-	if err != nil {
-		return "", errors.Wrapf(err, "cannot create '%s'", names.ManifestPath)
+	var writer io.Writer = io.Discard
+	if linkSrc == "" {
+		fileWriter, err := writefs.Create(object.fsys, names.ManifestPath)
+		if err != nil {
+			return "", errors.Wrapf(err, "cannot create '%s'", names.ManifestPath)
+		}
+		defer fileWriter.Close()
+		writer = fileWriter
 	}
-	defer writer.Close()
 
 	var checksums map[checksum.DigestAlgorithm]string
 	if noExtensionHook {
@@ -739,7 +908,7 @@ func (object *ObjectBase) addReader(r io.ReadCloser, versionFS fs.FS, names *Nam
 		extErrors := make(chan error, 1)
 		go func() {
 			defer wg.Done()
-			if err := object.extensionManager.StreamObject(object, pr, names.ExternalPaths, names.InternalPath); err != nil {
+			if err := object.extensionManager.StreamObject(ctx, object, pr, names.ExternalPaths, names.InternalPath); err != nil {
 				extErrors <- err
 			}
 		}()
@@ -768,6 +937,10 @@ func (object *ObjectBase) addReader(r io.ReadCloser, versionFS fs.FS, names *Nam
 		}
 	}
 
+	if err := object.checkTrusted(trusted, checksums, extra); err != nil {
+		return "", errors.WithStack(err)
+	}
+
 	if digest == "" {
 		var ok bool
 		digest, ok = checksums[object.i.GetDigestAlgorithm()]
@@ -777,6 +950,12 @@ func (object *ObjectBase) addReader(r io.ReadCloser, versionFS fs.FS, names *Nam
 	} else {
 		checksums[object.i.GetDigestAlgorithm()] = digest
 	}
+
+	if linkSrc != "" {
+		if err := object.placeContent(linkSrc, names.ManifestPath, digest); err != nil {
+			return "", errors.Wrapf(err, "cannot place '%s' at '%s'", linkSrc, names.ManifestPath)
+		}
+	}
 	if err := object.i.AddFile(names.ExternalPaths, names.ManifestPath, checksums); err != nil {
 		return "", errors.Wrapf(err, "cannot append '%v'/'%s' to inventory", names.ExternalPaths, names.InternalPath)
 	}
@@ -815,6 +994,7 @@ func (object *ObjectBase) AddReader(r io.ReadCloser, files []string, area string
 	if !object.i.IsWriteable() {
 		return "", errors.New("object not writeable")
 	}
+	ctx := object.updateContext()
 	path := files[0]
 	names, err := object.BuildNames(files, area)
 
@@ -827,23 +1007,26 @@ func (object *ObjectBase) AddReader(r io.ReadCloser, files []string, area string
 	).Msg("")
 
 	if !noExtensionHook {
-		if err := object.extensionManager.AddFileBefore(object, nil, path, names.InternalPath, area, false); err != nil {
+		if err := object.extensionManager.AddFileBefore(ctx, object, nil, path, names.InternalPath, area, false); err != nil {
 			return "", errors.Wrapf(err, "error on AddFileBefore() extension hook")
 		}
 	}
 
 	var digest string
 	if !isDir {
-		digest, err = object.addReader(r, nil, names, noExtensionHook)
+		copyProgress := object.progress.get(ProgressPhaseCopy)
+		copyProgress.startFile(path)
+		digest, err = object.addReader(ctx, newContextReader(ctx, copyProgress.reader(r)), nil, names, noExtensionHook, nil, "")
 		if err != nil {
-			return "", errors.Wrapf(err, "cannot add file '%s' to object", path)
+			return "", object.rollbackOnCancel(ctx, errors.Wrapf(err, "cannot add file '%s' to object", path))
 		}
+		copyProgress.endFile()
 	} else {
 		io.Copy(io.Discard, r)
 	}
 
 	if !noExtensionHook {
-		if err := object.extensionManager.AddFileAfter(object, nil, names.ExternalPaths, names.ManifestPath, digest, area, isDir); err != nil {
+		if err := object.extensionManager.AddFileAfter(ctx, object, nil, names.ExternalPaths, names.ManifestPath, digest, area, isDir); err != nil {
 			return "", errors.Wrapf(err, "error on AddFileAfter() extension hook")
 		}
 	}
@@ -852,6 +1035,7 @@ func (object *ObjectBase) AddReader(r io.ReadCloser, files []string, area string
 }
 
 func (object *ObjectBase) AddData(data []byte, path string, checkDuplicate bool, area string, noExtensionHook bool, isDir bool) error {
+	ctx := object.updateContext()
 
 	if !object.i.IsWriteable() {
 		return errors.New("object not writeable")
@@ -928,21 +1112,25 @@ func (object *ObjectBase) AddData(data []byte, path string, checkDuplicate bool,
 	}
 
 	if !noExtensionHook {
-		if err := object.extensionManager.AddFileBefore(object, nil, path, names.InternalPath, area, false); err != nil {
+		if err := object.extensionManager.AddFileBefore(ctx, object, nil, path, names.InternalPath, area, false); err != nil {
 			return errors.Wrapf(err, "error on AddFileBefore() extension hook")
 		}
 	}
 
 	var r = io.NopCloser(dataReader)
 	if !isDir {
-		digest, err = object.addReader(r, nil, names, noExtensionHook)
+		copyProgress := object.progress.get(ProgressPhaseCopy)
+		copyProgress.startFile(path)
+		digest, err = object.addReader(ctx, r, nil, names, noExtensionHook, nil, "")
 		if err != nil {
 			return errors.Wrapf(err, "cannot add file '%s' to object", path)
 		}
+		copyProgress.addBytes(int64(len(data)))
+		copyProgress.endFile()
 	}
 
 	if !noExtensionHook {
-		if err := object.extensionManager.AddFileAfter(object, nil, names.ExternalPaths, names.ManifestPath, digest, area, isDir); err != nil {
+		if err := object.extensionManager.AddFileAfter(ctx, object, nil, names.ExternalPaths, names.ManifestPath, digest, area, isDir); err != nil {
 			return errors.Wrapf(err, "error on AddFileAfter() extension hook")
 		}
 	}
@@ -950,7 +1138,14 @@ func (object *ObjectBase) AddData(data []byte, path string, checkDuplicate bool,
 	return nil
 }
 
-func (object *ObjectBase) AddFile(fsys fs.FS, versionFS fs.FS, path string, checkDuplicate bool, area string, noExtensionHook bool, isDir bool) error {
+func (object *ObjectBase) AddFile(ctx context.Context, fsys fs.FS, versionFS fs.FS, path string, checkDuplicate bool, area string, noExtensionHook bool, isDir bool) error {
+	if err := ctx.Err(); err != nil {
+		return object.rollbackOnCancel(ctx, errors.Wrapf(err, "cannot add file '%s'", path))
+	}
+	return object.rollbackOnCancel(ctx, object.addFile(ctx, fsys, versionFS, path, checkDuplicate, area, noExtensionHook, isDir))
+}
+
+func (object *ObjectBase) addFile(ctx context.Context, fsys fs.FS, versionFS fs.FS, path string, checkDuplicate bool, area string, noExtensionHook bool, isDir bool) error {
 	object.logger.Info().Any(
 		object.errorFactory.LogError(
 			ErrorOCFL,
@@ -981,6 +1176,12 @@ func (object *ObjectBase) AddFile(fsys fs.FS, versionFS fs.FS, path string, chec
 		if err != nil {
 			return errors.Wrapf(err, "cannot open file '%v/%s'", fsys, path)
 		}
+		var size int64
+		if info, err := file.Stat(); err == nil {
+			size = info.Size()
+		}
+		hashProgress := object.progress.get(ProgressPhaseHash)
+		copyProgress := object.progress.get(ProgressPhaseCopy)
 		newPath, err := object.extensionManager.BuildObjectStatePath(object, path, area)
 		if err != nil {
 			file.Close()
@@ -989,25 +1190,34 @@ func (object *ObjectBase) AddFile(fsys fs.FS, versionFS fs.FS, path string, chec
 
 		object.updateFiles = append(object.updateFiles, newPath)
 
-		if checkDuplicate {
-			// do the checksum
-			digest, err = checksum.Checksum(file, object.i.GetDigestAlgorithm())
-			if err != nil {
-				return errors.Wrapf(err, "cannot create digest of '%s'", path)
-			}
-			// set filepointer to beginning
-			if seeker, ok := file.(io.Seeker); ok {
-				// if we have a seeker, we just seek
-				if _, err := seeker.Seek(0, 0); err != nil {
-					panic(err)
-				}
-			} else {
-				// otherwise reopen it
-				file, err = fsys.Open(path)
+		trusted := object.trustedDigest(area, path)
+		// without deduplication, new files of echo updates are hashed to find moved files
+		if checkDuplicate || object.renames.isNew(newPath) {
+			var known bool
+			if digest, known = object.knownDigest(area, path); !known {
+				// do the checksum
+				hashProgress.startFile(path)
+				digest, err = checksum.Checksum(newContextReader(ctx, hashProgress.reader(file)), object.i.GetDigestAlgorithm())
 				if err != nil {
-					return errors.Wrapf(err, "cannot open file '%v/%s'", fsys, path)
+					return errors.Wrapf(err, "cannot create digest of '%s'", path)
+				}
+				hashProgress.endFile()
+				// set filepointer to beginning
+				if seeker, ok := file.(io.Seeker); ok {
+					// if we have a seeker, we just seek
+					if _, err := seeker.Seek(0, 0); err != nil {
+						panic(err)
+					}
+				} else {
+					// otherwise reopen it
+					file, err = fsys.Open(path)
+					if err != nil {
+						return errors.Wrapf(err, "cannot open file '%v/%s'", fsys, path)
+					}
 				}
 			}
+		}
+		if checkDuplicate || object.renames.matches(newPath, digest) {
 			// if file is already there we do nothing
 			dup, err := object.i.AlreadyExists(newPath, digest)
 			if err != nil {
@@ -1021,6 +1231,7 @@ func (object *ObjectBase) AddFile(fsys fs.FS, versionFS fs.FS, path string, chec
 						nil,
 					),
 				).Msg("")
+				copyProgress.skipFile(size)
 				return nil
 			}
 			// file already ingested, but new virtual name
@@ -1035,6 +1246,7 @@ func (object *ObjectBase) AddFile(fsys fs.FS, versionFS fs.FS, path string, chec
 				if err := object.i.CopyFile(newPath, digest); err != nil {
 					return errors.Wrapf(err, "cannot append '%s' to inventory as '%s'", path, names.InternalPath)
 				}
+				copyProgress.skipFile(size)
 				return nil
 			}
 		} else {
@@ -1043,25 +1255,34 @@ func (object *ObjectBase) AddFile(fsys fs.FS, versionFS fs.FS, path string, chec
 			}
 		}
 		if !noExtensionHook {
-			if err := object.extensionManager.AddFileBefore(object, nil, path, names.InternalPath, area, isDir); err != nil {
+			if err := object.extensionManager.AddFileBefore(ctx, object, nil, path, names.InternalPath, area, isDir); err != nil {
 				return errors.Wrapf(err, "error on AddFileBefore() extension hook")
 			}
 		}
 
-		digest, err = object.addReader(file, versionFS, names, noExtensionHook)
+		linkSrc := object.linkSource(fsys, path)
+		copyProgress.startFile(path)
+		digest, err = object.addReader(ctx, newContextReader(ctx, copyProgress.reader(file)), versionFS, names, noExtensionHook, trusted, linkSrc)
 		if err != nil {
 			file.Close()
 			return errors.Wrapf(err, "cannot add file '%s' to object", path)
 		}
+		if linkSrc == "" {
+			object.countCopied()
+		}
+		copyProgress.endFile()
 		if err := file.Close(); err != nil {
 			return errors.Wrapf(err, "cannot close file '%s'", path)
 		}
 
 	}
 	if !noExtensionHook {
-		if err := object.extensionManager.AddFileAfter(object, fsys, []string{path}, targetFilename, digest, area, isDir); err != nil {
+		extProgress := object.progress.get(ProgressPhaseExtension)
+		extProgress.startFile(path)
+		if err := object.extensionManager.AddFileAfter(ctx, object, fsys, []string{path}, targetFilename, digest, area, isDir); err != nil {
 			return errors.Wrapf(err, "error on AddFileAfter() extension hook")
 		}
+		extProgress.endFile()
 	}
 
 	return nil
@@ -1166,7 +1387,7 @@ func (object *ObjectBase) checkVersionFolder(version string) error {
 	return nil
 }
 
-func (object *ObjectBase) checkFilesAndVersions() error {
+func (object *ObjectBase) checkFilesAndVersions(ctx context.Context) error {
 	// create list of version content directories
 	versionContents := map[string]string{}
 	versionStrings := object.i.GetVersionStrings()
@@ -1243,7 +1464,7 @@ func (object *ObjectBase) checkFilesAndVersions() error {
 		return errors.Wrap(err, "cannot get version inventories")
 	}
 
-	csDigestFiles, err := object.createContentManifest()
+	csDigestFiles, err := object.createContentManifest(ctx)
 	if err != nil {
 		return errors.WithStack(err)
 	}
@@ -1405,7 +1626,7 @@ func (object *ObjectBase) checkFilesAndVersions() error {
 	return nil
 }
 
-func (object *ObjectBase) Check() error {
+func (object *ObjectBase) Check(ctx context.Context) error {
 	// https://ocfl.io/1.0/spec/#object-structure
 	//object.fs
 	object.logger.Info().Any(
@@ -1426,6 +1647,9 @@ func (object *ObjectBase) Check() error {
 		return errors.Wrap(err, "cannot read object folder")
 	}
 	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return errors.Wrapf(err, "check of object '%s' cancelled", object.GetID())
+		}
 		if entry.IsDir() {
 			if !slices.Contains(allowedDirs, entry.Name()) {
 				object.addValidationError(E001, "invalid directory '%s' found", entry.Name())
@@ -1465,7 +1689,7 @@ func (object *ObjectBase) Check() error {
 		object.addValidationError(E010, "number of versions in inventory (%v) does not fit versions in filesystem (%v)", versionCounter, len(versions))
 	}
 
-	if err := object.checkFilesAndVersions(); err != nil {
+	if err := object.checkFilesAndVersions(ctx); err != nil {
 		return errors.WithStack(err)
 	}
 
@@ -1475,7 +1699,7 @@ func (object *ObjectBase) Check() error {
 }
 
 // create checksums of all content files
-func (object *ObjectBase) createContentManifest() (map[checksum.DigestAlgorithm]map[string][]string, error) {
+func (object *ObjectBase) createContentManifest(ctx context.Context) (map[checksum.DigestAlgorithm]map[string][]string, error) {
 	// get all possible digest algs
 	digestAlgorithms, err := object.getAllDigests()
 	if err != nil {
@@ -1483,6 +1707,7 @@ func (object *ObjectBase) createContentManifest() (map[checksum.DigestAlgorithm]
 	}
 
 	result := map[checksum.DigestAlgorithm]map[string][]string{}
+	validateProgress := newProgress(ctx, object.GetID(), ProgressPhaseValidate)
 	versions := object.i.GetVersionStrings()
 	for _, version := range versions {
 		if err := fs.WalkDir(
@@ -1498,10 +1723,12 @@ func (object *ObjectBase) createContentManifest() (map[checksum.DigestAlgorithm]
 					return errors.Wrapf(err, "cannot open file '%v/%s'", object.fsys, fname)
 				}
 				defer fp.Close()
-				css, err := checksum.Copy(digestAlgorithms, fp, &checksum.NullWriter{})
+				validateProgress.startFile(fname)
+				css, err := checksum.Copy(digestAlgorithms, newContextReader(ctx, validateProgress.reader(fp)), &checksum.NullWriter{})
 				if err != nil {
 					return errors.Wrapf(err, "cannot read and create checksums for file '%s'", fname)
 				}
+				validateProgress.endFile()
 				for d, cs := range css {
 					if _, ok := result[d]; !ok {
 						result[d] = map[string][]string{}
@@ -1516,6 +1743,7 @@ func (object *ObjectBase) createContentManifest() (map[checksum.DigestAlgorithm]
 			return nil, errors.Wrapf(err, "cannot walk content dir '%s'", object.i.GetContentDir())
 		}
 	}
+	validateProgress.done()
 	return result, nil
 }
 
@@ -1575,12 +1803,29 @@ func (object *ObjectBase) getAllDigests() ([]checksum.DigestAlgorithm, error) {
 	return allDigestAlgs, nil
 }
 
-func (object *ObjectBase) Extract(fsys fs.FS, version string, withManifest bool, area string) error {
+func (object *ObjectBase) Extract(ctx context.Context, fsys fs.FS, version string, withManifest bool, area string) error {
 	var manifest strings.Builder
-	var err error
+	version, err := object.ResolveVersion(version)
+	if err != nil {
+		return errors.WithStack(err)
+	}
 	var digestAlg = object.i.GetDigestAlgorithm()
+	extractProgress := newProgress(ctx, object.GetID(), ProgressPhaseExtract)
+	if extractProgress.enabled() {
+		var files int64
+		if err := object.i.IterateStateFiles(version, func(internals, externals []string, digest string) error {
+			files += int64(len(externals))
+			return nil
+		}); err != nil {
+			return errors.Wrap(err, "cannot count external files")
+		}
+		extractProgress.addTotal(files, 0)
+	}
 	if err := object.i.IterateStateFiles(version, func(internals, externals []string, digest string) error {
 		for _, external := range externals {
+			if err := ctx.Err(); err != nil {
+				return errors.Wrapf(err, "extraction of object '%s' cancelled", object.GetID())
+			}
 			external, err = object.extensionManager.BuildObjectExtractPath(object, external, area)
 			if err != nil {
 				errCause := errors.Cause(err)
@@ -1611,7 +1856,8 @@ func (object *ObjectBase) Extract(fsys fs.FS, version string, withManifest bool,
 						nil,
 					),
 				).Msg("")
-				copyDigests, err := checksum.Copy([]checksum.DigestAlgorithm{digestAlg}, src, target)
+				extractProgress.startFile(external)
+				copyDigests, err := checksum.Copy([]checksum.DigestAlgorithm{digestAlg}, newContextReader(ctx, extractProgress.reader(src)), target)
 				if err != nil {
 					return errors.Wrapf(err, "error copying '%v/%s' -> '%v/%s'", object.fsys, internal, fsys, external)
 				}
@@ -1622,6 +1868,7 @@ func (object *ObjectBase) Extract(fsys fs.FS, version string, withManifest bool,
 				if copyDigest != digest {
 					return errors.Errorf("invalid digest for '%s' - [%s] != [%s]", internal, copyDigests, digest)
 				}
+				extractProgress.endFile()
 				return nil
 			}(); err != nil {
 				return err
@@ -1645,6 +1892,7 @@ func (object *ObjectBase) Extract(fsys fs.FS, version string, withManifest bool,
 		}
 		defer fp.Close()
 	}
+	extractProgress.done()
 	object.logger.Debug().Any(
 		object.errorFactory.LogError(
 			ErrorOCFL,
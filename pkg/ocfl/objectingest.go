@@ -0,0 +1,364 @@
+package ocfl
+
+import (
+	"context"
+	"emperror.dev/errors"
+	"fmt"
+	"github.com/je4/filesystem/v3/pkg/writefs"
+	"github.com/je4/utils/v2/pkg/checksum"
+	"io"
+	"io/fs"
+	"maps"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// extensionStreamChecker is implemented by extension managers, which know whether an extension consumes file streams
+type extensionStreamChecker interface {
+	HasStreamExtensions() bool
+}
+
+// SkippedFilesFS is implemented by source filesystems, which hide files from ingest.
+// On echo updates, hidden files are not removed from the object
+type SkippedFilesFS interface {
+	SkippedFiles() ([]string, error)
+}
+
+// skippedStatePaths returns the logical paths of the files, which are hidden by fsys
+func (object *ObjectBase) skippedStatePaths(fsys fs.FS, area string) ([]string, error) {
+	skippedFS, ok := fsys.(SkippedFilesFS)
+	if !ok {
+		return []string{}, nil
+	}
+	files, err := skippedFS.SkippedFiles()
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot get skipped files of '%v'", fsys)
+	}
+	var result = []string{}
+	for _, file := range files {
+		statePath, err := object.extensionManager.BuildObjectStatePath(object, file, area)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot map external path '%s'", file)
+		}
+		result = append(result, statePath)
+	}
+	return result, nil
+}
+
+// checkStatePaths applies the case collision rule of a path policy source against the current state of the object.
+// On echo updates, files missing in the source are removed, so only collisions within the source are relevant
+func (object *ObjectBase) checkStatePaths(fsys fs.FS, area string, echo bool) error {
+	policyFS, ok := fsys.(*PathPolicyFS)
+	if !ok || echo || len(object.i.GetVersionStrings()) == 0 {
+		return nil
+	}
+	state, err := object.versionStateMap(object.i.GetHead())
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := policyFS.CheckState(func(name string) (string, error) {
+		return object.extensionManager.BuildObjectStatePath(object, name, area)
+	}, slices.Collect(maps.Keys(state))); err != nil {
+		return errors.Wrapf(err, "cannot check paths of '%v'", fsys)
+	}
+	return nil
+}
+
+type ingestResult struct {
+	checksums map[checksum.DigestAlgorithm]string
+	extra     bool
+	err       error
+}
+
+// ingestJob is a file or folder of AddFolder. Files are copied by the workers, the results are committed in walk order
+type ingestJob struct {
+	path    string
+	isDir   bool
+	names   *NamesStruct
+	newPath string
+	trusted *trustedDigest
+	// known is the trusted digest of content, which is already in the object. The file is not copied
+	known string
+	size  int64
+	// linkSrc is the local path of the file, if it is linked into the object instead of copied
+	linkSrc string
+	result  chan ingestResult
+}
+
+// SetWorkers sets the number of concurrent readers and hashers for AddFolder.
+// More than one worker needs a filesystem, which supports concurrent writes and reading back written files
+func (object *ObjectBase) SetWorkers(workers int) {
+	object.workers = workers
+}
+
+// addFolderParallel copies and hashes the files of fsys with concurrent workers.
+// Deduplication, inventory changes and all extension hooks are done in walk order, so the result is the same as with a single worker.
+// The content of deduplicated files is removed after copying. Unlike addFile, AddFileBefore is called after the content was copied
+func (object *ObjectBase) addFolderParallel(ctx context.Context, fsys fs.FS, checkDuplicate bool, area string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// the walker uses a copy of the manifest digests, because the commit loop changes the inventory
+	var existing = map[string]bool{}
+	if checkDuplicate && object.trusted != nil {
+		for digest := range object.i.GetManifest() {
+			existing[digest] = true
+		}
+	}
+
+	jobs := make(chan *ingestJob)
+	pending := make(chan *ingestJob, object.workers*2)
+	var wg sync.WaitGroup
+	for i := 0; i < object.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				job.result <- object.ingestCopy(ctx, fsys, job)
+			}
+		}()
+	}
+
+	walkErr := make(chan error, 1)
+	go func() {
+		defer close(pending)
+		defer close(jobs)
+		walkErr <- fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if err := ctx.Err(); err != nil {
+				return errors.WithStack(err)
+			}
+			job, err := object.newIngestJob(fsys, filepath.ToSlash(path), d, area, existing)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			pending <- job
+			if !job.isDir && job.known == "" {
+				jobs <- job
+			}
+			return nil
+		})
+	}()
+
+	var errs = []error{}
+	for job := range pending {
+		if len(errs) > 0 {
+			// drain the queue
+			continue
+		}
+		if err := object.ingestCommit(ctx, fsys, job, checkDuplicate, area); err != nil {
+			errs = append(errs, errors.Wrapf(err, "cannot add file '%s'", job.path))
+			cancel()
+		}
+	}
+	wg.Wait()
+	if err := <-walkErr; err != nil && len(errs) == 0 {
+		errs = append(errs, errors.Wrap(err, "cannot walk filesystem"))
+	}
+	return errors.Combine(errs...)
+}
+
+// newIngestJob prepares the ingest of path. Files with a trusted digest of existing content are not queued for copying
+func (object *ObjectBase) newIngestJob(fsys fs.FS, path string, d fs.DirEntry, area string, existing map[string]bool) (*ingestJob, error) {
+	names, err := object.BuildNames([]string{path}, area)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot create virtual filename for '%s'", path)
+	}
+	job := &ingestJob{
+		path:   path,
+		isDir:  d.IsDir(),
+		names:  names,
+		result: make(chan ingestResult, 1),
+	}
+	if job.isDir {
+		return job, nil
+	}
+	if job.newPath, err = object.extensionManager.BuildObjectStatePath(object, path, area); err != nil {
+		return nil, errors.Wrapf(err, "cannot map external path '%s'", path)
+	}
+	job.trusted = object.trustedDigest(area, path)
+	if digest, ok := object.knownDigest(area, path); ok && existing[digest] {
+		job.known = digest
+		if info, err := d.Info(); err == nil {
+			job.size = info.Size()
+		}
+		return job, nil
+	}
+	job.linkSrc = object.linkSource(fsys, path)
+	return job, nil
+}
+
+// ingestCopy copies the file into the object and creates all digests.
+// Files, which are linked into the object, are only hashed
+func (object *ObjectBase) ingestCopy(ctx context.Context, fsys fs.FS, job *ingestJob) ingestResult {
+	if err := ctx.Err(); err != nil {
+		return ingestResult{err: errors.WithStack(err)}
+	}
+	digestAlgorithms, extra := object.copyDigestAlgorithms(job.trusted)
+	file, err := fsys.Open(job.path)
+	if err != nil {
+		return ingestResult{err: errors.Wrapf(err, "cannot open file '%v/%s'", fsys, job.path)}
+	}
+	defer file.Close()
+	copyProgress := object.progress.get(ProgressPhaseCopy)
+	copyProgress.startFile(job.path)
+	var checksums map[checksum.DigestAlgorithm]string
+	if job.linkSrc != "" {
+		checksums, err = checksum.Copy(digestAlgorithms, newContextReader(ctx, copyProgress.reader(file)), io.Discard)
+	} else {
+		writer, createErr := writefs.Create(object.fsys, job.names.ManifestPath)
+		if createErr != nil {
+			return ingestResult{err: errors.Wrapf(createErr, "cannot create '%s'", job.names.ManifestPath)}
+		}
+		checksums, err = checksum.Copy(digestAlgorithms, newContextReader(ctx, copyProgress.reader(file)), writer)
+		if err2 := writer.Close(); err2 != nil && err == nil {
+			err = errors.Wrapf(err2, "cannot close '%s'", job.names.ManifestPath)
+		}
+	}
+	if err != nil {
+		return ingestResult{err: errors.Wrapf(err, "cannot copy '%s' -> '%s'", job.path, job.names.ManifestPath)}
+	}
+	copyProgress.endFile()
+	return ingestResult{checksums: checksums, extra: extra}
+}
+
+// ingestCommit adds the copied file to the inventory and calls the extension hooks
+func (object *ObjectBase) ingestCommit(ctx context.Context, fsys fs.FS, job *ingestJob, checkDuplicate bool, area string) error {
+	object.logger.Info().Any(
+		object.errorFactory.LogError(
+			ErrorOCFL,
+			fmt.Sprintf("adding file %s:%s", area, job.path),
+			nil,
+		),
+	).Msg("")
+	extProgress := object.progress.get(ProgressPhaseExtension)
+	if job.isDir {
+		extProgress.startFile(job.path)
+		if err := object.extensionManager.AddFileAfter(ctx, object, fsys, []string{job.path}, object.i.BuildManifestName(job.names.InternalPath), "", area, true); err != nil {
+			return errors.Wrapf(err, "error on AddFileAfter() extension hook")
+		}
+		extProgress.endFile()
+		return nil
+	}
+	if job.known != "" {
+		object.updateFiles = append(object.updateFiles, job.newPath)
+		object.updateFiles = append(object.updateFiles, job.names.ExternalPaths...)
+		deduplicated, err := object.deduplicate(job.newPath, "", job.known)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if !deduplicated {
+			return errors.Errorf("content of '%s' [%s] not found in object", job.path, job.known)
+		}
+		object.progress.get(ProgressPhaseCopy).skipFile(job.size)
+		return nil
+	}
+	result := <-job.result
+	if result.err != nil {
+		return errors.WithStack(result.err)
+	}
+	if err := object.checkTrusted(job.trusted, result.checksums, result.extra); err != nil {
+		return errors.WithStack(err)
+	}
+	digest, ok := result.checksums[object.i.GetDigestAlgorithm()]
+	if !ok {
+		return errors.Errorf("digest '%s' not generated", object.i.GetDigestAlgorithm())
+	}
+	object.updateFiles = append(object.updateFiles, job.newPath)
+	object.updateFiles = append(object.updateFiles, job.names.ExternalPaths...)
+
+	if checkDuplicate || object.renames.matches(job.newPath, digest) {
+		// linked files are not placed yet
+		manifestPath := job.names.ManifestPath
+		if job.linkSrc != "" {
+			manifestPath = ""
+		}
+		if deduplicated, err := object.deduplicate(job.newPath, manifestPath, digest); err != nil {
+			return errors.WithStack(err)
+		} else if deduplicated {
+			return nil
+		}
+	}
+	// like addFile, the hook is only called for content, which is added to the object
+	if err := object.extensionManager.AddFileBefore(ctx, object, nil, job.path, job.names.InternalPath, area, false); err != nil {
+		return errors.Wrapf(err, "error on AddFileBefore() extension hook")
+	}
+	if job.linkSrc != "" {
+		if err := object.placeContent(job.linkSrc, job.names.ManifestPath, digest); err != nil {
+			return errors.Wrapf(err, "cannot place '%s' at '%s'", job.linkSrc, job.names.ManifestPath)
+		}
+	} else {
+		object.countCopied()
+	}
+	if err := object.i.AddFile(job.names.ExternalPaths, job.names.ManifestPath, result.checksums); err != nil {
+		return errors.Wrapf(err, "cannot append '%v'/'%s' to inventory", job.names.ExternalPaths, job.names.InternalPath)
+	}
+
+	extProgress.startFile(job.path)
+	if checker, ok := object.extensionManager.(extensionStreamChecker); !ok || checker.HasStreamExtensions() {
+		// stream extensions get the content in walk order from the copy in the object
+		fp, err := object.fsys.Open(job.names.ManifestPath)
+		if err != nil {
+			return errors.Wrapf(err, "cannot open '%s'", job.names.ManifestPath)
+		}
+		err = object.extensionManager.StreamObject(ctx, object, newContextReader(ctx, fp), job.names.ExternalPaths, job.names.InternalPath)
+		fp.Close()
+		if err != nil {
+			return errors.Wrapf(err, "error on StreamObject() extension hook for object '%s'", object.GetID())
+		}
+	}
+	if err := object.extensionManager.AddFileAfter(ctx, object, fsys, []string{job.path}, object.i.BuildManifestName(job.names.InternalPath), digest, area, false); err != nil {
+		return errors.Wrapf(err, "error on AddFileAfter() extension hook")
+	}
+	extProgress.endFile()
+	return nil
+}
+
+// deduplicate references existing content with the same digest and removes the file at manifestPath.
+// If the file cannot be removed, it is kept as additional copy of the content. An empty manifestPath has nothing to remove
+func (object *ObjectBase) deduplicate(newPath, manifestPath, digest string) (bool, error) {
+	dup, err := object.i.AlreadyExists(newPath, digest)
+	if err != nil {
+		return false, errors.Wrapf(err, "cannot check duplicate for '%s' [%s]", newPath, digest)
+	}
+	dups := object.i.GetDuplicates(digest)
+	if !dup && len(dups) == 0 {
+		return false, nil
+	}
+	if manifestPath != "" {
+		if err := writefs.Remove(object.fsys, manifestPath); err != nil {
+			object.logger.Warn().Any(
+				object.errorFactory.LogError(
+					ErrorOCFL,
+					fmt.Sprintf("[%s] cannot remove duplicate '%s'. keeping copy", object.GetID(), manifestPath),
+					err,
+				),
+			).Msg("")
+			return false, nil
+		}
+	}
+	if dup {
+		object.logger.Info().Any(
+			object.errorFactory.LogError(
+				ErrorOCFL,
+				fmt.Sprintf("[%s] '%s' already exists. ignoring", object.GetID(), newPath),
+				nil,
+			),
+		).Msg("")
+		return true, nil
+	}
+	object.logger.Info().Any(
+		object.errorFactory.LogError(
+			ErrorOCFL,
+			fmt.Sprintf("[%s] file with same content as '%s' already exists. creating virtual copy", object.GetID(), newPath),
+			nil,
+		),
+	).Msg("")
+	if err := object.i.CopyFile(newPath, digest); err != nil {
+		return false, errors.Wrapf(err, "cannot append '%s' to inventory", newPath)
+	}
+	return true, nil
+}
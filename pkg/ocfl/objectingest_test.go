@@ -0,0 +1,201 @@
+package ocfl_test
+
+import (
+	"context"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/je4/utils/v2/pkg/checksum"
+	"github.com/ocfl-archive/gocfl/v2/pkg/ocfl"
+	"github.com/ocfl-archive/gocfl/v2/pkg/subsystem/sourcefs"
+)
+
+// recordExtension records the calls of the content hooks
+type recordExtension struct {
+	hookExtension
+	sync.Mutex
+	calls []string
+}
+
+func (re *recordExtension) GetName() string { return "test-record" }
+
+func (re *recordExtension) record(call string) error {
+	re.Lock()
+	defer re.Unlock()
+	re.calls = append(re.calls, call)
+	return nil
+}
+
+func (re *recordExtension) AddFileBefore(ctx context.Context, object ocfl.Object, sourceFS fs.FS, source string, dest string, area string, isDir bool) error {
+	return re.record(fmt.Sprintf("before %s", source))
+}
+
+func (re *recordExtension) UpdateFileBefore(ctx context.Context, object ocfl.Object, sourceFS fs.FS, source, dest, area string, isDir bool) error {
+	return nil
+}
+
+func (re *recordExtension) DeleteFileBefore(ctx context.Context, object ocfl.Object, dest string, area string) error {
+	return nil
+}
+
+func (re *recordExtension) AddFileAfter(ctx context.Context, object ocfl.Object, sourceFS fs.FS, source []string, internalPath, digest, area string, isDir bool) error {
+	if isDir {
+		return nil
+	}
+	return re.record(fmt.Sprintf("after %s", source[0]))
+}
+
+func (re *recordExtension) UpdateFileAfter(ctx context.Context, object ocfl.Object, sourceFS fs.FS, source, dest, area string, isDir bool) error {
+	return nil
+}
+
+func (re *recordExtension) DeleteFileAfter(ctx context.Context, object ocfl.Object, dest string, area string) error {
+	return nil
+}
+
+var _ ocfl.ExtensionContentChange = &recordExtension{}
+
+// TestIngestHookOrder checks, that parallel ingest calls the content hooks like a single worker
+func TestIngestHookOrder(t *testing.T) {
+	source := testFiles(map[string]string{
+		"a.txt":     "same",
+		"b.txt":     "same",
+		"c.txt":     "other",
+		"d/e.txt":   "other",
+		"d/f.txt":   "unique f",
+		"d/g/h.txt": "unique h",
+	})
+	expected := []string{
+		"before a.txt", "after a.txt",
+		"before c.txt", "after c.txt",
+		"before d/f.txt", "after d/f.txt",
+		"before d/g/h.txt", "after d/g/h.txt",
+	}
+	for _, workers := range []int{1, 4} {
+		t.Run(fmt.Sprintf("%d workers", workers), func(t *testing.T) {
+			tr := newTestRoot(t)
+			o, err := tr.open("a")
+			if err != nil {
+				t.Fatal(err)
+			}
+			o.SetWorkers(workers)
+			ext := &recordExtension{}
+			if err := o.GetExtensionManager().Add(ext); err != nil {
+				t.Fatal(err)
+			}
+			if err := updateObject(o, source); err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(ext.calls, expected) {
+				t.Fatalf("expected hooks %v, got %v", expected, ext.calls)
+			}
+		})
+	}
+}
+
+// TestIngestKnownDigest checks, that files with a trusted digest of existing content are not copied
+func TestIngestKnownDigest(t *testing.T) {
+	sum := sha512.Sum512([]byte("same"))
+	for _, workers := range []int{1, 4} {
+		t.Run(fmt.Sprintf("%d workers", workers), func(t *testing.T) {
+			tr := newTestRoot(t)
+			if err := tr.update("a", testFiles(map[string]string{"a.txt": "same"})); err != nil {
+				t.Fatal(err)
+			}
+			o, err := tr.open("a")
+			if err != nil {
+				t.Fatal(err)
+			}
+			o.SetWorkers(workers)
+			// the content does not match the trusted digest, so a copy would fail the verification
+			o.SetTrustedDigests(&ocfl.TrustedDigests{
+				Algorithm: checksum.DigestSHA512,
+				Area:      "content",
+				Digests:   map[string]string{"a.txt": hex.EncodeToString(sum[:]), "b.txt": hex.EncodeToString(sum[:])},
+			})
+			if err := updateObject(o, testFiles(map[string]string{"a.txt": "changed", "b.txt": "changed", "c.txt": "new"})); err != nil {
+				t.Fatal(err)
+			}
+			if head := tr.head("a"); head != "v2" {
+				t.Fatalf("expected head v2, got %s", head)
+			}
+			if _, err := fs.Stat(tr.objectFS("a"), "v2/content/b.txt"); err == nil {
+				t.Fatal("content of b.txt copied")
+			}
+			if _, err := fs.Stat(tr.objectFS("a"), "v2/content/c.txt"); err != nil {
+				t.Fatalf("content of c.txt not copied: %v", err)
+			}
+		})
+	}
+}
+
+// TestIngestHTTPSource checks, that the files of a url manifest are downloaded once with deduplication,
+// if the digests of the manifest are trusted
+func TestIngestHTTPSource(t *testing.T) {
+	files := map[string]string{"a.txt": "same", "b.txt": "new b", "c.txt": "new c"}
+	var manifest strings.Builder
+	for name, content := range files {
+		sum := sha512.Sum512([]byte(content))
+		fmt.Fprintf(&manifest, "sha512:%x /files/%s %s\n", sum, name, name)
+	}
+	var lock sync.Mutex
+	var downloads = map[string]int{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/manifest.txt", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, manifest.String())
+	})
+	mux.HandleFunc("/files/", func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/files/")
+		lock.Lock()
+		downloads[name]++
+		lock.Unlock()
+		io.WriteString(w, files[name])
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	for _, workers := range []int{1, 4} {
+		t.Run(fmt.Sprintf("%d workers", workers), func(t *testing.T) {
+			tr := newTestRoot(t)
+			if err := tr.update("a", testFiles(map[string]string{"a.txt": "same"})); err != nil {
+				t.Fatal(err)
+			}
+			hfs, err := sourcefs.OpenHTTPManifest(context.Background(), server.Client(), server.URL+"/manifest.txt")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer hfs.Close()
+			algorithm, digests := hfs.Digests(checksum.DigestSHA512)
+			o, err := tr.open("a")
+			if err != nil {
+				t.Fatal(err)
+			}
+			o.SetWorkers(workers)
+			o.SetTrustedDigests(&ocfl.TrustedDigests{Algorithm: algorithm, Area: "content", Digests: digests})
+			lock.Lock()
+			clear(downloads)
+			lock.Unlock()
+			if err := updateObject(o, hfs); err != nil {
+				t.Fatal(err)
+			}
+			// a.txt is known content of the object and is not downloaded at all
+			expected := map[string]int{"b.txt": 1, "c.txt": 1}
+			if !maps.Equal(downloads, expected) {
+				t.Fatalf("expected downloads %v, got %v", expected, downloads)
+			}
+			if head := tr.head("a"); head != "v2" {
+				t.Fatalf("expected head v2, got %s", head)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package ocfl
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"emperror.dev/errors"
+	"github.com/je4/filesystem/v3/pkg/writefs"
+	"github.com/je4/utils/v2/pkg/checksum"
+)
+
+// IngestMode is the way, content of local source files is placed into the content folder of a new version
+type IngestMode string
+
+const (
+	IngestModeCopy     IngestMode = "copy"
+	IngestModeReflink  IngestMode = "reflink"
+	IngestModeHardlink IngestMode = "hardlink"
+)
+
+var ingestModes = []IngestMode{IngestModeCopy, IngestModeReflink, IngestModeHardlink}
+
+// ParseIngestMode returns the ingest mode of its name. An empty name is copy
+func ParseIngestMode(mode string) (IngestMode, error) {
+	if mode == "" {
+		return IngestModeCopy, nil
+	}
+	m := IngestMode(strings.ToLower(mode))
+	if !slices.Contains(ingestModes, m) {
+		return "", errors.Errorf("unknown ingest mode '%s' (%s|%s|%s)", mode, IngestModeCopy, IngestModeReflink, IngestModeHardlink)
+	}
+	return m, nil
+}
+
+// ingestLinks counts the linked and copied files of an update with ingest mode reflink or hardlink
+type ingestLinks struct {
+	sync.Mutex
+	linked int64
+	copied int64
+	// failed is set after the first link failed. the remaining files are copied
+	failed bool
+}
+
+// SetIngestMode sets the way, files of local sources are placed into the object.
+// With reflink or hardlink the files are hashed and linked afterwards, if the source and the object are on the same local filesystem.
+// Otherwise, they are copied
+func (object *ObjectBase) SetIngestMode(mode IngestMode) {
+	object.ingestMode = mode
+}
+
+// linking returns true, if files are linked instead of copied
+func (object *ObjectBase) linking() bool {
+	return object.ingestMode == IngestModeReflink || object.ingestMode == IngestModeHardlink
+}
+
+// resetIngestLinks starts counting the linked and copied files of a new version
+func (object *ObjectBase) resetIngestLinks() {
+	object.links.Lock()
+	defer object.links.Unlock()
+	object.links.linked, object.links.copied, object.links.failed = 0, 0, false
+}
+
+// linkSource returns the local path of the source file path in fsys, if it can be linked into the object.
+// An empty string is returned, if the file has to be copied
+func (object *ObjectBase) linkSource(fsys fs.FS, path string) string {
+	if !object.linking() {
+		return ""
+	}
+	object.links.Lock()
+	failed := object.links.failed
+	object.links.Unlock()
+	if failed {
+		return ""
+	}
+	folder, ok := localPath(object.fsys)
+	if !ok {
+		return ""
+	}
+	fullpath, err := writefs.Fullpath(fsys, path)
+	if err != nil {
+		return ""
+	}
+	src := filepath.FromSlash(fullpath)
+	if !filepath.IsAbs(src) || !sameDevice(src, folder) {
+		return ""
+	}
+	return src
+}
+
+// countCopied counts a file of a local source, which was copied instead of linked
+func (object *ObjectBase) countCopied() {
+	if !object.linking() {
+		return
+	}
+	object.links.Lock()
+	defer object.links.Unlock()
+	object.links.copied++
+}
+
+// placeContent links the hashed source file src to manifestPath.
+// If the link fails, the file is copied and linking is disabled for the rest of the update.
+// The placed file is hashed again, because the source may have changed after it was hashed
+func (object *ObjectBase) placeContent(src, manifestPath, digest string) error {
+	fullpath, err := writefs.Fullpath(object.fsys, manifestPath)
+	if err != nil {
+		return errors.Wrapf(err, "cannot get path of '%s'", manifestPath)
+	}
+	dst := filepath.FromSlash(fullpath)
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return errors.Wrapf(err, "cannot create folder of '%s'", dst)
+	}
+	var linkErr error
+	switch object.ingestMode {
+	case IngestModeReflink:
+		linkErr = reflink(src, dst)
+	case IngestModeHardlink:
+		linkErr = os.Link(src, dst)
+	default:
+		linkErr = errors.Errorf("unknown ingest mode '%s'", object.ingestMode)
+	}
+	object.links.Lock()
+	if linkErr == nil {
+		object.links.linked++
+		object.links.Unlock()
+		return errors.WithStack(object.verifyContent(manifestPath, digest))
+	}
+	failed := object.links.failed
+	object.links.failed = true
+	object.links.copied++
+	object.links.Unlock()
+	if !failed {
+		object.logger.Warn().Any(
+			object.errorFactory.LogError(
+				ErrorOCFL,
+				fmt.Sprintf("cannot %s '%s' -> '%s', copying files instead", object.ingestMode, src, dst),
+				linkErr,
+			),
+		).Msg("")
+	}
+	if err := copyContent(object.fsys, src, manifestPath); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(object.verifyContent(manifestPath, digest))
+}
+
+// verifyContent compares the digest of the file at manifestPath with the digest of the hashed source
+func (object *ObjectBase) verifyContent(manifestPath, digest string) error {
+	fp, err := object.fsys.Open(manifestPath)
+	if err != nil {
+		return errors.Wrapf(err, "cannot open '%s'", manifestPath)
+	}
+	defer fp.Close()
+	placed, err := checksum.Checksum(fp, object.i.GetDigestAlgorithm())
+	if err != nil {
+		return errors.Wrapf(err, "cannot create digest of '%s'", manifestPath)
+	}
+	if !strings.EqualFold(placed, digest) {
+		return errors.Wrapf(ErrDigestMismatch, "source of '%s' changed while ingesting, %s is %s, expected %s", manifestPath, object.i.GetDigestAlgorithm(), placed, digest)
+	}
+	return nil
+}
+
+// copyContent copies the local file src to manifestPath of fsys
+func copyContent(fsys fs.FS, src, manifestPath string) error {
+	fp, err := os.Open(src)
+	if err != nil {
+		return errors.Wrapf(err, "cannot open '%s'", src)
+	}
+	defer fp.Close()
+	writer, err := writefs.Create(fsys, manifestPath)
+	if err != nil {
+		return errors.Wrapf(err, "cannot create '%s'", manifestPath)
+	}
+	if _, err := io.Copy(writer, fp); err != nil {
+		writer.Close()
+		return errors.Wrapf(err, "cannot copy '%s' -> '%s'", src, manifestPath)
+	}
+	if err := writer.Close(); err != nil {
+		return errors.Wrapf(err, "cannot close '%s'", manifestPath)
+	}
+	return nil
+}
+
+// recordIngestMode appends the ingest mode and the number of linked and copied files to the message of the new version
+func (object *ObjectBase) recordIngestMode() {
+	if !object.linking() {
+		return
+	}
+	object.links.Lock()
+	linked, copied := object.links.linked, object.links.copied
+	object.links.Unlock()
+	if linked+copied == 0 {
+		return
+	}
+	version, ok := object.i.GetVersions()[object.i.GetHead()]
+	if !ok || version == nil {
+		return
+	}
+	var message string
+	if version.Message != nil {
+		message = version.Message.String()
+	}
+	version.Message = NewOCFLString(strings.TrimSpace(fmt.Sprintf("%s [ingest mode %s: %d linked, %d copied]", message, object.ingestMode, linked, copied)))
+}
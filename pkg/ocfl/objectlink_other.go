@@ -0,0 +1,8 @@
+//go:build !unix
+
+package ocfl
+
+// sameDevice cannot check the filesystem on this platform, files are always copied
+func sameDevice(a, b string) bool {
+	return false
+}
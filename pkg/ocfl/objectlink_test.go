@@ -0,0 +1,74 @@
+package ocfl_test
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/je4/filesystem/v3/pkg/osfsrw"
+	"github.com/ocfl-archive/gocfl/v2/pkg/ocfl"
+)
+
+// changeSourceExtension changes the source file after it was hashed, before it is placed into the object
+type changeSourceExtension struct {
+	recordExtension
+	folder string
+}
+
+func (ce *changeSourceExtension) AddFileBefore(ctx context.Context, object ocfl.Object, sourceFS fs.FS, source string, dest string, area string, isDir bool) error {
+	return os.WriteFile(filepath.Join(ce.folder, filepath.FromSlash(source)), []byte("changed"), 0644)
+}
+
+func TestPlaceContentVerify(t *testing.T) {
+	tests := []struct {
+		name   string
+		mode   ocfl.IngestMode
+		change bool
+	}{
+		{name: "hardlink", mode: ocfl.IngestModeHardlink},
+		{name: "reflink or copy", mode: ocfl.IngestModeReflink},
+		{name: "hardlink changed source", mode: ocfl.IngestModeHardlink, change: true},
+		{name: "reflink or copy changed source", mode: ocfl.IngestModeReflink, change: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tr := newTestRoot(t)
+			folder := filepath.Join(tr.folder, "source")
+			if err := os.MkdirAll(folder, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(folder, "a.txt"), []byte("original"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			source, err := osfsrw.NewFS(folder, false, tr.logger)
+			if err != nil {
+				t.Fatal(err)
+			}
+			o, err := tr.open("a")
+			if err != nil {
+				t.Fatal(err)
+			}
+			// with parallel workers, AddFileBefore is called between hashing and placing
+			o.SetWorkers(2)
+			o.SetIngestMode(test.mode)
+			if test.change {
+				if err := o.GetExtensionManager().Add(&changeSourceExtension{folder: folder}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			err = updateObject(o, source)
+			if !test.change {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if !errors.Is(err, ocfl.ErrDigestMismatch) {
+				t.Fatalf("expected digest mismatch, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+//go:build unix
+
+package ocfl
+
+import (
+	"os"
+	"syscall"
+)
+
+// sameDevice checks, whether the files or folders a and b are on the same filesystem
+func sameDevice(a, b string) bool {
+	fiA, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	fiB, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	statA, okA := fiA.Sys().(*syscall.Stat_t)
+	statB, okB := fiB.Sys().(*syscall.Stat_t)
+	return okA && okB && statA.Dev == statB.Dev
+}
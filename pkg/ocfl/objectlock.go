@@ -0,0 +1,330 @@
+package ocfl
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/je4/filesystem/v3/pkg/writefs"
+)
+
+// LockFile in the object workspace marks an object, which is written by a process
+const LockFile = "lock"
+
+// takeoverFile in the object workspace is created exclusively by the process, which removes a stale lock
+const takeoverFile = LockFile + ".takeover"
+
+var ErrLocked = errors.New("object is locked by another writer, use 'gocfl unlock' to remove stale locks")
+
+// lockSettle is the time between writing a lock file on a remote filesystem and checking, whether it was overwritten
+const lockSettle = time.Second
+
+// LockOptions controls the advisory object locks.
+// Objects in local folders are locked with an exclusively created lock file.
+// Other filesystems (e.g. S3) only get a lock file, if Remote is true. These locks are best effort,
+// because the lock file cannot be created atomically
+type LockOptions struct {
+	// Wait for a lock held by another writer instead of failing
+	Wait bool
+	// Timeout is the maximum time to wait for a lock. 0 waits forever
+	Timeout time.Duration
+	// Retry is the interval between two attempts to get a lock
+	Retry time.Duration
+	// Remote enables lock files on filesystems, which are not local folders
+	Remote bool
+}
+
+var DefaultLockOptions = LockOptions{
+	Wait:  false,
+	Retry: time.Second,
+}
+
+// LockInfo is the content of the lock file
+type LockInfo struct {
+	Token   string    `json:"token"`
+	Host    string    `json:"host"`
+	PID     int       `json:"pid"`
+	Created time.Time `json:"created"`
+}
+
+func (li *LockInfo) String() string {
+	return fmt.Sprintf("host %s, pid %d, since %s", li.Host, li.PID, li.Created.Format(time.RFC3339))
+}
+
+// Stale returns true, if the lock was created on this host by a process, which is not running anymore
+func (li *LockInfo) Stale() bool {
+	hostname, err := os.Hostname()
+	if err != nil || hostname != li.Host {
+		return false
+	}
+	alive, known := processAlive(li.PID)
+	return known && !alive
+}
+
+func newLockInfo() (*LockInfo, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return nil, errors.Wrap(err, "cannot create lock token")
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get hostname")
+	}
+	return &LockInfo{
+		Token:   hex.EncodeToString(token),
+		Host:    hostname,
+		PID:     os.Getpid(),
+		Created: time.Now(),
+	}, nil
+}
+
+// localPath returns the folder of fsys in the local filesystem
+func localPath(fsys fs.FS) (string, bool) {
+	fullpath, err := writefs.Fullpath(fsys, ".")
+	if err != nil || !filepath.IsAbs(filepath.FromSlash(fullpath)) {
+		return "", false
+	}
+	return filepath.FromSlash(fullpath), true
+}
+
+// ReadLock returns the lock of the object with the workspace fsys
+func ReadLock(fsys fs.FS) (*LockInfo, error) {
+	data, err := fs.ReadFile(fsys, LockFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read '%v/%s'", fsys, LockFile)
+	}
+	info := &LockInfo{}
+	if err := json.Unmarshal(data, info); err != nil {
+		return nil, errors.Wrapf(err, "cannot unmarshal '%v/%s'", fsys, LockFile)
+	}
+	return info, nil
+}
+
+// RemoveLock removes the lock of the object with the workspace fsys, regardless of its owner.
+// A takeover of a crashed process is removed as well. Empty workspace folders are removed
+func RemoveLock(fsys fs.FS) error {
+	if err := writefs.Remove(fsys, LockFile); err != nil {
+		return errors.Wrapf(err, "cannot remove '%v/%s'", fsys, LockFile)
+	}
+	_ = writefs.Remove(fsys, takeoverFile)
+	pruneWorkspace(fsys)
+	return nil
+}
+
+// tryLock creates the lock file in the workspace fsys. ErrLocked is returned, if the object is locked by another writer
+func tryLock(fsys fs.FS, remote bool) (*LockInfo, error) {
+	info, err := newLockInfo()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	data, err := json.Marshal(info)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot marshal lock")
+	}
+	locked := func() error {
+		if holder, err := ReadLock(fsys); err == nil {
+			return errors.Wrapf(ErrLocked, "'%v' (%s)", fsys, holder)
+		}
+		return errors.Wrapf(ErrLocked, "'%v'", fsys)
+	}
+
+	if folder, ok := localPath(fsys); ok {
+		var fp *os.File
+		// another writer may prune the empty workspace folders in between
+		for range 3 {
+			if err = os.MkdirAll(folder, 0755); err != nil {
+				return nil, errors.Wrapf(err, "cannot create folder '%s'", folder)
+			}
+			fp, err = os.OpenFile(filepath.Join(folder, LockFile), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+			if !errors.Is(err, fs.ErrNotExist) {
+				break
+			}
+		}
+		if err != nil {
+			if errors.Is(err, fs.ErrExist) {
+				return nil, locked()
+			}
+			return nil, errors.Wrapf(err, "cannot create '%s'", filepath.Join(folder, LockFile))
+		}
+		if _, err := fp.Write(data); err != nil {
+			fp.Close()
+			return nil, errors.Wrapf(err, "cannot write '%s'", filepath.Join(folder, LockFile))
+		}
+		if err := fp.Close(); err != nil {
+			return nil, errors.Wrapf(err, "cannot close '%s'", filepath.Join(folder, LockFile))
+		}
+		return info, nil
+	}
+
+	if !remote {
+		return nil, nil
+	}
+	// without conditional writes, the last writer wins. it owns the lock, if its token survives
+	if _, err := fs.Stat(fsys, LockFile); err == nil {
+		return nil, locked()
+	}
+	if _, err := writefs.WriteFile(fsys, LockFile, data); err != nil {
+		return nil, errors.Wrapf(err, "cannot write '%v/%s'", fsys, LockFile)
+	}
+	time.Sleep(lockSettle)
+	holder, err := ReadLock(fsys)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if holder.Token != info.Token {
+		return nil, errors.Wrapf(ErrLocked, "'%v' (%s)", fsys, holder)
+	}
+	return info, nil
+}
+
+// takeOverLock removes the lock in fsys, if it is still the stale lock of a process on this host, which is not running anymore.
+// In local folders, only the process, which creates the takeover file exclusively, removes the lock.
+// The lock is read again before, because another process may have taken it over and locked the object in between
+func takeOverLock(fsys fs.FS, stale *LockInfo) (bool, error) {
+	if stale == nil || !stale.Stale() {
+		return false, nil
+	}
+	if folder, ok := localPath(fsys); ok {
+		takeover := filepath.Join(folder, takeoverFile)
+		fp, err := os.OpenFile(takeover, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err != nil {
+			if errors.Is(err, fs.ErrExist) || errors.Is(err, fs.ErrNotExist) {
+				return false, nil
+			}
+			return false, errors.Wrapf(err, "cannot create '%s'", takeover)
+		}
+		if err := fp.Close(); err != nil {
+			return false, errors.Wrapf(err, "cannot close '%s'", takeover)
+		}
+		defer os.Remove(takeover)
+	}
+	current, err := ReadLock(fsys)
+	if err != nil || current.Token != stale.Token {
+		return false, nil
+	}
+	if err := writefs.Remove(fsys, LockFile); err != nil {
+		return false, errors.Wrapf(err, "cannot remove '%v/%s'", fsys, LockFile)
+	}
+	return true, nil
+}
+
+// acquireLock locks the object in fsys. If the object is locked, it waits according to options.
+// Stale locks of crashed processes on this host are taken over.
+// The returned lock is nil, if the filesystem is not locked
+func acquireLock(ctx context.Context, fsys fs.FS, options *LockOptions) (*LockInfo, error) {
+	if options == nil {
+		return nil, nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	retry := options.Retry
+	if retry <= 0 {
+		retry = DefaultLockOptions.Retry
+	}
+	start := time.Now()
+	for {
+		info, err := tryLock(fsys, options.Remote)
+		if errors.Is(err, ErrLocked) {
+			holder, _ := ReadLock(fsys)
+			removed, err2 := takeOverLock(fsys, holder)
+			if err2 != nil {
+				return nil, errors.Combine(err, err2)
+			}
+			if removed {
+				continue
+			}
+		}
+		if err == nil || !errors.Is(err, ErrLocked) || !options.Wait {
+			return info, err
+		}
+		wait := retry
+		if options.Timeout > 0 {
+			remaining := options.Timeout - time.Since(start)
+			if remaining <= 0 {
+				return nil, errors.Wrapf(err, "timeout after %s", options.Timeout)
+			}
+			wait = min(wait, remaining)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, errors.Wrap(errors.Combine(err, ctx.Err()), "cancelled")
+		case <-time.After(wait):
+		}
+	}
+}
+
+// releaseLock removes the lock file, if it still belongs to info
+func releaseLock(fsys fs.FS, info *LockInfo) error {
+	if info == nil {
+		return nil
+	}
+	holder, err := ReadLock(fsys)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return errors.WithStack(err)
+	}
+	if holder.Token != info.Token {
+		return errors.Errorf("lock of '%v' was taken over (%s)", fsys, holder)
+	}
+	return errors.WithStack(RemoveLock(fsys))
+}
+
+// lock locks the object for writing. It does nothing, if the object is already locked by this instance
+// or has no workspace
+func (object *ObjectBase) lock() error {
+	if object.lockInfo != nil || object.workspace == nil {
+		return nil
+	}
+	ctx := object.updateCtx
+	if ctx == nil {
+		ctx = object.ctx
+	}
+	info, err := acquireLock(ctx, object.workspace, object.storageRoot.GetLockOptions())
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	object.lockInfo = info
+	return nil
+}
+
+// unlock releases the lock of the object
+func (object *ObjectBase) unlock() error {
+	info := object.lockInfo
+	object.lockInfo = nil
+	return errors.WithStack(releaseLock(object.workspace, info))
+}
+
+// unlockOnError releases the lock of the object after a failed update step
+func (object *ObjectBase) unlockOnError(err error) error {
+	if err2 := object.unlock(); err2 != nil {
+		return errors.Combine(err, errors.Wrapf(err2, "cannot unlock object '%s'", object.GetID()))
+	}
+	return err
+}
+
+// reloadAfterLock reloads the inventory, if another writer added a version while the object was loaded without lock
+func (object *ObjectBase) reloadAfterLock() error {
+	if object.i == nil || len(object.i.GetVersionStrings()) == 0 {
+		return nil
+	}
+	head, _, ok := inventoryHead(object.fsys, ".")
+	if !ok || head == object.i.GetHead() {
+		return nil
+	}
+	i, err := object.LoadInventory(".")
+	if err != nil {
+		return errors.Wrapf(err, "cannot reload inventory of object '%s'", object.GetID())
+	}
+	object.i = i
+	return nil
+}
@@ -0,0 +1,89 @@
+package ocfl
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/je4/filesystem/v3/pkg/osfsrw"
+	"github.com/rs/zerolog"
+)
+
+// TestTakeOverLock lets two writers find the same stale lock. The second writer must not remove the lock,
+// which the first writer created after the takeover
+func TestTakeOverLock(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("processes cannot be checked on this platform")
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	l := zerolog.Nop()
+	folder := t.TempDir()
+	workspace, err := osfsrw.NewFS(folder, false, &l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(&LockInfo{Token: "stale", Host: hostname, PID: cmd.Process.Pid, Created: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(folder, LockFile), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// both writers read the stale lock, before one of them takes it over
+	first, err := ReadLock(workspace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := ReadLock(workspace)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a crashed takeover blocks all writers
+	if err := os.WriteFile(filepath.Join(folder, takeoverFile), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if removed, err := takeOverLock(workspace, first); err != nil || removed {
+		t.Fatalf("lock removed during takeover of another writer: %v, %v", removed, err)
+	}
+	if err := os.Remove(filepath.Join(folder, takeoverFile)); err != nil {
+		t.Fatal(err)
+	}
+
+	if removed, err := takeOverLock(workspace, first); err != nil || !removed {
+		t.Fatalf("stale lock not taken over: %v, %v", removed, err)
+	}
+	lock, err := tryLock(workspace, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed, err := takeOverLock(workspace, second); err != nil || removed {
+		t.Fatalf("lock of the first writer removed: %v, %v", removed, err)
+	}
+	if _, err := tryLock(workspace, false); !errors.Is(err, ErrLocked) {
+		t.Fatalf("second writer got the lock: %v", err)
+	}
+	holder, err := ReadLock(workspace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if holder.Token != lock.Token {
+		t.Fatalf("lock of '%s' instead of '%s'", holder.Token, lock.Token)
+	}
+	if _, err := os.Stat(filepath.Join(folder, takeoverFile)); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("takeover file left behind: %v", err)
+	}
+}
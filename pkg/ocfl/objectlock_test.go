@@ -0,0 +1,363 @@
+package ocfl_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ocfl-archive/gocfl/v2/pkg/ocfl"
+)
+
+// TestUpdateErrorReleasesLock checks, that a failed update does not leave its lock behind
+func TestUpdateErrorReleasesLock(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		hook   *hookExtension
+		before func(tr *testRoot) error
+		during func(tr *testRoot) error
+	}{
+		{
+			name: "abandoned staging",
+			before: func(tr *testRoot) error {
+				return os.MkdirAll(filepath.Join(tr.workspaceFolder("a"), ocfl.StagingFolder), 0755)
+			},
+		},
+		{
+			name: "UpdateObjectBefore",
+			hook: &hookExtension{failBefore: true},
+		},
+		{
+			name: "UpdateObjectAfter",
+			hook: &hookExtension{failAfter: true},
+		},
+		{
+			name: "promote",
+			during: func(tr *testRoot) error {
+				return os.Mkdir(filepath.Join(tr.objectFolder("a"), "v2"), 0755)
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			tr := newTestRoot(t)
+			if err := tr.update("a", testFiles(map[string]string{"x.txt": "x"})); err != nil {
+				t.Fatal(err)
+			}
+			source := testFiles(map[string]string{"x.txt": "x", "y.txt": "y"})
+			o, err := tr.open("a")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.hook != nil {
+				if err := o.GetExtensionManager().Add(test.hook); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if test.before != nil {
+				if err := test.before(tr); err != nil {
+					t.Fatal(err)
+				}
+			}
+			err = func() error {
+				if _, err := o.StartUpdate(context.Background(), source, "test", "tester", "mailto:tester@example.org", false); err != nil {
+					return err
+				}
+				if err := o.AddFolder(context.Background(), source, nil, true, "content"); err != nil {
+					return err
+				}
+				if test.during != nil {
+					if err := test.during(tr); err != nil {
+						t.Fatal(err)
+					}
+				}
+				return o.EndUpdate()
+			}()
+			if err == nil {
+				t.Fatal("update should fail")
+			}
+			if _, err := os.Stat(filepath.Join(tr.workspaceFolder("a"), ocfl.LockFile)); !errors.Is(err, os.ErrNotExist) {
+				t.Fatalf("lock file left behind: %v", err)
+			}
+			if _, err := ocfl.RecoverObject(context.Background(), tr.objectFS("a"), tr.workspaceFS("a"), &ocfl.LockOptions{}, false); err != nil {
+				t.Fatalf("cannot recover: %v", err)
+			}
+			if head := tr.head("a"); head != "v1" {
+				t.Fatalf("head is '%s' instead of 'v1'", head)
+			}
+			if err := tr.update("a", source); err != nil {
+				t.Fatalf("cannot update after failure: %v", err)
+			}
+			if head := tr.head("a"); head != "v2" {
+				t.Fatalf("head is '%s' instead of 'v2'", head)
+			}
+		})
+	}
+}
+
+// TestLockContention checks, that a second writer waits for the lock or fails
+func TestLockContention(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		options *ocfl.LockOptions
+		release time.Duration
+		fail    bool
+	}{
+		{name: "no wait", options: &ocfl.LockOptions{}, release: 100 * time.Millisecond, fail: true},
+		{name: "timeout", options: &ocfl.LockOptions{Wait: true, Timeout: 100 * time.Millisecond, Retry: 20 * time.Millisecond}, release: time.Second, fail: true},
+		{name: "wait", options: &ocfl.LockOptions{Wait: true, Timeout: 10 * time.Second, Retry: 20 * time.Millisecond}, release: 100 * time.Millisecond},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			tr := newTestRoot(t)
+			if err := tr.update("a", testFiles(map[string]string{"x.txt": "x"})); err != nil {
+				t.Fatal(err)
+			}
+			first, err := tr.load().LoadObjectByID("a")
+			if err != nil {
+				t.Fatal(err)
+			}
+			source := testFiles(map[string]string{"x.txt": "x", "y.txt": "y"})
+			if _, err := first.StartUpdate(context.Background(), source, "first", "tester", "mailto:tester@example.org", false); err != nil {
+				t.Fatal(err)
+			}
+			done := make(chan error, 1)
+			go func() {
+				time.Sleep(test.release)
+				if err := first.AddFolder(context.Background(), source, nil, true, "content"); err != nil {
+					done <- err
+					return
+				}
+				if err := first.EndUpdate(); err != nil {
+					done <- err
+					return
+				}
+				done <- first.Close()
+			}()
+
+			storageRoot := tr.load()
+			storageRoot.SetLockOptions(test.options)
+			second, err := storageRoot.LoadObjectByID("a")
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = second.StartUpdate(context.Background(), source, "second", "tester", "mailto:tester@example.org", false)
+			if test.fail {
+				if !errors.Is(err, ocfl.ErrLocked) {
+					t.Fatalf("expected ErrLocked, got %v", err)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("cannot start second update: %v", err)
+				}
+				if head := second.GetInventory().GetHead(); head != "v3" {
+					t.Fatalf("second update creates '%s' instead of 'v3'", head)
+				}
+				if err := second.Rollback(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := <-done; err != nil {
+				t.Fatalf("first update failed: %v", err)
+			}
+			if head := tr.head("a"); head != "v2" {
+				t.Fatalf("head is '%s' instead of 'v2'", head)
+			}
+		})
+	}
+}
+
+// deadPID returns the id of a process, which is not running anymore
+func deadPID(t *testing.T) int {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	return cmd.Process.Pid
+}
+
+// writeLock writes the lock of holder into the workspace folder
+func writeLock(t *testing.T, workspace string, holder ocfl.LockInfo) {
+	t.Helper()
+	holder.Created = time.Now()
+	data, err := json.Marshal(holder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(workspace, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(workspace, ocfl.LockFile), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestStaleLock checks, that only locks of crashed processes on this host are taken over
+func TestStaleLock(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("processes cannot be checked on this platform")
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name   string
+		holder ocfl.LockInfo
+		stale  bool
+	}{
+		{name: "crashed process", holder: ocfl.LockInfo{Token: "stale", Host: hostname, PID: deadPID(t)}, stale: true},
+		{name: "running process", holder: ocfl.LockInfo{Token: "running", Host: hostname, PID: os.Getpid()}},
+		{name: "other host", holder: ocfl.LockInfo{Token: "remote", Host: hostname + ".other", PID: deadPID(t)}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			tr := newTestRoot(t)
+			if err := tr.update("a", testFiles(map[string]string{"x.txt": "x"})); err != nil {
+				t.Fatal(err)
+			}
+			writeLock(t, tr.workspaceFolder("a"), test.holder)
+
+			actions, err := ocfl.RecoverObject(context.Background(), tr.objectFS("a"), tr.workspaceFS("a"), &ocfl.LockOptions{}, true)
+			if test.stale != (err == nil) {
+				t.Fatalf("dry run of recover: stale %v, error %v", test.stale, err)
+			}
+			if test.stale && (len(actions) != 1 || actions[0].Path != ocfl.LockFile) {
+				t.Fatalf("dry run of recover should take over the lock: %v", actions)
+			}
+
+			err = tr.update("a", testFiles(map[string]string{"x.txt": "x", "y.txt": "y"}))
+			if test.stale {
+				if err != nil {
+					t.Fatalf("stale lock not taken over: %v", err)
+				}
+				if head := tr.head("a"); head != "v2" {
+					t.Fatalf("head is '%s' instead of 'v2'", head)
+				}
+				if _, err := os.Stat(filepath.Join(tr.workspaceFolder("a"), ocfl.LockFile)); !errors.Is(err, os.ErrNotExist) {
+					t.Fatalf("lock file left behind: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ocfl.ErrLocked) {
+				t.Fatalf("expected ErrLocked, got %v", err)
+			}
+			if _, err := ocfl.RecoverObject(context.Background(), tr.objectFS("a"), tr.workspaceFS("a"), &ocfl.LockOptions{}, false); !errors.Is(err, ocfl.ErrLocked) {
+				t.Fatalf("recover should not take over the lock: %v", err)
+			}
+		})
+	}
+}
+
+// TestCheckDuringUpdate checks, that lock and staging area of a running update are not reported by the validation
+func TestCheckDuringUpdate(t *testing.T) {
+	tr := newTestRoot(t)
+	if err := tr.update("a", testFiles(map[string]string{"x.txt": "x"})); err != nil {
+		t.Fatal(err)
+	}
+	source := testFiles(map[string]string{"x.txt": "x", "y.txt": "y"})
+	o, err := tr.open("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := o.StartUpdate(context.Background(), source, "test", "tester", "mailto:tester@example.org", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.AddFolder(context.Background(), source, nil, true, "content"); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{ocfl.LockFile, ocfl.StagingFolder} {
+		if _, err := os.Stat(filepath.Join(tr.workspaceFolder("a"), name)); err != nil {
+			t.Fatalf("'%s' not in workspace: %v", name, err)
+		}
+	}
+
+	ctx := ocfl.NewContextValidation(context.Background())
+	if err := tr.loadContext(ctx).Check(ctx); err != nil {
+		t.Fatal(err)
+	}
+	status, err := ocfl.GetValidationStatus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, valErr := range status.Errors {
+		if strings.HasPrefix(string(valErr.Code), "E") {
+			t.Errorf("validation error during update: %v", valErr)
+		}
+	}
+
+	if err := o.EndUpdate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(tr.folder, ocfl.WorkspaceFolder)); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("workspace left behind: %v", err)
+	}
+}
+
+// TestStaleLockContenders checks, that only one of several writers takes over a stale lock
+func TestStaleLockContenders(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("processes cannot be checked on this platform")
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Fatal(err)
+	}
+	const contenders = 8
+	tr := newTestRoot(t)
+	if err := tr.update("a", testFiles(map[string]string{"x.txt": "x"})); err != nil {
+		t.Fatal(err)
+	}
+	source := testFiles(map[string]string{"x.txt": "x", "y.txt": "y"})
+	holder := ocfl.LockInfo{Token: "stale", Host: hostname, PID: deadPID(t)}
+	for round := 0; round < 20; round++ {
+		writeLock(t, tr.workspaceFolder("a"), holder)
+		var objects = make([]ocfl.Object, contenders)
+		for i := range objects {
+			if objects[i], err = tr.load().LoadObjectByID("a"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		var results = make([]error, contenders)
+		var wg sync.WaitGroup
+		start := make(chan struct{})
+		for i, o := range objects {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				_, results[i] = o.StartUpdate(context.Background(), source, "test", "tester", "mailto:tester@example.org", false)
+			}()
+		}
+		close(start)
+		wg.Wait()
+		var winners []ocfl.Object
+		for i, err := range results {
+			switch {
+			case err == nil:
+				winners = append(winners, objects[i])
+			case !errors.Is(err, ocfl.ErrLocked):
+				t.Fatal(err)
+			}
+		}
+		for _, o := range winners {
+			if err := o.Rollback(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if len(winners) != 1 {
+			t.Fatalf("round %d: %d writers got the lock", round, len(winners))
+		}
+		if _, err := os.Stat(filepath.Join(tr.workspaceFolder("a"), ocfl.LockFile)); !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("round %d: lock file left behind: %v", round, err)
+		}
+	}
+}
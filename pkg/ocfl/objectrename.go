@@ -0,0 +1,194 @@
+package ocfl
+
+import (
+	"emperror.dev/errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// maximum number of renames listed in the version message
+const renameMessageMax = 100
+
+// renamePair is a file, which is moved to another logical path without changing the content
+type renamePair struct {
+	source string
+	dest   string
+	digest string
+}
+
+// findRenames pairs removed and added logical paths with the same digest.
+// The paths are paired in sorted order, so the result does not depend on the order of ingest
+func findRenames(removed, added map[string]string) []renamePair {
+	var sources = map[string][]string{}
+	for _, path := range slices.Sorted(maps.Keys(removed)) {
+		digest := removed[path]
+		sources[digest] = append(sources[digest], path)
+	}
+	var pairs = []renamePair{}
+	for _, path := range slices.Sorted(maps.Keys(added)) {
+		digest := added[path]
+		if paths := sources[digest]; len(paths) > 0 {
+			pairs = append(pairs, renamePair{source: paths[0], dest: path, digest: digest})
+			sources[digest] = paths[1:]
+		}
+	}
+	return pairs
+}
+
+func (object *ObjectBase) versionStateMap(version string) (map[string]string, error) {
+	var state = map[string]string{}
+	if err := object.i.IterateStateFiles(version, func(internal []string, external []string, digest string) error {
+		for _, name := range external {
+			state[name] = digest
+		}
+		return nil
+	}); err != nil {
+		return nil, errors.Wrapf(err, "cannot get state of version '%s'", version)
+	}
+	return state, nil
+}
+
+// renameSources are the files of the previous version, which are missing in the source of an echo update without deduplication.
+// New files with the same content are referenced instead of copied, echoRename records them as renames
+type renameSources struct {
+	state   map[string]string
+	digests map[string]bool
+}
+
+// isNew returns true, if newPath is not in the previous version and may be the destination of a rename
+func (rs *renameSources) isNew(newPath string) bool {
+	if rs == nil {
+		return false
+	}
+	_, ok := rs.state[newPath]
+	return !ok
+}
+
+// matches returns true, if newPath is new and has the content of a file, which is missing in the source
+func (rs *renameSources) matches(newPath, digest string) bool {
+	return rs.isNew(newPath) && rs.digests[digest]
+}
+
+// findRenameSources returns the files of the previous version, which are missing in fsys, or nil if there are none
+func (object *ObjectBase) findRenameSources(fsys fs.FS, area string) (*renameSources, error) {
+	versions := object.i.GetVersionStrings()
+	if len(versions) < 2 {
+		return nil, nil
+	}
+	state, err := object.versionStateMap(versions[len(versions)-2])
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	basePath, err := object.extensionManager.BuildObjectStatePath(object, ".", "")
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot build external path for '.'")
+	}
+	if basePath == "." {
+		basePath = ""
+	}
+	var present = []string{}
+	if err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		statePath, err := object.extensionManager.BuildObjectStatePath(object, filepath.ToSlash(path), area)
+		if err != nil {
+			return errors.Wrapf(err, "cannot map external path '%s'", path)
+		}
+		present = append(present, statePath)
+		return nil
+	}); err != nil {
+		return nil, errors.Wrapf(err, "cannot walk '%v'", fsys)
+	}
+	// hidden files are kept. filtered sources know them only after the walk
+	skipped, err := object.skippedStatePaths(fsys, area)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	present = append(present, skipped...)
+	slices.Sort(present)
+	var digests = map[string]bool{}
+	for path, digest := range state {
+		if !strings.HasPrefix(path, basePath) {
+			continue
+		}
+		if _, found := slices.BinarySearch(present, path); !found {
+			digests[digest] = true
+		}
+	}
+	if len(digests) == 0 {
+		return nil, nil
+	}
+	return &renameSources{state: state, digests: digests}, nil
+}
+
+// echoRename finds files of the last version, which would be removed by echoDelete and are added at another
+// path with the same content. They are renamed instead and listed in the message of the version.
+// object.updateFiles must be sorted
+func (object *ObjectBase) echoRename(basePath string) error {
+	versions := object.i.GetVersionStrings()
+	if len(versions) < 2 {
+		return nil
+	}
+	head := object.i.GetHead()
+	previousState, err := object.versionStateMap(versions[len(versions)-2])
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	headState, err := object.versionStateMap(head)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	var removed = map[string]string{}
+	var added = map[string]string{}
+	for path, digest := range headState {
+		if _, ok := previousState[path]; !ok {
+			added[path] = digest
+			continue
+		}
+		if !strings.HasPrefix(path, basePath) {
+			continue
+		}
+		if _, found := slices.BinarySearch(object.updateFiles, path); !found {
+			removed[path] = digest
+		}
+	}
+	pairs := findRenames(removed, added)
+	if len(pairs) == 0 {
+		return nil
+	}
+	var lines = []string{}
+	for _, pair := range pairs {
+		if err := object.i.DeleteFile(pair.dest); err != nil {
+			return errors.Wrapf(err, "cannot remove '%s' for rename", pair.dest)
+		}
+		if err := object.RenameFile(pair.source, pair.dest, pair.digest); err != nil {
+			return errors.Wrapf(err, "cannot rename '%s' to '%s'", pair.source, pair.dest)
+		}
+		object.logger.Info().Any(
+			object.errorFactory.LogError(
+				ErrorOCFL,
+				fmt.Sprintf("[%s] '%s' renamed to '%s'", object.GetID(), pair.source, pair.dest),
+				nil,
+			),
+		).Msg("")
+		if len(lines) < renameMessageMax {
+			lines = append(lines, fmt.Sprintf("'%s' -> '%s'", pair.source, pair.dest))
+		}
+	}
+	if len(pairs) > renameMessageMax {
+		lines = append(lines, fmt.Sprintf("... %d more", len(pairs)-renameMessageMax))
+	}
+	if version, ok := object.i.GetVersions()[head]; ok {
+		msg := ""
+		if version.Message != nil {
+			msg = version.Message.string
+		}
+		version.Message = NewOCFLString(fmt.Sprintf("%s\n\nrenamed %d files:\n%s", msg, len(pairs), strings.Join(lines, "\n")))
+	}
+	return nil
+}
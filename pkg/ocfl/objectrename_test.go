@@ -0,0 +1,123 @@
+package ocfl_test
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ocfl-archive/gocfl/v2/pkg/subsystem/ingestfilter"
+)
+
+// TestEchoRenameNoDeduplicate checks, that moved files are not copied by echo updates without deduplication
+func TestEchoRenameNoDeduplicate(t *testing.T) {
+	moved := strings.Repeat("moved content ", 100)
+	source := testFiles(map[string]string{"moved.txt": moved, "b.txt": "b"})
+	for _, workers := range []int{1, 4} {
+		t.Run(fmt.Sprintf("%d workers", workers), func(t *testing.T) {
+			tr := newTestRoot(t)
+			if err := tr.update("a", testFiles(map[string]string{"a.txt": moved, "b.txt": "b"})); err != nil {
+				t.Fatal(err)
+			}
+			o, err := tr.open("a")
+			if err != nil {
+				t.Fatal(err)
+			}
+			plan, err := o.Plan(context.Background(), source, "content", nil, false, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// b.txt is copied again without deduplication
+			if plan.Renamed != 1 || plan.BytesWritten != 1 {
+				t.Fatalf("expected 1 rename and 1 byte to write, got %d renames and %d bytes", plan.Renamed, plan.BytesWritten)
+			}
+			o.SetWorkers(workers)
+			if _, err := o.StartUpdate(context.Background(), source, "test", "tester", "mailto:tester@example.org", true); err != nil {
+				t.Fatal(err)
+			}
+			if err := o.AddFolder(context.Background(), source, nil, false, "content"); err != nil {
+				t.Fatal(err)
+			}
+			if err := o.EndUpdate(); err != nil {
+				t.Fatal(err)
+			}
+			if err := o.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := fs.Stat(tr.objectFS("a"), "v2/content/moved.txt"); err == nil {
+				t.Fatal("content of moved file copied")
+			}
+			o, err = tr.load().LoadObjectByID("a")
+			if err != nil {
+				t.Fatal(err)
+			}
+			message := o.GetInventory().GetVersions()["v2"].Message.String()
+			if !strings.Contains(message, "'a.txt' -> 'moved.txt'") {
+				t.Fatalf("rename missing in message '%s'", message)
+			}
+		})
+	}
+}
+
+// TestEchoRenameExclude checks, that files hidden by an exclude rule are no rename sources.
+// New files with their content are copied, because the hidden files stay in the object
+func TestEchoRenameExclude(t *testing.T) {
+	moved := strings.Repeat("moved content ", 100)
+	hidden := strings.Repeat("hidden content ", 100)
+	filter, err := ingestfilter.NewFilter(nil, []string{"*.log"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, workers := range []int{1, 4} {
+		t.Run(fmt.Sprintf("%d workers", workers), func(t *testing.T) {
+			tr := newTestRoot(t)
+			if err := tr.update("a", testFiles(map[string]string{"a.txt": moved, "keep.log": hidden})); err != nil {
+				t.Fatal(err)
+			}
+			source, err := ingestfilter.NewFS(testFiles(map[string]string{"moved.txt": moved, "keep.log": hidden, "copy.txt": hidden}), filter, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			o, err := tr.open("a")
+			if err != nil {
+				t.Fatal(err)
+			}
+			o.SetWorkers(workers)
+			if _, err := o.StartUpdate(context.Background(), source, "test", "tester", "mailto:tester@example.org", true); err != nil {
+				t.Fatal(err)
+			}
+			if err := o.AddFolder(context.Background(), source, nil, false, "content"); err != nil {
+				t.Fatal(err)
+			}
+			if err := o.EndUpdate(); err != nil {
+				t.Fatal(err)
+			}
+			if err := o.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := fs.Stat(tr.objectFS("a"), "v2/content/moved.txt"); err == nil {
+				t.Fatal("content of moved file copied")
+			}
+			if _, err := fs.Stat(tr.objectFS("a"), "v2/content/copy.txt"); err != nil {
+				t.Fatalf("content of new file not copied: %v", err)
+			}
+			o, err = tr.load().LoadObjectByID("a")
+			if err != nil {
+				t.Fatal(err)
+			}
+			vfs, err := o.VersionFS("v2", "content")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := fstest.TestFS(vfs, "copy.txt", "keep.log", "moved.txt"); err != nil {
+				t.Fatal(err)
+			}
+			message := o.GetInventory().GetVersions()["v2"].Message.String()
+			if !strings.Contains(message, "renamed 1 files") || !strings.Contains(message, "'a.txt' -> 'moved.txt'") {
+				t.Fatalf("unexpected renames in message '%s'", message)
+			}
+		})
+	}
+}
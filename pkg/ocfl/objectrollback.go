@@ -0,0 +1,118 @@
+package ocfl
+
+import (
+	"context"
+	"emperror.dev/errors"
+	"fmt"
+	"io"
+)
+
+// contextReader stops reading, if the context is done
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func newContextReader(ctx context.Context, r io.Reader) *contextReader {
+	return &contextReader{ctx: ctx, r: r}
+}
+
+func (cr *contextReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(p)
+}
+
+func (cr *contextReader) Close() error {
+	if closer, ok := cr.r.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// updateContext returns the context of the open version
+func (object *ObjectBase) updateContext() context.Context {
+	if object.updateCtx == nil {
+		return context.Background()
+	}
+	return object.updateCtx
+}
+
+// rollbackOnCancel rolls back the open version, if ctx is done.
+// err is returned unchanged, if the context is still active
+func (object *ObjectBase) rollbackOnCancel(ctx context.Context, err error) error {
+	if ctx.Err() == nil {
+		return err
+	}
+	if err == nil {
+		err = errors.WithStack(ctx.Err())
+	}
+	err = errors.Wrapf(err, "update of object '%s' cancelled", object.GetID())
+	if err2 := object.Rollback(); err2 != nil {
+		return errors.Combine(err, errors.Wrap(err2, "cannot roll back"))
+	}
+	return err
+}
+
+// rollbackOnError discards the open version after a failed step of the update. The object lock is released
+func (object *ObjectBase) rollbackOnError(err error) error {
+	if err2 := object.Rollback(); err2 != nil {
+		return errors.Combine(err, errors.Wrap(err2, "cannot roll back"))
+	}
+	// Rollback does nothing, if the version was already rolled back
+	return object.unlockOnError(err)
+}
+
+// Rollback discards the open version. The content of the version is removed, the extensions folder is restored,
+// the inventory of the last stored version is reloaded and the object lock is released. A new object is removed completely
+func (object *ObjectBase) Rollback() (err error) {
+	if object.updateCtx == nil {
+		return nil
+	}
+	defer func() {
+		if err2 := object.unlock(); err2 != nil {
+			err = errors.Combine(err, errors.Wrapf(err2, "cannot unlock object '%s'", object.GetID()))
+		}
+	}()
+	object.updateCtx = nil
+	object.updateFiles = []string{}
+	object.area = ""
+
+	head := object.i.GetHead()
+	newObject := len(object.i.GetVersionStrings()) <= 1
+	object.logger.Info().Any(
+		object.errorFactory.LogError(
+			ErrorOCFL,
+			fmt.Sprintf("rolling back version '%s' of object '%s'", head, object.GetID()),
+			nil,
+		),
+	).Msg("")
+	root := head
+	if newObject {
+		root = "."
+	}
+	if err := removeAll(object.fsys, root); err != nil {
+		return errors.WithStack(err)
+	}
+	// extension hooks may have changed the extensions folder of the object
+	if sfs, ok := object.fsys.(*stagingFS); ok && !newObject {
+		if err := restoreExtensions(sfs.fsys, object.workspace); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	object.discardStaging()
+
+	if newObject {
+		i, err := object.CreateInventory(object.i.GetID(), object.i.GetDigestAlgorithm(), object.i.GetFixityDigestAlgorithm())
+		if err != nil {
+			return errors.Wrap(err, "cannot reset inventory")
+		}
+		object.i = i
+		return nil
+	}
+	if err := object.Load(); err != nil {
+		return errors.Wrapf(err, "cannot reload object '%s'", object.GetID())
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package ocfl_test
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/je4/filesystem/v3/pkg/writefs"
+	"github.com/ocfl-archive/gocfl/v2/pkg/ocfl"
+)
+
+// failContentExtension fails in the AddFileBefore hook
+type failContentExtension struct {
+	recordExtension
+}
+
+func (fe *failContentExtension) AddFileBefore(ctx context.Context, object ocfl.Object, sourceFS fs.FS, source string, dest string, area string, isDir bool) error {
+	return errors.New("AddFileBefore failed")
+}
+
+// TestRollbackAfterHookError checks, that a failed extension hook leaves the object at its previous version
+func TestRollbackAfterHookError(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		ext  ocfl.Extension
+	}{
+		{name: "UpdateObjectBefore", ext: &hookExtension{failBefore: true}},
+		{name: "AddFileBefore", ext: &failContentExtension{}},
+		{name: "UpdateObjectAfter", ext: &hookExtension{failAfter: true}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			tr := newTestRoot(t)
+			if err := tr.update("a", testFiles(map[string]string{"x.txt": "x"})); err != nil {
+				t.Fatal(err)
+			}
+			o, err := tr.open("a")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := o.GetExtensionManager().Add(test.ext); err != nil {
+				t.Fatal(err)
+			}
+			if err := updateObject(o, testFiles(map[string]string{"y.txt": "y"})); err == nil {
+				t.Fatal("update should fail")
+			}
+			// like the commands, the caller rolls back after an error
+			if err := o.Rollback(); err != nil {
+				t.Fatalf("cannot roll back: %v", err)
+			}
+			if _, err := os.Stat(filepath.Join(tr.objectFolder("a"), "v2")); !errors.Is(err, os.ErrNotExist) {
+				t.Fatalf("'v2' left behind: %v", err)
+			}
+			for _, name := range []string{ocfl.LockFile, ocfl.StagingFolder} {
+				if _, err := os.Stat(filepath.Join(tr.workspaceFolder("a"), name)); !errors.Is(err, os.ErrNotExist) {
+					t.Fatalf("'%s' left behind in workspace: %v", name, err)
+				}
+			}
+			if head := tr.head("a"); head != "v1" {
+				t.Fatalf("head is '%s' instead of 'v1'", head)
+			}
+		})
+	}
+}
+
+// stateExtension changes its extension folder in the object hooks, before it fails as requested
+type stateExtension struct {
+	hookExtension
+}
+
+func (se *stateExtension) UpdateObjectBefore(ctx context.Context, object ocfl.Object) error {
+	if _, err := writefs.WriteFile(se.fsys, "state.txt", []byte("changed")); err != nil {
+		return err
+	}
+	return se.hookExtension.UpdateObjectBefore(ctx, object)
+}
+
+func (se *stateExtension) UpdateObjectAfter(ctx context.Context, object ocfl.Object) error {
+	if _, err := writefs.WriteFile(se.fsys, "new.txt", []byte("new")); err != nil {
+		return err
+	}
+	return se.hookExtension.UpdateObjectAfter(ctx, object)
+}
+
+// TestRollbackExtensions checks, that a rollback restores the extensions folder, which was changed by the hooks
+func TestRollbackExtensions(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		ext   *stateExtension
+		state string
+		added bool
+	}{
+		{name: "success", ext: &stateExtension{}, state: "changed", added: true},
+		{name: "UpdateObjectBefore", ext: &stateExtension{hookExtension{failBefore: true}}, state: "original"},
+		{name: "UpdateObjectAfter", ext: &stateExtension{hookExtension{failAfter: true}}, state: "original"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			tr := newTestRoot(t)
+			if err := tr.update("a", testFiles(map[string]string{"x.txt": "x"})); err != nil {
+				t.Fatal(err)
+			}
+			folder := filepath.Join(tr.objectFolder("a"), "extensions", hookExtensionName)
+			if err := os.MkdirAll(folder, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(folder, "state.txt"), []byte("original"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			o, err := tr.open("a")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := o.GetExtensionManager().Add(test.ext); err != nil {
+				t.Fatal(err)
+			}
+			err = updateObject(o, testFiles(map[string]string{"y.txt": "y"}))
+			if test.added != (err == nil) {
+				t.Fatalf("update failed: %v", err)
+			}
+			if err := o.Rollback(); err != nil {
+				t.Fatalf("cannot roll back: %v", err)
+			}
+			data, err := os.ReadFile(filepath.Join(folder, "state.txt"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != test.state {
+				t.Fatalf("extension state '%s' instead of '%s'", data, test.state)
+			}
+			if _, err := os.Stat(filepath.Join(folder, "new.txt")); test.added != (err == nil) {
+				t.Fatalf("file of the extension hook exists %v: %v", test.added, err)
+			}
+			if _, err := os.Stat(tr.workspaceFolder("a")); !errors.Is(err, os.ErrNotExist) {
+				t.Fatalf("workspace left behind: %v", err)
+			}
+		})
+	}
+}
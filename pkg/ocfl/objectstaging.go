@@ -0,0 +1,183 @@
+package ocfl
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+
+	"emperror.dev/errors"
+	"github.com/je4/filesystem/v3/pkg/writefs"
+)
+
+var ErrAbandonedStaging = errors.New("abandoned staging area found, use 'gocfl recover' to clean up")
+
+// ExtensionsBackupFolder is the copy of the extensions folder of the object in the workspace.
+// Extensions write their state in place, so it is restored, if the update is rolled back
+const ExtensionsBackupFolder = "extensions.backup"
+
+// checkStaging makes sure, that no other update left its staging area behind
+func (object *ObjectBase) checkStaging() error {
+	if _, ok := object.fsys.(*stagingFS); ok {
+		return errors.Errorf("update of object '%s' already running", object.GetID())
+	}
+	if object.workspace == nil {
+		return nil
+	}
+	for _, name := range []string{StagingFolder, ExtensionsBackupFolder} {
+		if _, err := fs.Stat(object.workspace, name); err == nil {
+			return errors.Wrapf(ErrAbandonedStaging, "object '%s'", object.GetID())
+		}
+	}
+	return nil
+}
+
+// saveExtensions copies the extensions folder of the object to the workspace, if the update is staged
+func (object *ObjectBase) saveExtensions() error {
+	if !canStage(object.fsys, object.workspace) {
+		return nil
+	}
+	if err := copyLocal(object.fsys, "extensions", object.workspace, ExtensionsBackupFolder); err != nil {
+		_ = removeAll(object.workspace, ExtensionsBackupFolder)
+		return errors.Wrapf(err, "cannot save extensions of object '%s'", object.GetID())
+	}
+	return nil
+}
+
+// startStaging redirects the new head version into the staging folder of the object workspace.
+// The version folder is created before any extension hook runs, so RecoverObject knows, that the saved extensions must be restored.
+// Objects on filesystems without atomic renames are written in place
+func (object *ObjectBase) startStaging() error {
+	object.committed = false
+	if !canStage(object.fsys, object.workspace) {
+		return nil
+	}
+	object.fsys = newStagingFS(object.fsys, object.workspace, object.i.GetHead())
+	folder, err := writefs.Fullpath(object.workspace, path.Join(StagingFolder, object.i.GetHead()))
+	if err != nil {
+		return errors.Wrapf(err, "cannot get path of '%v/%s'", object.workspace, StagingFolder)
+	}
+	if err := os.MkdirAll(filepath.FromSlash(folder), 0755); err != nil {
+		return errors.Wrapf(err, "cannot create staging folder '%s'", folder)
+	}
+	return nil
+}
+
+// promote moves the staged version into the object root and replaces the root inventory with the inventory of the new version.
+// The version folder is renamed first, then the inventory and its sidecar are copied to the staging folder and renamed one by one.
+// Workspace and object are folders of the same storage root, so all renames are atomic.
+// If the update is interrupted in between, the root inventory is outdated or does not match its sidecar. RecoverObject completes the version
+func (object *ObjectBase) promote() error {
+	sfs, ok := object.fsys.(*stagingFS)
+	if !ok {
+		return nil
+	}
+	head := object.i.GetHead()
+	object.fsys = sfs.fsys
+	if _, err := fs.Stat(object.fsys, head); err == nil {
+		return errors.Errorf("version folder '%v/%s' already exists", object.fsys, head)
+	}
+	if err := renameLocal(object.workspace, path.Join(StagingFolder, head), object.fsys, head); err != nil {
+		return errors.Wrapf(err, "cannot move staged version '%s' to '%v'", head, object.fsys)
+	}
+	for _, name := range []string{"inventory.json", fmt.Sprintf("inventory.json.%s", object.i.GetDigestAlgorithm())} {
+		tmp := path.Join(StagingFolder, name)
+		if err := moveToObject(object.workspace, tmp, object.fsys, path.Join(head, name), name); err != nil {
+			return errors.Wrapf(err, "cannot replace '%v/%s'", object.fsys, name)
+		}
+	}
+	for _, name := range []string{StagingFolder, ExtensionsBackupFolder} {
+		if err := removeAll(object.workspace, name); err != nil {
+			return errors.Wrapf(err, "cannot remove '%v/%s'", object.workspace, name)
+		}
+	}
+	pruneWorkspace(object.workspace)
+	object.committed = true
+	object.logger.Debug().Any(
+		object.errorFactory.LogError(
+			ErrorOCFL,
+			fmt.Sprintf("version '%s' of object '%s' promoted", head, object.GetID()),
+			nil,
+		),
+	).Msg("")
+	return nil
+}
+
+// moveToObject copies src of the object to tmp in the workspace and renames it to dst in the object
+func moveToObject(workspace fs.FS, tmp string, fsys fs.FS, src, dst string) error {
+	data, err := fs.ReadFile(fsys, src)
+	if err != nil {
+		return errors.Wrapf(err, "cannot read '%v/%s'", fsys, src)
+	}
+	if _, err := writefs.WriteFile(workspace, tmp, data); err != nil {
+		return errors.Wrapf(err, "cannot write '%v/%s'", workspace, tmp)
+	}
+	return errors.WithStack(renameLocal(workspace, tmp, fsys, dst))
+}
+
+// renameLocal renames src in srcFS to dst in dstFS. Both must be local folders on the same device
+func renameLocal(srcFS fs.FS, src string, dstFS fs.FS, dst string) error {
+	srcPath, err := writefs.Fullpath(srcFS, src)
+	if err != nil {
+		return errors.Wrapf(err, "cannot get path of '%v/%s'", srcFS, src)
+	}
+	dstPath, err := writefs.Fullpath(dstFS, dst)
+	if err != nil {
+		return errors.Wrapf(err, "cannot get path of '%v/%s'", dstFS, dst)
+	}
+	dstPath = filepath.FromSlash(dstPath)
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
+		return errors.Wrapf(err, "cannot create folder '%s'", filepath.Dir(dstPath))
+	}
+	if err := os.Rename(filepath.FromSlash(srcPath), dstPath); err != nil {
+		return errors.Wrapf(err, "cannot move '%s' to '%s'", srcPath, dstPath)
+	}
+	return nil
+}
+
+// discardStaging removes the staging folder and the saved extensions after a rollback
+func (object *ObjectBase) discardStaging() {
+	if _, ok := object.fsys.(*stagingFS); !ok {
+		return
+	}
+	object.fsys = object.fsys.(*stagingFS).fsys
+	_ = removeAll(object.workspace, StagingFolder)
+	_ = removeAll(object.workspace, ExtensionsBackupFolder)
+	pruneWorkspace(object.workspace)
+}
+
+// restoreExtensions replaces the extensions folder of the object with the copy in the workspace
+func restoreExtensions(fsys, workspace fs.FS) error {
+	if _, err := fs.Stat(workspace, ExtensionsBackupFolder); err != nil {
+		return nil
+	}
+	if err := removeAll(fsys, "extensions"); err != nil {
+		return errors.Wrapf(err, "cannot remove '%v/extensions'", fsys)
+	}
+	if err := copyLocal(workspace, ExtensionsBackupFolder, fsys, "extensions"); err != nil {
+		return errors.Wrapf(err, "cannot restore '%v/extensions'", fsys)
+	}
+	return errors.WithStack(removeAll(workspace, ExtensionsBackupFolder))
+}
+
+// copyLocal copies the folder src in srcFS to dst in dstFS. Both must be local folders.
+// dst is created, even if src does not exist
+func copyLocal(srcFS fs.FS, src string, dstFS fs.FS, dst string) error {
+	dstPath, err := writefs.Fullpath(dstFS, dst)
+	if err != nil {
+		return errors.Wrapf(err, "cannot get path of '%v/%s'", dstFS, dst)
+	}
+	dstPath = filepath.FromSlash(dstPath)
+	sub, err := fs.Sub(srcFS, src)
+	if err != nil {
+		return errors.Wrapf(err, "cannot create subfs of '%v' for '%s'", srcFS, src)
+	}
+	if _, err := fs.Stat(srcFS, src); errors.Is(err, fs.ErrNotExist) {
+		return errors.WithStack(os.MkdirAll(dstPath, 0755))
+	}
+	if err := os.CopyFS(dstPath, sub); err != nil {
+		return errors.Wrapf(err, "cannot copy '%v/%s' to '%s'", srcFS, src, dstPath)
+	}
+	return nil
+}
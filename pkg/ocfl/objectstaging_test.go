@@ -0,0 +1,53 @@
+package ocfl_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ocfl-archive/gocfl/v2/pkg/ocfl"
+)
+
+// TestStagingWorkspace checks, that a new version is staged in the workspace of the storage root and not in the object root
+func TestStagingWorkspace(t *testing.T) {
+	tr := newTestRoot(t)
+	if err := tr.update("a", testFiles(map[string]string{"x.txt": "x"})); err != nil {
+		t.Fatal(err)
+	}
+	source := testFiles(map[string]string{"x.txt": "x", "y.txt": "y"})
+	o, err := tr.open("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := o.StartUpdate(context.Background(), source, "test", "tester", "mailto:tester@example.org", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.AddFolder(context.Background(), source, nil, true, "content"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(tr.workspaceFolder("a"), ocfl.StagingFolder, "v2")); err != nil {
+		t.Fatalf("version not staged in workspace: %v", err)
+	}
+	for _, name := range []string{ocfl.StagingFolder, "v2"} {
+		if _, err := os.Stat(filepath.Join(tr.objectFolder("a"), name)); !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("'%s' in object root during update: %v", name, err)
+		}
+	}
+	if err := o.EndUpdate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(tr.objectFolder("a"), "v2", "inventory.json")); err != nil {
+		t.Fatalf("version not promoted: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tr.workspaceFolder("a"), ocfl.StagingFolder)); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("staging folder left behind: %v", err)
+	}
+	if head := tr.head("a"); head != "v2" {
+		t.Fatalf("head is '%s' instead of 'v2'", head)
+	}
+}
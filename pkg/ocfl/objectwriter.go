@@ -0,0 +1,194 @@
+package ocfl
+
+import (
+	"context"
+	"emperror.dev/errors"
+	"fmt"
+	"github.com/je4/filesystem/v3/pkg/writefs"
+	"github.com/je4/utils/v2/pkg/checksum"
+	"io"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// objectWriter streams content into the object.
+// Digests and fixity are computed while writing, the file is registered in the inventory on Close
+type objectWriter struct {
+	ctx       context.Context
+	object    *ObjectBase
+	path      string
+	area      string
+	names     *NamesStruct
+	file      io.WriteCloser
+	cw        *checksum.ChecksumWriter
+	pw        *io.PipeWriter
+	wg        sync.WaitGroup
+	extErrors chan error
+	closed    bool
+}
+
+// Create returns a writer for a new file of the current version.
+// All digests and fixity are computed while writing. On Close the file is registered in the inventory
+// and the extension hooks are called. If content with the same digest already exists, the written file is removed
+// and the existing content is referenced instead. AddFileAfter gets the manifest path of the existing content then.
+// The returned writer implements CloseWithError(error) error, which discards the written content.
+func (object *ObjectBase) Create(path string, area string) (io.WriteCloser, error) {
+	ctx := object.updateContext()
+	path = filepath.ToSlash(path)
+	if !object.i.IsWriteable() {
+		return nil, errors.New("object not writeable")
+	}
+	names, err := object.BuildNames([]string{path}, area)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot create names for '%s'", path)
+	}
+
+	object.logger.Info().Any(
+		object.errorFactory.LogError(
+			ErrorOCFL,
+			fmt.Sprintf("creating file %s:%s", area, path),
+			nil,
+		),
+	).Msg("")
+
+	if err := object.extensionManager.AddFileBefore(ctx, object, nil, path, names.InternalPath, area, false); err != nil {
+		return nil, errors.Wrapf(err, "error on AddFileBefore() extension hook")
+	}
+
+	digestAlgorithms := object.i.GetFixityDigestAlgorithm()
+	if !slices.Contains(digestAlgorithms, object.i.GetDigestAlgorithm()) {
+		digestAlgorithms = append(digestAlgorithms, object.i.GetDigestAlgorithm())
+	}
+
+	file, err := writefs.Create(object.fsys, names.ManifestPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot create '%s'", names.ManifestPath)
+	}
+	pr, pw := io.Pipe()
+	cw, err := checksum.NewChecksumWriter(digestAlgorithms, file, pw)
+	if err != nil {
+		file.Close()
+		pw.Close()
+		return nil, errors.Wrap(err, "cannot create checksum writer")
+	}
+	ow := &objectWriter{
+		ctx:       ctx,
+		object:    object,
+		path:      path,
+		area:      area,
+		names:     names,
+		file:      file,
+		cw:        cw,
+		pw:        pw,
+		extErrors: make(chan error, 1),
+	}
+	object.progress.get(ProgressPhaseCopy).startFile(path)
+	ow.wg.Add(1)
+	go func() {
+		defer ow.wg.Done()
+		if err := object.extensionManager.StreamObject(ctx, object, pr, names.ExternalPaths, names.InternalPath); err != nil {
+			ow.extErrors <- err
+		}
+		// drain the pipe, if the extensions do not read all content
+		io.Copy(io.Discard, pr)
+	}()
+	return ow, nil
+}
+
+func (ow *objectWriter) Write(p []byte) (int, error) {
+	if ow.closed {
+		return 0, errors.Errorf("write to closed file '%s'", ow.path)
+	}
+	if err := ow.ctx.Err(); err != nil {
+		return 0, errors.Wrapf(err, "cannot write '%s'", ow.path)
+	}
+	n, err := ow.cw.Write(p)
+	ow.object.progress.get(ProgressPhaseCopy).addBytes(int64(n))
+	return n, err
+}
+
+// finish closes all writers and waits for the extensions
+func (ow *objectWriter) finish() error {
+	var errs = []error{}
+	if err := ow.cw.Close(); err != nil {
+		errs = append(errs, errors.Wrap(err, "cannot close checksum writer"))
+	}
+	if err := ow.pw.Close(); err != nil {
+		errs = append(errs, errors.Wrap(err, "cannot close pipe writer"))
+	}
+	ow.wg.Wait()
+	if err := ow.file.Close(); err != nil {
+		errs = append(errs, errors.Wrapf(err, "cannot close '%s'", ow.names.ManifestPath))
+	}
+	close(ow.extErrors)
+	if err, ok := <-ow.extErrors; ok {
+		errs = append(errs, errors.Wrapf(err, "error on StreamObject() extension hook for object '%s'", ow.object.GetID()))
+	}
+	return errors.Combine(errs...)
+}
+
+// CloseWithError discards the written content
+func (ow *objectWriter) CloseWithError(cause error) error {
+	if ow.closed {
+		return nil
+	}
+	ow.closed = true
+	ow.object.logger.Info().Any(
+		ow.object.errorFactory.LogError(
+			ErrorOCFL,
+			fmt.Sprintf("discarding '%s'", ow.path),
+			cause,
+		),
+	).Msg("")
+	err := ow.finish()
+	if err2 := writefs.Remove(ow.object.fsys, ow.names.ManifestPath); err2 != nil {
+		err = errors.Combine(err, errors.Wrapf(err2, "cannot remove '%s'", ow.names.ManifestPath))
+	}
+	return err
+}
+
+func (ow *objectWriter) Close() error {
+	if ow.closed {
+		return nil
+	}
+	if err := ow.ctx.Err(); err != nil {
+		return ow.object.rollbackOnCancel(ow.ctx, errors.Combine(errors.Wrapf(err, "cannot write '%s'", ow.path), ow.CloseWithError(err)))
+	}
+	ow.closed = true
+	object := ow.object
+	ctx := ow.ctx
+	if err := ow.finish(); err != nil {
+		return errors.Wrapf(err, "cannot write '%s'", ow.path)
+	}
+	object.progress.get(ProgressPhaseCopy).endFile()
+	checksums, err := ow.cw.GetChecksums()
+	if err != nil {
+		return errors.Wrapf(err, "cannot get checksums of '%s'", ow.path)
+	}
+	digest, ok := checksums[object.i.GetDigestAlgorithm()]
+	if !ok {
+		return errors.Errorf("digest '%s' not generated", object.i.GetDigestAlgorithm())
+	}
+	object.updateFiles = append(object.updateFiles, ow.names.ExternalPaths...)
+
+	manifestPath := ow.names.ManifestPath
+	deduplicated, err := object.deduplicate(ow.names.ExternalPaths[0], manifestPath, digest)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if deduplicated {
+		// AddFileBefore was called in Create, so the extensions get the existing content instead of the removed copy
+		if dups := object.i.GetDuplicates(digest); len(dups) > 0 {
+			manifestPath = dups[0]
+		}
+	} else if err := object.i.AddFile(ow.names.ExternalPaths, manifestPath, checksums); err != nil {
+		return errors.Wrapf(err, "cannot append '%v'/'%s' to inventory", ow.names.ExternalPaths, ow.names.InternalPath)
+	}
+	if err := object.extensionManager.AddFileAfter(ctx, object, nil, ow.names.ExternalPaths, manifestPath, digest, ow.area, false); err != nil {
+		return errors.Wrapf(err, "error on AddFileAfter() extension hook")
+	}
+	return nil
+}
+
+var _ io.WriteCloser = (*objectWriter)(nil)
@@ -0,0 +1,146 @@
+package ocfl_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/ocfl-archive/gocfl/v2/pkg/ocfl"
+)
+
+// TestCreate writes a file with the object writer and checks inventory, content and extension hooks
+func TestCreate(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		content string
+		close   func(w io.WriteCloser, cancel context.CancelFunc) error
+		fail    bool
+		calls   []string
+		head    string
+		added   bool
+		stored  bool
+	}{
+		{
+			name:    "write",
+			content: "new",
+			close:   func(w io.WriteCloser, cancel context.CancelFunc) error { return w.Close() },
+			calls:   []string{"before new.txt", "after new.txt"},
+			head:    "v2",
+			added:   true,
+			stored:  true,
+		},
+		{
+			name:    "deduplicate",
+			content: "x",
+			close:   func(w io.WriteCloser, cancel context.CancelFunc) error { return w.Close() },
+			calls:   []string{"before new.txt", "after new.txt"},
+			head:    "v2",
+			added:   true,
+		},
+		{
+			name:    "CloseWithError",
+			content: "new",
+			close: func(w io.WriteCloser, cancel context.CancelFunc) error {
+				return w.(interface{ CloseWithError(error) error }).CloseWithError(errors.New("discard"))
+			},
+			calls: []string{"before new.txt"},
+			head:  "v1",
+		},
+		{
+			name:    "cancelled context",
+			content: "new",
+			close: func(w io.WriteCloser, cancel context.CancelFunc) error {
+				cancel()
+				return w.Close()
+			},
+			fail:  true,
+			calls: []string{"before new.txt"},
+			head:  "v1",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			tr := newTestRoot(t)
+			if err := tr.update("a", testFiles(map[string]string{"x.txt": "x"})); err != nil {
+				t.Fatal(err)
+			}
+			o, err := tr.open("a")
+			if err != nil {
+				t.Fatal(err)
+			}
+			record := &recordExtension{}
+			if err := o.GetExtensionManager().Add(record); err != nil {
+				t.Fatal(err)
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			source := testFiles(map[string]string{"x.txt": "x"})
+			if _, err := o.StartUpdate(ctx, source, "test", "tester", "mailto:tester@example.org", false); err != nil {
+				t.Fatal(err)
+			}
+			if err := o.AddFolder(ctx, source, nil, true, "content"); err != nil {
+				t.Fatal(err)
+			}
+			w, err := o.Create("new.txt", "content")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Write([]byte(test.content)); err != nil {
+				t.Fatal(err)
+			}
+			err = test.close(w, cancel)
+			if test.fail {
+				if !errors.Is(err, context.Canceled) {
+					t.Fatalf("expected cancellation, got %v", err)
+				}
+			} else {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := o.EndUpdate(); err != nil {
+					t.Fatal(err)
+				}
+				if err := o.Close(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if !slices.Equal(record.calls, test.calls) {
+				t.Fatalf("expected hooks %v, got %v", test.calls, record.calls)
+			}
+			if head := tr.head("a"); head != test.head {
+				t.Fatalf("head is '%s' instead of '%s'", head, test.head)
+			}
+			if _, err := os.Stat(filepath.Join(tr.workspaceFolder("a"), ocfl.LockFile)); !errors.Is(err, os.ErrNotExist) {
+				t.Fatalf("lock file left behind: %v", err)
+			}
+			if _, err := os.Stat(filepath.Join(tr.objectFolder("a"), "v2", "content", "new.txt")); test.stored != (err == nil) {
+				t.Fatalf("content stored %v: %v", test.stored, err)
+			}
+			loaded, err := tr.load().LoadObjectByID("a")
+			if err != nil {
+				t.Fatal(err)
+			}
+			vfs, err := loaded.VersionFS("latest", "content")
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := fs.ReadFile(vfs, "new.txt")
+			if !test.added {
+				if !errors.Is(err, fs.ErrNotExist) {
+					t.Fatalf("discarded file in version: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != test.content {
+				t.Fatalf("expected '%s', got '%s'", test.content, data)
+			}
+		})
+	}
+}
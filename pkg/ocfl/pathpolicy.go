@@ -0,0 +1,182 @@
+package ocfl
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"emperror.dev/errors"
+	"golang.org/x/text/unicode/norm"
+)
+
+// PathPolicyAction is the reaction on a path, which violates a rule of the path policy
+type PathPolicyAction string
+
+const (
+	PathPolicyIgnore PathPolicyAction = "ignore"
+	PathPolicyWarn   PathPolicyAction = "warn"
+	PathPolicyReject PathPolicyAction = "reject"
+	PathPolicyFix    PathPolicyAction = "fix"
+)
+
+var ErrPathPolicy = errors.New("path rejected by path policy")
+
+func NewPathPolicyAction(action string) (PathPolicyAction, error) {
+	switch a := PathPolicyAction(strings.ToLower(strings.TrimSpace(action))); a {
+	case "":
+		return PathPolicyIgnore, nil
+	case PathPolicyIgnore, PathPolicyWarn, PathPolicyReject, PathPolicyFix:
+		return a, nil
+	default:
+		return "", errors.Errorf("invalid path policy action '%s' (ignore|warn|reject|fix)", action)
+	}
+}
+
+// PathPolicy defines the handling of logical paths on ingest, which are not portable
+type PathPolicy struct {
+	// Normalization checks for unicode normalization form NFC (macOS delivers NFD)
+	Normalization PathPolicyAction
+	// CaseCollision checks for paths, which differ only in case
+	CaseCollision PathPolicyAction
+	// Windows checks for reserved names and characters on windows
+	Windows PathPolicyAction
+}
+
+func NewPathPolicy(normalization, caseCollision, windows string) (*PathPolicy, error) {
+	var err error
+	policy := &PathPolicy{}
+	if policy.Normalization, err = NewPathPolicyAction(normalization); err != nil {
+		return nil, errors.Wrap(err, "normalization")
+	}
+	if policy.CaseCollision, err = NewPathPolicyAction(caseCollision); err != nil {
+		return nil, errors.Wrap(err, "case collision")
+	}
+	if policy.Windows, err = NewPathPolicyAction(windows); err != nil {
+		return nil, errors.Wrap(err, "windows")
+	}
+	return policy, nil
+}
+
+// IsEmpty returns true, if all rules are ignored
+func (policy *PathPolicy) IsEmpty() bool {
+	return policy == nil ||
+		(policy.Normalization == PathPolicyIgnore && policy.CaseCollision == PathPolicyIgnore && policy.Windows == PathPolicyIgnore)
+}
+
+// PathViolation is a path, which violates a rule of the path policy
+type PathViolation struct {
+	Path    string           `json:"path"`
+	Fixed   string           `json:"fixed,omitempty"`
+	Rule    string           `json:"rule"`
+	Problem string           `json:"problem"`
+	Action  PathPolicyAction `json:"action"`
+}
+
+func (v *PathViolation) String() string {
+	if v.Fixed != "" {
+		return fmt.Sprintf("%s: '%s' %s - renamed to '%s'", v.Rule, v.Path, v.Problem, v.Fixed)
+	}
+	return fmt.Sprintf("%s: '%s' %s", v.Rule, v.Path, v.Problem)
+}
+
+// fixName applies the normalization and windows rules to one element of a path.
+// Violations are returned for all rules, which are not ignored
+func (policy *PathPolicy) fixName(name, fullpath string) (string, []*PathViolation) {
+	var violations = []*PathViolation{}
+	if policy.Normalization != PathPolicyIgnore && !IsNFC(name) {
+		v := &PathViolation{Path: fullpath, Rule: "normalization", Problem: "is not NFC normalized", Action: policy.Normalization}
+		if policy.Normalization == PathPolicyFix {
+			name = norm.NFC.String(name)
+		}
+		violations = append(violations, v)
+	}
+	if policy.Windows != PathPolicyIgnore {
+		if problem := WindowsNameProblem(name); problem != "" {
+			v := &PathViolation{Path: fullpath, Rule: "windows", Problem: problem, Action: policy.Windows}
+			if policy.Windows == PathPolicyFix {
+				name = FixWindowsName(name)
+			}
+			violations = append(violations, v)
+		}
+	}
+	return name, violations
+}
+
+// fixPath applies the normalization and windows rules with action fix to all elements of name
+func (policy *PathPolicy) fixPath(name string) string {
+	if policy == nil || (policy.Normalization != PathPolicyFix && policy.Windows != PathPolicyFix) {
+		return name
+	}
+	parts := strings.Split(name, "/")
+	for i, part := range parts {
+		if policy.Normalization == PathPolicyFix {
+			part = norm.NFC.String(part)
+		}
+		if policy.Windows == PathPolicyFix && WindowsNameProblem(part) != "" {
+			part = FixWindowsName(part)
+		}
+		parts[i] = part
+	}
+	return strings.Join(parts, "/")
+}
+
+// IsNFC returns true, if name is in unicode normalization form NFC
+func IsNFC(name string) bool {
+	return norm.NFC.IsNormalString(name)
+}
+
+// FoldPath returns the representation of name, which is used to find paths differing only in case
+func FoldPath(name string) string {
+	return strings.ToLower(norm.NFC.String(name))
+}
+
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+var windowsReservedChars = regexp.MustCompile("[\x00-\x1F<>:\"\\\\|?*]")
+
+// WindowsNameProblem checks one element of a path for names and characters, which cannot be used on windows.
+// It returns an empty string, if name is valid
+func WindowsNameProblem(name string) string {
+	if c := windowsReservedChars.FindString(name); c != "" {
+		if c[0] < 0x20 {
+			return fmt.Sprintf("contains control character 0x%02x", c[0])
+		}
+		return fmt.Sprintf("contains reserved character '%s'", c)
+	}
+	if strings.HasSuffix(name, ".") || strings.HasSuffix(name, " ") {
+		return "ends with dot or space"
+	}
+	stem, _, _ := strings.Cut(name, ".")
+	if windowsReservedNames[strings.ToUpper(strings.TrimRight(stem, " "))] {
+		return fmt.Sprintf("is reserved name '%s'", stem)
+	}
+	return ""
+}
+
+// FixWindowsName replaces reserved characters with '_', removes trailing dots and spaces and prefixes reserved names with '_'
+func FixWindowsName(name string) string {
+	name = windowsReservedChars.ReplaceAllString(name, "_")
+	name = strings.TrimRight(name, ". ")
+	if name == "" {
+		return "_"
+	}
+	stem, _, _ := strings.Cut(name, ".")
+	if windowsReservedNames[strings.ToUpper(strings.TrimRight(stem, " "))] {
+		name = "_" + name
+	}
+	return name
+}
+
+// collisionName returns a variant of name with a counter before the extension
+func collisionName(name string, counter int) string {
+	ext := path.Ext(path.Base(name))
+	if ext == path.Base(name) {
+		ext = ""
+	}
+	return fmt.Sprintf("%s_%d%s", strings.TrimSuffix(name, ext), counter, ext)
+}
@@ -0,0 +1,286 @@
+package ocfl_test
+
+import (
+	"context"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"slices"
+	"testing"
+
+	"github.com/je4/utils/v2/pkg/checksum"
+	"github.com/ocfl-archive/gocfl/v2/pkg/ocfl"
+)
+
+// policyFiles returns the logical paths of all files of fsys
+func policyFiles(t *testing.T, fsys fs.FS) []string {
+	t.Helper()
+	var files = []string{}
+	if err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			files = append(files, path)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+// TestPathPolicyFS applies every action of the policy to the paths, which violate one rule
+func TestPathPolicyFS(t *testing.T) {
+	tr := newTestRoot(t)
+	for _, rule := range []struct {
+		name   string
+		policy func(action string) (*ocfl.PathPolicy, error)
+		files  []string
+		fixed  []string
+	}{
+		{
+			name:   "normalization",
+			policy: func(action string) (*ocfl.PathPolicy, error) { return ocfl.NewPathPolicy(action, "ignore", "ignore") },
+			files:  []string{"cafe\u0301/x.txt"},
+			fixed:  []string{"caf\u00e9/x.txt"},
+		},
+		{
+			name:   "windows reserved name",
+			policy: func(action string) (*ocfl.PathPolicy, error) { return ocfl.NewPathPolicy("ignore", "ignore", action) },
+			files:  []string{"CON.txt"},
+			fixed:  []string{"_CON.txt"},
+		},
+		{
+			name:   "windows reserved character",
+			policy: func(action string) (*ocfl.PathPolicy, error) { return ocfl.NewPathPolicy("ignore", "ignore", action) },
+			files:  []string{"a:b.txt"},
+			fixed:  []string{"a_b.txt"},
+		},
+		{
+			name:   "case collision",
+			policy: func(action string) (*ocfl.PathPolicy, error) { return ocfl.NewPathPolicy("ignore", action, "ignore") },
+			files:  []string{"A.txt", "a.txt"},
+			fixed:  []string{"A.txt", "a_1.txt"},
+		},
+	} {
+		for _, test := range []struct {
+			action     string
+			violations int
+			reject     bool
+			fixed      bool
+		}{
+			{action: "ignore"},
+			{action: "warn", violations: 1},
+			{action: "reject", reject: true},
+			{action: "fix", violations: 1, fixed: true},
+		} {
+			t.Run(rule.name+"/"+test.action, func(t *testing.T) {
+				policy, err := rule.policy(test.action)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var files = map[string]string{}
+				for _, name := range rule.files {
+					files[name] = name
+				}
+				pfs, err := ocfl.NewPathPolicyFS(testFiles(files), policy, tr.logger, tr.errorFactory)
+				if test.reject {
+					if !errors.Is(err, ocfl.ErrPathPolicy) {
+						t.Fatalf("expected rejection, got %v", err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				expected := rule.files
+				if test.fixed {
+					expected = rule.fixed
+				}
+				if got := policyFiles(t, pfs); !slices.Equal(got, expected) {
+					t.Fatalf("expected %v, got %v", expected, got)
+				}
+				violations := pfs.Violations()
+				if len(violations) != test.violations {
+					t.Fatalf("expected %d violations, got %v", test.violations, violations)
+				}
+				for _, v := range violations {
+					if test.fixed != (v.Fixed != "") {
+						t.Fatalf("violation '%s' with action %s", v, v.Action)
+					}
+				}
+				// the logical paths read the content of the source paths
+				for i, name := range expected {
+					data, err := fs.ReadFile(pfs, name)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if string(data) != rule.files[i] {
+						t.Fatalf("'%s' reads '%s' instead of '%s'", name, data, rule.files[i])
+					}
+				}
+			})
+		}
+	}
+}
+
+// TestPathPolicyCollisions checks the names of colliding paths within the source and against the object
+func TestPathPolicyCollisions(t *testing.T) {
+	tr := newTestRoot(t)
+	policy, err := ocfl.NewPathPolicy("fix", "fix", "fix")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("source", func(t *testing.T) {
+		for _, test := range []struct {
+			name     string
+			files    []string
+			expected []string
+		}{
+			{
+				name:     "three",
+				files:    []string{"A.txt", "a.txt", "a_1.txt"},
+				expected: []string{"A.txt", "a_1.txt", "a_1_1.txt"},
+			},
+			{
+				name:     "fixed name",
+				files:    []string{"a:b.txt", "a_b.txt"},
+				expected: []string{"a_b.txt", "a_b_1.txt"},
+			},
+			{
+				name:     "folder",
+				files:    []string{"Dir/x.txt", "dir/y.txt"},
+				expected: []string{"Dir/x.txt", "dir_1/y.txt"},
+			},
+		} {
+			t.Run(test.name, func(t *testing.T) {
+				var files = map[string]string{}
+				for _, name := range test.files {
+					files[name] = name
+				}
+				pfs, err := ocfl.NewPathPolicyFS(testFiles(files), policy, tr.logger, tr.errorFactory)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := policyFiles(t, pfs); !slices.Equal(got, test.expected) {
+					t.Fatalf("expected %v, got %v", test.expected, got)
+				}
+			})
+		}
+	})
+
+	t.Run("object", func(t *testing.T) {
+		if err := tr.update("a", testFiles(map[string]string{"README.txt": "old", "readme_1.txt": "other"})); err != nil {
+			t.Fatal(err)
+		}
+		pfs, err := ocfl.NewPathPolicyFS(testFiles(map[string]string{"readme.txt": "new"}), policy, tr.logger, tr.errorFactory)
+		if err != nil {
+			t.Fatal(err)
+		}
+		o, err := tr.open("a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := updateObject(o, pfs); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := tr.load().LoadObjectByID("a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		vfs, err := loaded.VersionFS("latest", "content")
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := fs.ReadFile(vfs, "readme_2.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "new" {
+			t.Fatalf("expected 'new', got '%s'", data)
+		}
+	})
+
+	t.Run("reject object", func(t *testing.T) {
+		policy, err := ocfl.NewPathPolicy("ignore", "reject", "ignore")
+		if err != nil {
+			t.Fatal(err)
+		}
+		pfs, err := ocfl.NewPathPolicyFS(testFiles(map[string]string{"readme.txt": "new"}), policy, tr.logger, tr.errorFactory)
+		if err != nil {
+			t.Fatal(err)
+		}
+		o, err := tr.open("a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := updateObject(o, pfs); !errors.Is(err, ocfl.ErrPathPolicy) {
+			t.Fatalf("expected rejection, got %v", err)
+		}
+	})
+}
+
+// TestPathPolicyTrustedDigests checks, that the trusted digest of a source path is used for the renamed file.
+// The trusted digest is wrong on purpose, so it shows up in the plan and fails the ingest
+func TestPathPolicyTrustedDigests(t *testing.T) {
+	tr := newTestRoot(t)
+	if err := tr.update("a", testFiles(map[string]string{"x.txt": "x"})); err != nil {
+		t.Fatal(err)
+	}
+	policy, err := ocfl.NewPathPolicy("ignore", "ignore", "fix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha512.Sum512([]byte("other"))
+	trusted := &ocfl.TrustedDigests{
+		Algorithm: checksum.DigestSHA512,
+		Area:      "content",
+		Digests:   map[string]string{"a:b.txt": hex.EncodeToString(sum[:])},
+	}
+	source := func() fs.FS {
+		pfs, err := ocfl.NewPathPolicyFS(testFiles(map[string]string{"x.txt": "x", "a:b.txt": "new"}), policy, tr.logger, tr.errorFactory)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pfs
+	}
+
+	t.Run("plan", func(t *testing.T) {
+		o, err := tr.open("a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		o.SetTrustedDigests(trusted)
+		plan, err := o.Plan(context.Background(), source(), "content", nil, true, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var found bool
+		for _, entry := range plan.Entries {
+			if entry.Path != "a_b.txt" {
+				continue
+			}
+			found = true
+			if entry.Digest != trusted.Digests["a:b.txt"] {
+				t.Fatalf("planned digest %s instead of the trusted digest", entry.Digest)
+			}
+		}
+		if !found {
+			t.Fatalf("'a_b.txt' not planned: %v", plan.Entries)
+		}
+	})
+
+	t.Run("ingest", func(t *testing.T) {
+		o, err := tr.open("a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		o.SetTrustedDigests(trusted)
+		if err := updateObject(o, source()); !errors.Is(err, ocfl.ErrDigestMismatch) {
+			t.Fatalf("expected digest mismatch, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,319 @@
+package ocfl
+
+import (
+	"cmp"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"sync"
+
+	"emperror.dev/errors"
+	"github.com/je4/filesystem/v3/pkg/writefs"
+	"github.com/je4/utils/v2/pkg/zLogger"
+	archiveerror "github.com/ocfl-archive/error/pkg/error"
+)
+
+// policyDirEntry is a directory entry with the logical name of the path policy
+type policyDirEntry struct {
+	fs.DirEntry
+	name string
+}
+
+func (entry *policyDirEntry) Name() string {
+	return entry.name
+}
+
+// PathPolicyFS presents the files of a source folder with the logical paths of a path policy.
+// The whole tree is checked on creation, so rejected paths stop the ingest before anything is written
+type PathPolicyFS struct {
+	sync.Mutex
+	fsys         fs.FS
+	policy       *PathPolicy
+	logger       zLogger.ZLogger
+	errorFactory *archiveerror.Factory
+	// logical path -> path in fsys
+	source map[string]string
+	// logical folder -> entries with logical names
+	dirs map[string][]fs.DirEntry
+	// folded logical path -> logical path
+	folded     map[string]string
+	violations []*PathViolation
+}
+
+func NewPathPolicyFS(fsys fs.FS, policy *PathPolicy, logger zLogger.ZLogger, errorFactory *archiveerror.Factory) (*PathPolicyFS, error) {
+	pfs := &PathPolicyFS{
+		fsys:         fsys,
+		policy:       policy,
+		logger:       logger,
+		errorFactory: errorFactory,
+		source:       map[string]string{".": "."},
+		dirs:         map[string][]fs.DirEntry{},
+		folded:       map[string]string{},
+		violations:   []*PathViolation{},
+	}
+	if err := pfs.readDir(".", "."); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := pfs.rejected(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return pfs, nil
+}
+
+func (pfs *PathPolicyFS) String() string {
+	return fmt.Sprintf("%v", pfs.fsys)
+}
+
+// readDir reads the folder sourceDir of fsys recursively and builds the logical names
+func (pfs *PathPolicyFS) readDir(logicalDir, sourceDir string) error {
+	entries, err := fs.ReadDir(pfs.fsys, sourceDir)
+	if err != nil {
+		return errors.Wrapf(err, "cannot read folder '%v/%s'", pfs.fsys, sourceDir)
+	}
+	var result = []fs.DirEntry{}
+	for _, entry := range entries {
+		sourcePath := path.Join(sourceDir, entry.Name())
+		name, violations := pfs.policy.fixName(entry.Name(), sourcePath)
+		logicalPath := path.Join(logicalDir, name)
+		for _, v := range violations {
+			if v.Action == PathPolicyFix {
+				v.Fixed = logicalPath
+			}
+			pfs.addViolation(v)
+		}
+		logicalPath = pfs.checkCollision(logicalPath, sourcePath, func(name string) bool {
+			_, ok := pfs.folded[FoldPath(name)]
+			return ok
+		})
+		pfs.folded[FoldPath(logicalPath)] = logicalPath
+		pfs.source[logicalPath] = sourcePath
+		result = append(result, &policyDirEntry{DirEntry: entry, name: path.Base(logicalPath)})
+		if entry.IsDir() {
+			if err := pfs.readDir(logicalPath, sourcePath); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	}
+	slices.SortFunc(result, func(a, b fs.DirEntry) int {
+		return cmp.Compare(a.Name(), b.Name())
+	})
+	pfs.dirs[logicalDir] = result
+	return nil
+}
+
+// checkCollision applies the case collision rule to logicalPath. exists reports paths, which are already used
+func (pfs *PathPolicyFS) checkCollision(logicalPath, sourcePath string, exists func(name string) bool) string {
+	if pfs.policy.CaseCollision == PathPolicyIgnore || !exists(logicalPath) {
+		return logicalPath
+	}
+	v := &PathViolation{
+		Path:    sourcePath,
+		Rule:    "case collision",
+		Problem: fmt.Sprintf("collides with '%s'", pfs.folded[FoldPath(logicalPath)]),
+		Action:  pfs.policy.CaseCollision,
+	}
+	if v.Action == PathPolicyFix {
+		for counter := 1; ; counter++ {
+			if name := collisionName(logicalPath, counter); !exists(name) {
+				logicalPath = name
+				break
+			}
+		}
+		v.Fixed = logicalPath
+	}
+	pfs.addViolation(v)
+	return logicalPath
+}
+
+func (pfs *PathPolicyFS) addViolation(v *PathViolation) {
+	pfs.violations = append(pfs.violations, v)
+	switch v.Action {
+	case PathPolicyWarn:
+		pfs.logger.Warn().Any(pfs.errorFactory.LogError(ErrorOCFL, fmt.Sprintf("path policy: %s", v), nil)).Msg("")
+	case PathPolicyReject:
+		pfs.logger.Error().Any(pfs.errorFactory.LogError(ErrorOCFL, fmt.Sprintf("path policy: %s", v), nil)).Msg("")
+	case PathPolicyFix:
+		pfs.logger.Info().Any(pfs.errorFactory.LogError(ErrorOCFL, fmt.Sprintf("path policy: %s", v), nil)).Msg("")
+	}
+}
+
+// rejected returns an error, if there are violations with action reject
+func (pfs *PathPolicyFS) rejected() error {
+	var count int
+	for _, v := range pfs.violations {
+		if v.Action == PathPolicyReject {
+			count++
+		}
+	}
+	if count > 0 {
+		return errors.Wrapf(ErrPathPolicy, "%d paths in '%v'", count, pfs.fsys)
+	}
+	return nil
+}
+
+// CheckState applies the case collision rule to the logical paths against the paths of the object.
+// statePath maps a logical path to the path in the object state
+func (pfs *PathPolicyFS) CheckState(statePath func(name string) (string, error), state []string) error {
+	pfs.Lock()
+	defer pfs.Unlock()
+	if pfs.policy.CaseCollision == PathPolicyIgnore || len(state) == 0 {
+		return nil
+	}
+	var stateFolded = map[string]string{}
+	for _, name := range state {
+		stateFolded[FoldPath(name)] = name
+	}
+	var files = []string{}
+	for logicalPath := range pfs.source {
+		if _, isDir := pfs.dirs[logicalPath]; !isDir {
+			files = append(files, logicalPath)
+		}
+	}
+	slices.Sort(files)
+	for _, logicalPath := range files {
+		newPath, err := statePath(logicalPath)
+		if err != nil {
+			return errors.Wrapf(err, "cannot map external path '%s'", logicalPath)
+		}
+		existing, ok := stateFolded[FoldPath(newPath)]
+		if !ok || existing == newPath {
+			continue
+		}
+		v := &PathViolation{
+			Path:    pfs.source[logicalPath],
+			Rule:    "case collision",
+			Problem: fmt.Sprintf("collides with '%s' in object", existing),
+			Action:  pfs.policy.CaseCollision,
+		}
+		if v.Action == PathPolicyFix {
+			for counter := 1; ; counter++ {
+				name := collisionName(logicalPath, counter)
+				if _, ok := pfs.folded[FoldPath(name)]; ok {
+					continue
+				}
+				nameState, err := statePath(name)
+				if err != nil {
+					return errors.Wrapf(err, "cannot map external path '%s'", name)
+				}
+				if _, ok := stateFolded[FoldPath(nameState)]; ok {
+					continue
+				}
+				pfs.rename(logicalPath, name)
+				v.Fixed = name
+				break
+			}
+		}
+		pfs.addViolation(v)
+	}
+	return errors.WithStack(pfs.rejected())
+}
+
+// rename moves the file logicalPath to name in the same folder
+func (pfs *PathPolicyFS) rename(logicalPath, name string) {
+	dir := path.Dir(logicalPath)
+	for i, entry := range pfs.dirs[dir] {
+		if path.Join(dir, entry.Name()) == logicalPath {
+			pfs.dirs[dir][i] = &policyDirEntry{DirEntry: entry, name: path.Base(name)}
+		}
+	}
+	slices.SortFunc(pfs.dirs[dir], func(a, b fs.DirEntry) int {
+		return cmp.Compare(a.Name(), b.Name())
+	})
+	pfs.source[name] = pfs.source[logicalPath]
+	delete(pfs.source, logicalPath)
+	delete(pfs.folded, FoldPath(logicalPath))
+	pfs.folded[FoldPath(name)] = name
+}
+
+// trustedDigests returns a copy of trusted, which maps the logical paths instead of the source paths to the digests
+func (pfs *PathPolicyFS) trustedDigests(trusted *TrustedDigests) *TrustedDigests {
+	pfs.Lock()
+	defer pfs.Unlock()
+	result := &TrustedDigests{
+		Algorithm: trusted.Algorithm,
+		Area:      trusted.Area,
+		Digests:   map[string]string{},
+	}
+	for logicalPath, sourcePath := range pfs.source {
+		if digest, ok := trusted.Digests[sourcePath]; ok {
+			result.Digests[logicalPath] = digest
+		}
+	}
+	return result
+}
+
+// Violations returns all paths, which violate a rule of the path policy
+func (pfs *PathPolicyFS) Violations() []*PathViolation {
+	pfs.Lock()
+	defer pfs.Unlock()
+	return slices.Clone(pfs.violations)
+}
+
+func (pfs *PathPolicyFS) sourcePath(op, name string) (string, error) {
+	pfs.Lock()
+	defer pfs.Unlock()
+	sourcePath, ok := pfs.source[name]
+	if !ok {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return sourcePath, nil
+}
+
+func (pfs *PathPolicyFS) Open(name string) (fs.File, error) {
+	sourcePath, err := pfs.sourcePath("open", name)
+	if err != nil {
+		return nil, err
+	}
+	return pfs.fsys.Open(sourcePath)
+}
+
+func (pfs *PathPolicyFS) Stat(name string) (fs.FileInfo, error) {
+	sourcePath, err := pfs.sourcePath("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return fs.Stat(pfs.fsys, sourcePath)
+}
+
+func (pfs *PathPolicyFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	pfs.Lock()
+	defer pfs.Unlock()
+	entries, ok := pfs.dirs[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	return slices.Clone(entries), nil
+}
+
+func (pfs *PathPolicyFS) Fullpath(name string) (string, error) {
+	sourcePath, err := pfs.sourcePath("fullpath", name)
+	if err != nil {
+		return "", err
+	}
+	return writefs.Fullpath(pfs.fsys, sourcePath)
+}
+
+// SkippedFiles returns the files hidden by the underlying filesystem with fixed names
+func (pfs *PathPolicyFS) SkippedFiles() ([]string, error) {
+	skippedFS, ok := pfs.fsys.(SkippedFilesFS)
+	if !ok {
+		return []string{}, nil
+	}
+	files, err := skippedFS.SkippedFiles()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var result = []string{}
+	for _, file := range files {
+		result = append(result, pfs.policy.fixPath(file))
+	}
+	return result, nil
+}
+
+var (
+	_ fs.ReadDirFS       = (*PathPolicyFS)(nil)
+	_ fs.StatFS          = (*PathPolicyFS)(nil)
+	_ writefs.FullpathFS = (*PathPolicyFS)(nil)
+	_ SkippedFilesFS     = (*PathPolicyFS)(nil)
+)
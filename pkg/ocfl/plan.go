@@ -0,0 +1,332 @@
+package ocfl
+
+import (
+	"context"
+	"emperror.dev/errors"
+	"fmt"
+	"github.com/je4/utils/v2/pkg/checksum"
+	"github.com/je4/utils/v2/pkg/zLogger"
+	archiveerror "github.com/ocfl-archive/error/pkg/error"
+	"io/fs"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+type PlanAction string
+
+const (
+	PlanActionAdded        PlanAction = "added"
+	PlanActionReplaced     PlanAction = "replaced"
+	PlanActionUnchanged    PlanAction = "unchanged"
+	PlanActionDeduplicated PlanAction = "deduplicated"
+	PlanActionDeleted      PlanAction = "deleted"
+	PlanActionRenamed      PlanAction = "renamed"
+)
+
+// PlanEntry is the planned change of one logical path
+type PlanEntry struct {
+	Action         PlanAction `json:"action"`
+	Path           string     `json:"path"`
+	PreviousPath   string     `json:"previousPath,omitempty"`
+	Source         string     `json:"source,omitempty"`
+	Area           string     `json:"area,omitempty"`
+	Digest         string     `json:"digest,omitempty"`
+	PreviousDigest string     `json:"previousDigest,omitempty"`
+	Size           int64      `json:"size"`
+}
+
+// UpdatePlan describes what an update of an object would do. BytesWritten is the amount of content,
+// which would be copied into the object
+type UpdatePlan struct {
+	ObjectID     string       `json:"objectID"`
+	Head         string       `json:"head,omitempty"`
+	Echo         bool         `json:"echo"`
+	Deduplicate  bool         `json:"deduplicate"`
+	Entries      []*PlanEntry `json:"entries"`
+	Added        int64        `json:"added"`
+	Replaced     int64        `json:"replaced"`
+	Unchanged    int64        `json:"unchanged"`
+	Deduplicated int64        `json:"deduplicated"`
+	Deleted      int64        `json:"deleted"`
+	Renamed      int64        `json:"renamed"`
+	BytesWritten int64        `json:"bytesWritten"`
+}
+
+func (plan *UpdatePlan) add(entry *PlanEntry) {
+	plan.Entries = append(plan.Entries, entry)
+	switch entry.Action {
+	case PlanActionAdded:
+		plan.Added++
+	case PlanActionReplaced:
+		plan.Replaced++
+	case PlanActionUnchanged:
+		plan.Unchanged++
+	case PlanActionDeduplicated:
+		plan.Deduplicated++
+	case PlanActionDeleted:
+		plan.Deleted++
+	case PlanActionRenamed:
+		plan.Renamed++
+	}
+}
+
+// NewPlanObject creates an object, which exists only in memory.
+// It is used to plan the ingest into an object, which does not exist yet
+func NewPlanObject(
+	ctx context.Context,
+	id string,
+	version OCFLVersion,
+	digest checksum.DigestAlgorithm,
+	fixity []checksum.DigestAlgorithm,
+	extensionManager ExtensionManager,
+	logger zLogger.ZLogger,
+	errorFactory *archiveerror.Factory,
+) (Object, error) {
+	if version == "" {
+		return nil, errors.New("no ocfl version for plan object")
+	}
+	o, err := newObject(ctx, nil, nil, version, nil, extensionManager, logger, errorFactory)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot instantiate object")
+	}
+	planObject, ok := o.(interface {
+		initPlan(id string, digest checksum.DigestAlgorithm, fixity []checksum.DigestAlgorithm) error
+	})
+	if !ok {
+		return nil, errors.Errorf("object version '%s' cannot be planned", version)
+	}
+	if err := planObject.initPlan(id, digest, fixity); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return o, nil
+}
+
+func (object *ObjectBase) initPlan(id string, digest checksum.DigestAlgorithm, fixity []checksum.DigestAlgorithm) error {
+	i, err := object.CreateInventory(id, digest, fixity)
+	if err != nil {
+		return errors.Wrapf(err, "cannot create inventory for '%s'", id)
+	}
+	object.i = i
+	return nil
+}
+
+// Plan computes the changes of an update with the content of fsys and areaPaths against the current inventory.
+// Nothing is written, but all files without trusted digest are read to create their digests
+func (object *ObjectBase) Plan(ctx context.Context, fsys fs.FS, area string, areaPaths map[string]fs.FS, checkDuplicate bool, echo bool) (*UpdatePlan, error) {
+	plan := &UpdatePlan{
+		ObjectID:    object.GetID(),
+		Echo:        echo,
+		Deduplicate: checkDuplicate,
+		Entries:     []*PlanEntry{},
+	}
+	// logical path -> digest of the current version
+	state := map[string]string{}
+	if len(object.i.GetVersionStrings()) > 0 {
+		plan.Head = object.i.GetHead()
+		if err := object.i.IterateStateFiles(plan.Head, func(internal []string, external []string, digest string) error {
+			for _, name := range external {
+				state[name] = digest
+			}
+			return nil
+		}); err != nil {
+			return nil, errors.Wrapf(err, "cannot get state of version '%s'", plan.Head)
+		}
+	}
+	digests := map[string]bool{}
+	for digest := range object.i.GetManifest() {
+		digests[digest] = true
+	}
+	hashProgress := newProgress(ctx, object.GetID(), ProgressPhaseHash)
+	var seen = []string{}
+	planFolder := func(fsys fs.FS, area string) error {
+		if err := object.checkStatePaths(fsys, area, echo); err != nil {
+			return errors.WithStack(err)
+		}
+		defer object.policyTrustedDigests(fsys)()
+		if err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if err := ctx.Err(); err != nil {
+				return errors.WithStack(err)
+			}
+			if d.IsDir() {
+				return nil
+			}
+			path = filepath.ToSlash(path)
+			names, err := object.BuildNames([]string{path}, area)
+			if err != nil {
+				return errors.Wrapf(err, "cannot create virtual filename for '%s'", path)
+			}
+			entry, err := object.planFile(ctx, fsys, path, area, names.ExternalPaths[0], hashProgress)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			seen = append(seen, names.ExternalPaths...)
+			previous, exists := state[entry.Path]
+			switch {
+			case exists && previous == entry.Digest:
+				entry.Action = PlanActionUnchanged
+				if !checkDuplicate {
+					// without deduplication the content is copied again
+					plan.BytesWritten += entry.Size
+				}
+			case checkDuplicate && digests[entry.Digest]:
+				entry.Action = PlanActionDeduplicated
+				entry.PreviousDigest = previous
+			case exists:
+				entry.Action = PlanActionReplaced
+				entry.PreviousDigest = previous
+				plan.BytesWritten += entry.Size
+			default:
+				entry.Action = PlanActionAdded
+				plan.BytesWritten += entry.Size
+			}
+			digests[entry.Digest] = true
+			plan.add(entry)
+			return nil
+		}); err != nil {
+			return errors.WithStack(err)
+		}
+		if echo {
+			// hidden files are kept
+			skipped, err := object.skippedStatePaths(fsys, area)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			seen = append(seen, skipped...)
+		}
+		return nil
+	}
+	if err := planFolder(fsys, area); err != nil {
+		return nil, errors.Wrapf(err, "cannot plan folder '%v'", fsys)
+	}
+	var areas = []string{}
+	for a := range areaPaths {
+		areas = append(areas, a)
+	}
+	slices.Sort(areas)
+	for _, a := range areas {
+		if err := planFolder(areaPaths[a], a); err != nil {
+			return nil, errors.Wrapf(err, "cannot plan area '%s' folder '%v'", a, areaPaths[a])
+		}
+	}
+	hashProgress.done()
+
+	if echo {
+		// same selection as echoDelete
+		basePath, err := object.extensionManager.BuildObjectStatePath(object, ".", "")
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot build external path for '.'")
+		}
+		if basePath == "." {
+			basePath = ""
+		}
+		slices.Sort(seen)
+		var removed = map[string]string{}
+		for name, digest := range state {
+			if !strings.HasPrefix(name, basePath) {
+				continue
+			}
+			if _, found := slices.BinarySearch(seen, name); !found {
+				removed[name] = digest
+			}
+		}
+		if !checkDuplicate {
+			// new files with the content of a removed file are not copied, see findRenameSources
+			var removedDigests = map[string]bool{}
+			for _, digest := range removed {
+				removedDigests[digest] = true
+			}
+			for _, entry := range plan.Entries {
+				if entry.Action == PlanActionAdded && entry.PreviousDigest == "" && removedDigests[entry.Digest] {
+					plan.BytesWritten -= entry.Size
+				}
+			}
+		}
+		// same pairing as echoRename
+		var added = map[string]string{}
+		var addedEntries = map[string]*PlanEntry{}
+		for _, entry := range plan.Entries {
+			// only new logical paths
+			if (entry.Action == PlanActionAdded || entry.Action == PlanActionDeduplicated) && entry.PreviousDigest == "" {
+				added[entry.Path] = entry.Digest
+				addedEntries[entry.Path] = entry
+			}
+		}
+		for _, pair := range findRenames(removed, added) {
+			entry := addedEntries[pair.dest]
+			if entry.Action == PlanActionAdded {
+				plan.Added--
+			} else {
+				plan.Deduplicated--
+			}
+			entry.Action = PlanActionRenamed
+			entry.PreviousPath = pair.source
+			plan.Renamed++
+			delete(removed, pair.source)
+		}
+		for _, name := range slices.Sorted(maps.Keys(removed)) {
+			plan.add(&PlanEntry{
+				Action:         PlanActionDeleted,
+				Path:           name,
+				PreviousDigest: removed[name],
+			})
+		}
+	}
+	return plan, nil
+}
+
+func (object *ObjectBase) planFile(ctx context.Context, fsys fs.FS, path, area, statePath string, hashProgress *progress) (*PlanEntry, error) {
+	if digest, ok := object.knownDigest(area, path); ok {
+		var size int64
+		if info, err := fs.Stat(fsys, path); err == nil {
+			size = info.Size()
+		}
+		object.logger.Debug().Any(
+			object.errorFactory.LogError(
+				ErrorOCFL,
+				fmt.Sprintf("planning %s:%s -> '%s' [%s, trusted]", area, path, statePath, digest),
+				nil,
+			),
+		).Msg("")
+		return &PlanEntry{
+			Path:   statePath,
+			Source: path,
+			Area:   area,
+			Digest: digest,
+			Size:   size,
+		}, nil
+	}
+	file, err := fsys.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot open file '%v/%s'", fsys, path)
+	}
+	defer file.Close()
+	var size int64
+	if info, err := file.Stat(); err == nil {
+		size = info.Size()
+	}
+	hashProgress.startFile(path)
+	digest, err := checksum.Checksum(newContextReader(ctx, hashProgress.reader(file)), object.i.GetDigestAlgorithm())
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot create digest of '%s'", path)
+	}
+	hashProgress.endFile()
+	object.logger.Debug().Any(
+		object.errorFactory.LogError(
+			ErrorOCFL,
+			fmt.Sprintf("planning %s:%s -> '%s' [%s]", area, path, statePath, digest),
+			nil,
+		),
+	).Msg("")
+	return &PlanEntry{
+		Path:   statePath,
+		Source: path,
+		Area:   area,
+		Digest: digest,
+		Size:   size,
+	}, nil
+}
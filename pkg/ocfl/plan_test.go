@@ -0,0 +1,195 @@
+package ocfl_test
+
+import (
+	"context"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/je4/utils/v2/pkg/checksum"
+	"github.com/ocfl-archive/gocfl/v2/pkg/ocfl"
+)
+
+// state returns the logical paths of the head version of object id with their digests
+func (tr *testRoot) state(id string) map[string]string {
+	tr.t.Helper()
+	var state = map[string]string{}
+	exists, err := tr.storageRoot.ObjectExists(id)
+	if err != nil {
+		tr.t.Fatal(err)
+	}
+	if !exists {
+		return state
+	}
+	o, err := tr.load().LoadObjectByID(id)
+	if err != nil {
+		tr.t.Fatal(err)
+	}
+	i := o.GetInventory()
+	if err := i.IterateStateFiles(i.GetHead(), func(internal []string, external []string, digest string) error {
+		for _, name := range external {
+			state[name] = digest
+		}
+		return nil
+	}); err != nil {
+		tr.t.Fatal(err)
+	}
+	return state
+}
+
+// contentSize returns the size of the content, which was stored in version of object id
+func (tr *testRoot) contentSize(id, version string) int64 {
+	tr.t.Helper()
+	var size int64
+	if err := filepath.WalkDir(filepath.Join(tr.objectFolder(id), version), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || strings.HasPrefix(d.Name(), "inventory.json") {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	}); err != nil {
+		tr.t.Fatal(err)
+	}
+	return size
+}
+
+// TestPlanMatchesIngest plans an update, runs the same update and compares the plan with the new version
+func TestPlanMatchesIngest(t *testing.T) {
+	initial := map[string]string{"a.txt": "a", "b.txt": "b", "dir/c.txt": "c"}
+	for _, test := range []struct {
+		name        string
+		initial     map[string]string
+		source      map[string]string
+		deduplicate bool
+		echo        bool
+		actions     map[ocfl.PlanAction]int64
+	}{
+		{
+			name:        "new object",
+			source:      map[string]string{"a.txt": "a", "copy.txt": "a", "b.txt": "b"},
+			deduplicate: true,
+			actions:     map[ocfl.PlanAction]int64{ocfl.PlanActionAdded: 2, ocfl.PlanActionDeduplicated: 1},
+		},
+		{
+			name:        "contribute",
+			initial:     initial,
+			source:      map[string]string{"a.txt": "changed", "b.txt": "b", "d.txt": "c", "e.txt": "new"},
+			deduplicate: true,
+			actions: map[ocfl.PlanAction]int64{
+				ocfl.PlanActionReplaced: 1, ocfl.PlanActionUnchanged: 1, ocfl.PlanActionDeduplicated: 1, ocfl.PlanActionAdded: 1,
+			},
+		},
+		{
+			name:    "contribute without deduplication",
+			initial: initial,
+			source:  map[string]string{"a.txt": "changed", "b.txt": "b", "d.txt": "c", "e.txt": "new"},
+			actions: map[ocfl.PlanAction]int64{
+				ocfl.PlanActionReplaced: 1, ocfl.PlanActionUnchanged: 1, ocfl.PlanActionAdded: 2,
+			},
+		},
+		{
+			name:        "echo",
+			initial:     initial,
+			source:      map[string]string{"a.txt": "a", "moved.txt": "b", "new.txt": "new", "copy.txt": "a"},
+			deduplicate: true,
+			echo:        true,
+			actions: map[ocfl.PlanAction]int64{
+				ocfl.PlanActionUnchanged: 1, ocfl.PlanActionRenamed: 1, ocfl.PlanActionAdded: 1,
+				ocfl.PlanActionDeduplicated: 1, ocfl.PlanActionDeleted: 1,
+			},
+		},
+		{
+			name:    "echo without deduplication",
+			initial: initial,
+			source:  map[string]string{"a.txt": "a", "moved.txt": "b", "new.txt": "new", "copy.txt": "a"},
+			echo:    true,
+			actions: map[ocfl.PlanAction]int64{
+				ocfl.PlanActionUnchanged: 1, ocfl.PlanActionRenamed: 1, ocfl.PlanActionAdded: 2, ocfl.PlanActionDeleted: 1,
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			tr := newTestRoot(t)
+			if test.initial != nil {
+				if err := tr.update("a", testFiles(test.initial)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			before := tr.state("a")
+			source := testFiles(test.source)
+
+			var o ocfl.Object
+			var err error
+			if test.initial == nil {
+				o, err = ocfl.NewPlanObject(context.Background(), "a", ocfl.Version1_1, checksum.DigestSHA512, nil, tr.objectManager(), tr.logger, tr.errorFactory)
+			} else {
+				o, err = tr.open("a")
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			plan, err := o.Plan(context.Background(), source, "content", nil, test.deduplicate, test.echo)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actions := map[ocfl.PlanAction]int64{}
+			for _, entry := range plan.Entries {
+				actions[entry.Action]++
+			}
+			if !maps.Equal(actions, test.actions) {
+				t.Fatalf("expected actions %v, got %v", test.actions, actions)
+			}
+			if state := tr.state("a"); !maps.Equal(state, before) {
+				t.Fatalf("plan changed the object: %v", state)
+			}
+			if _, err := os.Stat(tr.objectFolder("a")); test.initial == nil && err == nil {
+				t.Fatal("plan created the object")
+			}
+
+			o, err = tr.open("a")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := o.StartUpdate(context.Background(), source, "test", "tester", "mailto:tester@example.org", test.echo); err != nil {
+				t.Fatal(err)
+			}
+			if err := o.AddFolder(context.Background(), source, nil, test.deduplicate, "content"); err != nil {
+				t.Fatal(err)
+			}
+			if err := o.EndUpdate(); err != nil {
+				t.Fatal(err)
+			}
+			if err := o.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			// the new version is the old state with the changes of the plan
+			expected := maps.Clone(before)
+			for _, entry := range plan.Entries {
+				switch entry.Action {
+				case ocfl.PlanActionDeleted:
+					delete(expected, entry.Path)
+				case ocfl.PlanActionRenamed:
+					delete(expected, entry.PreviousPath)
+					expected[entry.Path] = entry.Digest
+				default:
+					expected[entry.Path] = entry.Digest
+				}
+			}
+			if after := tr.state("a"); !maps.Equal(after, expected) {
+				t.Fatalf("planned state %v, got %v", expected, after)
+			}
+			head := tr.head("a")
+			if size := tr.contentSize("a", head); size != plan.BytesWritten {
+				t.Fatalf("planned %d bytes, %d bytes written to '%s'", plan.BytesWritten, size, head)
+			}
+		})
+	}
+}
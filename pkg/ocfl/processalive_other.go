@@ -0,0 +1,8 @@
+//go:build !unix
+
+package ocfl
+
+// processAlive cannot check processes on this platform
+func processAlive(pid int) (alive bool, known bool) {
+	return false, false
+}
@@ -0,0 +1,17 @@
+//go:build unix
+
+package ocfl
+
+import (
+	"errors"
+	"syscall"
+)
+
+// processAlive checks, whether a process with pid is running on this host
+func processAlive(pid int) (alive bool, known bool) {
+	if pid <= 0 {
+		return false, false
+	}
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM), true
+}
@@ -0,0 +1,190 @@
+package ocfl
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+type ProgressPhase string
+
+const (
+	ProgressPhaseHash      ProgressPhase = "hash"
+	ProgressPhaseCopy      ProgressPhase = "copy"
+	ProgressPhaseExtension ProgressPhase = "extension"
+	ProgressPhaseInventory ProgressPhase = "inventory"
+	ProgressPhaseValidate  ProgressPhase = "validate"
+	ProgressPhaseExtract   ProgressPhase = "extract"
+)
+
+// ProgressEvent reports the state of a phase of an object operation.
+// Files and Bytes are the amounts processed so far, TotalFiles and TotalBytes are 0 if unknown
+type ProgressEvent struct {
+	Phase      ProgressPhase `json:"phase"`
+	ObjectID   string        `json:"objectID,omitempty"`
+	File       string        `json:"file,omitempty"`
+	Files      int64         `json:"files"`
+	Bytes      int64         `json:"bytes"`
+	TotalFiles int64         `json:"totalFiles,omitempty"`
+	TotalBytes int64         `json:"totalBytes,omitempty"`
+	Done       bool          `json:"done,omitempty"`
+}
+
+// ProgressReporter receives progress events. Progress may be called concurrently and should return quickly
+type ProgressReporter interface {
+	Progress(event ProgressEvent)
+}
+
+type ProgressFunc func(event ProgressEvent)
+
+func (f ProgressFunc) Progress(event ProgressEvent) { f(event) }
+
+type progressContextKey struct{}
+
+// NewContextProgress returns a context, which reports the progress of all object operations to reporter
+func NewContextProgress(parent context.Context, reporter ProgressReporter) context.Context {
+	return context.WithValue(parent, progressContextKey{}, reporter)
+}
+
+// GetProgressReporter returns the reporter of the context or nil
+func GetProgressReporter(ctx context.Context) ProgressReporter {
+	if ctx == nil {
+		return nil
+	}
+	reporter, _ := ctx.Value(progressContextKey{}).(ProgressReporter)
+	return reporter
+}
+
+// progress tracks the state of one phase. all methods do nothing, if there is no reporter
+type progress struct {
+	sync.Mutex
+	reporter ProgressReporter
+	event    ProgressEvent
+}
+
+func newProgress(ctx context.Context, objectID string, phase ProgressPhase) *progress {
+	return &progress{
+		reporter: GetProgressReporter(ctx),
+		event:    ProgressEvent{Phase: phase, ObjectID: objectID},
+	}
+}
+
+func (p *progress) enabled() bool { return p != nil && p.reporter != nil }
+
+func (p *progress) addTotal(files, bytes int64) {
+	if !p.enabled() {
+		return
+	}
+	p.Lock()
+	defer p.Unlock()
+	p.event.TotalFiles += files
+	p.event.TotalBytes += bytes
+}
+
+func (p *progress) startFile(name string) {
+	if !p.enabled() {
+		return
+	}
+	p.Lock()
+	p.event.File = name
+	event := p.event
+	p.Unlock()
+	p.reporter.Progress(event)
+}
+
+func (p *progress) addBytes(n int64) {
+	if !p.enabled() || n == 0 {
+		return
+	}
+	p.Lock()
+	p.event.Bytes += n
+	event := p.event
+	p.Unlock()
+	p.reporter.Progress(event)
+}
+
+func (p *progress) endFile() {
+	if !p.enabled() {
+		return
+	}
+	p.Lock()
+	p.event.Files++
+	event := p.event
+	p.Unlock()
+	p.reporter.Progress(event)
+}
+
+// skipFile counts a file, which is not processed. e.g. duplicates
+func (p *progress) skipFile(size int64) {
+	if !p.enabled() {
+		return
+	}
+	p.Lock()
+	p.event.Files++
+	p.event.Bytes += size
+	event := p.event
+	p.Unlock()
+	p.reporter.Progress(event)
+}
+
+// done reports the end of the phase. Phases without any activity are not reported
+func (p *progress) done() {
+	if !p.enabled() {
+		return
+	}
+	p.Lock()
+	if p.event.Files == 0 && p.event.TotalFiles == 0 && p.event.File == "" {
+		p.Unlock()
+		return
+	}
+	p.event.File = ""
+	p.event.Done = true
+	event := p.event
+	p.Unlock()
+	p.reporter.Progress(event)
+}
+
+// reader counts the bytes read from r
+func (p *progress) reader(r io.Reader) io.Reader {
+	if !p.enabled() {
+		return r
+	}
+	return &progressReader{r: r, progress: p}
+}
+
+type progressReader struct {
+	r        io.Reader
+	progress *progress
+}
+
+func (pr *progressReader) Read(b []byte) (int, error) {
+	n, err := pr.r.Read(b)
+	pr.progress.addBytes(int64(n))
+	return n, err
+}
+
+func (pr *progressReader) Close() error {
+	if closer, ok := pr.r.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// objectProgress holds the progress of all phases of an object update
+type objectProgress map[ProgressPhase]*progress
+
+func newObjectProgress(ctx context.Context, objectID string, phases ...ProgressPhase) objectProgress {
+	var op = objectProgress{}
+	for _, phase := range phases {
+		op[phase] = newProgress(ctx, objectID, phase)
+	}
+	return op
+}
+
+// get returns nil for unknown phases, which disables reporting
+func (op objectProgress) get(phase ProgressPhase) *progress {
+	if op == nil {
+		return nil
+	}
+	return op[phase]
+}
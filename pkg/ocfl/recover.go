@@ -0,0 +1,263 @@
+package ocfl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/je4/filesystem/v3/pkg/writefs"
+	"github.com/je4/utils/v2/pkg/checksum"
+)
+
+// RecoverAction is a repair of an object, which was left behind by an interrupted update
+type RecoverAction struct {
+	Path   string `json:"path"`
+	Action string `json:"action"`
+}
+
+func (ra *RecoverAction) String() string {
+	return fmt.Sprintf("%s: %s", ra.Path, ra.Action)
+}
+
+// inventoryHead reads the head of the inventory in folder.
+// ok is false, if the inventory or its sidecar is missing or the digest does not match
+func inventoryHead(fsys fs.FS, folder string) (head string, digest checksum.DigestAlgorithm, ok bool) {
+	data, err := fs.ReadFile(fsys, path.Join(folder, "inventory.json"))
+	if err != nil {
+		return "", "", false
+	}
+	var inv struct {
+		Head            string                   `json:"head"`
+		DigestAlgorithm checksum.DigestAlgorithm `json:"digestAlgorithm"`
+	}
+	if err := json.Unmarshal(data, &inv); err != nil || inv.Head == "" {
+		return "", "", false
+	}
+	sidecar, err := fs.ReadFile(fsys, path.Join(folder, fmt.Sprintf("inventory.json.%s", inv.DigestAlgorithm)))
+	if err != nil {
+		return "", "", false
+	}
+	matches := inventorySideCarFormat.FindStringSubmatch(strings.TrimSpace(string(sidecar)))
+	if matches == nil {
+		return "", "", false
+	}
+	h, err := checksum.GetHash(inv.DigestAlgorithm)
+	if err != nil {
+		return "", "", false
+	}
+	h.Write(data)
+	if !strings.EqualFold(fmt.Sprintf("%x", h.Sum(nil)), matches[1]) {
+		return "", "", false
+	}
+	return inv.Head, inv.DigestAlgorithm, true
+}
+
+// versionFolders returns the version folders of the object root in ascending order
+func versionFolders(fsys fs.FS) ([]string, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read '%v'", fsys)
+	}
+	var versions = []string{}
+	for _, entry := range entries {
+		if entry.IsDir() && vRegexp.MatchString(entry.Name()) {
+			versions = append(versions, entry.Name())
+		}
+	}
+	slices.SortFunc(versions, func(a, b string) int {
+		na, _ := strconv.Atoi(a[1:])
+		nb, _ := strconv.Atoi(b[1:])
+		return na - nb
+	})
+	return versions, nil
+}
+
+// RecoverObject repairs an object after an interrupted update.
+// Abandoned staging areas and incomplete versions are removed. The extensions folder is restored, if its version was not promoted. A complete version,
+// which is newer than the root inventory, is made the head of the object.
+// The object is locked during recovery, so objects, which are written by another process, are not touched.
+// Stale locks of crashed processes on this host are taken over.
+// workspace is the object workspace in the storage root (see ObjectWorkspace).
+// If dryRun is true, the actions are only returned
+func RecoverObject(ctx context.Context, fsys, workspace fs.FS, lockOptions *LockOptions, dryRun bool) (_ []*RecoverAction, err error) {
+	var actions = []*RecoverAction{}
+	if holder, err := ReadLock(workspace); err == nil {
+		switch {
+		case holder.Stale() && lockOptions != nil:
+			actions = append(actions, &RecoverAction{Path: LockFile, Action: fmt.Sprintf("take over stale lock (%s)", holder)})
+		case dryRun:
+			return actions, errors.Wrapf(ErrLocked, "'%v' (%s)", fsys, holder)
+		}
+	}
+	if !dryRun {
+		lock, err := acquireLock(ctx, workspace, lockOptions)
+		if err != nil {
+			return actions, errors.WithStack(err)
+		}
+		defer func() {
+			if err2 := releaseLock(workspace, lock); err2 != nil {
+				err = errors.Combine(err, err2)
+			}
+			pruneWorkspace(workspace)
+		}()
+	}
+	do := func(name, action string, f func() error) error {
+		actions = append(actions, &RecoverAction{Path: name, Action: action})
+		if dryRun {
+			return nil
+		}
+		return errors.WithStack(f())
+	}
+
+	// the saved extensions are restored, if the version was not promoted
+	if _, err := fs.Stat(workspace, ExtensionsBackupFolder); err == nil {
+		if stagedVersion(workspace) {
+			if err := do("extensions", "restore extensions folder from the workspace", func() error {
+				return restoreExtensions(fsys, workspace)
+			}); err != nil {
+				return actions, errors.Wrapf(err, "cannot restore extensions of '%v'", fsys)
+			}
+		} else {
+			if err := do(ExtensionsBackupFolder, "remove saved extensions of a promoted version", func() error {
+				return removeAll(workspace, ExtensionsBackupFolder)
+			}); err != nil {
+				return actions, errors.Wrapf(err, "cannot remove '%v/%s'", workspace, ExtensionsBackupFolder)
+			}
+		}
+	}
+	if _, err := fs.Stat(workspace, StagingFolder); err == nil {
+		if err := do(StagingFolder, "remove abandoned staging area of the workspace", func() error {
+			return removeAll(workspace, StagingFolder)
+		}); err != nil {
+			return actions, errors.Wrapf(err, "cannot remove '%v/%s'", workspace, StagingFolder)
+		}
+	}
+
+	versions, err := versionFolders(fsys)
+	if err != nil {
+		return actions, errors.WithStack(err)
+	}
+	rootHead, _, rootOK := inventoryHead(fsys, ".")
+	_, rootErr := fs.Stat(fsys, "inventory.json")
+
+	// the newest version with a complete inventory, which continues the version sequence
+	var head string
+	for i, version := range versions {
+		if vNumber(version) != i+1 {
+			break
+		}
+		vHead, _, ok := inventoryHead(fsys, version)
+		if !ok || vHead != version {
+			break
+		}
+		head = version
+	}
+
+	switch {
+	case head == "" && errors.Is(rootErr, fs.ErrNotExist):
+		// a new object, which never got its first version
+		entries, err := fs.ReadDir(fsys, ".")
+		if err != nil {
+			return actions, errors.Wrapf(err, "cannot read '%v'", fsys)
+		}
+		for _, entry := range entries {
+			if err := do(entry.Name(), "remove incomplete object", func() error {
+				return removeAll(fsys, entry.Name())
+			}); err != nil {
+				return actions, errors.Wrapf(err, "cannot remove '%v/%s'", fsys, entry.Name())
+			}
+		}
+		return actions, nil
+	case head == "":
+		return actions, errors.Errorf("no complete version in '%v'", fsys)
+	case rootOK && vNumber(rootHead) > vNumber(head):
+		return actions, errors.Errorf("root inventory of '%v' is at version '%s', but the newest complete version is '%s'", fsys, rootHead, head)
+	case !rootOK || rootHead != head:
+		_, digest, _ := inventoryHead(fsys, head)
+		action := fmt.Sprintf("complete version '%s' - update root inventory", head)
+		if !rootOK {
+			action = fmt.Sprintf("restore root inventory from version '%s'", head)
+		}
+		if err := do("inventory.json", action, func() error {
+			for _, name := range []string{"inventory.json", fmt.Sprintf("inventory.json.%s", digest)} {
+				if _, err := writefs.Copy(fsys, path.Join(head, name), name); err != nil {
+					return errors.Wrapf(err, "cannot copy '%s/%s'", head, name)
+				}
+			}
+			return nil
+		}); err != nil {
+			return actions, errors.Wrapf(err, "cannot update root inventory of '%v'", fsys)
+		}
+	}
+
+	// everything after the head is incomplete
+	for _, version := range versions {
+		if vNumber(version) <= vNumber(head) {
+			continue
+		}
+		if err := do(version, "remove incomplete version", func() error {
+			return removeAll(fsys, version)
+		}); err != nil {
+			return actions, errors.Wrapf(err, "cannot remove '%v/%s'", fsys, version)
+		}
+	}
+	return actions, nil
+}
+
+// stagedVersion returns true, if the staging folder of the workspace holds a version, which was not promoted
+func stagedVersion(workspace fs.FS) bool {
+	entries, err := fs.ReadDir(workspace, StagingFolder)
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			return true
+		}
+	}
+	return false
+}
+
+func vNumber(version string) int {
+	n, _ := strconv.Atoi(strings.TrimPrefix(version, "v"))
+	return n
+}
+
+// removeAll removes name and everything below it
+func removeAll(fsys fs.FS, name string) error {
+	var names = []string{}
+	var dirs = []string{}
+	if err := fs.WalkDir(fsys, name, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return errors.WithStack(err)
+		}
+		if d.IsDir() {
+			dirs = append(dirs, path)
+		} else {
+			names = append(names, path)
+		}
+		return nil
+	}); err != nil {
+		return errors.Wrapf(err, "cannot walk '%v/%s'", fsys, name)
+	}
+	for _, name := range names {
+		if err := writefs.Remove(fsys, name); err != nil {
+			return errors.Wrapf(err, "cannot remove '%v/%s'", fsys, name)
+		}
+	}
+	// remove the deepest folders first. folders do not exist on all filesystems
+	slices.Reverse(dirs)
+	for _, dir := range dirs {
+		_ = writefs.Remove(fsys, dir)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package ocfl_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ocfl-archive/gocfl/v2/pkg/ocfl"
+)
+
+// TestRecoverObject simulates crashes between the steps of promote and checks, that RecoverObject repairs the object
+func TestRecoverObject(t *testing.T) {
+	const sidecar = "inventory.json.sha512"
+	for _, test := range []struct {
+		name  string
+		head  string
+		next  string
+		state string
+		crash func(folder, workspace string) error
+	}{
+		{
+			name:  "before version rename",
+			head:  "v1",
+			next:  "v2",
+			state: "original",
+			crash: func(folder, workspace string) error {
+				if err := os.MkdirAll(filepath.Join(workspace, ocfl.StagingFolder), 0755); err != nil {
+					return err
+				}
+				if err := os.Rename(filepath.Join(folder, "v2"), filepath.Join(workspace, ocfl.StagingFolder, "v2")); err != nil {
+					return err
+				}
+				return restore(folder, "v1", "inventory.json", sidecar)
+			},
+		},
+		{
+			name:  "after version rename",
+			head:  "v2",
+			next:  "v3",
+			state: "changed",
+			crash: func(folder, workspace string) error {
+				if err := os.MkdirAll(filepath.Join(workspace, ocfl.StagingFolder), 0755); err != nil {
+					return err
+				}
+				return restore(folder, "v1", "inventory.json", sidecar)
+			},
+		},
+		{
+			name:  "after inventory rename",
+			head:  "v2",
+			next:  "v3",
+			state: "changed",
+			crash: func(folder, workspace string) error {
+				if err := os.MkdirAll(filepath.Join(workspace, ocfl.StagingFolder), 0755); err != nil {
+					return err
+				}
+				if err := copyFile(filepath.Join(folder, "v2", sidecar), filepath.Join(workspace, ocfl.StagingFolder, sidecar)); err != nil {
+					return err
+				}
+				return restore(folder, "v1", sidecar)
+			},
+		},
+		{
+			name:  "before staging removal",
+			head:  "v2",
+			next:  "v3",
+			state: "changed",
+			crash: func(folder, workspace string) error {
+				return os.MkdirAll(filepath.Join(workspace, ocfl.StagingFolder), 0755)
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			tr := newTestRoot(t)
+			if err := tr.update("a", testFiles(map[string]string{"x.txt": "x"})); err != nil {
+				t.Fatal(err)
+			}
+			if err := tr.update("a", testFiles(map[string]string{"x.txt": "x", "y.txt": "y"})); err != nil {
+				t.Fatal(err)
+			}
+			// the extension hooks of the crashed update changed the extensions folder
+			folder := filepath.Join(tr.objectFolder("a"), "extensions", hookExtensionName)
+			backup := filepath.Join(tr.workspaceFolder("a"), ocfl.ExtensionsBackupFolder, hookExtensionName)
+			for dir, state := range map[string]string{folder: "changed", backup: "original"} {
+				if err := os.MkdirAll(dir, 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(dir, "state.txt"), []byte(state), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := test.crash(tr.objectFolder("a"), tr.workspaceFolder("a")); err != nil {
+				t.Fatal(err)
+			}
+			if err := tr.update("a", testFiles(map[string]string{"z.txt": "z"})); err == nil {
+				t.Fatal("update of crashed object succeeded")
+			}
+			if _, err := ocfl.RecoverObject(context.Background(), tr.objectFS("a"), tr.workspaceFS("a"), &ocfl.LockOptions{}, false); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := os.Stat(filepath.Join(tr.workspaceFolder("a"), ocfl.StagingFolder)); err == nil {
+				t.Fatal("staging folder not removed")
+			}
+			if _, err := os.Stat(filepath.Join(tr.objectFolder("a"), ocfl.StagingFolder)); err == nil {
+				t.Fatal("staging folder in object root")
+			}
+			if head := tr.head("a"); head != test.head {
+				t.Fatalf("expected head %s, got %s", test.head, head)
+			}
+			if data, err := os.ReadFile(filepath.Join(folder, "state.txt")); err != nil || string(data) != test.state {
+				t.Fatalf("expected extension state '%s', got '%s': %v", test.state, data, err)
+			}
+			if _, err := os.Stat(filepath.Dir(backup)); err == nil {
+				t.Fatal("saved extensions not removed")
+			}
+			if err := tr.update("a", testFiles(map[string]string{"z.txt": "z"})); err != nil {
+				t.Fatal(err)
+			}
+			if head := tr.head("a"); head != test.next {
+				t.Fatalf("expected head %s after recovery, got %s", test.next, head)
+			}
+		})
+	}
+}
+
+// restore copies the files of version into the object root
+func restore(folder, version string, names ...string) error {
+	for _, name := range names {
+		if err := copyFile(filepath.Join(folder, version, name), filepath.Join(folder, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, 0644)
+}
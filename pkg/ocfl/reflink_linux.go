@@ -0,0 +1,32 @@
+//go:build linux
+
+package ocfl
+
+import (
+	"os"
+
+	"emperror.dev/errors"
+	"golang.org/x/sys/unix"
+)
+
+// reflink creates dst as copy-on-write clone of src (FICLONE)
+func reflink(src, dst string) error {
+	srcFP, err := os.Open(src)
+	if err != nil {
+		return errors.Wrapf(err, "cannot open '%s'", src)
+	}
+	defer srcFP.Close()
+	dstFP, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return errors.Wrapf(err, "cannot create '%s'", dst)
+	}
+	if err := unix.IoctlFileClone(int(dstFP.Fd()), int(srcFP.Fd())); err != nil {
+		dstFP.Close()
+		os.Remove(dst)
+		return errors.Wrapf(err, "cannot clone '%s' -> '%s'", src, dst)
+	}
+	if err := dstFP.Close(); err != nil {
+		return errors.Wrapf(err, "cannot close '%s'", dst)
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package ocfl
+
+import (
+	"emperror.dev/errors"
+)
+
+// reflink is not supported on this platform
+func reflink(src, dst string) error {
+	return errors.Errorf("reflink '%s' -> '%s' not supported on this platform", src, dst)
+}
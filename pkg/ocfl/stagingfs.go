@@ -0,0 +1,157 @@
+package ocfl
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/je4/filesystem/v3/pkg/writefs"
+)
+
+// StagingFolder is the folder in the object workspace, which holds a new version until it is complete
+const StagingFolder = "staging"
+
+// stagingFS redirects all paths of one version folder into the staging folder of the object workspace.
+// All other paths are passed to the object filesystem unchanged
+type stagingFS struct {
+	fsys      fs.FS
+	workspace fs.FS
+	version   string
+}
+
+func newStagingFS(fsys, workspace fs.FS, version string) *stagingFS {
+	return &stagingFS{fsys: fsys, workspace: workspace, version: version}
+}
+
+// canStage returns true, if the object and its workspace are local folders, where folders can be renamed atomically.
+// zip files and object stores are written in place
+func canStage(fsys, workspace fs.FS) bool {
+	if workspace == nil {
+		return false
+	}
+	folder, ok := localPath(fsys)
+	if !ok {
+		return false
+	}
+	if _, ok := localPath(workspace); !ok {
+		return false
+	}
+	fi, err := os.Stat(folder)
+	return err == nil && fi.IsDir()
+}
+
+// path returns the filesystem and the path of name
+func (sfs *stagingFS) path(name string) (fs.FS, string) {
+	if name == sfs.version || strings.HasPrefix(name, sfs.version+"/") {
+		return sfs.workspace, path.Join(StagingFolder, name)
+	}
+	return sfs.fsys, name
+}
+
+func (sfs *stagingFS) Open(name string) (fs.File, error) {
+	fsys, name := sfs.path(name)
+	return fsys.Open(name)
+}
+
+func (sfs *stagingFS) Stat(name string) (fs.FileInfo, error) {
+	fsys, name := sfs.path(name)
+	return fs.Stat(fsys, name)
+}
+
+func (sfs *stagingFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	fsys, name := sfs.path(name)
+	return fs.ReadDir(fsys, name)
+}
+
+func (sfs *stagingFS) ReadFile(name string) ([]byte, error) {
+	fsys, name := sfs.path(name)
+	return fs.ReadFile(fsys, name)
+}
+
+func (sfs *stagingFS) Sub(dir string) (fs.FS, error) {
+	return writefs.Sub(sfs, dir)
+}
+
+func (sfs *stagingFS) Create(name string) (writefs.FileWrite, error) {
+	fsys, name := sfs.path(name)
+	return writefs.Create(fsys, name)
+}
+
+func (sfs *stagingFS) Append(name string) (writefs.FileWrite, error) {
+	fsys, name := sfs.path(name)
+	return writefs.Append(fsys, name)
+}
+
+func (sfs *stagingFS) WriteFile(name string, data []byte) (int64, error) {
+	fsys, name := sfs.path(name)
+	return writefs.WriteFile(fsys, name, data)
+}
+
+func (sfs *stagingFS) MkDir(name string) error {
+	fsys, name := sfs.path(name)
+	return writefs.MkDir(fsys, name)
+}
+
+func (sfs *stagingFS) Remove(name string) error {
+	fsys, name := sfs.path(name)
+	return writefs.Remove(fsys, name)
+}
+
+// Rename moves files between the workspace and the object with the local paths. Both are folders of the same storage root
+func (sfs *stagingFS) Rename(oldPath, newPath string) error {
+	oldFS, oldName := sfs.path(oldPath)
+	newFS, newName := sfs.path(newPath)
+	if oldFS == newFS {
+		return writefs.Rename(oldFS, oldName, newName)
+	}
+	return errors.WithStack(renameLocal(oldFS, oldName, newFS, newName))
+}
+
+func (sfs *stagingFS) Copy(dst, src string) (int64, error) {
+	srcFS, srcName := sfs.path(src)
+	dstFS, dstName := sfs.path(dst)
+	if srcFS == dstFS {
+		return writefs.Copy(srcFS, srcName, dstName)
+	}
+	fp, err := srcFS.Open(srcName)
+	if err != nil {
+		return 0, errors.Wrapf(err, "cannot open '%s'", src)
+	}
+	defer fp.Close()
+	w, err := writefs.Create(dstFS, dstName)
+	if err != nil {
+		return 0, errors.Wrapf(err, "cannot create '%s'", dst)
+	}
+	n, err := io.Copy(w, fp)
+	if err != nil {
+		w.Close()
+		return n, errors.Wrapf(err, "cannot copy '%s' to '%s'", src, dst)
+	}
+	return n, errors.Wrapf(w.Close(), "cannot close '%s'", dst)
+}
+
+func (sfs *stagingFS) Fullpath(name string) (string, error) {
+	fsys, name := sfs.path(name)
+	return writefs.Fullpath(fsys, name)
+}
+
+func (sfs *stagingFS) Equal(fsys fs.FS) bool {
+	return writefs.Equal(sfs.fsys, fsys)
+}
+
+func (sfs *stagingFS) Close() error {
+	return nil
+}
+
+func (sfs *stagingFS) String() string {
+	return fmt.Sprintf("%v", sfs.fsys)
+}
+
+var (
+	_ writefs.FullFS = (*stagingFS)(nil)
+	_ fmt.Stringer   = (*stagingFS)(nil)
+)
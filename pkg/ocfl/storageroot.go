@@ -24,22 +24,25 @@ type StorageRoot interface {
 	GetFiles() ([]string, error)
 	GetFolders() ([]string, error)
 	GetObjectFolders() ([]string, error)
+	GetObjectFolder(id string) (string, error)
 	ObjectExists(id string) (bool, error)
 	LoadObjectByFolder(folder string) (Object, error)
 	LoadObjectByID(id string) (Object, error)
 	CreateObject(id string, version OCFLVersion, digest checksum.DigestAlgorithm, fixity []checksum.DigestAlgorithm, manager ExtensionManager) (Object, error)
 	CreateExtension(fsys fs.FS) (Extension, error)
 	CreateExtensions(fsys fs.FS, validation Validation) (ExtensionManager, error)
-	Check() error
-	CheckObjectByFolder(objectFolder string) error
-	CheckObjectByID(objectID string) error
+	Check(ctx context.Context) error
+	CheckObjectByFolder(ctx context.Context, objectFolder string) error
+	CheckObjectByID(ctx context.Context, objectID string) error
 	Init(version OCFLVersion, digest checksum.DigestAlgorithm, manager ExtensionManager) error
 	Load() error
 	IsModified() bool
 	setModified()
+	SetLockOptions(options *LockOptions)
+	GetLockOptions() *LockOptions
 	GetVersion() OCFLVersion
 	Stat(w io.Writer, path string, id string, statInfo []StatInfo) error
-	Extract(fsys fs.FS, path, id, version string, withManifest bool, area string) error
+	Extract(ctx context.Context, fsys fs.FS, path, id, version string, withManifest bool, area string) error
 	ExtractMeta(path, id string) (*StorageRootMetadata, error)
 }
 
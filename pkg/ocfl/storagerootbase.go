@@ -33,6 +33,7 @@ type StorageRootBase struct {
 	modified         bool
 	errorFactory     *archiveerror.Factory
 	documentation    string
+	lockOptions      *LockOptions
 }
 
 //var rootConformanceDeclaration = fmt.Sprintf("0=ocfl_%s", VERSION)
@@ -59,6 +60,8 @@ func NewStorageRootBase(
 		errorFactory:     errorFactory,
 		documentation:    documentation,
 	}
+	lockOptions := DefaultLockOptions
+	ocfl.lockOptions = &lockOptions
 	if err != nil {
 		return nil, errors.Wrap(err, "cannot instantiate extension manager")
 	}
@@ -80,6 +83,15 @@ func (osr *StorageRootBase) setModified() {
 	osr.modified = true
 }
 
+// SetLockOptions sets the locking of objects for writing. nil disables locking
+func (osr *StorageRootBase) SetLockOptions(options *LockOptions) {
+	osr.lockOptions = options
+}
+
+func (osr *StorageRootBase) GetLockOptions() *LockOptions {
+	return osr.lockOptions
+}
+
 func (osr *StorageRootBase) addValidationError(errno ValidationErrorCode, format string, a ...any) error {
 	valError := GetValidationError(osr.version, errno).AppendDescription(format, a...).AppendContext("storage root '%v' ", osr.fsys)
 	_, file, line, _ := runtime.Caller(1)
@@ -345,7 +357,8 @@ func (osr *StorageRootBase) GetObjectFolders() ([]string, error) {
 	}
 	var result = []string{}
 	for _, dir := range dirs {
-		if dir == "extensions" {
+		// the workspace holds locks and staged versions of running updates
+		if dir == "extensions" || dir == WorkspaceFolder {
 			continue
 		}
 		dirs, err := recurse(dir)
@@ -380,7 +393,11 @@ func (osr *StorageRootBase) LoadObjectByFolder(folder string) (Object, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "cannot create extension manager")
 	}
-	object, err := newObject(osr.ctx, subfs, version, osr, extensionManager, osr.logger, osr.errorFactory)
+	workspace, err := ObjectWorkspace(osr.fsys, folder)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	object, err := newObject(osr.ctx, subfs, workspace, version, osr, extensionManager, osr.logger, osr.errorFactory)
 	if err != nil {
 		return nil, errors.Wrapf(err, "cannot instantiate object")
 	}
@@ -411,6 +428,15 @@ func (osr *StorageRootBase) LoadObjectByFolder(folder string) (Object, error) {
 	return object, nil
 }
 
+// GetObjectFolder returns the folder of the object with the given id
+func (osr *StorageRootBase) GetObjectFolder(id string) (string, error) {
+	folder, err := osr.extensionManager.BuildStorageRootPath(osr, id)
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot create folder from id '%s'", id)
+	}
+	return folder, nil
+}
+
 func (osr *StorageRootBase) LoadObjectByID(id string) (object Object, err error) {
 	folder, err := osr.extensionManager.BuildStorageRootPath(osr, id)
 	if err != nil {
@@ -426,7 +452,11 @@ func (osr *StorageRootBase) CreateObject(id string, version OCFLVersion, digest
 		return nil, errors.Wrapf(err, "cannot create sub fs of %v for '%s'", osr.fsys, folder)
 	}
 
-	object, err := newObject(osr.ctx, subfs, version, osr, manager, osr.logger, osr.errorFactory)
+	workspace, err := ObjectWorkspace(osr.fsys, folder)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	object, err := newObject(osr.ctx, subfs, workspace, version, osr, manager, osr.logger, osr.errorFactory)
 	if err != nil {
 		return nil, errors.Wrap(err, "cannot instantiate object")
 	}
@@ -447,7 +477,7 @@ func (osr *StorageRootBase) CreateObject(id string, version OCFLVersion, digest
 // Check functions
 //
 
-func (osr *StorageRootBase) Check() error {
+func (osr *StorageRootBase) Check(ctx context.Context) error {
 	// https://ocfl.io/1.0/spec/validation-codes.html
 
 	if err := osr.CheckDirectory(); err != nil {
@@ -461,7 +491,7 @@ func (osr *StorageRootBase) Check() error {
 			),
 		).Msg("")
 	}
-	if err := osr.CheckObjects(); err != nil {
+	if err := osr.CheckObjects(ctx); err != nil {
 		return errors.WithStack(err)
 	}
 
@@ -507,7 +537,7 @@ func (osr *StorageRootBase) CheckDirectory() (err error) {
 	}
 	return nil
 }
-func (osr *StorageRootBase) CheckObjectByFolder(objectFolder string) error {
+func (osr *StorageRootBase) CheckObjectByFolder(ctx context.Context, objectFolder string) error {
 	osr.logger.Info().Any(
 		osr.errorFactory.LogError(
 			ErrorOCFL,
@@ -521,14 +551,14 @@ func (osr *StorageRootBase) CheckObjectByFolder(objectFolder string) error {
 			return errors.Wrapf(err, "cannot add validation error %s", E001)
 		}
 	} else {
-		if err := object.Check(); err != nil {
+		if err := object.Check(ctx); err != nil {
 			return errors.Wrapf(err, "check of '%s' failed", object.GetID())
 		}
 	}
 	return nil
 }
 
-func (osr *StorageRootBase) CheckObjectByID(objectID string) error {
+func (osr *StorageRootBase) CheckObjectByID(ctx context.Context, objectID string) error {
 	osr.logger.Info().Any(
 		osr.errorFactory.LogError(
 			ErrorOCFL,
@@ -542,20 +572,23 @@ func (osr *StorageRootBase) CheckObjectByID(objectID string) error {
 			return errors.Wrapf(err, "cannot add validation error %s", E001)
 		}
 	} else {
-		if err := object.Check(); err != nil {
+		if err := object.Check(ctx); err != nil {
 			return errors.Wrapf(err, "check of '%s' failed", object.GetID())
 		}
 	}
 	return nil
 }
 
-func (osr *StorageRootBase) CheckObjects() error {
+func (osr *StorageRootBase) CheckObjects(ctx context.Context) error {
 	objectFolders, err := osr.GetObjectFolders()
 	if err != nil {
 		return errors.Wrapf(err, "cannot get object folders")
 	}
 	for _, objectFolder := range objectFolders {
-		if err := osr.CheckObjectByFolder(objectFolder); err != nil {
+		if err := ctx.Err(); err != nil {
+			return errors.Wrap(err, "check of storage root cancelled")
+		}
+		if err := osr.CheckObjectByFolder(ctx, objectFolder); err != nil {
 			return errors.WithStack(err)
 		}
 	}
@@ -711,7 +744,7 @@ func (osr *StorageRootBase) ExtractMeta(path, id string) (*StorageRootMetadata,
 	return result, nil
 }
 
-func (osr *StorageRootBase) Extract(fsys fs.FS, path, id, version string, withManifest bool, area string) error {
+func (osr *StorageRootBase) Extract(ctx context.Context, fsys fs.FS, path, id, version string, withManifest bool, area string) error {
 	if version == "" {
 		version = "latest"
 	}
@@ -736,7 +769,7 @@ func (osr *StorageRootBase) Extract(fsys fs.FS, path, id, version string, withMa
 			if err != nil {
 				return errors.Wrapf(err, "cannot create subfolder '%s' of '%v'", oFolder, fsys)
 			}
-			if err := o.Extract(subFS, version, withManifest, ""); err != nil {
+			if err := o.Extract(ctx, subFS, version, withManifest, ""); err != nil {
 				return errors.Wrapf(err, "cannot extract object in folder '%s'", oFolder)
 			}
 		}
@@ -758,7 +791,7 @@ func (osr *StorageRootBase) Extract(fsys fs.FS, path, id, version string, withMa
 		if err != nil {
 			return errors.Wrapf(err, "cannot load object '%s%s'", path, id)
 		}
-		if err := o.Extract(fsys, version, withManifest, area); err != nil {
+		if err := o.Extract(ctx, fsys, version, withManifest, area); err != nil {
 			return errors.Wrapf(err, "cannot extract object '%s%s'", path, id)
 		}
 	}
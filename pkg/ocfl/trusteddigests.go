@@ -0,0 +1,108 @@
+package ocfl
+
+import (
+	"io/fs"
+	"slices"
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/je4/utils/v2/pkg/checksum"
+)
+
+var ErrDigestMismatch = errors.New("content does not match trusted digest")
+
+// TrustedDigests are digests of the source files, which were created and verified by the producer of the content.
+// They are used for deduplication and planning instead of reading the files.
+// The files are still verified against them, while they are copied into the object
+type TrustedDigests struct {
+	Algorithm checksum.DigestAlgorithm
+	// Area of the source, which is described by Digests
+	Area string
+	// Digests maps the paths in the source to their hex digests
+	Digests map[string]string
+}
+
+// trustedDigest is the trusted digest of one source file
+type trustedDigest struct {
+	path      string
+	algorithm checksum.DigestAlgorithm
+	digest    string
+}
+
+// verify compares the checksums of the copied file with the trusted digest
+func (td *trustedDigest) verify(checksums map[checksum.DigestAlgorithm]string) error {
+	if td == nil {
+		return nil
+	}
+	digest, ok := checksums[td.algorithm]
+	if !ok {
+		return errors.Errorf("digest '%s' of '%s' not generated", td.algorithm, td.path)
+	}
+	if !strings.EqualFold(digest, td.digest) {
+		return errors.Wrapf(ErrDigestMismatch, "'%s' %s is %s, expected %s", td.path, td.algorithm, digest, td.digest)
+	}
+	return nil
+}
+
+// SetTrustedDigests sets the digests of the source files of the next AddFolder calls. nil removes them
+func (object *ObjectBase) SetTrustedDigests(trusted *TrustedDigests) {
+	object.trusted = trusted
+}
+
+// policyTrustedDigests maps the trusted digests to the logical paths, if fsys applies a path policy.
+// The returned function restores the trusted digests of the source paths
+func (object *ObjectBase) policyTrustedDigests(fsys fs.FS) func() {
+	trusted := object.trusted
+	policyFS, ok := fsys.(*PathPolicyFS)
+	if !ok || trusted == nil {
+		return func() {}
+	}
+	object.trusted = policyFS.trustedDigests(trusted)
+	return func() { object.trusted = trusted }
+}
+
+// trustedDigest returns the trusted digest of the source file path in area or nil, if there is none
+func (object *ObjectBase) trustedDigest(area, path string) *trustedDigest {
+	if object.trusted == nil || object.trusted.Area != area {
+		return nil
+	}
+	digest, ok := object.trusted.Digests[path]
+	if !ok {
+		return nil
+	}
+	return &trustedDigest{path: path, algorithm: object.trusted.Algorithm, digest: strings.ToLower(digest)}
+}
+
+// knownDigest returns the trusted digest of the source file path in area, if it uses the digest algorithm of the inventory
+func (object *ObjectBase) knownDigest(area, path string) (string, bool) {
+	td := object.trustedDigest(area, path)
+	if td == nil || td.algorithm != object.i.GetDigestAlgorithm() {
+		return "", false
+	}
+	return td.digest, true
+}
+
+// copyDigestAlgorithms returns the algorithms, which are created while a file is copied into the object.
+// extra is true, if the algorithm of trusted is needed for verification only
+func (object *ObjectBase) copyDigestAlgorithms(trusted *trustedDigest) (algorithms []checksum.DigestAlgorithm, extra bool) {
+	algorithms = slices.Clone(object.i.GetFixityDigestAlgorithm())
+	if !slices.Contains(algorithms, object.i.GetDigestAlgorithm()) {
+		algorithms = append(algorithms, object.i.GetDigestAlgorithm())
+	}
+	if trusted != nil && !slices.Contains(algorithms, trusted.algorithm) {
+		algorithms = append(algorithms, trusted.algorithm)
+		extra = true
+	}
+	return algorithms, extra
+}
+
+// checkTrusted verifies the checksums of a copied file and removes the checksum, which was created for verification only
+func (object *ObjectBase) checkTrusted(trusted *trustedDigest, checksums map[checksum.DigestAlgorithm]string, extra bool) error {
+	if err := trusted.verify(checksums); err != nil {
+		return errors.WithStack(err)
+	}
+	if extra {
+		delete(checksums, trusted.algorithm)
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+package ocfl
+
+import (
+	"emperror.dev/errors"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+type versionFSFile struct {
+	internal string
+	digest   string
+}
+
+// versionFS is a read-only filesystem with the logical state of an object version.
+// File content is read from the internal manifest path of the object
+type versionFS struct {
+	fsys    fs.FS
+	modTime time.Time
+	files   map[string]*versionFSFile // logical path -> content
+	dirs    map[string][]string       // logical folder -> sorted names of entries
+}
+
+// VersionFS returns a read-only filesystem with the logical state of a version.
+// version "latest" or an empty version refers to the head version. Logical paths are mapped to the area like in Extract
+func (object *ObjectBase) VersionFS(version, area string) (fs.FS, error) {
+	version, err := object.ResolveVersion(version)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	ver, ok := object.i.GetVersions()[version]
+	if !ok {
+		return nil, errors.Errorf("version '%s' not found in object '%s'", version, object.GetID())
+	}
+	vfs := &versionFS{
+		fsys:    object.fsys,
+		modTime: ver.Created.Time,
+		files:   map[string]*versionFSFile{},
+		dirs:    map[string][]string{".": {}},
+	}
+	if err := object.i.IterateStateFiles(version, func(internals, externals []string, digest string) error {
+		for _, external := range externals {
+			logical, err := object.extensionManager.BuildObjectExtractPath(object, external, area)
+			if err != nil {
+				if errors.Is(errors.Cause(err), ExtensionObjectExtractPathWrongAreaError) {
+					continue
+				}
+				return errors.Wrapf(err, "cannot map path '%s'", external)
+			}
+			logical = strings.Trim(logical, "/")
+			if !fs.ValidPath(logical) || logical == "." {
+				return errors.Errorf("invalid logical path '%s'", logical)
+			}
+			vfs.files[logical] = &versionFSFile{internal: internals[0], digest: digest}
+			for name := logical; name != "."; name = path.Dir(name) {
+				dir := path.Dir(name)
+				i, found := slices.BinarySearch(vfs.dirs[dir], path.Base(name))
+				if found {
+					// parent folders are already registered
+					break
+				}
+				vfs.dirs[dir] = slices.Insert(vfs.dirs[dir], i, path.Base(name))
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, errors.Wrapf(err, "cannot iterate state files of version '%s'", version)
+	}
+	for name := range vfs.files {
+		if _, ok := vfs.dirs[name]; ok {
+			return nil, errors.Errorf("'%s' is a file and a folder in version '%s'", name, version)
+		}
+	}
+	return vfs, nil
+}
+
+func (vfs *versionFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if file, ok := vfs.files[name]; ok {
+		fp, err := vfs.fsys.Open(file.internal)
+		if err != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+		return &versionFile{File: fp, name: path.Base(name)}, nil
+	}
+	if _, ok := vfs.dirs[name]; ok {
+		entries, err := vfs.ReadDir(name)
+		if err != nil {
+			return nil, err
+		}
+		return &versionDir{info: vfs.dirInfo(name), entries: entries}, nil
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+func (vfs *versionFS) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+	if file, ok := vfs.files[name]; ok {
+		info, err := fs.Stat(vfs.fsys, file.internal)
+		if err != nil {
+			return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+		}
+		return &versionFileInfo{FileInfo: info, name: path.Base(name)}, nil
+	}
+	if _, ok := vfs.dirs[name]; ok {
+		return vfs.dirInfo(name), nil
+	}
+	return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+}
+
+func (vfs *versionFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	names, ok := vfs.dirs[name]
+	if !ok {
+		if _, ok := vfs.files[name]; ok {
+			return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+		}
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	var entries = make([]fs.DirEntry, 0, len(names))
+	for _, entryName := range names {
+		entries = append(entries, &versionDirEntry{vfs: vfs, name: path.Join(name, entryName)})
+	}
+	return entries, nil
+}
+
+func (vfs *versionFS) dirInfo(name string) *versionDirInfo {
+	return &versionDirInfo{name: path.Base(name), modTime: vfs.modTime}
+}
+
+// versionDirEntry reads the file info from the object only if needed
+type versionDirEntry struct {
+	vfs  *versionFS
+	name string
+}
+
+func (de *versionDirEntry) Name() string { return path.Base(de.name) }
+
+func (de *versionDirEntry) IsDir() bool {
+	_, ok := de.vfs.dirs[de.name]
+	return ok
+}
+
+func (de *versionDirEntry) Type() fs.FileMode {
+	if de.IsDir() {
+		return fs.ModeDir
+	}
+	return 0
+}
+
+func (de *versionDirEntry) Info() (fs.FileInfo, error) { return de.vfs.Stat(de.name) }
+
+type versionDirInfo struct {
+	name    string
+	modTime time.Time
+}
+
+func (di *versionDirInfo) Name() string       { return di.name }
+func (di *versionDirInfo) Size() int64        { return 0 }
+func (di *versionDirInfo) Mode() fs.FileMode  { return fs.ModeDir | 0555 }
+func (di *versionDirInfo) ModTime() time.Time { return di.modTime }
+func (di *versionDirInfo) IsDir() bool        { return true }
+func (di *versionDirInfo) Sys() any           { return nil }
+
+// versionFileInfo replaces the internal name with the logical name
+type versionFileInfo struct {
+	fs.FileInfo
+	name string
+}
+
+func (fi *versionFileInfo) Name() string      { return fi.name }
+func (fi *versionFileInfo) Mode() fs.FileMode { return fi.FileInfo.Mode() &^ 0222 }
+
+type versionFile struct {
+	fs.File
+	name string
+}
+
+func (f *versionFile) Stat() (fs.FileInfo, error) {
+	info, err := f.File.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return &versionFileInfo{FileInfo: info, name: f.name}, nil
+}
+
+// Seek is supported, if the underlying file is seekable
+func (f *versionFile) Seek(offset int64, whence int) (int64, error) {
+	seeker, ok := f.File.(io.Seeker)
+	if !ok {
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: errors.New("not supported")}
+	}
+	return seeker.Seek(offset, whence)
+}
+
+// ReadAt is supported, if the underlying file implements io.ReaderAt
+func (f *versionFile) ReadAt(p []byte, off int64) (int, error) {
+	readerAt, ok := f.File.(io.ReaderAt)
+	if !ok {
+		return 0, &fs.PathError{Op: "readat", Path: f.name, Err: errors.New("not supported")}
+	}
+	return readerAt.ReadAt(p, off)
+}
+
+type versionDir struct {
+	info    *versionDirInfo
+	entries []fs.DirEntry
+	pos     int
+}
+
+func (d *versionDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+
+func (d *versionDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: errors.New("is a directory")}
+}
+
+func (d *versionDir) Close() error { return nil }
+
+func (d *versionDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	entries := d.entries[d.pos:]
+	if n > 0 {
+		if len(entries) == 0 {
+			return nil, io.EOF
+		}
+		if len(entries) > n {
+			entries = entries[:n]
+		}
+	}
+	d.pos += len(entries)
+	return entries, nil
+}
+
+var (
+	_ fs.ReadDirFS   = &versionFS{}
+	_ fs.StatFS      = &versionFS{}
+	_ fs.ReadDirFile = &versionDir{}
+)
@@ -0,0 +1,75 @@
+package ocfl_test
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestVersionFS(t *testing.T) {
+	tr := newTestRoot(t)
+	if err := tr.update("a", testFiles(map[string]string{"a.txt": "first", "sub/b.txt": "b", "sub/deep/c.txt": "c"})); err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.update("a", testFiles(map[string]string{"a.txt": "second", "sub/b.txt": "b", "d.txt": "d"})); err != nil {
+		t.Fatal(err)
+	}
+	o, err := tr.load().LoadObjectByID("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		version string
+		files   []string
+		content string
+	}{
+		{version: "v1", files: []string{"a.txt", "sub/b.txt", "sub/deep/c.txt"}, content: "first"},
+		{version: "v2", files: []string{"a.txt", "d.txt", "sub/b.txt"}, content: "second"},
+		{version: "latest", files: []string{"a.txt", "d.txt", "sub/b.txt"}, content: "second"},
+	} {
+		t.Run(test.version, func(t *testing.T) {
+			vfs, err := o.VersionFS(test.version, "content")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := fstest.TestFS(vfs, test.files...); err != nil {
+				t.Fatal(err)
+			}
+			fp, err := vfs.Open("a.txt")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer fp.Close()
+			seeker, ok := fp.(io.Seeker)
+			if !ok {
+				t.Fatal("file is not seekable")
+			}
+			if _, err := seeker.Seek(1, io.SeekStart); err != nil {
+				t.Fatal(err)
+			}
+			data, err := io.ReadAll(fp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != test.content[1:] {
+				t.Fatalf("expected '%s' after seek, got '%s'", test.content[1:], data)
+			}
+			readerAt, ok := fp.(io.ReaderAt)
+			if !ok {
+				t.Fatal("file does not implement io.ReaderAt")
+			}
+			buf := make([]byte, 3)
+			if _, err := readerAt.ReadAt(buf, 2); err != nil {
+				t.Fatal(err)
+			}
+			if string(buf) != test.content[2:5] {
+				t.Fatalf("expected '%s' at offset 2, got '%s'", test.content[2:5], buf)
+			}
+			if _, err := fs.Stat(vfs, "missing.txt"); !errors.Is(err, fs.ErrNotExist) {
+				t.Fatalf("expected fs.ErrNotExist, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package ocfl
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+
+	"emperror.dev/errors"
+)
+
+var ErrNoVersionTags = errors.New("object has no version tags extension")
+
+// ExtensionVersionTags maps labels like 'received' or 'published' to versions of an object.
+// The tags are not part of the versions and can be changed without a new version
+type ExtensionVersionTags interface {
+	Extension
+	GetVersionTags(object Object) (map[string]string, error)
+	SetVersionTags(object Object, tags map[string]string) error
+}
+
+var versionTagRegexp = regexp.MustCompile(`^[\p{L}\p{N}][\p{L}\p{N}._:-]*$`)
+var versionNameRegexp = regexp.MustCompile(`^v[0-9]+$`)
+
+// CheckVersionTag checks, whether tag can be used as label of a version
+func CheckVersionTag(tag string) error {
+	if tag == "latest" || versionNameRegexp.MatchString(tag) {
+		return errors.Errorf("tag '%s' is a version name", tag)
+	}
+	if !versionTagRegexp.MatchString(tag) {
+		return errors.Errorf("invalid tag '%s', only letters, digits and '.', '_', ':', '-' are allowed", tag)
+	}
+	return nil
+}
+
+func (object *ObjectBase) versionTagExtensions() []ExtensionVersionTags {
+	var result = []ExtensionVersionTags{}
+	for _, ext := range object.extensionManager.GetExtensions() {
+		if vt, ok := ext.(ExtensionVersionTags); ok {
+			result = append(result, vt)
+		}
+	}
+	return result
+}
+
+// GetVersionTags returns the tags of the object with their versions
+func (object *ObjectBase) GetVersionTags() (map[string]string, error) {
+	var result = map[string]string{}
+	for _, ext := range object.versionTagExtensions() {
+		tags, err := ext.GetVersionTags(object)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot get version tags of extension '%s'", ext.GetName())
+		}
+		for tag, version := range tags {
+			result[tag] = version
+		}
+	}
+	return result, nil
+}
+
+// ResolveVersion returns the version of a version name or tag. An empty version or 'latest' is the head version
+func (object *ObjectBase) ResolveVersion(version string) (string, error) {
+	if version == "" || version == "latest" {
+		return object.i.GetHead(), nil
+	}
+	if _, ok := object.i.GetVersions()[version]; ok {
+		return version, nil
+	}
+	tags, err := object.GetVersionTags()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	if v, ok := tags[version]; ok {
+		if _, ok := object.i.GetVersions()[v]; ok {
+			return v, nil
+		}
+		return "", errors.Errorf("tag '%s' of object '%s' points to missing version '%s'", version, object.GetID(), v)
+	}
+	return "", errors.Errorf("version or tag '%s' not found in object '%s'", version, object.GetID())
+}
+
+// UpdateVersionTags changes the tags of the object with fn while the object is locked.
+// All tags must point to existing versions. No new version is created
+func (object *ObjectBase) UpdateVersionTags(fn func(tags map[string]string) error) (err error) {
+	exts := object.versionTagExtensions()
+	if len(exts) == 0 {
+		return errors.Wrapf(ErrNoVersionTags, "object '%s'", object.GetID())
+	}
+	if object.lockInfo == nil {
+		if err := object.lock(); err != nil {
+			return errors.Wrapf(err, "cannot lock object '%s'", object.GetID())
+		}
+		defer func() {
+			if err2 := object.unlock(); err2 != nil {
+				err = errors.Combine(err, errors.Wrapf(err2, "cannot unlock object '%s'", object.GetID()))
+			}
+		}()
+		if err := object.reloadAfterLock(); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	ext := exts[0]
+	tags, err := ext.GetVersionTags(object)
+	if err != nil {
+		return errors.Wrapf(err, "cannot get version tags of object '%s'", object.GetID())
+	}
+	if err := fn(tags); err != nil {
+		return errors.WithStack(err)
+	}
+	versions := object.i.GetVersionStrings()
+	for tag, version := range tags {
+		if err := CheckVersionTag(tag); err != nil {
+			return errors.WithStack(err)
+		}
+		if !slices.Contains(versions, version) {
+			return errors.Errorf("version '%s' of tag '%s' not found in object '%s'", version, tag, object.GetID())
+		}
+	}
+	if err := ext.SetVersionTags(object, tags); err != nil {
+		return errors.Wrapf(err, "cannot store version tags of object '%s'", object.GetID())
+	}
+	object.logger.Debug().Any(
+		object.errorFactory.LogError(
+			ErrorOCFL,
+			fmt.Sprintf("version tags of object '%s' updated", object.GetID()),
+			nil,
+		),
+	).Msg("")
+	return nil
+}
+
+// ResolveVersion returns the version of a version name or tag. An empty version or 'latest' is the head version
+func (om *ObjectMetadata) ResolveVersion(version string) (string, bool) {
+	if version == "" || version == "latest" {
+		return om.Head, om.Head != ""
+	}
+	if _, ok := om.Versions[version]; ok {
+		return version, true
+	}
+	for v, vm := range om.Versions {
+		if vm != nil && slices.Contains(vm.Tags, version) {
+			return v, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,203 @@
+package ocfl_test
+
+import (
+	"errors"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/je4/filesystem/v3/pkg/writefs"
+	"github.com/ocfl-archive/gocfl/v2/pkg/extension"
+	"github.com/ocfl-archive/gocfl/v2/pkg/ocfl"
+)
+
+func TestCheckVersionTag(t *testing.T) {
+	for tag, valid := range map[string]bool{
+		"received":    true,
+		"release-1.0": true,
+		"2024:final":  true,
+		"geprüft":     true,
+		"a_b":         true,
+		"":            false,
+		"latest":      false,
+		"v1":          false,
+		"v12":         false,
+		"-received":   false,
+		"a b":         false,
+		"a/b":         false,
+	} {
+		if err := ocfl.CheckVersionTag(tag); valid != (err == nil) {
+			t.Errorf("tag '%s': valid %v, got %v", tag, valid, err)
+		}
+	}
+}
+
+// newTaggedObject creates object id with two versions and the version tags extension
+func newTaggedObject(tr *testRoot, id string) ocfl.Object {
+	tr.t.Helper()
+	tr.extensionFactory.AddCreator(extension.VersionTagsName, func(fsys fs.FS) (ocfl.Extension, error) {
+		return extension.NewVersionTagsFS(fsys)
+	})
+	if err := tr.update(id, testFiles(map[string]string{"x.txt": "x"})); err != nil {
+		tr.t.Fatal(err)
+	}
+	if err := tr.update(id, testFiles(map[string]string{"x.txt": "changed"})); err != nil {
+		tr.t.Fatal(err)
+	}
+	o, err := tr.open(id)
+	if err != nil {
+		tr.t.Fatal(err)
+	}
+	ext, err := extension.NewVersionTags(&extension.VersionTagsConfig{
+		ExtensionConfig: &ocfl.ExtensionConfig{ExtensionName: extension.VersionTagsName},
+	})
+	if err != nil {
+		tr.t.Fatal(err)
+	}
+	extFS, err := writefs.SubFSCreate(o.GetFS(), "extensions/"+extension.VersionTagsName)
+	if err != nil {
+		tr.t.Fatal(err)
+	}
+	ext.SetFS(extFS, true)
+	if err := o.GetExtensionManager().Add(ext); err != nil {
+		tr.t.Fatal(err)
+	}
+	return o
+}
+
+// TestVersionTags sets tags and resolves them in the reloaded object
+func TestVersionTags(t *testing.T) {
+	tr := newTestRoot(t)
+	o := newTaggedObject(tr, "a")
+	tags := map[string]string{"received": "v1", "published": "v2"}
+	if err := o.UpdateVersionTags(func(current map[string]string) error {
+		maps.Copy(current, tags)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(tr.workspaceFolder("a"), ocfl.LockFile)); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("lock file left behind: %v", err)
+	}
+	if head := tr.head("a"); head != "v2" {
+		t.Fatalf("tags created version '%s'", head)
+	}
+
+	loaded, err := tr.load().LoadObjectByID("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err := loaded.GetVersionTags()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !maps.Equal(stored, tags) {
+		t.Fatalf("expected tags %v, got %v", tags, stored)
+	}
+	for _, test := range []struct {
+		version  string
+		expected string
+	}{
+		{version: "", expected: "v2"},
+		{version: "latest", expected: "v2"},
+		{version: "v1", expected: "v1"},
+		{version: "received", expected: "v1"},
+		{version: "published", expected: "v2"},
+		{version: "missing"},
+		{version: "v3"},
+	} {
+		version, err := loaded.ResolveVersion(test.version)
+		if test.expected == "" {
+			if err == nil {
+				t.Errorf("'%s' resolved to '%s'", test.version, version)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("cannot resolve '%s': %v", test.version, err)
+			continue
+		}
+		if version != test.expected {
+			t.Errorf("'%s' resolved to '%s' instead of '%s'", test.version, version, test.expected)
+		}
+	}
+}
+
+// TestVersionTagsRefused checks, that invalid tags and tags of missing versions are not stored
+func TestVersionTagsRefused(t *testing.T) {
+	tr := newTestRoot(t)
+	o := newTaggedObject(tr, "a")
+	if err := o.UpdateVersionTags(func(tags map[string]string) error {
+		tags["received"] = "v1"
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for name, fn := range map[string]func(tags map[string]string) error{
+		"missing version": func(tags map[string]string) error {
+			tags["published"] = "v3"
+			return nil
+		},
+		"version name": func(tags map[string]string) error {
+			tags["v1"] = "v2"
+			return nil
+		},
+		"invalid tag": func(tags map[string]string) error {
+			tags["a b"] = "v2"
+			return nil
+		},
+		"error": func(tags map[string]string) error {
+			delete(tags, "received")
+			return errors.New("refused")
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if err := o.UpdateVersionTags(fn); err == nil {
+				t.Fatal("tags stored")
+			}
+			tags, err := o.GetVersionTags()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if expected := map[string]string{"received": "v1"}; !maps.Equal(tags, expected) {
+				t.Fatalf("expected tags %v, got %v", expected, tags)
+			}
+		})
+	}
+
+	t.Run("tag of missing version", func(t *testing.T) {
+		// the tags file was changed outside of gocfl
+		file := filepath.Join(tr.objectFolder("a"), "extensions", extension.VersionTagsName, extension.VersionTagsFile)
+		if err := os.WriteFile(file, []byte(`{"received": "v1", "old": "v9"}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := tr.load().LoadObjectByID("a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loaded.ResolveVersion("old"); err == nil {
+			t.Fatal("tag of missing version resolved")
+		}
+		if version, err := loaded.ResolveVersion("received"); err != nil || version != "v1" {
+			t.Fatalf("cannot resolve 'received': %s, %v", version, err)
+		}
+	})
+
+	t.Run("no extension", func(t *testing.T) {
+		if err := tr.update("b", testFiles(map[string]string{"x.txt": "x"})); err != nil {
+			t.Fatal(err)
+		}
+		o, err := tr.open("b")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := o.UpdateVersionTags(func(tags map[string]string) error { return nil }); !errors.Is(err, ocfl.ErrNoVersionTags) {
+			t.Fatalf("expected ErrNoVersionTags, got %v", err)
+		}
+		if _, err := o.ResolveVersion("received"); err == nil {
+			t.Fatal("tag of object without tags resolved")
+		}
+	})
+}
@@ -0,0 +1,42 @@
+package ocfl
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/je4/filesystem/v3/pkg/writefs"
+)
+
+// WorkspaceFolder is the folder in the storage root, which holds the working data of running updates.
+// Every object gets a subfolder with the path of the object folder, so nothing is written into the object root
+// but the new version itself
+const WorkspaceFolder = ".gocfl-workspace"
+
+// ObjectWorkspace returns the workspace of the object in folder of the storage root fsys.
+// The folders of the workspace are created on demand
+func ObjectWorkspace(fsys fs.FS, folder string) (fs.FS, error) {
+	workspace, err := writefs.Sub(fsys, path.Join(WorkspaceFolder, folder))
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot create subfs of '%v' for '%s'", fsys, path.Join(WorkspaceFolder, folder))
+	}
+	return workspace, nil
+}
+
+// pruneWorkspace removes the empty folders of the workspace up to the workspace folder of the storage root
+func pruneWorkspace(workspace fs.FS) {
+	folder, ok := localPath(workspace)
+	if !ok || !strings.Contains(filepath.ToSlash(folder), "/"+WorkspaceFolder+"/") {
+		return
+	}
+	for {
+		// folders, which are not empty, are still used by other objects
+		if err := os.Remove(folder); err != nil || filepath.Base(folder) == WorkspaceFolder {
+			return
+		}
+		folder = filepath.Dir(folder)
+	}
+}
@@ -0,0 +1,203 @@
+package batch
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"emperror.dev/errors"
+)
+
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+// Row is one object of a batch manifest. Empty message, user and fixity are taken from the command defaults
+type Row struct {
+	Line        int               `json:"-"`
+	ID          string            `json:"id"`
+	Source      string            `json:"source"`
+	Areas       map[string]string `json:"areas,omitempty"`
+	Message     string            `json:"message,omitempty"`
+	UserName    string            `json:"userName,omitempty"`
+	UserAddress string            `json:"userAddress,omitempty"`
+	Fixity      []string          `json:"fixity,omitempty"`
+}
+
+// Key identifies the row in the report of a batch
+func (row *Row) Key() string {
+	return row.ID + "\x00" + row.Source
+}
+
+// FormatFromName returns the manifest format for the extension of filename
+func FormatFromName(filename string) (string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".jsonl", ".ndjson":
+		return FormatJSONL, nil
+	default:
+		return "", errors.Errorf("unknown manifest format of '%s' (csv|jsonl)", filename)
+	}
+}
+
+// LoadManifest reads the rows of a manifest file
+func LoadManifest(filename string) ([]*Row, error) {
+	format, err := FormatFromName(filename)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	fp, err := os.Open(filename)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot open manifest '%s'", filename)
+	}
+	defer fp.Close()
+	rows, err := ReadManifest(fp, format)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read manifest '%s'", filename)
+	}
+	return rows, nil
+}
+
+// ReadManifest reads the rows of a manifest in csv or jsonl format.
+// csv files need a header with the columns id, source, areas, message, user_name, user_address and fixity.
+// areas are separated by ';' as area:path, fixity algorithms by ','
+func ReadManifest(r io.Reader, format string) ([]*Row, error) {
+	var rows []*Row
+	var err error
+	switch format {
+	case FormatCSV:
+		rows, err = readCSV(r)
+	case FormatJSONL:
+		rows, err = readJSONL(r)
+	default:
+		return nil, errors.Errorf("unknown manifest format '%s'", format)
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for _, row := range rows {
+		if row.ID == "" {
+			return nil, errors.Errorf("line %d: no object id", row.Line)
+		}
+		if row.Source == "" {
+			return nil, errors.Errorf("line %d: no source for object '%s'", row.Line, row.ID)
+		}
+	}
+	return rows, nil
+}
+
+var csvColumns = []string{"id", "source", "areas", "message", "user_name", "user_address", "fixity"}
+
+func readCSV(r io.Reader) ([]*Row, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read csv header")
+	}
+	var columns = map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(csvColumns, name) {
+			return nil, errors.Errorf("unknown csv column '%s' (%s)", name, strings.Join(csvColumns, ", "))
+		}
+		columns[name] = i
+	}
+	for _, name := range []string{"id", "source"} {
+		if _, ok := columns[name]; !ok {
+			return nil, errors.Errorf("no column '%s' in csv header", name)
+		}
+	}
+	var rows = []*Row{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot read csv record")
+		}
+		line, _ := reader.FieldPos(0)
+		value := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		row := &Row{
+			Line:        line,
+			ID:          value("id"),
+			Source:      value("source"),
+			Message:     value("message"),
+			UserName:    value("user_name"),
+			UserAddress: value("user_address"),
+		}
+		if row.Areas, err = parseAreas(value("areas")); err != nil {
+			return nil, errors.Wrapf(err, "line %d", line)
+		}
+		for _, alg := range strings.Split(value("fixity"), ",") {
+			if alg = strings.TrimSpace(alg); alg != "" {
+				row.Fixity = append(row.Fixity, alg)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func readJSONL(r io.Reader) ([]*Row, error) {
+	var rows = []*Row{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := strings.TrimSpace(scanner.Text())
+		if data == "" || strings.HasPrefix(data, "#") {
+			continue
+		}
+		row := &Row{}
+		if err := json.Unmarshal([]byte(data), row); err != nil {
+			return nil, errors.Wrapf(err, "line %d: cannot unmarshal row", line)
+		}
+		row.Line = line
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "cannot read jsonl")
+	}
+	return rows, nil
+}
+
+var areaRegexp = regexp.MustCompile("^([a-z]{2,}):(.*)$")
+
+// parseAreas parses a list of area:path separated by ';'
+func parseAreas(str string) (map[string]string, error) {
+	var areas = map[string]string{}
+	for _, part := range strings.Split(str, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		matches := areaRegexp.FindStringSubmatch(part)
+		if matches == nil {
+			return nil, errors.Errorf("invalid area path '%s' (area:path)", part)
+		}
+		areas[matches[1]] = matches[2]
+	}
+	if len(areas) == 0 {
+		return nil, nil
+	}
+	return areas, nil
+}
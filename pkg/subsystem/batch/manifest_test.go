@@ -0,0 +1,112 @@
+package batch
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReadManifestCSV(t *testing.T) {
+	data := `id,source,areas,message,user_name,user_address,fixity
+# comment
+id:a,/data/a,meta:/meta/a;docs:/docs/a,first,Jane,mailto:jane@example.org,"md5, sha256"
+id:b,/data/b,,,,,
+`
+	rows, err := ReadManifest(strings.NewReader(data), FormatCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("%d rows, expected 2", len(rows))
+	}
+	a := rows[0]
+	if a.ID != "id:a" || a.Source != "/data/a" || a.Message != "first" || a.UserName != "Jane" || a.UserAddress != "mailto:jane@example.org" {
+		t.Errorf("unexpected row %+v", a)
+	}
+	if a.Areas["meta"] != "/meta/a" || a.Areas["docs"] != "/docs/a" {
+		t.Errorf("unexpected areas %v", a.Areas)
+	}
+	if !slices.Equal(a.Fixity, []string{"md5", "sha256"}) {
+		t.Errorf("unexpected fixity %v", a.Fixity)
+	}
+	if a.Line != 3 {
+		t.Errorf("line %d, expected 3", a.Line)
+	}
+	if b := rows[1]; b.Areas != nil || b.Fixity != nil || b.Message != "" {
+		t.Errorf("unexpected row %+v", b)
+	}
+}
+
+func TestReadManifestJSONL(t *testing.T) {
+	data := `{"id":"id:a","source":"/data/a","areas":{"meta":"/meta/a"},"fixity":["md5"]}
+
+{"id":"id:b","source":"/data/b","message":"second"}
+`
+	rows, err := ReadManifest(strings.NewReader(data), FormatJSONL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0].Areas["meta"] != "/meta/a" || rows[1].Message != "second" || rows[1].Line != 3 {
+		t.Errorf("unexpected rows %+v %+v", rows[0], rows[1])
+	}
+}
+
+func TestReadManifestInvalid(t *testing.T) {
+	for name, data := range map[string]string{
+		"unknown column": "id,source,owner\nid:a,/a,x\n",
+		"no source":      "id,message\nid:a,x\n",
+		"empty id":       "id,source\n,/a\n",
+		"invalid area":   "id,source,areas\nid:a,/a,/meta\n",
+	} {
+		if _, err := ReadManifest(strings.NewReader(data), FormatCSV); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+	if _, err := FormatFromName("batch.txt"); err == nil {
+		t.Error("unknown extension accepted")
+	}
+}
+
+func TestGroupByID(t *testing.T) {
+	rows := []*Row{{ID: "a", Line: 1}, {ID: "b", Line: 2}, {ID: "a", Line: 3}, {ID: "c", Line: 4}}
+	groups := GroupByID(rows)
+	if len(groups) != 3 || len(groups[0]) != 2 || groups[0][1].Line != 3 || groups[1][0].ID != "b" || groups[2][0].ID != "c" {
+		t.Errorf("unexpected groups %v", groups)
+	}
+}
+
+func TestReport(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "batch.report.jsonl")
+	ok := &Row{ID: "id:a", Source: "/data/a", Line: 2}
+	failed := &Row{ID: "id:b", Source: "/data/b", Line: 3}
+	report, err := OpenReport(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := report.Write(NewResult(ok).Done(nil)); err != nil {
+		t.Fatal(err)
+	}
+	if err := report.Write(NewResult(failed).Done(errors.New("broken"))); err != nil {
+		t.Fatal(err)
+	}
+	if err := report.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err = OpenReport(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer report.Close()
+	if !report.Completed(ok) {
+		t.Error("successful row not completed")
+	}
+	if report.Completed(failed) {
+		t.Error("failed row completed")
+	}
+	if report.Completed(&Row{ID: "id:a", Source: "/data/other"}) {
+		t.Error("row with other source completed")
+	}
+}
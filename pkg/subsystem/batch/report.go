@@ -0,0 +1,136 @@
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+)
+
+const (
+	StatusOK     = "ok"
+	StatusFailed = "failed"
+)
+
+// Result is the outcome of one row. It is written as one json line to the report
+type Result struct {
+	Line     int       `json:"line,omitempty"`
+	ID       string    `json:"id"`
+	Source   string    `json:"source"`
+	Status   string    `json:"status"`
+	Modified bool      `json:"modified"`
+	Skipped  int       `json:"skipped,omitempty"`
+	Errors   int       `json:"validationErrors,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Start    time.Time `json:"start"`
+	Duration string    `json:"duration"`
+}
+
+func NewResult(row *Row) *Result {
+	return &Result{
+		Line:   row.Line,
+		ID:     row.ID,
+		Source: row.Source,
+		Start:  time.Now(),
+	}
+}
+
+// Done sets the status and the duration of the result
+func (result *Result) Done(err error) *Result {
+	result.Duration = time.Since(result.Start).String()
+	if err != nil {
+		result.Status = StatusFailed
+		result.Error = err.Error()
+	} else {
+		result.Status = StatusOK
+	}
+	return result
+}
+
+// Report is the result file of a batch. Results are appended, so a batch can be restarted
+// and skips the rows, which are already completed
+type Report struct {
+	sync.Mutex
+	fp        *os.File
+	completed map[string]bool
+}
+
+// OpenReport loads the completed rows of filename and opens it for appending
+func OpenReport(filename string) (*Report, error) {
+	report := &Report{completed: map[string]bool{}}
+	if data, err := os.ReadFile(filename); err == nil {
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+			result := &Result{}
+			if err := json.Unmarshal([]byte(line), result); err != nil {
+				return nil, errors.Wrapf(err, "invalid line in report '%s'", filename)
+			}
+			key := (&Row{ID: result.ID, Source: result.Source}).Key()
+			report.completed[key] = result.Status == StatusOK
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, errors.Wrapf(err, "cannot read report '%s'", filename)
+	}
+	fp, err := os.OpenFile(filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot open report '%s'", filename)
+	}
+	report.fp = fp
+	return report, nil
+}
+
+// Completed returns true, if the row was successful in an earlier run
+func (report *Report) Completed(row *Row) bool {
+	report.Lock()
+	defer report.Unlock()
+	return report.completed[row.Key()]
+}
+
+// Write appends result to the report
+func (report *Report) Write(result *Result) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return errors.Wrapf(err, "cannot marshal result of '%s'", result.ID)
+	}
+	report.Lock()
+	defer report.Unlock()
+	if _, err := report.fp.Write(append(data, '\n')); err != nil {
+		return errors.Wrapf(err, "cannot write result of '%s'", result.ID)
+	}
+	if err := report.fp.Sync(); err != nil {
+		return errors.Wrapf(err, "cannot sync report")
+	}
+	report.completed[(&Row{ID: result.ID, Source: result.Source}).Key()] = result.Status == StatusOK
+	return nil
+}
+
+func (report *Report) Close() error {
+	return errors.WithStack(report.fp.Close())
+}
+
+// GroupByID groups the rows by object id in order of appearance.
+// The rows of a group belong to the same object and must be processed sequentially
+func GroupByID(rows []*Row) [][]*Row {
+	var groups = [][]*Row{}
+	var index = map[string]int{}
+	for _, row := range rows {
+		i, ok := index[row.ID]
+		if !ok {
+			i = len(groups)
+			index[row.ID] = i
+			groups = append(groups, []*Row{})
+		}
+		groups[i] = append(groups[i], row)
+	}
+	return groups
+}
@@ -0,0 +1,153 @@
+package dedup
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/je4/utils/v2/pkg/checksum"
+)
+
+// Location is a logical path of an object version with the content of a digest.
+// A path is listed with the first version, in which it has this content
+type Location struct {
+	Object  string `json:"object"`
+	Version string `json:"version"`
+	Path    string `json:"path"`
+}
+
+// SizeFunc returns the size of the stored content contentPath of object
+type SizeFunc func(object, contentPath string) (int64, error)
+
+// content is the stored content of a digest in all objects
+type content struct {
+	// object -> content paths of the manifest
+	copies    map[string][]string
+	locations []*Location
+}
+
+// Index maps the digests of all objects of a storage root to their stored copies and logical paths
+type Index struct {
+	Algorithm checksum.DigestAlgorithm
+	contents  map[string]*content
+	objects   []string
+	skipped   []string
+}
+
+func NewIndex(algorithm checksum.DigestAlgorithm) *Index {
+	return &Index{
+		Algorithm: algorithm,
+		contents:  map[string]*content{},
+		objects:   []string{},
+		skipped:   []string{},
+	}
+}
+
+func (idx *Index) get(digest string) *content {
+	digest = strings.ToLower(digest)
+	c, ok := idx.contents[digest]
+	if !ok {
+		c = &content{copies: map[string][]string{}, locations: []*Location{}}
+		idx.contents[digest] = c
+	}
+	return c
+}
+
+// AddObject registers an indexed object
+func (idx *Index) AddObject(object string) {
+	idx.objects = append(idx.objects, object)
+}
+
+// SkipObject registers an object, which has no digests of the index algorithm
+func (idx *Index) SkipObject(object string) {
+	idx.skipped = append(idx.skipped, object)
+}
+
+// AddContent adds the content paths of the manifest of object with digest
+func (idx *Index) AddContent(object, digest string, contentPaths []string) {
+	c := idx.get(digest)
+	c.copies[object] = append(c.copies[object], contentPaths...)
+}
+
+// AddLocation adds a logical path with the content of digest
+func (idx *Index) AddLocation(digest string, location *Location) {
+	c := idx.get(digest)
+	c.locations = append(c.locations, location)
+}
+
+// Cluster is the content of a digest, which is stored more than once in the storage root
+type Cluster struct {
+	Digest string `json:"digest"`
+	Size   int64  `json:"size"`
+	// Copies is the number of stored copies in all objects
+	Copies  int `json:"copies"`
+	Objects int `json:"objects"`
+	// WastedBytes is the size of all copies but one
+	WastedBytes int64       `json:"wastedBytes"`
+	Locations   []*Location `json:"locations"`
+}
+
+// Report lists the duplicate clusters of an index, ordered by wasted bytes
+type Report struct {
+	Algorithm      checksum.DigestAlgorithm `json:"digestAlgorithm"`
+	Objects        int                      `json:"objects"`
+	SkippedObjects []string                 `json:"skippedObjects,omitempty"`
+	Digests        int                      `json:"digests"`
+	Clusters       int                      `json:"clusters"`
+	WastedBytes    int64                    `json:"wastedBytes"`
+	// Largest are the clusters with the most wasted bytes
+	Largest []*Cluster `json:"largest"`
+}
+
+// Report builds the duplicate clusters. The size of a content is only requested for digests with more than one copy.
+// top limits the number of listed clusters, 0 lists all
+func (idx *Index) Report(size SizeFunc, top int) (*Report, error) {
+	report := &Report{
+		Algorithm:      idx.Algorithm,
+		Objects:        len(idx.objects),
+		SkippedObjects: idx.skipped,
+		Digests:        len(idx.contents),
+	}
+	var clusters = []*Cluster{}
+	for digest, c := range idx.contents {
+		var copies int
+		var object, contentPath string
+		for o, paths := range c.copies {
+			copies += len(paths)
+			if len(paths) > 0 {
+				object, contentPath = o, paths[0]
+			}
+		}
+		if copies < 2 {
+			continue
+		}
+		s, err := size(object, contentPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot get size of '%s' in object '%s'", contentPath, object)
+		}
+		locations := slices.Clone(c.locations)
+		slices.SortFunc(locations, func(a, b *Location) int {
+			return cmp.Or(cmp.Compare(a.Object, b.Object), cmp.Compare(a.Path, b.Path), cmp.Compare(a.Version, b.Version))
+		})
+		cluster := &Cluster{
+			Digest:      digest,
+			Size:        s,
+			Copies:      copies,
+			Objects:     len(c.copies),
+			WastedBytes: s * int64(copies-1),
+			Locations:   locations,
+		}
+		report.WastedBytes += cluster.WastedBytes
+		clusters = append(clusters, cluster)
+	}
+	slices.SortFunc(clusters, func(a, b *Cluster) int {
+		return cmp.Or(cmp.Compare(b.WastedBytes, a.WastedBytes), cmp.Compare(b.Copies, a.Copies), cmp.Compare(a.Digest, b.Digest))
+	})
+	report.Clusters = len(clusters)
+	if top > 0 && len(clusters) > top {
+		clusters = clusters[:top]
+	}
+	report.Largest = clusters
+	return report, nil
+}
@@ -0,0 +1,118 @@
+package dedup
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/je4/utils/v2/pkg/checksum"
+)
+
+func testIndex() *Index {
+	idx := NewIndex(checksum.DigestSHA512)
+	idx.AddObject("id:a")
+	idx.AddObject("id:b")
+	idx.AddObject("id:c")
+	idx.SkipObject("id:md5")
+	// big.tif is in all objects, small.txt in a and b, b stores it twice
+	idx.AddContent("id:a", "AAAA", []string{"v1/content/big.tif"})
+	idx.AddContent("id:b", "aaaa", []string{"v1/content/master.tif"})
+	idx.AddContent("id:c", "aaaa", []string{"v2/content/big.tif"})
+	idx.AddContent("id:a", "bbbb", []string{"v1/content/small.txt"})
+	idx.AddContent("id:b", "bbbb", []string{"v1/content/small.txt", "v2/content/copy.txt"})
+	idx.AddContent("id:c", "cccc", []string{"v1/content/unique.txt"})
+	idx.AddLocation("aaaa", &Location{Object: "id:a", Version: "v1", Path: "big.tif"})
+	idx.AddLocation("aaaa", &Location{Object: "id:b", Version: "v1", Path: "master.tif"})
+	idx.AddLocation("aaaa", &Location{Object: "id:c", Version: "v2", Path: "big.tif"})
+	idx.AddLocation("bbbb", &Location{Object: "id:b", Version: "v1", Path: "small.txt"})
+	idx.AddLocation("bbbb", &Location{Object: "id:a", Version: "v1", Path: "small.txt"})
+	idx.AddLocation("bbbb", &Location{Object: "id:b", Version: "v2", Path: "copy.txt"})
+	idx.AddLocation("cccc", &Location{Object: "id:c", Version: "v1", Path: "unique.txt"})
+	return idx
+}
+
+func testSize(object, contentPath string) (int64, error) {
+	if strings.HasSuffix(contentPath, ".tif") {
+		return 1000, nil
+	}
+	return 10, nil
+}
+
+func TestReport(t *testing.T) {
+	report, err := testIndex().Report(testSize, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Objects != 3 || report.Digests != 3 || report.Clusters != 2 || len(report.Largest) != 2 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if report.WastedBytes != 2*1000+2*10 {
+		t.Errorf("wasted %d, expected %d", report.WastedBytes, 2*1000+2*10)
+	}
+	big := report.Largest[0]
+	if big.Digest != "aaaa" || big.Copies != 3 || big.Objects != 3 || big.WastedBytes != 2000 {
+		t.Errorf("unexpected largest cluster %+v", big)
+	}
+	small := report.Largest[1]
+	if small.Digest != "bbbb" || small.Copies != 3 || small.Objects != 2 || len(small.Locations) != 3 {
+		t.Errorf("unexpected cluster %+v", small)
+	}
+	if loc := small.Locations[0]; loc.Object != "id:a" || loc.Path != "small.txt" {
+		t.Errorf("locations not sorted: %+v", loc)
+	}
+
+	report, err = testIndex().Report(testSize, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Clusters != 2 || len(report.Largest) != 1 || report.WastedBytes != 2020 {
+		t.Errorf("unexpected top report %+v", report)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	report, err := testIndex().Report(testSize, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := report.Write(buf, FormatText); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"Duplicate clusters: 2", "Skipped objects (no sha512 digests): id:md5", "1. 2.0 kB wasted, 3 copies of 1.0 kB in 3 objects", "   id:c v2 big.tif"} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("text report does not contain '%s':\n%s", expected, buf.String())
+		}
+	}
+
+	buf.Reset()
+	if err := report.Write(buf, FormatCSV); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 7 || rows[1][0] != "aaaa" || rows[1][4] != "2000" || rows[5][7] != "copy.txt" {
+		t.Errorf("unexpected csv %v", rows)
+	}
+
+	buf.Reset()
+	if err := report.Write(buf, FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	var result = &Report{}
+	if err := json.Unmarshal(buf.Bytes(), result); err != nil {
+		t.Fatal(err)
+	}
+	if result.WastedBytes != report.WastedBytes || len(result.Largest) != 2 || result.Largest[1].Locations[1].Version != "v2" {
+		t.Errorf("unexpected json %s", buf.String())
+	}
+
+	if err := report.Write(buf, "xml"); err == nil {
+		t.Error("format xml should fail")
+	}
+}
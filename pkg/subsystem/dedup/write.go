@@ -0,0 +1,105 @@
+package dedup
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/dustin/go-humanize"
+)
+
+const (
+	FormatText = "text"
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+var Formats = []string{FormatText, FormatCSV, FormatJSON}
+
+// Write writes the report in format
+func (report *Report) Write(w io.Writer, format string) error {
+	switch strings.ToLower(format) {
+	case FormatText:
+		return errors.WithStack(report.WriteText(w))
+	case FormatCSV:
+		return errors.WithStack(report.WriteCSV(w))
+	case FormatJSON:
+		return errors.WithStack(report.WriteJSON(w))
+	default:
+		return errors.Errorf("unknown report format '%s' (%s)", format, strings.Join(Formats, "|"))
+	}
+}
+
+// WriteText writes a summary and the largest clusters with their locations
+func (report *Report) WriteText(w io.Writer) error {
+	var lines = []string{
+		fmt.Sprintf("Digest algorithm: %s", report.Algorithm),
+		fmt.Sprintf("Objects: %d", report.Objects),
+	}
+	if len(report.SkippedObjects) > 0 {
+		lines = append(lines, fmt.Sprintf("Skipped objects (no %s digests): %s", report.Algorithm, strings.Join(report.SkippedObjects, ", ")))
+	}
+	lines = append(lines,
+		fmt.Sprintf("Digests: %d", report.Digests),
+		fmt.Sprintf("Duplicate clusters: %d", report.Clusters),
+		fmt.Sprintf("Wasted: %s (%d bytes)", humanize.Bytes(uint64(report.WastedBytes)), report.WastedBytes),
+	)
+	if len(report.Largest) > 0 {
+		lines = append(lines, "", fmt.Sprintf("Largest %d clusters:", len(report.Largest)))
+	}
+	for num, cluster := range report.Largest {
+		lines = append(lines,
+			"",
+			fmt.Sprintf("%d. %s wasted, %d copies of %s in %d objects", num+1, humanize.Bytes(uint64(cluster.WastedBytes)), cluster.Copies, humanize.Bytes(uint64(cluster.Size)), cluster.Objects),
+			fmt.Sprintf("   %s", cluster.Digest),
+		)
+		for _, loc := range cluster.Locations {
+			lines = append(lines, fmt.Sprintf("   %s %s %s", loc.Object, loc.Version, loc.Path))
+		}
+	}
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return errors.Wrap(err, "cannot write report")
+		}
+	}
+	return nil
+}
+
+// WriteCSV writes one row per location of the largest clusters
+func (report *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"digest", "size", "copies", "objects", "wasted_bytes", "object", "version", "path"}); err != nil {
+		return errors.Wrap(err, "cannot write csv header")
+	}
+	for _, cluster := range report.Largest {
+		for _, loc := range cluster.Locations {
+			if err := cw.Write([]string{
+				cluster.Digest,
+				strconv.FormatInt(cluster.Size, 10),
+				strconv.Itoa(cluster.Copies),
+				strconv.Itoa(cluster.Objects),
+				strconv.FormatInt(cluster.WastedBytes, 10),
+				loc.Object,
+				loc.Version,
+				loc.Path,
+			}); err != nil {
+				return errors.Wrapf(err, "cannot write csv row of '%s'", cluster.Digest)
+			}
+		}
+	}
+	cw.Flush()
+	return errors.Wrap(cw.Error(), "cannot write csv")
+}
+
+func (report *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return errors.Wrap(err, "cannot write json report")
+	}
+	return nil
+}
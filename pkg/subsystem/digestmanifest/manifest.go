@@ -0,0 +1,196 @@
+package digestmanifest
+
+import (
+	"bufio"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/je4/utils/v2/pkg/checksum"
+)
+
+const (
+	// FormatSum is the output of sha512sum and similar tools, in gnu or bsd style
+	FormatSum = "sum"
+	// FormatBagIt is a payload manifest of a BagIt bag (manifest-<algorithm>.txt)
+	FormatBagIt = "bagit"
+)
+
+// BagItPayload is the payload folder of a BagIt bag
+const BagItPayload = "data"
+
+// Manifest is a list of files and their digests
+type Manifest struct {
+	Format    string
+	Algorithm checksum.DigestAlgorithm
+	// Digests maps the slash separated paths to their lower case hex digests
+	Digests map[string]string
+}
+
+// digestLength maps the length of a hex digest to its algorithm, if the algorithm is not known otherwise
+var digestLength = map[int]checksum.DigestAlgorithm{
+	32:  checksum.DigestMD5,
+	40:  checksum.DigestSHA1,
+	64:  checksum.DigestSHA256,
+	128: checksum.DigestSHA512,
+}
+
+var bagItNameRegexp = regexp.MustCompile(`^manifest-([a-z0-9-]+)\.txt$`)
+var sumNameRegexp = regexp.MustCompile(`^(?:.*\.)?([a-z0-9-]+?)(?:sums?)?$`)
+var bsdLineRegexp = regexp.MustCompile(`^([A-Za-z0-9-]+) ?\((.+)\) ?= ?([0-9a-fA-F]+)$`)
+var hexRegexp = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+
+// FormatFromName returns the format and the digest algorithm of a manifest file.
+// The algorithm is empty, if it cannot be derived from the name
+func FormatFromName(filename string) (string, checksum.DigestAlgorithm) {
+	name := strings.ToLower(filepath.Base(filename))
+	if matches := bagItNameRegexp.FindStringSubmatch(name); matches != nil {
+		return FormatBagIt, checksum.DigestAlgorithm(matches[1])
+	}
+	if matches := sumNameRegexp.FindStringSubmatch(strings.TrimSuffix(name, ".txt")); matches != nil && checksum.HashExists(checksum.DigestAlgorithm(matches[1])) {
+		return FormatSum, checksum.DigestAlgorithm(matches[1])
+	}
+	return FormatSum, ""
+}
+
+// Load reads the manifest file filename. The format and algorithm are derived from its name and content
+func Load(filename string) (*Manifest, error) {
+	format, alg := FormatFromName(filename)
+	fp, err := os.Open(filename)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot open digest manifest '%s'", filename)
+	}
+	defer fp.Close()
+	m, err := Read(fp, format, alg)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read digest manifest '%s'", filename)
+	}
+	return m, nil
+}
+
+// Read reads a manifest in format. If alg is empty, it is taken from bsd style lines or guessed from the length of the digests
+func Read(r io.Reader, format string, alg checksum.DigestAlgorithm) (*Manifest, error) {
+	if format != FormatSum && format != FormatBagIt {
+		return nil, errors.Errorf("unknown digest manifest format '%s' (%s|%s)", format, FormatSum, FormatBagIt)
+	}
+	m := &Manifest{
+		Format:    format,
+		Algorithm: alg,
+		Digests:   map[string]string{},
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	var lineNo int
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" || (format == FormatSum && strings.HasPrefix(line, "#")) {
+			continue
+		}
+		var lineAlg checksum.DigestAlgorithm
+		var digest, name string
+		var err error
+		if format == FormatBagIt {
+			digest, name, err = parseBagItLine(line)
+		} else {
+			lineAlg, digest, name, err = parseSumLine(line)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d", lineNo)
+		}
+		if lineAlg == "" {
+			if lineAlg = m.Algorithm; lineAlg == "" {
+				var ok bool
+				if lineAlg, ok = digestLength[len(digest)]; !ok {
+					return nil, errors.Errorf("line %d: cannot guess digest algorithm of '%s'", lineNo, digest)
+				}
+			}
+		}
+		if m.Algorithm == "" {
+			m.Algorithm = lineAlg
+		}
+		if lineAlg != m.Algorithm {
+			return nil, errors.Errorf("line %d: digest algorithm '%s' differs from '%s'", lineNo, lineAlg, m.Algorithm)
+		}
+		if name, err = cleanName(name); err != nil {
+			return nil, errors.Wrapf(err, "line %d", lineNo)
+		}
+		digest = strings.ToLower(digest)
+		if existing, ok := m.Digests[name]; ok && existing != digest {
+			return nil, errors.Errorf("line %d: different digests for '%s'", lineNo, name)
+		}
+		m.Digests[name] = digest
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "cannot read digest manifest")
+	}
+	if m.Algorithm == "" {
+		return nil, errors.New("empty digest manifest")
+	}
+	if !checksum.HashExists(m.Algorithm) {
+		return nil, errors.Errorf("unknown digest algorithm '%s'", m.Algorithm)
+	}
+	return m, nil
+}
+
+// parseSumLine parses gnu style lines '<digest>  <path>' or '<digest> *<path>' and bsd style lines 'SHA512 (<path>) = <digest>'
+func parseSumLine(line string) (checksum.DigestAlgorithm, string, string, error) {
+	if matches := bsdLineRegexp.FindStringSubmatch(line); matches != nil {
+		return checksum.DigestAlgorithm(strings.ToLower(matches[1])), matches[3], matches[2], nil
+	}
+	// names with backslash or newline are escaped and the line starts with a backslash
+	escaped := strings.HasPrefix(line, "\\")
+	if escaped {
+		line = line[1:]
+	}
+	digest, name, found := strings.Cut(line, " ")
+	if !found || !hexRegexp.MatchString(digest) {
+		return "", "", "", errors.Errorf("expected '<digest>  <path>': '%s'", line)
+	}
+	if strings.HasPrefix(name, " ") || strings.HasPrefix(name, "*") {
+		name = name[1:]
+	}
+	if escaped {
+		name = strings.NewReplacer("\\\\", "\\", "\\n", "\n", "\\r", "\r").Replace(name)
+	}
+	return "", digest, name, nil
+}
+
+// parseBagItLine parses the lines '<digest> <path>' of a BagIt manifest. Line breaks and '%' are percent encoded in the path
+func parseBagItLine(line string) (string, string, error) {
+	fields := strings.SplitN(strings.TrimSpace(line), " ", 2)
+	if len(fields) != 2 || !hexRegexp.MatchString(fields[0]) {
+		fields = strings.SplitN(strings.TrimSpace(line), "\t", 2)
+	}
+	if len(fields) != 2 || !hexRegexp.MatchString(fields[0]) {
+		return "", "", errors.Errorf("expected '<digest> <path>': '%s'", line)
+	}
+	name := strings.TrimLeft(fields[1], " \t")
+	name = strings.NewReplacer("%0A", "\n", "%0a", "\n", "%0D", "\r", "%0d", "\r", "%25", "%").Replace(name)
+	return fields[0], name, nil
+}
+
+func cleanName(name string) (string, error) {
+	name = strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(name)), "/")
+	if name == "" || !fs.ValidPath(name) {
+		return "", errors.Errorf("invalid path '%s'", name)
+	}
+	return name, nil
+}
+
+// StripPrefix removes prefix from all paths. Paths outside of prefix are removed
+func (m *Manifest) StripPrefix(prefix string) {
+	prefix = strings.Trim(prefix, "/") + "/"
+	var digests = map[string]string{}
+	for name, digest := range m.Digests {
+		if rest, ok := strings.CutPrefix(name, prefix); ok {
+			digests[rest] = digest
+		}
+	}
+	m.Digests = digests
+}
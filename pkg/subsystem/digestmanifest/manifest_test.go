@@ -0,0 +1,101 @@
+package digestmanifest
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/je4/utils/v2/pkg/checksum"
+)
+
+const (
+	md5A = "d41d8cd98f00b204e9800998ecf8427e"
+	md5B = "0cc175b9c0f1b6a831c399e269772661"
+)
+
+func TestFormatFromName(t *testing.T) {
+	for name, expected := range map[string]struct {
+		format string
+		alg    checksum.DigestAlgorithm
+	}{
+		"manifest-sha512.txt":    {FormatBagIt, checksum.DigestSHA512},
+		"/bag/manifest-md5.txt":  {FormatBagIt, checksum.DigestMD5},
+		"SHA512SUMS":             {FormatSum, checksum.DigestSHA512},
+		"sha256sum.txt":          {FormatSum, checksum.DigestSHA256},
+		"delivery.md5":           {FormatSum, checksum.DigestMD5},
+		"checksums.sha1":         {FormatSum, checksum.DigestSHA1},
+		"digests.txt":            {FormatSum, ""},
+		"tagmanifest-sha512.txt": {FormatSum, ""},
+	} {
+		format, alg := FormatFromName(name)
+		if format != expected.format || alg != expected.alg {
+			t.Errorf("%s: %s/%s, expected %s/%s", name, format, alg, expected.format, expected.alg)
+		}
+	}
+}
+
+func TestReadSum(t *testing.T) {
+	data := `# comment
+` + md5A + `  a.txt
+` + strings.ToUpper(md5B) + ` *./dir/b c.txt
+
+\` + md5A + `  dir/new\nline
+`
+	m, err := Read(strings.NewReader(data), FormatSum, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Algorithm != checksum.DigestMD5 {
+		t.Errorf("algorithm %s, expected md5", m.Algorithm)
+	}
+	expected := map[string]string{"a.txt": md5A, "dir/b c.txt": md5B, "dir/new\nline": md5A}
+	if !maps.Equal(m.Digests, expected) {
+		t.Errorf("%v, expected %v", m.Digests, expected)
+	}
+
+	m, err = Read(strings.NewReader("MD5 (a.txt) = "+md5A+"\nMD5 (dir/b.txt) = "+md5B+"\n"), FormatSum, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Algorithm != checksum.DigestMD5 || m.Digests["dir/b.txt"] != md5B || len(m.Digests) != 2 {
+		t.Errorf("unexpected bsd manifest %+v", m)
+	}
+
+	for _, data := range []string{
+		"",
+		"xyz  a.txt",
+		"abc  a.txt",
+		md5A + "  a.txt\n" + md5B + "  a.txt",
+		md5A + "  a.txt\nSHA1 (b.txt) = da39a3ee5e6b4b0d3255bfef95601890afd80709",
+	} {
+		if _, err := Read(strings.NewReader(data), FormatSum, ""); err == nil {
+			t.Errorf("'%s' should fail", data)
+		}
+	}
+}
+
+func TestLoadBagIt(t *testing.T) {
+	bag := t.TempDir()
+	filename := filepath.Join(bag, "manifest-md5.txt")
+	data := md5A + "  data/a.txt\n" + md5B + " data/dir/100%25%0Ab.txt\n" + md5B + "\tother/c.txt\n"
+	if err := os.WriteFile(filename, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := Load(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Format != FormatBagIt || m.Algorithm != checksum.DigestMD5 {
+		t.Errorf("unexpected manifest %s/%s", m.Format, m.Algorithm)
+	}
+	if len(m.Digests) != 3 || m.Digests["data/dir/100%\nb.txt"] != md5B || m.Digests["other/c.txt"] != md5B {
+		t.Errorf("unexpected digests %v", m.Digests)
+	}
+	m.StripPrefix(BagItPayload)
+	expected := map[string]string{"a.txt": md5A, "dir/100%\nb.txt": md5B}
+	if !maps.Equal(m.Digests, expected) {
+		t.Errorf("%v, expected %v", m.Digests, expected)
+	}
+}
@@ -0,0 +1,171 @@
+package ingestfilter
+
+import (
+	"bufio"
+	"emperror.dev/errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// DefaultIgnoreFile is the name of the file with exclude rules in the root of a source folder
+const DefaultIgnoreFile = ".gocflignore"
+
+// prefix of regular expressions in ignore files
+const regexpPrefix = "regexp:"
+
+type rule struct {
+	pattern string
+	glob    string
+	re      *regexp.Regexp
+	dirOnly bool
+}
+
+func newGlobRule(pattern string) (*rule, error) {
+	glob := strings.TrimSpace(pattern)
+	r := &rule{pattern: pattern}
+	if strings.HasSuffix(glob, "/") {
+		r.dirOnly = true
+		glob = strings.TrimSuffix(glob, "/")
+	}
+	glob = strings.TrimPrefix(glob, "/")
+	if glob == "" {
+		return nil, errors.Errorf("empty glob pattern '%s'", pattern)
+	}
+	if _, err := path.Match(glob, ""); err != nil {
+		return nil, errors.Wrapf(err, "invalid glob pattern '%s'", pattern)
+	}
+	r.glob = glob
+	return r, nil
+}
+
+func newRegexpRule(pattern string) (*rule, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid regular expression '%s'", pattern)
+	}
+	return &rule{pattern: regexpPrefix + pattern, re: re}, nil
+}
+
+// match checks the slash separated path relative to the source root.
+// globs without a slash match the name in every folder, globs with a slash match the whole path
+func (r *rule) match(name string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if r.re != nil {
+		return r.re.MatchString(name)
+	}
+	if !strings.Contains(r.glob, "/") {
+		ok, _ := path.Match(r.glob, path.Base(name))
+		return ok
+	}
+	ok, _ := path.Match(r.glob, name)
+	return ok
+}
+
+// Filter decides, which files of a source folder are ingested.
+// Exclude rules are applied to files and folders, include rules only to files.
+// If there are include rules, only files matching one of them are ingested
+type Filter struct {
+	include []*rule
+	exclude []*rule
+}
+
+func NewFilter(include, exclude, includeRegexp, excludeRegexp []string) (*Filter, error) {
+	f := &Filter{
+		include: []*rule{},
+		exclude: []*rule{},
+	}
+	for _, pattern := range include {
+		r, err := newGlobRule(pattern)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		f.include = append(f.include, r)
+	}
+	for _, pattern := range exclude {
+		r, err := newGlobRule(pattern)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		f.exclude = append(f.exclude, r)
+	}
+	for _, pattern := range includeRegexp {
+		r, err := newRegexpRule(pattern)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		f.include = append(f.include, r)
+	}
+	for _, pattern := range excludeRegexp {
+		r, err := newRegexpRule(pattern)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		f.exclude = append(f.exclude, r)
+	}
+	return f, nil
+}
+
+// IsEmpty returns true, if the filter has no rules
+func (f *Filter) IsEmpty() bool {
+	return f == nil || (len(f.include) == 0 && len(f.exclude) == 0)
+}
+
+// WithIgnoreFile returns a copy of the filter with the additional exclude rules of an ignore file.
+// Every line is a glob pattern, lines starting with 'regexp:' are regular expressions. Empty lines and lines starting with '#' are ignored
+func (f *Filter) WithIgnoreFile(r io.Reader) (*Filter, error) {
+	result := &Filter{}
+	if f != nil {
+		result.include = append(result.include, f.include...)
+		result.exclude = append(result.exclude, f.exclude...)
+	}
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var rl *rule
+		var err error
+		if pattern, ok := strings.CutPrefix(line, regexpPrefix); ok {
+			rl, err = newRegexpRule(pattern)
+		} else {
+			rl, err = newGlobRule(line)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid rule in line %d", lineNo)
+		}
+		result.exclude = append(result.exclude, rl)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "cannot read ignore file")
+	}
+	return result, nil
+}
+
+// Skip returns the reason, why name is not ingested, or an empty string
+func (f *Filter) Skip(name string, isDir bool) string {
+	if f == nil {
+		return ""
+	}
+	for _, r := range f.exclude {
+		if r.match(name, isDir) {
+			return fmt.Sprintf("excluded by '%s'", r.pattern)
+		}
+	}
+	if isDir || len(f.include) == 0 {
+		return ""
+	}
+	for _, r := range f.include {
+		if r.match(name, isDir) {
+			return ""
+		}
+	}
+	return "not included"
+}
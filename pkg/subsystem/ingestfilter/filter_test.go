@@ -0,0 +1,128 @@
+package ingestfilter
+
+import (
+	"io/fs"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFilterSkip(t *testing.T) {
+	filter, err := NewFilter(nil, []string{".DS_Store", "~$*", ".git/", "docs/*.tmp"}, nil, []string{`^raw/.*\.cr2$`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name  string
+		isDir bool
+		skip  bool
+	}{
+		{"a.txt", false, false},
+		{".DS_Store", false, true},
+		{"sub/.DS_Store", false, true},
+		{"sub/~$lock.docx", false, true},
+		{".git", true, true},
+		{"sub/.git", true, true},
+		{".git", false, false},
+		{"docs/x.tmp", false, true},
+		{"sub/docs/x.tmp", false, false},
+		{"raw/img.cr2", false, true},
+		{"img.cr2", false, false},
+	} {
+		if reason := filter.Skip(tc.name, tc.isDir); (reason != "") != tc.skip {
+			t.Errorf("Skip(%s, %v) = '%s', expected skip %v", tc.name, tc.isDir, reason, tc.skip)
+		}
+	}
+}
+
+func TestFilterInclude(t *testing.T) {
+	filter, err := NewFilter([]string{"*.tif"}, []string{"tmp/"}, []string{`^docs/`}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reason := filter.Skip("img/a.tif", false); reason != "" {
+		t.Errorf("img/a.tif skipped: %s", reason)
+	}
+	if reason := filter.Skip("docs/readme.txt", false); reason != "" {
+		t.Errorf("docs/readme.txt skipped: %s", reason)
+	}
+	if reason := filter.Skip("img/a.jpg", false); reason != "not included" {
+		t.Errorf("img/a.jpg: unexpected reason '%s'", reason)
+	}
+	if reason := filter.Skip("img", true); reason != "" {
+		t.Errorf("include rules must not skip folders: %s", reason)
+	}
+	if reason := filter.Skip("tmp", true); reason == "" {
+		t.Error("tmp not skipped")
+	}
+}
+
+func TestFilterInvalid(t *testing.T) {
+	if _, err := NewFilter(nil, []string{"["}, nil, nil); err == nil {
+		t.Error("invalid glob accepted")
+	}
+	if _, err := NewFilter(nil, nil, nil, []string{"("}); err == nil {
+		t.Error("invalid regexp accepted")
+	}
+}
+
+func TestFS(t *testing.T) {
+	src := fstest.MapFS{
+		DefaultIgnoreFile:     {Data: []byte("# comment\n\n*.bak\nregexp:^data/cache/\n")},
+		"a.txt":               {Data: []byte("a")},
+		"a.bak":               {Data: []byte("b")},
+		"data/b.txt":          {Data: []byte("b")},
+		"data/cache/c.bin":    {Data: []byte("c")},
+		"data/cache/d/e.bin":  {Data: []byte("e")},
+		"data/.DS_Store":      {Data: []byte("x")},
+		"data/sub/keep.txt":   {Data: []byte("k")},
+		"data/sub/drop.bak":   {Data: []byte("d")},
+		"data/sub/.DS_Store":  {Data: []byte("x")},
+		"data/sub/other.text": {Data: []byte("o")},
+	}
+	filter, err := NewFilter(nil, []string{".DS_Store"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ffs, err := NewFS(src, filter, DefaultIgnoreFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var files = []string{}
+	if err := fs.WalkDir(ffs, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			files = append(files, path)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"a.txt", "data/b.txt", "data/sub/keep.txt", "data/sub/other.text"}
+	if !slices.Equal(files, expected) {
+		t.Errorf("ingested files %v, expected %v", files, expected)
+	}
+	if _, err := ffs.Open("a.bak"); err == nil {
+		t.Error("skipped file a.bak can be opened")
+	}
+	if _, err := fs.Stat(ffs, "data/cache/d/e.bin"); err == nil {
+		t.Error("file in skipped folder can be accessed")
+	}
+	skipped, err := ffs.SkippedFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(skipped)
+	expected = []string{DefaultIgnoreFile, "a.bak", "data/.DS_Store", "data/cache/c.bin", "data/cache/d/e.bin", "data/sub/.DS_Store", "data/sub/drop.bak"}
+	if !slices.Equal(skipped, expected) {
+		t.Errorf("skipped files %v, expected %v", skipped, expected)
+	}
+	for _, s := range ffs.Skipped() {
+		if s.Path == "data/cache" && !strings.Contains(s.Reason, "regexp:") {
+			t.Errorf("unexpected reason for data/cache: %s", s.Reason)
+		}
+	}
+}
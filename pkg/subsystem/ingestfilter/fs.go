@@ -0,0 +1,180 @@
+package ingestfilter
+
+import (
+	"cmp"
+	"emperror.dev/errors"
+	"fmt"
+	"github.com/je4/filesystem/v3/pkg/writefs"
+	"io/fs"
+	"path"
+	"slices"
+	"sync"
+)
+
+// Skipped is a file or folder, which is hidden from ingest
+type Skipped struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+	IsDir  bool   `json:"isDir,omitempty"`
+}
+
+// FS hides the files of a source folder, which are skipped by a filter.
+// The content of skipped folders is hidden completely
+type FS struct {
+	sync.Mutex
+	fsys       fs.FS
+	filter     *Filter
+	ignoreFile string
+	skipped    map[string]*Skipped
+}
+
+// NewFS wraps fsys with filter. If ignoreFile exists in the root of fsys, its rules are added to the filter
+// and the ignore file itself is skipped
+func NewFS(fsys fs.FS, filter *Filter, ignoreFile string) (*FS, error) {
+	ffs := &FS{
+		fsys:       fsys,
+		filter:     filter,
+		ignoreFile: ignoreFile,
+		skipped:    map[string]*Skipped{},
+	}
+	if ignoreFile != "" {
+		fp, err := fsys.Open(ignoreFile)
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				return nil, errors.Wrapf(err, "cannot open '%v/%s'", fsys, ignoreFile)
+			}
+			ffs.ignoreFile = ""
+		} else {
+			ffs.filter, err = filter.WithIgnoreFile(fp)
+			fp.Close()
+			if err != nil {
+				return nil, errors.Wrapf(err, "cannot load '%v/%s'", fsys, ignoreFile)
+			}
+		}
+	}
+	return ffs, nil
+}
+
+func (ffs *FS) String() string {
+	return fmt.Sprintf("%v", ffs.fsys)
+}
+
+// skip checks name and all parent folders
+func (ffs *FS) skip(name string, isDir bool) (string, bool) {
+	if name == "." {
+		return "", false
+	}
+	if ffs.ignoreFile != "" && name == ffs.ignoreFile {
+		return "ignore file", true
+	}
+	for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+		if reason := ffs.filter.Skip(dir, true); reason != "" {
+			return fmt.Sprintf("folder '%s' %s", dir, reason), true
+		}
+	}
+	if reason := ffs.filter.Skip(name, isDir); reason != "" {
+		return reason, true
+	}
+	return "", false
+}
+
+func (ffs *FS) Open(name string) (fs.File, error) {
+	fp, err := ffs.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	isDir := false
+	if info, err := fp.Stat(); err == nil {
+		isDir = info.IsDir()
+	}
+	if _, ok := ffs.skip(name, isDir); ok {
+		fp.Close()
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return fp, nil
+}
+
+func (ffs *FS) Stat(name string) (fs.FileInfo, error) {
+	info, err := fs.Stat(ffs.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := ffs.skip(name, info.IsDir()); ok {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	return info, nil
+}
+
+// Fullpath returns the path of name in the underlying filesystem, if it is not skipped
+func (ffs *FS) Fullpath(name string) (string, error) {
+	if _, err := ffs.Stat(name); err != nil {
+		return "", err
+	}
+	return writefs.Fullpath(ffs.fsys, name)
+}
+
+// ReadDir returns the entries of folder name, which are not skipped. Skipped entries are recorded
+func (ffs *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if _, ok := ffs.skip(name, true); ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	entries, err := fs.ReadDir(ffs.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	var result = []fs.DirEntry{}
+	for _, entry := range entries {
+		entryPath := path.Join(name, entry.Name())
+		if reason, ok := ffs.skip(entryPath, entry.IsDir()); ok {
+			ffs.Lock()
+			ffs.skipped[entryPath] = &Skipped{Path: entryPath, Reason: reason, IsDir: entry.IsDir()}
+			ffs.Unlock()
+			continue
+		}
+		result = append(result, entry)
+	}
+	return result, nil
+}
+
+// Skipped returns the skipped files and folders found so far, sorted by path
+func (ffs *FS) Skipped() []*Skipped {
+	ffs.Lock()
+	defer ffs.Unlock()
+	var result = []*Skipped{}
+	for _, s := range ffs.skipped {
+		result = append(result, s)
+	}
+	slices.SortFunc(result, func(a, b *Skipped) int {
+		return cmp.Compare(a.Path, b.Path)
+	})
+	return result
+}
+
+// SkippedFiles returns all files, which are hidden. The content of skipped folders is included
+func (ffs *FS) SkippedFiles() ([]string, error) {
+	var result = []string{}
+	for _, s := range ffs.Skipped() {
+		if !s.IsDir {
+			result = append(result, s.Path)
+			continue
+		}
+		if err := fs.WalkDir(ffs.fsys, s.Path, func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if !d.IsDir() {
+				result = append(result, name)
+			}
+			return nil
+		}); err != nil {
+			return nil, errors.Wrapf(err, "cannot walk skipped folder '%s'", s.Path)
+		}
+	}
+	return result, nil
+}
+
+var (
+	_ fs.ReadDirFS       = (*FS)(nil)
+	_ fs.StatFS          = (*FS)(nil)
+	_ writefs.FullpathFS = (*FS)(nil)
+)
@@ -0,0 +1,295 @@
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"emperror.dev/errors"
+)
+
+// Operation is an operation of a JSON Patch (RFC 6902)
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Apply applies patch to a copy of doc.
+// A patch with a JSON array is a JSON Patch (RFC 6902), a patch with a JSON object is a JSON Merge Patch (RFC 7386)
+func Apply(doc any, patch []byte) (any, error) {
+	doc, err := normalize(doc)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot convert document to json")
+	}
+	patch = bytes.TrimSpace(patch)
+	if len(patch) == 0 {
+		return nil, errors.New("empty patch")
+	}
+	switch patch[0] {
+	case '[':
+		var ops = []*Operation{}
+		if err := json.Unmarshal(patch, &ops); err != nil {
+			return nil, errors.Wrap(err, "cannot decode json patch")
+		}
+		for num, op := range ops {
+			if doc, err = apply(doc, op); err != nil {
+				return nil, errors.Wrapf(err, "cannot apply operation #%d '%s %s'", num, op.Op, op.Path)
+			}
+		}
+		return doc, nil
+	case '{':
+		var merge any
+		if err := json.Unmarshal(patch, &merge); err != nil {
+			return nil, errors.Wrap(err, "cannot decode json merge patch")
+		}
+		return mergePatch(doc, merge), nil
+	default:
+		return nil, errors.New("patch is neither a json patch array nor a json merge patch object")
+	}
+}
+
+// normalize converts doc to the types of encoding/json and returns a deep copy
+func normalize(doc any) (any, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var result any
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return result, nil
+}
+
+func mergePatch(doc, patch any) any {
+	patchMap, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	docMap, ok := doc.(map[string]any)
+	if !ok {
+		docMap = map[string]any{}
+	}
+	for key, value := range patchMap {
+		if value == nil {
+			delete(docMap, key)
+			continue
+		}
+		docMap[key] = mergePatch(docMap[key], value)
+	}
+	return docMap
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into its unescaped reference tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, errors.Errorf("invalid json pointer '%s'", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// arrayIndex returns the index of token in an array of length size. "-" is the index after the last element
+func arrayIndex(token string, size int, insert bool) (int, error) {
+	if token == "-" && insert {
+		return size, nil
+	}
+	idx, err := strconv.Atoi(token)
+	if err != nil || idx < 0 || (token != "0" && strings.HasPrefix(token, "0")) {
+		return 0, errors.Errorf("invalid array index '%s'", token)
+	}
+	if idx > size || (idx == size && !insert) {
+		return 0, errors.Errorf("array index %d out of range", idx)
+	}
+	return idx, nil
+}
+
+// get returns the value at pointer
+func get(doc any, pointer string) (any, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	current := doc
+	for _, token := range tokens {
+		switch node := current.(type) {
+		case map[string]any:
+			value, ok := node[token]
+			if !ok {
+				return nil, errors.Errorf("path '%s' not found", pointer)
+			}
+			current = value
+		case []any:
+			idx, err := arrayIndex(token, len(node), false)
+			if err != nil {
+				return nil, errors.Wrapf(err, "path '%s' not found", pointer)
+			}
+			current = node[idx]
+		default:
+			return nil, errors.Errorf("path '%s' not found", pointer)
+		}
+	}
+	return current, nil
+}
+
+// change replaces the container of the last token of pointer with the result of fn and returns the new document
+func change(doc any, tokens []string, fn func(parent any, token string) (any, error)) (any, error) {
+	if len(tokens) == 1 {
+		return fn(doc, tokens[0])
+	}
+	switch node := doc.(type) {
+	case map[string]any:
+		value, ok := node[tokens[0]]
+		if !ok {
+			return nil, errors.Errorf("member '%s' not found", tokens[0])
+		}
+		value, err := change(value, tokens[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		node[tokens[0]] = value
+		return node, nil
+	case []any:
+		idx, err := arrayIndex(tokens[0], len(node), false)
+		if err != nil {
+			return nil, err
+		}
+		value, err := change(node[idx], tokens[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		node[idx] = value
+		return node, nil
+	default:
+		return nil, errors.Errorf("cannot traverse '%s'", tokens[0])
+	}
+}
+
+func add(doc any, pointer string, value any) (any, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	return change(doc, tokens, func(parent any, token string) (any, error) {
+		switch node := parent.(type) {
+		case map[string]any:
+			node[token] = value
+			return node, nil
+		case []any:
+			idx, err := arrayIndex(token, len(node), true)
+			if err != nil {
+				return nil, err
+			}
+			node = append(node, nil)
+			copy(node[idx+1:], node[idx:])
+			node[idx] = value
+			return node, nil
+		default:
+			return nil, errors.Errorf("cannot add '%s' to a scalar", token)
+		}
+	})
+}
+
+func remove(doc any, pointer string) (any, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if len(tokens) == 0 {
+		return nil, errors.New("cannot remove the whole document")
+	}
+	return change(doc, tokens, func(parent any, token string) (any, error) {
+		switch node := parent.(type) {
+		case map[string]any:
+			if _, ok := node[token]; !ok {
+				return nil, errors.Errorf("member '%s' not found", token)
+			}
+			delete(node, token)
+			return node, nil
+		case []any:
+			idx, err := arrayIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			return append(node[:idx], node[idx+1:]...), nil
+		default:
+			return nil, errors.Errorf("cannot remove '%s' from a scalar", token)
+		}
+	})
+}
+
+func apply(doc any, op *Operation) (any, error) {
+	var value any
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, errors.Errorf("missing value")
+		}
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return nil, errors.Wrap(err, "cannot decode value")
+		}
+	}
+	switch op.Op {
+	case "add":
+		return add(doc, op.Path, value)
+	case "remove":
+		return remove(doc, op.Path)
+	case "replace":
+		if _, err := get(doc, op.Path); err != nil {
+			return nil, err
+		}
+		if op.Path == "" {
+			return value, nil
+		}
+		doc, err := remove(doc, op.Path)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, op.Path, value)
+	case "move":
+		if strings.HasPrefix(op.Path+"/", op.From+"/") && op.Path != op.From {
+			return nil, errors.Errorf("cannot move '%s' into itself", op.From)
+		}
+		value, err := get(doc, op.From)
+		if err != nil {
+			return nil, err
+		}
+		if doc, err = remove(doc, op.From); err != nil {
+			return nil, err
+		}
+		return add(doc, op.Path, value)
+	case "copy":
+		value, err := get(doc, op.From)
+		if err != nil {
+			return nil, err
+		}
+		if value, err = normalize(value); err != nil {
+			return nil, errors.Wrap(err, "cannot copy value")
+		}
+		return add(doc, op.Path, value)
+	case "test":
+		current, err := get(doc, op.Path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return nil, errors.Errorf("test failed, value is %v", current)
+		}
+		return doc, nil
+	default:
+		return nil, errors.Errorf("unknown operation '%s'", op.Op)
+	}
+}
@@ -0,0 +1,67 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const testDoc = `{"title":"Annual Reprot","user":"jane","tags":["a","b"],"address":{"city":"Basel","zip":"4051"}}`
+
+func testApply(t *testing.T, patch string) string {
+	var doc any
+	if err := json.Unmarshal([]byte(testDoc), &doc); err != nil {
+		t.Fatal(err)
+	}
+	result, err := Apply(doc, []byte(patch))
+	if err != nil {
+		t.Fatalf("cannot apply %s: %v", patch, err)
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestPatch(t *testing.T) {
+	for _, test := range []struct{ patch, expected string }{
+		{`[{"op":"replace","path":"/title","value":"Annual Report"}]`, `{"address":{"city":"Basel","zip":"4051"},"tags":["a","b"],"title":"Annual Report","user":"jane"}`},
+		{`[{"op":"add","path":"/tags/1","value":"x"},{"op":"add","path":"/tags/-","value":"z"}]`, `{"address":{"city":"Basel","zip":"4051"},"tags":["a","x","b","z"],"title":"Annual Reprot","user":"jane"}`},
+		{`[{"op":"remove","path":"/address/zip"},{"op":"remove","path":"/tags/0"}]`, `{"address":{"city":"Basel"},"tags":["b"],"title":"Annual Reprot","user":"jane"}`},
+		{`[{"op":"move","from":"/user","path":"/address/user"},{"op":"copy","from":"/tags","path":"/keywords"}]`, `{"address":{"city":"Basel","user":"jane","zip":"4051"},"keywords":["a","b"],"tags":["a","b"],"title":"Annual Reprot"}`},
+		{`[{"op":"test","path":"/address/city","value":"Basel"},{"op":"add","path":"/a~1b","value":1}]`, `{"a/b":1,"address":{"city":"Basel","zip":"4051"},"tags":["a","b"],"title":"Annual Reprot","user":"jane"}`},
+	} {
+		if result := testApply(t, test.patch); result != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.patch, test.expected, result)
+		}
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	result := testApply(t, `{"title":"Annual Report","user":null,"address":{"zip":"4056"}}`)
+	expected := `{"address":{"city":"Basel","zip":"4056"},"tags":["a","b"],"title":"Annual Report"}`
+	if result != expected {
+		t.Errorf("expected %s, got %s", expected, result)
+	}
+}
+
+func TestPatchErrors(t *testing.T) {
+	var doc = map[string]any{"title": "x", "tags": []any{"a"}}
+	for _, patch := range []string{
+		`[{"op":"replace","path":"/missing","value":1}]`,
+		`[{"op":"remove","path":"/tags/1"}]`,
+		`[{"op":"test","path":"/title","value":"y"}]`,
+		`[{"op":"add","path":"title","value":1}]`,
+		`[{"op":"move","from":"/tags","path":"/tags/0"}]`,
+		`[{"op":"unknown","path":"/title"}]`,
+		`"title"`,
+		``,
+	} {
+		if _, err := Apply(doc, []byte(patch)); err == nil {
+			t.Errorf("patch %s should fail", patch)
+		}
+	}
+	if doc["title"] != "x" || len(doc["tags"].([]any)) != 1 {
+		t.Errorf("document was modified: %v", doc)
+	}
+}
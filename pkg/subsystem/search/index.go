@@ -0,0 +1,408 @@
+package search
+
+import (
+	"cmp"
+	"emperror.dev/errors"
+	"encoding/json"
+	"fmt"
+	"github.com/ocfl-archive/gocfl/v2/pkg/extension"
+	"github.com/ocfl-archive/gocfl/v2/pkg/ocfl"
+	"github.com/ocfl-archive/indexer/v3/pkg/indexer"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+const (
+	FacetFormat = "format"
+	FacetPronom = "pronom"
+	FacetSize   = "size"
+	FacetDate   = "date"
+)
+
+var Facets = []string{FacetFormat, FacetPronom, FacetSize, FacetDate}
+
+// Document is the searchable unit: one logical path in one version of an object
+type Document struct {
+	ObjectID string    `json:"objectID"`
+	Version  string    `json:"version"`
+	Path     string    `json:"path"`
+	Digest   string    `json:"digest"`
+	Mimetype string    `json:"mimetype,omitempty"`
+	Pronom   string    `json:"pronom,omitempty"`
+	Size     uint64    `json:"size"`
+	Created  time.Time `json:"created"`
+	Text     string    `json:"-"`
+}
+
+func (doc *Document) facetValue(facet string) string {
+	switch facet {
+	case FacetFormat:
+		return doc.Mimetype
+	case FacetPronom:
+		return doc.Pronom
+	case FacetSize:
+		return SizeBucket(doc.Size)
+	case FacetDate:
+		if doc.Created.IsZero() {
+			return ""
+		}
+		return doc.Created.Format("2006-01")
+	default:
+		return ""
+	}
+}
+
+var sizeBuckets = []struct {
+	limit uint64
+	name  string
+}{
+	{1 << 20, "< 1 MB"},
+	{10 << 20, "1 - 10 MB"},
+	{100 << 20, "10 - 100 MB"},
+	{1 << 30, "100 MB - 1 GB"},
+}
+
+// SizeBucket returns the size facet value for a file size
+func SizeBucket(size uint64) string {
+	for _, b := range sizeBuckets {
+		if size < b.limit {
+			return b.name
+		}
+	}
+	return "> 1 GB"
+}
+
+type objectEntry struct {
+	Head      string      `json:"head"`
+	Documents []*Document `json:"documents"`
+	Text      []string    `json:"text"`
+}
+
+// Index is a simple in-memory full text index with facets over the metadata of the objects of a storage root.
+// It is updated incrementally per object.
+type Index struct {
+	sync.RWMutex
+	objects     map[string]*objectEntry
+	terms       map[string]map[*Document]struct{}
+	lastRefresh time.Time
+}
+
+func NewIndex() *Index {
+	return &Index{
+		objects: map[string]*objectEntry{},
+		terms:   map[string]map[*Document]struct{}{},
+	}
+}
+
+// Tokenize splits a text into lowercase terms
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// LastRefresh returns the time of the last completed refresh or zero, if the index was never refreshed
+func (idx *Index) LastRefresh() time.Time {
+	idx.RLock()
+	defer idx.RUnlock()
+	return idx.lastRefresh
+}
+
+// NeedsUpdate returns true, if the object is not indexed or was indexed with another head version
+func (idx *Index) NeedsUpdate(id, head string) bool {
+	idx.RLock()
+	defer idx.RUnlock()
+	entry, ok := idx.objects[id]
+	return !ok || entry.Head != head
+}
+
+// ObjectIDs returns the id's of all indexed objects
+func (idx *Index) ObjectIDs() []string {
+	idx.RLock()
+	defer idx.RUnlock()
+	var ids = make([]string, 0, len(idx.objects))
+	for id := range idx.objects {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// UpdateObject replaces all documents of an object
+func (idx *Index) UpdateObject(meta *ocfl.ObjectMetadata) {
+	entry := &objectEntry{
+		Head:      meta.Head,
+		Documents: []*Document{},
+		Text:      []string{meta.ID},
+	}
+	if extMap, ok := meta.Extension.(map[string]any); ok {
+		entry.Text = appendStrings(entry.Text, extMap[extension.MetaFileName])
+	}
+	for digest, file := range meta.Files {
+		idxAny, _ := file.Extension[extension.IndexerName]
+		idxResult, _ := idxAny.(*indexer.ResultV2)
+		for version, names := range file.VersionName {
+			var versionText []string
+			var created time.Time
+			if vm, ok := meta.Versions[version]; ok {
+				created = vm.Created
+				versionText = append(versionText, vm.Message)
+			}
+			for _, name := range names {
+				doc := &Document{
+					ObjectID: meta.ID,
+					Version:  version,
+					Path:     name,
+					Digest:   digest,
+					Created:  created,
+				}
+				text := append([]string{name}, versionText...)
+				if idxResult != nil {
+					doc.Mimetype = idxResult.Mimetype
+					doc.Pronom = idxResult.Pronom
+					doc.Size = idxResult.Size
+					text = append(text, idxResult.Mimetype, idxResult.Pronom, idxResult.Type, idxResult.Subtype)
+					text = appendStrings(text, idxResult.Metadata)
+				}
+				doc.Text = strings.Join(text, " ")
+				entry.Documents = append(entry.Documents, doc)
+			}
+		}
+	}
+
+	idx.Lock()
+	defer idx.Unlock()
+	idx.removeObject(meta.ID)
+	idx.addObject(meta.ID, entry)
+}
+
+// RemoveObject removes all documents of an object
+func (idx *Index) RemoveObject(id string) {
+	idx.Lock()
+	defer idx.Unlock()
+	idx.removeObject(id)
+}
+
+func (idx *Index) addObject(id string, entry *objectEntry) {
+	idx.objects[id] = entry
+	objectTerms := Tokenize(strings.Join(entry.Text, " "))
+	for _, doc := range entry.Documents {
+		for _, term := range append(Tokenize(doc.Text), objectTerms...) {
+			docs, ok := idx.terms[term]
+			if !ok {
+				docs = map[*Document]struct{}{}
+				idx.terms[term] = docs
+			}
+			docs[doc] = struct{}{}
+		}
+	}
+}
+
+func (idx *Index) removeObject(id string) {
+	entry, ok := idx.objects[id]
+	if !ok {
+		return
+	}
+	objectTerms := Tokenize(strings.Join(entry.Text, " "))
+	for _, doc := range entry.Documents {
+		for _, term := range append(Tokenize(doc.Text), objectTerms...) {
+			if docs, ok := idx.terms[term]; ok {
+				delete(docs, doc)
+				if len(docs) == 0 {
+					delete(idx.terms, term)
+				}
+			}
+		}
+	}
+	delete(idx.objects, id)
+}
+
+type Query struct {
+	// Text contains the search terms, all terms must match. A trailing '*' does a prefix search
+	Text string `json:"text"`
+	// Filter restricts the result to the given facet values
+	Filter map[string][]string `json:"filter,omitempty"`
+	Offset int                 `json:"offset"`
+	Limit  int                 `json:"limit"`
+}
+
+type Result struct {
+	Total  int                       `json:"total"`
+	Hits   []*Document               `json:"hits"`
+	Facets map[string]map[string]int `json:"facets"`
+}
+
+// Search executes a query. Facet counts are calculated on the full result
+func (idx *Index) Search(q *Query) *Result {
+	idx.RLock()
+	defer idx.RUnlock()
+
+	var candidates map[*Document]struct{}
+	for _, term := range strings.Fields(strings.ToLower(q.Text)) {
+		prefix := strings.HasSuffix(term, "*")
+		var termDocs = map[*Document]struct{}{}
+		for _, t := range Tokenize(term) {
+			var matches = map[*Document]struct{}{}
+			if prefix {
+				for indexTerm, docs := range idx.terms {
+					if strings.HasPrefix(indexTerm, t) {
+						for doc := range docs {
+							matches[doc] = struct{}{}
+						}
+					}
+				}
+			} else {
+				for doc := range idx.terms[t] {
+					matches[doc] = struct{}{}
+				}
+			}
+			termDocs = intersect(termDocs, matches, len(termDocs) == 0)
+		}
+		candidates = intersect(candidates, termDocs, candidates == nil)
+	}
+	if candidates == nil {
+		// no search terms: everything matches
+		candidates = map[*Document]struct{}{}
+		for _, entry := range idx.objects {
+			for _, doc := range entry.Documents {
+				candidates[doc] = struct{}{}
+			}
+		}
+	}
+
+	result := &Result{
+		Hits:   []*Document{},
+		Facets: map[string]map[string]int{},
+	}
+	for _, facet := range Facets {
+		result.Facets[facet] = map[string]int{}
+	}
+	var hits = []*Document{}
+	for doc := range candidates {
+		if !matchFilter(doc, q.Filter) {
+			continue
+		}
+		hits = append(hits, doc)
+		for _, facet := range Facets {
+			if val := doc.facetValue(facet); val != "" {
+				result.Facets[facet][val]++
+			}
+		}
+	}
+	slices.SortFunc(hits, func(a, b *Document) int {
+		return cmp.Or(
+			strings.Compare(a.ObjectID, b.ObjectID),
+			a.Created.Compare(b.Created),
+			strings.Compare(a.Path, b.Path),
+		)
+	})
+	result.Total = len(hits)
+	if q.Offset > 0 {
+		hits = hits[min(q.Offset, len(hits)):]
+	}
+	if q.Limit > 0 && len(hits) > q.Limit {
+		hits = hits[:q.Limit]
+	}
+	result.Hits = hits
+	return result
+}
+
+func matchFilter(doc *Document, filter map[string][]string) bool {
+	for facet, values := range filter {
+		if len(values) == 0 {
+			continue
+		}
+		if !slices.Contains(values, doc.facetValue(facet)) {
+			return false
+		}
+	}
+	return true
+}
+
+func intersect(a, b map[*Document]struct{}, first bool) map[*Document]struct{} {
+	if first {
+		return b
+	}
+	var result = map[*Document]struct{}{}
+	for doc := range a {
+		if _, ok := b[doc]; ok {
+			result[doc] = struct{}{}
+		}
+	}
+	return result
+}
+
+// appendStrings adds all string values of a nested structure
+func appendStrings(result []string, val any) []string {
+	switch v := val.(type) {
+	case string:
+		result = append(result, v)
+	case []string:
+		result = append(result, v...)
+	case []any:
+		for _, e := range v {
+			result = appendStrings(result, e)
+		}
+	case map[string]any:
+		for _, e := range v {
+			result = appendStrings(result, e)
+		}
+	case nil:
+	default:
+		result = append(result, fmt.Sprintf("%v", v))
+	}
+	return result
+}
+
+// Save writes the index as json
+func (idx *Index) Save(w io.Writer) error {
+	idx.RLock()
+	defer idx.RUnlock()
+	type docText struct {
+		*Document
+		Text string `json:"text"`
+	}
+	var data = map[string]any{}
+	for id, entry := range idx.objects {
+		var docs = []*docText{}
+		for _, doc := range entry.Documents {
+			docs = append(docs, &docText{Document: doc, Text: doc.Text})
+		}
+		data[id] = map[string]any{"head": entry.Head, "text": entry.Text, "documents": docs}
+	}
+	return errors.Wrap(json.NewEncoder(w).Encode(data), "cannot encode search index")
+}
+
+// Load reads an index written by Save and replaces the current content
+func (idx *Index) Load(r io.Reader) error {
+	type docText struct {
+		Document
+		Text string `json:"text"`
+	}
+	var data = map[string]struct {
+		Head      string     `json:"head"`
+		Text      []string   `json:"text"`
+		Documents []*docText `json:"documents"`
+	}{}
+	if err := json.NewDecoder(r).Decode(&data); err != nil {
+		return errors.Wrap(err, "cannot decode search index")
+	}
+	idx.Lock()
+	defer idx.Unlock()
+	idx.objects = map[string]*objectEntry{}
+	idx.terms = map[string]map[*Document]struct{}{}
+	for id, e := range data {
+		entry := &objectEntry{Head: e.Head, Text: e.Text, Documents: []*Document{}}
+		for _, dt := range e.Documents {
+			doc := dt.Document
+			doc.Text = dt.Text
+			entry.Documents = append(entry.Documents, &doc)
+		}
+		idx.addObject(id, entry)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package search
+
+import (
+	"bytes"
+	"github.com/ocfl-archive/gocfl/v2/pkg/extension"
+	"github.com/ocfl-archive/gocfl/v2/pkg/ocfl"
+	"github.com/ocfl-archive/indexer/v3/pkg/indexer"
+	"testing"
+	"time"
+)
+
+func testMetadata(id, head string) *ocfl.ObjectMetadata {
+	created := time.Date(2023, 2, 24, 14, 30, 0, 0, time.UTC)
+	return &ocfl.ObjectMetadata{
+		ID:   id,
+		Head: head,
+		Versions: map[string]*ocfl.VersionMetadata{
+			"v1": {Created: created, Message: "initial commit"},
+		},
+		Files: ocfl.FilesMetadata{
+			"aaa": {
+				VersionName: map[string][]string{"v1": {"data/together.png"}},
+				Extension:   map[string]any{extension.IndexerName: &indexer.ResultV2{Mimetype: "image/png", Pronom: "fmt/12", Size: 645553}},
+			},
+			"bbb": {
+				VersionName: map[string][]string{"v1": {"data/readme.txt"}},
+				Extension:   map[string]any{extension.IndexerName: &indexer.ResultV2{Mimetype: "text/plain", Size: 20 << 20}},
+			},
+		},
+		Extension: map[string]any{extension.MetaFileName: map[string]any{"title": "Kakophonie"}},
+	}
+}
+
+func TestIndex(t *testing.T) {
+	idx := NewIndex()
+	idx.UpdateObject(testMetadata("id:one", "v1"))
+
+	if result := idx.Search(&Query{Text: "together"}); result.Total != 1 {
+		t.Errorf("expected 1 hit for 'together', got %d", result.Total)
+	}
+	// object metadata matches all files of the object
+	if result := idx.Search(&Query{Text: "kakophonie"}); result.Total != 2 {
+		t.Errorf("expected 2 hits for 'kakophonie', got %d", result.Total)
+	}
+	if result := idx.Search(&Query{Text: "kako*"}); result.Total != 2 {
+		t.Errorf("expected 2 hits for 'kako*', got %d", result.Total)
+	}
+	result := idx.Search(&Query{Filter: map[string][]string{FacetFormat: {"text/plain"}}})
+	if result.Total != 1 || result.Hits[0].Path != "data/readme.txt" {
+		t.Errorf("format filter failed: %v", result.Hits)
+	}
+	if result.Facets[FacetSize]["10 - 100 MB"] != 1 || result.Facets[FacetDate]["2023-02"] != 1 {
+		t.Errorf("wrong facets: %v", result.Facets)
+	}
+
+	if idx.NeedsUpdate("id:one", "v1") {
+		t.Errorf("unchanged object needs update")
+	}
+	if !idx.NeedsUpdate("id:one", "v2") {
+		t.Errorf("changed object does not need update")
+	}
+
+	// incremental update replaces documents
+	idx.UpdateObject(testMetadata("id:one", "v1"))
+	if result := idx.Search(&Query{Text: "together"}); result.Total != 1 {
+		t.Errorf("expected 1 hit after update, got %d", result.Total)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := idx.Save(buf); err != nil {
+		t.Fatalf("cannot save index: %v", err)
+	}
+	idx2 := NewIndex()
+	if err := idx2.Load(buf); err != nil {
+		t.Fatalf("cannot load index: %v", err)
+	}
+	if result := idx2.Search(&Query{Text: "together png"}); result.Total != 1 {
+		t.Errorf("expected 1 hit in loaded index, got %d", result.Total)
+	}
+
+	idx.RemoveObject("id:one")
+	if result := idx.Search(&Query{}); result.Total != 0 {
+		t.Errorf("expected empty index, got %d", result.Total)
+	}
+}
@@ -0,0 +1,49 @@
+package search
+
+import (
+	"emperror.dev/errors"
+	"github.com/je4/utils/v2/pkg/zLogger"
+	"github.com/ocfl-archive/gocfl/v2/pkg/ocfl"
+	"slices"
+	"time"
+)
+
+// Refresh brings the index up to date with the storage root.
+// Only objects with a new head version are reindexed, objects which do not exist anymore are removed.
+func (idx *Index) Refresh(storageRoot ocfl.StorageRoot, logger zLogger.ZLogger) error {
+	folders, err := storageRoot.GetObjectFolders()
+	if err != nil {
+		return errors.Wrap(err, "cannot get object folders")
+	}
+	var ids = []string{}
+	var errs = []error{}
+	for _, folder := range folders {
+		object, err := storageRoot.LoadObjectByFolder(folder)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "cannot load object from folder '%s'", folder))
+			continue
+		}
+		id := object.GetID()
+		ids = append(ids, id)
+		if !idx.NeedsUpdate(id, object.GetInventory().GetHead()) {
+			continue
+		}
+		logger.Debug().Msgf("indexing object '%s'", id)
+		meta, err := object.GetMetadata()
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "cannot get metadata of object '%s'", id))
+			continue
+		}
+		idx.UpdateObject(meta)
+	}
+	for _, id := range idx.ObjectIDs() {
+		if !slices.Contains(ids, id) {
+			logger.Debug().Msgf("removing object '%s' from index", id)
+			idx.RemoveObject(id)
+		}
+	}
+	idx.Lock()
+	idx.lastRefresh = time.Now()
+	idx.Unlock()
+	return errors.Combine(errs...)
+}
@@ -0,0 +1,312 @@
+package sourcefs
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/je4/utils/v2/pkg/checksum"
+)
+
+// IsURL returns true, if name is a http or https url
+func IsURL(name string) bool {
+	lower := strings.ToLower(name)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// URLEntry is one file of a url manifest
+type URLEntry struct {
+	URL string `json:"url"`
+	// Path of the file in the object. Defaults to the path of the url
+	Path string `json:"path,omitempty"`
+	// Digest is the expected digest as algorithm:hex
+	Digest string `json:"digest"`
+	// Size is the expected size. 0 if unknown
+	Size int64 `json:"size,omitempty"`
+}
+
+// digestLength maps the length of a hex digest to its algorithm, if no algorithm is given
+var digestLength = map[int]checksum.DigestAlgorithm{
+	32:  checksum.DigestMD5,
+	40:  checksum.DigestSHA1,
+	64:  checksum.DigestSHA256,
+	128: checksum.DigestSHA512,
+}
+
+// parseDigest splits a digest of the form [algorithm:]hex
+func parseDigest(digest string) (checksum.DigestAlgorithm, string, error) {
+	digest = strings.ToLower(strings.TrimSpace(digest))
+	alg, value, found := strings.Cut(digest, ":")
+	if !found {
+		if alg, ok := digestLength[len(digest)]; ok {
+			return alg, digest, nil
+		}
+		return "", "", errors.Errorf("cannot guess digest algorithm of '%s'", digest)
+	}
+	if !checksum.HashExists(checksum.DigestAlgorithm(alg)) {
+		return "", "", errors.Errorf("unknown digest algorithm '%s'", alg)
+	}
+	return checksum.DigestAlgorithm(alg), value, nil
+}
+
+// ReadURLManifest reads a manifest of urls with their expected digests.
+// Every line is either a json object with url, path, digest and size, or a line
+// '<digest> <url> [<path>]'. Lines starting with '#' are comments.
+// Relative urls are resolved against base
+func ReadURLManifest(r io.Reader, base *url.URL) ([]*URLEntry, error) {
+	var entries = []*URLEntry{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	var lineNo int
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entry := &URLEntry{}
+		if strings.HasPrefix(line, "{") {
+			if err := json.Unmarshal([]byte(line), entry); err != nil {
+				return nil, errors.Wrapf(err, "line %d: cannot unmarshal '%s'", lineNo, line)
+			}
+		} else {
+			fields := strings.Fields(line)
+			if len(fields) < 2 || len(fields) > 3 {
+				return nil, errors.Errorf("line %d: expected '<digest> <url> [<path>]': '%s'", lineNo, line)
+			}
+			entry.Digest, entry.URL = fields[0], fields[1]
+			if len(fields) == 3 {
+				entry.Path = fields[2]
+			}
+		}
+		if entry.URL == "" {
+			return nil, errors.Errorf("line %d: no url", lineNo)
+		}
+		if entry.Digest == "" {
+			return nil, errors.Errorf("line %d: no digest for '%s'", lineNo, entry.URL)
+		}
+		if _, _, err := parseDigest(entry.Digest); err != nil {
+			return nil, errors.Wrapf(err, "line %d", lineNo)
+		}
+		u, err := url.Parse(entry.URL)
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d: invalid url '%s'", lineNo, entry.URL)
+		}
+		if base != nil {
+			u = base.ResolveReference(u)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return nil, errors.Errorf("line %d: '%s' is not a http url", lineNo, u)
+		}
+		entry.URL = u.String()
+		if entry.Path == "" {
+			entry.Path = u.Path
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "cannot read url manifest")
+	}
+	return entries, nil
+}
+
+// HTTPFS is a read only filesystem of the files of a url manifest.
+// Files are downloaded, when they are read. Their digest is checked at the end of the download
+type HTTPFS struct {
+	ctx     context.Context
+	client  *http.Client
+	name    string
+	tree    *tree
+	entries map[string]*URLEntry
+}
+
+// OpenHTTPManifest loads the url manifest from manifestURL and creates a filesystem of its files
+func OpenHTTPManifest(ctx context.Context, client *http.Client, manifestURL string) (*HTTPFS, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	base, err := url.Parse(manifestURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid url '%s'", manifestURL)
+	}
+	body, err := get(ctx, client, manifestURL)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer body.Close()
+	entries, err := ReadURLManifest(body, base)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read url manifest '%s'", manifestURL)
+	}
+	hfs, err := NewHTTPFS(ctx, client, manifestURL, entries)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return hfs, nil
+}
+
+// NewHTTPFS creates a filesystem of the files in entries
+func NewHTTPFS(ctx context.Context, client *http.Client, name string, entries []*URLEntry) (*HTTPFS, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	hfs := &HTTPFS{
+		ctx:     ctx,
+		client:  client,
+		name:    name,
+		tree:    newTree(),
+		entries: map[string]*URLEntry{},
+	}
+	for _, entry := range entries {
+		name, err := cleanName(entry.Path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid path of '%s'", entry.URL)
+		}
+		if name == "." {
+			return nil, errors.Errorf("no file name for '%s'", entry.URL)
+		}
+		if _, err := hfs.tree.add(name, &node{size: entry.Size, mode: 0444}); err != nil {
+			return nil, errors.Wrapf(err, "cannot add '%s'", entry.URL)
+		}
+		hfs.entries[name] = entry
+	}
+	return hfs, nil
+}
+
+// Digests returns the digests of the url manifest by the paths of the files, which use the same algorithm.
+// preferred is used, if a file has a digest of it. Otherwise the algorithm of most files is used
+func (hfs *HTTPFS) Digests(preferred checksum.DigestAlgorithm) (checksum.DigestAlgorithm, map[string]string) {
+	var digests = map[checksum.DigestAlgorithm]map[string]string{}
+	for name, entry := range hfs.entries {
+		alg, digest, err := parseDigest(entry.Digest)
+		if err != nil {
+			continue
+		}
+		if digests[alg] == nil {
+			digests[alg] = map[string]string{}
+		}
+		digests[alg][name] = digest
+	}
+	if result, ok := digests[preferred]; ok {
+		return preferred, result
+	}
+	var alg checksum.DigestAlgorithm
+	for a, result := range digests {
+		if len(result) > len(digests[alg]) || (len(result) == len(digests[alg]) && a < alg) {
+			alg = a
+		}
+	}
+	return alg, digests[alg]
+}
+
+func get(ctx context.Context, client *http.Client, u string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot create request for '%s'", u)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot get '%s'", u)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, errors.Errorf("cannot get '%s': %s", u, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+func (hfs *HTTPFS) Open(name string) (fs.File, error) {
+	n, err := hfs.tree.stat("open", name)
+	if err != nil {
+		return nil, err
+	}
+	if n.IsDir() {
+		return hfs.tree.openDir(name, n)
+	}
+	entry := hfs.entries[name]
+	alg, digest, err := parseDigest(entry.Digest)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	h, err := checksum.GetHash(alg)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &httpFile{hfs: hfs, n: n, entry: entry, hash: h, digest: digest}, nil
+}
+
+func (hfs *HTTPFS) Stat(name string) (fs.FileInfo, error) {
+	return hfs.tree.stat("stat", name)
+}
+
+func (hfs *HTTPFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return hfs.tree.readDir(name)
+}
+
+func (hfs *HTTPFS) Close() error {
+	hfs.client.CloseIdleConnections()
+	return nil
+}
+
+func (hfs *HTTPFS) String() string {
+	return hfs.name
+}
+
+// httpFile downloads its content on the first read
+type httpFile struct {
+	hfs    *HTTPFS
+	n      *node
+	entry  *URLEntry
+	body   io.ReadCloser
+	hash   hash.Hash
+	digest string
+	size   int64
+}
+
+func (hf *httpFile) Stat() (fs.FileInfo, error) { return hf.n, nil }
+
+func (hf *httpFile) Read(p []byte) (int, error) {
+	if hf.body == nil {
+		body, err := get(hf.hfs.ctx, hf.hfs.client, hf.entry.URL)
+		if err != nil {
+			return 0, errors.WithStack(err)
+		}
+		hf.body = body
+	}
+	n, err := hf.body.Read(p)
+	hf.hash.Write(p[:n])
+	hf.size += int64(n)
+	if errors.Is(err, io.EOF) {
+		if hf.entry.Size > 0 && hf.size != hf.entry.Size {
+			return n, errors.Errorf("size of '%s' is %d, expected %d", hf.entry.URL, hf.size, hf.entry.Size)
+		}
+		if digest := fmt.Sprintf("%x", hf.hash.Sum(nil)); digest != hf.digest {
+			return n, errors.Errorf("digest of '%s' is %s, expected %s", hf.entry.URL, digest, hf.digest)
+		}
+	}
+	return n, err
+}
+
+func (hf *httpFile) Close() error {
+	if hf.body == nil {
+		return nil
+	}
+	return errors.WithStack(hf.body.Close())
+}
+
+var (
+	_ fs.ReadDirFS = (*HTTPFS)(nil)
+	_ fs.StatFS    = (*HTTPFS)(nil)
+	_ io.Closer    = (*HTTPFS)(nil)
+)
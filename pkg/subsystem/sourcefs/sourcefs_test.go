@@ -0,0 +1,240 @@
+package sourcefs
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha512"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/je4/utils/v2/pkg/checksum"
+)
+
+var testFiles = map[string]string{
+	"a.txt":       "content of a",
+	"dir/b.txt":   "content of b",
+	"dir/sub/c":   "content of c",
+	"empty/d.txt": "",
+}
+
+func writeTar(t *testing.T, name string, compress bool) string {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), name)
+	fp, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fp.Close()
+	var w io.Writer = fp
+	if compress {
+		zw := gzip.NewWriter(fp)
+		defer zw.Close()
+		w = zw
+	}
+	tw := tar.NewWriter(w)
+	defer tw.Close()
+	// explicit folder and implicit folders
+	if err := tw.WriteHeader(&tar.Header{Name: "./dir/", Typeflag: tar.TypeDir, Mode: 0755}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "a.txt"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.txt", "dir/b.txt", "dir/sub/c", "empty/d.txt"} {
+		content := testFiles[name]
+		if err := tw.WriteHeader(&tar.Header{Name: "./" + name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return filename
+}
+
+func testFS(t *testing.T, fsys fs.FS) {
+	t.Helper()
+	var names []string
+	for name := range testFiles {
+		names = append(names, name)
+	}
+	if err := fstest.TestFS(fsys, names...); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range testFiles {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != content {
+			t.Errorf("%s: '%s', expected '%s'", name, data, content)
+		}
+	}
+	if _, err := fs.Stat(fsys, "link"); err == nil {
+		t.Error("links should be skipped")
+	}
+}
+
+func TestTarFS(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		compress bool
+	}{{"test.tar", false}, {"test.tar.gz", true}, {"test.tgz", true}} {
+		t.Run(tc.name, func(t *testing.T) {
+			if !IsTar(tc.name) {
+				t.Errorf("'%s' not detected as tar", tc.name)
+			}
+			tfs, err := NewTarFS(writeTar(t, tc.name, tc.compress))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tfs.Close()
+			if tfs.gzip != tc.compress {
+				t.Errorf("gzip %v, expected %v", tfs.gzip, tc.compress)
+			}
+			testFS(t, tfs)
+		})
+	}
+}
+
+func sha512Hex(s string) string {
+	return fmt.Sprintf("%x", sha512.Sum512([]byte(s)))
+}
+
+func TestReadURLManifest(t *testing.T) {
+	data := `# comment
+` + sha512Hex("x") + ` files/a.txt
+md5:d41d8cd98f00b204e9800998ecf8427e https://example.org/b renamed/b.txt
+{"url": "/c", "digest": "sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", "path": "c.txt", "size": 0}
+`
+	base, _ := url.Parse("http://localhost/manifest/list.txt")
+	entries, err := ReadURLManifest(strings.NewReader(data), base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("%d entries, expected 3", len(entries))
+	}
+	expected := []URLEntry{
+		{URL: "http://localhost/manifest/files/a.txt", Path: "/manifest/files/a.txt", Digest: sha512Hex("x")},
+		{URL: "https://example.org/b", Path: "renamed/b.txt", Digest: "md5:d41d8cd98f00b204e9800998ecf8427e"},
+		{URL: "http://localhost/c", Path: "c.txt", Digest: "sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+	}
+	for i, entry := range entries {
+		if *entry != expected[i] {
+			t.Errorf("entry %d: %+v, expected %+v", i, *entry, expected[i])
+		}
+	}
+
+	for _, line := range []string{
+		"abc http://localhost/a",
+		"foo:abc http://localhost/a",
+		sha512Hex("x") + " ftp://localhost/a",
+		sha512Hex("x"),
+	} {
+		if _, err := ReadURLManifest(strings.NewReader(line), nil); err == nil {
+			t.Errorf("'%s' should fail", line)
+		}
+	}
+}
+
+func TestHTTPFS(t *testing.T) {
+	var manifest strings.Builder
+	for name, content := range testFiles {
+		fmt.Fprintf(&manifest, "sha512:%s /files/%s %s\n", sha512Hex(content), name, name)
+	}
+	fmt.Fprintf(&manifest, "%s /files/bad bad.txt\n", sha512Hex("other content"))
+	mux := http.NewServeMux()
+	mux.HandleFunc("/manifest.txt", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, manifest.String())
+	})
+	mux.HandleFunc("/files/", func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/files/")
+		if name == "bad" {
+			io.WriteString(w, "unexpected content")
+			return
+		}
+		content, ok := testFiles[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, content)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	hfs, err := OpenHTTPManifest(context.Background(), server.Client(), server.URL+"/manifest.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hfs.Close()
+	for name, content := range testFiles {
+		data, err := fs.ReadFile(hfs, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != content {
+			t.Errorf("%s: '%s', expected '%s'", name, data, content)
+		}
+	}
+	entries, err := fs.ReadDir(hfs, "dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Name() != "b.txt" || !entries[1].IsDir() {
+		t.Errorf("unexpected entries of 'dir': %v", entries)
+	}
+	if _, err := fs.ReadFile(hfs, "bad.txt"); err == nil || !strings.Contains(err.Error(), "digest") {
+		t.Errorf("digest mismatch not detected: %v", err)
+	}
+
+	if _, err := OpenHTTPManifest(context.Background(), server.Client(), server.URL+"/missing.txt"); err == nil {
+		t.Error("missing manifest should fail")
+	}
+}
+
+func TestHTTPFSDigests(t *testing.T) {
+	entries := []*URLEntry{
+		{URL: "http://localhost/a", Path: "a.txt", Digest: "sha512:" + sha512Hex("a")},
+		{URL: "http://localhost/b", Path: "b.txt", Digest: sha512Hex("b")},
+		{URL: "http://localhost/c", Path: "dir/c.txt", Digest: "md5:d41d8cd98f00b204e9800998ecf8427e"},
+	}
+	hfs, err := NewHTTPFS(context.Background(), nil, "test", entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		preferred checksum.DigestAlgorithm
+		algorithm checksum.DigestAlgorithm
+		paths     []string
+	}{
+		{preferred: checksum.DigestSHA512, algorithm: checksum.DigestSHA512, paths: []string{"a.txt", "b.txt"}},
+		{preferred: checksum.DigestMD5, algorithm: checksum.DigestMD5, paths: []string{"dir/c.txt"}},
+		// without files of the preferred algorithm, the algorithm of most files is used
+		{preferred: checksum.DigestSHA256, algorithm: checksum.DigestSHA512, paths: []string{"a.txt", "b.txt"}},
+	} {
+		algorithm, digests := hfs.Digests(test.preferred)
+		if algorithm != test.algorithm {
+			t.Errorf("%s: algorithm %s, expected %s", test.preferred, algorithm, test.algorithm)
+		}
+		if paths := slices.Sorted(maps.Keys(digests)); !slices.Equal(paths, test.paths) {
+			t.Errorf("%s: paths %v, expected %v", test.preferred, paths, test.paths)
+		}
+	}
+	if _, digests := hfs.Digests(checksum.DigestSHA512); digests["a.txt"] != sha512Hex("a") {
+		t.Errorf("wrong digest of 'a.txt': %s", digests["a.txt"])
+	}
+}
@@ -0,0 +1,179 @@
+package sourcefs
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+
+	"emperror.dev/errors"
+)
+
+// IsTar returns true, if name has the extension of a tar archive
+func IsTar(name string) bool {
+	name = strings.ToLower(name)
+	return strings.HasSuffix(name, ".tar") || strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz")
+}
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// TarFS is a read only filesystem of a tar archive. Gzip compressed archives are detected by their content.
+// Files are read directly from the archive without extracting it
+type TarFS struct {
+	name    string
+	fp      *os.File
+	gzip    bool
+	tree    *tree
+	offsets map[string]int64
+}
+
+// NewTarFS opens the tar archive name and reads its table of contents
+func NewTarFS(name string) (*TarFS, error) {
+	fp, err := os.Open(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot open '%s'", name)
+	}
+	tfs := &TarFS{
+		name:    name,
+		fp:      fp,
+		tree:    newTree(),
+		offsets: map[string]int64{},
+	}
+	if err := tfs.index(); err != nil {
+		fp.Close()
+		return nil, errors.Wrapf(err, "cannot read tar archive '%s'", name)
+	}
+	return tfs, nil
+}
+
+// reader returns a new uncompressed stream of the whole archive
+func (tfs *TarFS) reader() (io.Reader, func() error, error) {
+	r := io.NewSectionReader(tfs.fp, 0, 1<<63-1)
+	if !tfs.gzip {
+		return r, func() error { return nil }, nil
+	}
+	zr, err := gzip.NewReader(bufio.NewReader(r))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "cannot open gzip stream")
+	}
+	return zr, zr.Close, nil
+}
+
+func (tfs *TarFS) index() error {
+	magic := make([]byte, len(gzipMagic))
+	if _, err := tfs.fp.ReadAt(magic, 0); err != nil && !errors.Is(err, io.EOF) {
+		return errors.Wrap(err, "cannot read header")
+	}
+	tfs.gzip = bytes.Equal(magic, gzipMagic)
+
+	r, closer, err := tfs.reader()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer closer()
+	// the section reader knows the position of the data in uncompressed archives
+	seeker, _ := r.(io.Seeker)
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		name, err := cleanName(hdr.Name)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if name == "." {
+			continue
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if _, err := tfs.tree.add(name, &node{mode: fs.ModeDir | 0555, modTime: hdr.ModTime}); err != nil {
+				return errors.WithStack(err)
+			}
+		case tar.TypeReg:
+			if _, err := tfs.tree.add(name, &node{size: hdr.Size, mode: 0444, modTime: hdr.ModTime}); err != nil {
+				return errors.WithStack(err)
+			}
+			tfs.offsets[name] = -1
+			if seeker != nil {
+				if tfs.offsets[name], err = seeker.Seek(0, io.SeekCurrent); err != nil {
+					return errors.WithStack(err)
+				}
+			}
+		default:
+			// links and special files are not part of an object
+		}
+	}
+}
+
+func (tfs *TarFS) Open(name string) (fs.File, error) {
+	n, err := tfs.tree.stat("open", name)
+	if err != nil {
+		return nil, err
+	}
+	if n.IsDir() {
+		return tfs.tree.openDir(name, n)
+	}
+	if offset := tfs.offsets[name]; offset >= 0 {
+		return &tarFile{n: n, Reader: io.NewSectionReader(tfs.fp, offset, n.size), closer: func() error { return nil }}, nil
+	}
+	// compressed archives are read from the beginning until the file is found
+	r, closer, err := tfs.reader()
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			closer()
+			if errors.Is(err, io.EOF) {
+				err = fs.ErrNotExist
+			}
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+		if hdrName, err := cleanName(hdr.Name); err == nil && hdrName == name {
+			return &tarFile{n: n, Reader: io.LimitReader(tr, n.size), closer: closer}, nil
+		}
+	}
+}
+
+func (tfs *TarFS) Stat(name string) (fs.FileInfo, error) {
+	return tfs.tree.stat("stat", name)
+}
+
+func (tfs *TarFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return tfs.tree.readDir(name)
+}
+
+func (tfs *TarFS) Close() error {
+	return errors.WithStack(tfs.fp.Close())
+}
+
+func (tfs *TarFS) String() string {
+	return fmt.Sprintf("tarfs://%s", tfs.name)
+}
+
+type tarFile struct {
+	io.Reader
+	n      *node
+	closer func() error
+}
+
+func (tf *tarFile) Stat() (fs.FileInfo, error) { return tf.n, nil }
+func (tf *tarFile) Close() error               { return tf.closer() }
+
+var (
+	_ fs.ReadDirFS = (*TarFS)(nil)
+	_ fs.StatFS    = (*TarFS)(nil)
+	_ io.Closer    = (*TarFS)(nil)
+)
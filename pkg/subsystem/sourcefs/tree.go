@@ -0,0 +1,139 @@
+package sourcefs
+
+import (
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+)
+
+// node is a file or folder of an archive or a manifest
+type node struct {
+	name     string
+	size     int64
+	mode     fs.FileMode
+	modTime  time.Time
+	children []*node
+}
+
+func (n *node) Name() string               { return n.name }
+func (n *node) Size() int64                { return n.size }
+func (n *node) Mode() fs.FileMode          { return n.mode }
+func (n *node) ModTime() time.Time         { return n.modTime }
+func (n *node) IsDir() bool                { return n.mode.IsDir() }
+func (n *node) Sys() any                   { return nil }
+func (n *node) Type() fs.FileMode          { return n.mode.Type() }
+func (n *node) Info() (fs.FileInfo, error) { return n, nil }
+
+// tree is the folder structure of all files of a source
+type tree struct {
+	nodes map[string]*node
+}
+
+func newTree() *tree {
+	return &tree{nodes: map[string]*node{".": {name: ".", mode: fs.ModeDir | 0555}}}
+}
+
+// cleanName converts an archive or url path to a valid fs.FS path
+func cleanName(name string) (string, error) {
+	name = strings.TrimPrefix(path.Clean("/"+strings.ReplaceAll(name, "\\", "/")), "/")
+	if name == "" {
+		name = "."
+	}
+	if !fs.ValidPath(name) {
+		return "", errors.Errorf("invalid path '%s'", name)
+	}
+	return name, nil
+}
+
+// add adds a file or folder and all missing parent folders
+func (t *tree) add(name string, n *node) (*node, error) {
+	if existing, ok := t.nodes[name]; ok {
+		if existing.IsDir() && n.IsDir() {
+			existing.modTime = n.modTime
+			return existing, nil
+		}
+		return nil, errors.Errorf("duplicate path '%s'", name)
+	}
+	n.name = path.Base(name)
+	t.nodes[name] = n
+	dir := path.Dir(name)
+	parent, ok := t.nodes[dir]
+	if !ok {
+		var err error
+		if parent, err = t.add(dir, &node{mode: fs.ModeDir | 0555}); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	if !parent.IsDir() {
+		return nil, errors.Errorf("'%s' is not a folder", dir)
+	}
+	parent.children = append(parent.children, n)
+	return n, nil
+}
+
+func (t *tree) stat(op, name string) (*node, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	n, ok := t.nodes[name]
+	if !ok {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return n, nil
+}
+
+func (t *tree) readDir(name string) ([]fs.DirEntry, error) {
+	n, err := t.stat("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if !n.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+	entries := make([]fs.DirEntry, 0, len(n.children))
+	for _, child := range n.children {
+		entries = append(entries, child)
+	}
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+	return entries, nil
+}
+
+// dirFile is an opened folder
+type dirFile struct {
+	n       *node
+	entries []fs.DirEntry
+	pos     int
+}
+
+func (d *dirFile) Stat() (fs.FileInfo, error) { return d.n, nil }
+func (d *dirFile) Close() error               { return nil }
+func (d *dirFile) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.n.name, Err: errors.New("is a directory")}
+}
+
+func (d *dirFile) ReadDir(count int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.pos:]
+	if count <= 0 {
+		d.pos = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	count = min(count, len(rest))
+	d.pos += count
+	return rest[:count], nil
+}
+
+func (t *tree) openDir(name string, n *node) (fs.File, error) {
+	entries, err := t.readDir(name)
+	if err != nil {
+		return nil, err
+	}
+	return &dirFile{n: n, entries: entries}, nil
+}
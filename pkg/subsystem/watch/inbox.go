@@ -0,0 +1,215 @@
+package watch
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/ocfl-archive/gocfl/v2/pkg/subsystem/batch"
+)
+
+// Delivery is a folder in the inbox, which is complete and can be ingested
+type Delivery struct {
+	Name string
+	Path string
+}
+
+// signature changes, whenever a file of a delivery is added, removed or modified
+type signature struct {
+	files   int
+	size    int64
+	modTime time.Time
+}
+
+type pending struct {
+	signature
+	stableSince time.Time
+}
+
+// Inbox is a folder with one subfolder per delivery.
+// A delivery is complete, if it contains the sentinel file and/or there were no changes for the quiet period
+type Inbox struct {
+	path     string
+	done     string
+	failed   string
+	sentinel string
+	quiet    time.Duration
+	idFile   string
+	idPrefix string
+	pending  map[string]*pending
+	broken   map[string]bool
+}
+
+// NewInbox creates an inbox. done and failed are the folders for the processed deliveries.
+// If idFile is empty, the object id is the folder name with idPrefix
+func NewInbox(path, done, failed, sentinel string, quiet time.Duration, idFile, idPrefix string) (*Inbox, error) {
+	if sentinel == "" && quiet <= 0 {
+		return nil, errors.New("neither sentinel file nor quiet period defined")
+	}
+	for _, folder := range []string{path, done, failed} {
+		if err := os.MkdirAll(folder, 0755); err != nil {
+			return nil, errors.Wrapf(err, "cannot create folder '%s'", folder)
+		}
+	}
+	return &Inbox{
+		path:     filepath.Clean(path),
+		done:     filepath.Clean(done),
+		failed:   filepath.Clean(failed),
+		sentinel: sentinel,
+		quiet:    quiet,
+		idFile:   idFile,
+		idPrefix: idPrefix,
+		pending:  map[string]*pending{},
+		broken:   map[string]bool{},
+	}, nil
+}
+
+func (inbox *Inbox) Path() string {
+	return inbox.path
+}
+
+// Scan returns the complete deliveries and the time, when the next delivery reaches the end of its quiet period.
+// next is zero, if no delivery waits. Folders starting with '.' are ignored
+func (inbox *Inbox) Scan(now time.Time) (ready []*Delivery, next time.Time, err error) {
+	entries, err := os.ReadDir(inbox.path)
+	if err != nil {
+		return nil, time.Time{}, errors.Wrapf(err, "cannot read inbox '%s'", inbox.path)
+	}
+	ready = []*Delivery{}
+	wait := func(t time.Time) {
+		if next.IsZero() || t.Before(next) {
+			next = t
+		}
+	}
+	var found = map[string]bool{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() || strings.HasPrefix(name, ".") || inbox.broken[name] {
+			continue
+		}
+		folder := filepath.Join(inbox.path, name)
+		if folder == inbox.done || folder == inbox.failed {
+			continue
+		}
+		found[name] = true
+		if inbox.sentinel != "" {
+			if _, err := os.Stat(filepath.Join(folder, inbox.sentinel)); err != nil {
+				continue
+			}
+		}
+		if inbox.quiet > 0 {
+			sig, err := folderSignature(folder)
+			if err != nil {
+				// files may disappear while the delivery is written
+				wait(now.Add(inbox.quiet))
+				continue
+			}
+			p, ok := inbox.pending[name]
+			if !ok || p.signature != sig {
+				inbox.pending[name] = &pending{signature: sig, stableSince: now}
+				wait(now.Add(inbox.quiet))
+				continue
+			}
+			if now.Sub(p.stableSince) < inbox.quiet {
+				wait(p.stableSince.Add(inbox.quiet))
+				continue
+			}
+		}
+		ready = append(ready, &Delivery{Name: name, Path: folder})
+	}
+	for name := range inbox.pending {
+		if !found[name] {
+			delete(inbox.pending, name)
+		}
+	}
+	return ready, next, nil
+}
+
+func folderSignature(folder string) (signature, error) {
+	var sig signature
+	err := filepath.WalkDir(folder, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		sig.files++
+		if !d.IsDir() {
+			sig.size += info.Size()
+		}
+		if info.ModTime().After(sig.modTime) {
+			sig.modTime = info.ModTime()
+		}
+		return nil
+	})
+	return sig, errors.WithStack(err)
+}
+
+// Row returns the ingest parameters of the delivery.
+// The id file contains either a json object with the fields of a batch row or the object id in the first line
+func (inbox *Inbox) Row(delivery *Delivery) (*batch.Row, error) {
+	row := &batch.Row{Source: delivery.Path}
+	if inbox.idFile == "" {
+		row.ID = inbox.idPrefix + delivery.Name
+		return row, nil
+	}
+	filename := filepath.Join(delivery.Path, inbox.idFile)
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return row, errors.Wrapf(err, "cannot read id file '%s'", filename)
+	}
+	data = bytes.TrimSpace(data)
+	if bytes.HasPrefix(data, []byte("{")) {
+		if err := json.Unmarshal(data, row); err != nil {
+			return row, errors.Wrapf(err, "cannot unmarshal id file '%s'", filename)
+		}
+		if len(row.Areas) > 0 {
+			return row, errors.Errorf("areas not supported in id file '%s'", filename)
+		}
+		row.Source = delivery.Path
+	} else {
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		if scanner.Scan() {
+			row.ID = strings.TrimSpace(scanner.Text())
+		}
+	}
+	if row.ID == "" {
+		return row, errors.Errorf("no object id in '%s'", filename)
+	}
+	row.ID = inbox.idPrefix + row.ID
+	return row, nil
+}
+
+// Finish moves the delivery to the done or failed folder and writes the result next to it
+func (inbox *Inbox) Finish(delivery *Delivery, result *batch.Result) (string, error) {
+	folder := inbox.done
+	if result.Status != batch.StatusOK {
+		folder = inbox.failed
+	}
+	target := filepath.Join(folder, delivery.Name)
+	if _, err := os.Stat(target); err == nil {
+		target = filepath.Join(folder, delivery.Name+"."+time.Now().Format("20060102T150405.000"))
+	}
+	if err := os.Rename(delivery.Path, target); err != nil {
+		// never pick up this delivery again
+		inbox.broken[delivery.Name] = true
+		return "", errors.Wrapf(err, "cannot move '%s' to '%s'", delivery.Path, target)
+	}
+	delete(inbox.pending, delivery.Name)
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return target, errors.Wrapf(err, "cannot marshal result of '%s'", delivery.Name)
+	}
+	if err := os.WriteFile(target+".result.json", data, 0644); err != nil {
+		return target, errors.Wrapf(err, "cannot write result of '%s'", delivery.Name)
+	}
+	return target, nil
+}
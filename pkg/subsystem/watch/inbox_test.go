@@ -0,0 +1,135 @@
+package watch
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ocfl-archive/gocfl/v2/pkg/subsystem/batch"
+)
+
+func writeFile(t *testing.T, name, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestInbox(t *testing.T, sentinel string, quiet time.Duration, idFile string) (*Inbox, string) {
+	t.Helper()
+	dir := t.TempDir()
+	inbox, err := NewInbox(dir, filepath.Join(dir, "done"), filepath.Join(dir, "failed"), sentinel, quiet, idFile, "id:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return inbox, dir
+}
+
+func names(deliveries []*Delivery) []string {
+	var result = []string{}
+	for _, d := range deliveries {
+		result = append(result, d.Name)
+	}
+	return result
+}
+
+func TestInboxSentinel(t *testing.T) {
+	inbox, dir := newTestInbox(t, "READY", 0, "")
+	writeFile(t, filepath.Join(dir, "a", "file.txt"), "a")
+	writeFile(t, filepath.Join(dir, "b", "file.txt"), "b")
+	writeFile(t, filepath.Join(dir, "b", "READY"), "")
+	writeFile(t, filepath.Join(dir, ".c", "READY"), "")
+	ready, next, err := inbox.Scan(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ready) != 1 || ready[0].Name != "b" || !next.IsZero() {
+		t.Errorf("ready %v, next %v - expected [b] and no waiting delivery", names(ready), next)
+	}
+}
+
+func TestInboxQuiet(t *testing.T) {
+	inbox, dir := newTestInbox(t, "", time.Minute, "")
+	writeFile(t, filepath.Join(dir, "a", "file.txt"), "a")
+	now := time.Now()
+	ready, next, err := inbox.Scan(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ready) != 0 || !next.Equal(now.Add(time.Minute)) {
+		t.Fatalf("ready %v, next %v - expected delivery waiting for quiet period", names(ready), next)
+	}
+	// a change restarts the quiet period
+	writeFile(t, filepath.Join(dir, "a", "other.txt"), "other")
+	if ready, _, _ = inbox.Scan(now.Add(2 * time.Minute)); len(ready) != 0 {
+		t.Fatalf("ready %v after change", names(ready))
+	}
+	if ready, _, _ = inbox.Scan(now.Add(150 * time.Second)); len(ready) != 0 {
+		t.Fatalf("ready %v within quiet period", names(ready))
+	}
+	ready, next, _ = inbox.Scan(now.Add(3 * time.Minute))
+	if len(ready) != 1 || ready[0].Name != "a" || !next.IsZero() {
+		t.Errorf("ready %v, next %v - expected [a]", names(ready), next)
+	}
+}
+
+func TestInboxRow(t *testing.T) {
+	inbox, dir := newTestInbox(t, "READY", 0, "object.json")
+	writeFile(t, filepath.Join(dir, "json", "object.json"), `{"id":"obj1","message":"delivery 1","fixity":["md5"]}`)
+	writeFile(t, filepath.Join(dir, "text", "object.json"), "obj2\nsecond line\n")
+	writeFile(t, filepath.Join(dir, "empty", "object.json"), "")
+	row, err := inbox.Row(&Delivery{Name: "json", Path: filepath.Join(dir, "json")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row.ID != "id:obj1" || row.Message != "delivery 1" || len(row.Fixity) != 1 || row.Source != filepath.Join(dir, "json") {
+		t.Errorf("unexpected row %+v", row)
+	}
+	if row, err = inbox.Row(&Delivery{Name: "text", Path: filepath.Join(dir, "text")}); err != nil || row.ID != "id:obj2" {
+		t.Errorf("unexpected row %+v (%v)", row, err)
+	}
+	if _, err = inbox.Row(&Delivery{Name: "empty", Path: filepath.Join(dir, "empty")}); err == nil {
+		t.Error("no error for empty id file")
+	}
+	if _, err = inbox.Row(&Delivery{Name: "missing", Path: filepath.Join(dir, "missing")}); err == nil {
+		t.Error("no error for missing id file")
+	}
+
+	inbox, dir = newTestInbox(t, "READY", 0, "")
+	if row, err = inbox.Row(&Delivery{Name: "folder", Path: filepath.Join(dir, "folder")}); err != nil || row.ID != "id:folder" {
+		t.Errorf("unexpected row %+v (%v)", row, err)
+	}
+}
+
+func TestInboxFinish(t *testing.T) {
+	inbox, dir := newTestInbox(t, "READY", 0, "")
+	for _, name := range []string{"a", "b"} {
+		writeFile(t, filepath.Join(dir, name, "READY"), "")
+	}
+	ready, _, err := inbox.Scan(time.Now())
+	if err != nil || len(ready) != 2 {
+		t.Fatalf("ready %v (%v)", names(ready), err)
+	}
+	for _, delivery := range ready {
+		var err error
+		if delivery.Name == "b" {
+			err = errors.New("broken")
+		}
+		if _, err := inbox.Finish(delivery, batch.NewResult(&batch.Row{ID: delivery.Name}).Done(err)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"done/a/READY", "done/a.result.json", "failed/b/READY", "failed/b.result.json"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	if ready, _, _ := inbox.Scan(time.Now()); len(ready) != 0 {
+		t.Errorf("ready %v after finish", names(ready))
+	}
+}
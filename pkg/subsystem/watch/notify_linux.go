@@ -0,0 +1,120 @@
+//go:build linux
+
+package watch
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"unsafe"
+
+	"emperror.dev/errors"
+	"golang.org/x/sys/unix"
+)
+
+const inotifyMask = unix.IN_CREATE | unix.IN_CLOSE_WRITE | unix.IN_MODIFY | unix.IN_ATTRIB |
+	unix.IN_MOVED_FROM | unix.IN_MOVED_TO | unix.IN_DELETE | unix.IN_DELETE_SELF
+
+// inotify watches a folder and all its subfolders
+type inotify struct {
+	sync.Mutex
+	fd     int
+	file   *os.File
+	folder map[int]string
+	events chan struct{}
+}
+
+// NewNotifier watches folder and its subfolders with inotify
+func NewNotifier(folder string) (Notifier, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot initialize inotify")
+	}
+	n := &inotify{
+		fd: fd,
+		// a non-blocking file uses the runtime poller, so Close interrupts a pending Read
+		file:   os.NewFile(uintptr(fd), "inotify"),
+		folder: map[int]string{},
+		events: make(chan struct{}, 1),
+	}
+	if err := n.addTree(folder); err != nil {
+		n.file.Close()
+		return nil, errors.WithStack(err)
+	}
+	go n.read()
+	return n, nil
+}
+
+func (n *inotify) addTree(folder string) error {
+	return filepath.WalkDir(folder, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// the folder may already be moved away
+			if path != folder && errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		wd, err := unix.InotifyAddWatch(n.fd, path, inotifyMask)
+		if err != nil {
+			return errors.Wrapf(err, "cannot watch '%s'", path)
+		}
+		n.Lock()
+		n.folder[wd] = path
+		n.Unlock()
+		return nil
+	})
+}
+
+func (n *inotify) read() {
+	defer close(n.events)
+	var buf [unix.SizeofInotifyEvent * 4096]byte
+	for {
+		num, err := n.file.Read(buf[:])
+		if err != nil {
+			return
+		}
+		for offset := 0; offset+unix.SizeofInotifyEvent <= num; {
+			event := (*unix.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			name := buf[offset+unix.SizeofInotifyEvent : offset+unix.SizeofInotifyEvent+int(event.Len)]
+			offset += unix.SizeofInotifyEvent + int(event.Len)
+			n.Lock()
+			folder, ok := n.folder[int(event.Wd)]
+			if event.Mask&unix.IN_IGNORED != 0 {
+				delete(n.folder, int(event.Wd))
+			}
+			n.Unlock()
+			if ok && event.Mask&unix.IN_ISDIR != 0 && event.Mask&(unix.IN_CREATE|unix.IN_MOVED_TO) != 0 {
+				// new subfolders are watched too. errors only lead to fewer notifications, the inbox is still polled
+				_ = n.addTree(filepath.Join(folder, string(name[:clen(name)])))
+			}
+		}
+		select {
+		case n.events <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// clen returns the length of the null terminated name
+func clen(name []byte) int {
+	for i, b := range name {
+		if b == 0 {
+			return i
+		}
+	}
+	return len(name)
+}
+
+func (n *inotify) Events() <-chan struct{} {
+	return n.events
+}
+
+func (n *inotify) Close() error {
+	return errors.WithStack(n.file.Close())
+}
+
+var _ Notifier = (*inotify)(nil)
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package watch
+
+// NewNotifier is only implemented on linux, other systems poll the inbox
+func NewNotifier(folder string) (Notifier, error) {
+	return nil, ErrNotifyNotSupported
+}
@@ -0,0 +1,97 @@
+package watch
+
+import (
+	"context"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/je4/utils/v2/pkg/zLogger"
+	"github.com/ocfl-archive/gocfl/v2/pkg/subsystem/batch"
+)
+
+var ErrNotifyNotSupported = errors.New("file system notifications not supported")
+
+// Notifier signals changes in the inbox
+type Notifier interface {
+	Events() <-chan struct{}
+	Close() error
+}
+
+// Handler ingests a delivery. If it returns nil, the delivery stays in the inbox
+type Handler func(ctx context.Context, delivery *Delivery) *batch.Result
+
+// events of a running copy are collected for this time before the inbox is scanned
+const settleTime = time.Second
+
+// Watch scans the inbox on changes, at the end of quiet periods and every poll interval and passes the complete deliveries to handler.
+// Changes are detected with file system notifications, if available.
+// If once is true, Watch returns as soon as no delivery waits for the end of its quiet period
+func Watch(ctx context.Context, inbox *Inbox, poll time.Duration, once bool, handler Handler, logger zLogger.ZLogger) error {
+	if poll <= 0 {
+		poll = 10 * time.Second
+	}
+	var events <-chan struct{}
+	notifier, err := NewNotifier(inbox.Path())
+	if err != nil {
+		logger.Info().Msgf("cannot watch '%s' (%v), polling every %s", inbox.Path(), err, poll)
+	} else {
+		defer notifier.Close()
+		events = notifier.Events()
+	}
+	for {
+		ready, next, err := inbox.Scan(time.Now())
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		for _, delivery := range ready {
+			if ctx.Err() != nil {
+				return nil
+			}
+			result := handler(ctx, delivery)
+			if result == nil || ctx.Err() != nil {
+				logger.Info().Msgf("delivery '%s' not finished, leaving it in the inbox", delivery.Name)
+				continue
+			}
+			target, err := inbox.Finish(delivery, result)
+			if err != nil {
+				logger.Error().Stack().Err(err).Msgf("cannot finish delivery '%s'", delivery.Name)
+				continue
+			}
+			logger.Info().Msgf("delivery '%s' %s, moved to '%s'", delivery.Name, result.Status, target)
+		}
+		if len(ready) > 0 {
+			continue
+		}
+		if once && next.IsZero() {
+			return nil
+		}
+		timeout := poll
+		if !next.IsZero() && time.Until(next) < timeout {
+			timeout = max(time.Until(next), 0)
+		}
+		timer := time.NewTimer(timeout)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		case _, ok := <-events:
+			timer.Stop()
+			if !ok {
+				logger.Info().Msgf("notifications for '%s' stopped, polling every %s", inbox.Path(), poll)
+				events = nil
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(settleTime):
+			}
+			// drain the events of the settle time
+			select {
+			case <-events:
+			default:
+			}
+		}
+	}
+}